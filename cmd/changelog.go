@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/qflag"
+	"gitee.com/MM-Q/verman"
+)
+
+// newChangelogCmd 创建 changelog 子命令
+//
+// 返回值:
+//   - *qflag.Cmd: changelog 子命令
+func newChangelogCmd() *qflag.Cmd {
+	changelogCmd := qflag.NewCmd("changelog", "cl", qflag.ContinueOnError)
+	changelogTagFlag = changelogCmd.String("tag", "t", "生成指定标签的变更日志, 默认为当前提交的版本标签", "")
+	changelogOutputFlag = changelogCmd.String("output", "o", "将变更日志写入指定文件, 默认输出到标准输出", "")
+	changelogCmdOpts := &qflag.CmdOpts{
+		Desc:        "将上一个版本标签与当前版本之间的提交按约定式提交类型分组, 生成Markdown格式的变更日志",
+		UsageSyntax: "gob changelog [options] [task|file]",
+		UseChinese:  true,
+		RunFunc:     runChangelog,
+		Examples: map[string]string{
+			"输出当前版本的变更日志":          "gob changelog",
+			"使用 release 任务的变更日志配置": "gob changelog release",
+			"生成指定标签的变更日志":          "gob changelog --tag v1.2.0",
+			"写入文件":                 "gob changelog -o RELEASE_NOTES.md",
+		},
+		Notes: []string{
+			"分组、排除规则和模板位于构建文件的 [changelog] 部分",
+			"当前提交不是版本标签时生成最高版本标签之后的提交, 标题使用Git版本",
+			"正式版本的上一个版本跳过预发布版本, 因此包含其所有预发布版本的提交",
+		},
+	}
+	if err := changelogCmd.ApplyOpts(changelogCmdOpts); err != nil {
+		panic(err)
+	}
+	return changelogCmd
+}
+
+// runChangelog 生成变更日志并输出到标准输出或文件
+//
+// 参数:
+//   - cmd: changelog 命令
+//
+// 返回值:
+//   - error: 错误信息
+func runChangelog(cmd qflag.Command) error {
+	args, err := parseTrailingFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("只能指定一个构建文件")
+	}
+	var arg string
+	if len(args) == 1 {
+		arg = args[0]
+	}
+
+	configPath, err := resolveConfigPath(arg)
+	if err != nil {
+		return err
+	}
+	config := &types.GobConfig{}
+	if err := loadAndValidateConfig(config, configPath); err != nil {
+		return err
+	}
+	utils.CL.SetColor(config.Build.UI.Color)
+
+	// 模板可以引用Git元数据和自定义变量
+	timeout := config.Build.TimeoutDuration
+	if err := utils.GetGitMetaData(timeout, verman.V, config); err != nil {
+		return fmt.Errorf("Git信息获取失败: %w", err)
+	}
+	if len(config.Vars) > 0 {
+		values, err := utils.ResolveVars(config)
+		if err != nil {
+			return fmt.Errorf("自定义变量解析失败: %w", err)
+		}
+		config.VarValues = values
+	}
+
+	tag := changelogTagFlag.Get()
+	if tag != "" {
+		exists, err := utils.GitTagExists(timeout, tag)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("标签 %s 不存在", tag)
+		}
+	}
+	changelog, err := utils.GenerateChangelog(timeout, releaseTemplateData(verman.V, config), tag)
+	if err != nil {
+		return err
+	}
+
+	output := changelogOutputFlag.Get()
+	if output == "" {
+		_, err := os.Stdout.WriteString(changelog)
+		return err
+	}
+	if err := os.WriteFile(output, []byte(changelog), 0644); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", output, err)
+	}
+	utils.CL.Greenf("%s 已生成: %s\n", types.PrintPrefix, output)
+	return nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// runChangelogArgs 以给定参数执行 gob changelog
+func runChangelogArgs(t *testing.T, args ...string) error {
+	t.Helper()
+	cmd := newChangelogCmd()
+	if err := cmd.ParseOnly(args); err != nil {
+		t.Fatal(err)
+	}
+	return runChangelog(cmd)
+}
+
+func TestChangelog(t *testing.T) {
+	git := newBumpRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "fix(cli): handle empty args")
+	git("tag", "v1.1.0")
+	feat := git("log", "-1", "--format=%h", "--abbrev=7", "HEAD~1")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string // 期望变更日志包含的内容
+		missing []string // 期望变更日志不包含的内容
+	}{
+		{
+			name:    "当前提交的版本标签",
+			args:    []string{"-o", "current.md"},
+			want:    []string{"## v1.1.0 (", "### Features\n\n- add x (" + feat + ")", "### Bug Fixes\n\n- **cli:** handle empty args ("},
+			missing: []string{"init"},
+		},
+		{
+			name:    "指定标签",
+			args:    []string{"--tag", "v1.0.0", "-o", "v1.0.0.md"},
+			want:    []string{"## v1.0.0 ("},
+			missing: []string{"add x", "handle empty args"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runChangelogArgs(t, tt.args...); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(tt.args[len(tt.args)-1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("变更日志应包含 %q, got:\n%s", s, data)
+				}
+			}
+			for _, s := range tt.missing {
+				if strings.Contains(string(data), s) {
+					t.Errorf("变更日志不应包含 %q, got:\n%s", s, data)
+				}
+			}
+		})
+	}
+
+	for args, want := range map[string]string{
+		"--tag v9.9.9":       "标签 v9.9.9 不存在",
+		"gob.toml gob.toml":  "只能指定一个构建文件",
+		"gob.toml --unknown": "未知的标志",
+	} {
+		if err := runChangelogArgs(t, strings.Fields(args)...); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("gob changelog %s 期望错误包含 %q, got %v", args, want, err)
+		}
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	git := newBumpRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "fix: crash")
+
+	config := utils.GetDefaultConfig()
+	config.Build.Git.Sources = []string{"git"}
+	config.Build.Output.Dir = t.TempDir()
+	config.Build.Git.Snapshot = "v{{.Git.NextPatch}}-dev.{{.Git.CommitsSinceTag}}"
+	v := &verman.Info{}
+	if err := utils.GetGitMetaData(config.Build.TimeoutDuration, v, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeChangelog(v, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 快照版本的标题使用Git版本, 包含最高版本标签之后的提交
+	data, err := os.ReadFile(filepath.Join(config.Build.Output.Dir, config.Changelog.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## v1.0.1-dev.2 (", "### Features\n\n- add x (", "### Bug Fixes\n\n- crash ("} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("变更日志应包含 %q, got:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/qflag"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// newConfigCmd 创建 config 子命令及其下属命令
+//
+// 返回值:
+//   - *qflag.Cmd: config 子命令
+func newConfigCmd() *qflag.Cmd {
+	// show 子命令: 查看任务的合并配置
+	showCmd := qflag.NewCmd("show", "s", qflag.ContinueOnError)
+	configResolvedFlag = showCmd.Bool("resolved", "r", "显示包含默认值的完整配置, 并标注每个值的来源文件", false)
+	showCmdOpts := &qflag.CmdOpts{
+		Desc:        "显示任务合并 extends 后的配置",
+		UsageSyntax: "gob config show [--resolved] [task|file]",
+		UseChinese:  true,
+		RunFunc:     runConfigShow,
+		Examples: map[string]string{
+			"显示 gob.toml 合并后的配置": "gob config show",
+			"显示发布任务的完整配置及来源":     "gob config show release --resolved",
+		},
+	}
+	if err := showCmd.ApplyOpts(showCmdOpts); err != nil {
+		panic(err)
+	}
+
+	// validate 子命令: 校验配置文件
+	validateCmd := qflag.NewCmd("validate", "v", qflag.ContinueOnError)
+	validateCmdOpts := &qflag.CmdOpts{
+		Desc:        "校验配置文件, 报告未知的配置项和无效的配置值",
+		UsageSyntax: "gob config validate [task|file...]",
+		UseChinese:  true,
+		RunFunc:     runConfigValidate,
+		Examples: map[string]string{
+			"校验 gobf/ 目录下的所有配置文件": "gob config validate",
+			"校验指定的配置文件":           "gob config validate gobf/release.toml gob.toml",
+		},
+		Notes: []string{
+			"未指定文件时校验 gobf/ 目录下的所有 .toml 文件, gobf/ 不存在时校验 gob.toml",
+			"任一文件校验失败时以非零退出码退出, 可用于CI",
+		},
+	}
+	if err := validateCmd.ApplyOpts(validateCmdOpts); err != nil {
+		panic(err)
+	}
+
+	// schema 子命令: 生成JSON Schema
+	schemaCmd := qflag.NewCmd("schema", "sc", qflag.ContinueOnError)
+	configSchemaOutputFlag = schemaCmd.String("output", "o", "将JSON Schema写入指定文件, 默认输出到标准输出", "")
+	schemaCmdOpts := &qflag.CmdOpts{
+		Desc:        "生成 gob.toml 的JSON Schema, 供编辑器补全和校验",
+		UsageSyntax: "gob config schema [--output file]",
+		UseChinese:  true,
+		RunFunc:     runConfigSchema,
+		Examples: map[string]string{
+			"输出JSON Schema":     "gob config schema",
+			"写入 gobf/ 目录供编辑器使用": "gob config schema -o gobf/gob.schema.json",
+		},
+		Notes: []string{
+			"在配置文件首行添加 #:schema ./gob.schema.json 即可被 Even Better TOML 等插件识别",
+		},
+	}
+	if err := schemaCmd.ApplyOpts(schemaCmdOpts); err != nil {
+		panic(err)
+	}
+
+	// migrate 子命令: 升级旧版本的配置文件
+	migrateCmd := qflag.NewCmd("migrate", "m", qflag.ContinueOnError)
+	migrateCmdOpts := &qflag.CmdOpts{
+		Desc:        "将旧版本格式的配置文件原地升级为当前格式, 保留文件中的注释",
+		UsageSyntax: "gob config migrate [task|file...]",
+		UseChinese:  true,
+		RunFunc:     runConfigMigrate,
+		Examples: map[string]string{
+			"升级 gobf/ 目录下的所有配置文件": "gob config migrate",
+			"升级指定的配置文件":           "gob config migrate gob.toml",
+		},
+		Notes: []string{
+			"未指定文件时升级 gobf/ 目录下的所有 .toml 文件, gobf/ 不存在时升级 gob.toml",
+			"旧配置项所在的行被移除, 新配置项写入对应的表中, 其余内容保持不变",
+		},
+	}
+	if err := migrateCmd.ApplyOpts(migrateCmdOpts); err != nil {
+		panic(err)
+	}
+
+	configCmd := qflag.NewCmd("config", "cfg", qflag.ContinueOnError)
+	configCmdOpts := &qflag.CmdOpts{
+		Desc:        "查看和检查构建配置",
+		UsageSyntax: "gob config <command> [options]",
+		UseChinese:  true,
+		RunFunc:     runConfig,
+		SubCmds:     []qflag.Command{showCmd, validateCmd, schemaCmd, migrateCmd},
+	}
+	if err := configCmd.ApplyOpts(configCmdOpts); err != nil {
+		panic(err)
+	}
+
+	return configCmd
+}
+
+// runConfig 将 config 命令分发到下属子命令
+//
+// 参数:
+//   - cmd: config 命令
+//
+// 返回值:
+//   - error: 错误信息
+func runConfig(cmd qflag.Command) error {
+	if cmd.NArg() == 0 {
+		cmd.PrintHelp()
+		return nil
+	}
+
+	subCmd, ok := cmd.GetSubCmd(cmd.Arg(0))
+	if !ok {
+		return fmt.Errorf("未知的 config 子命令: %s", cmd.Arg(0))
+	}
+	return subCmd.Run()
+}
+
+// runConfigShow 显示任务合并后的配置
+//
+// 参数:
+//   - cmd: show 命令
+//
+// 返回值:
+//   - error: 错误信息
+func runConfigShow(cmd qflag.Command) error {
+	args, err := parseTrailingFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("只能指定一个任务或配置文件")
+	}
+
+	configFilePath, err := resolveConfigPath(strings.Join(args, ""))
+	if err != nil {
+		return err
+	}
+
+	layers, err := utils.LoadConfigLayers(configFilePath)
+	if err != nil {
+		return fmt.Errorf("加载配置文件失败:\n%s", formatProblems(err))
+	}
+
+	// 未指定 --resolved 时仅显示配置文件中声明的值
+	if !configResolvedFlag.Get() {
+		content, err := toml.Marshal(layers.Doc)
+		if err != nil {
+			return fmt.Errorf("序列化配置失败: %w", err)
+		}
+		fmt.Printf("# 配置文件: %s\n\n%s", configFilePath, content)
+		return nil
+	}
+
+	// 显示包含默认值的完整配置
+	config, err := utils.LoadConfig(configFilePath)
+	if err != nil {
+		return err
+	}
+	content, err := toml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	fmt.Printf("# 配置文件: %s\n# 每个值后的注释为其来源文件, 未在任何文件中设置的值为默认值\n\n%s", configFilePath, annotateOrigins(string(content), layers.Origins))
+	return nil
+}
+
+// runConfigValidate 校验配置文件
+//
+// 参数:
+//   - cmd: validate 命令
+//
+// 返回值:
+//   - error: 任一文件校验失败时返回错误
+func runConfigValidate(cmd qflag.Command) error {
+	files, err := resolveConfigFiles(cmd.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, file := range files {
+		config, err := utils.LoadConfig(file)
+		if err == nil {
+			err = utils.ValidateConfig(config)
+		}
+		if err != nil {
+			failed++
+			utils.CL.Redf("%s ✗ %s\n", types.PrintPrefix, file)
+			fmt.Println(formatProblems(err))
+			continue
+		}
+		utils.CL.Greenf("%s ✓ %s\n", types.PrintPrefix, file)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个配置文件校验失败", failed, len(files))
+	}
+	return nil
+}
+
+// runConfigSchema 生成 gob.toml 的JSON Schema
+//
+// 参数:
+//   - cmd: schema 命令
+//
+// 返回值:
+//   - error: 生成或写入失败时返回错误
+func runConfigSchema(cmd qflag.Command) error {
+	if _, err := parseTrailingFlags(cmd); err != nil {
+		return err
+	}
+
+	data, err := utils.GenerateSchema()
+	if err != nil {
+		return err
+	}
+
+	output := configSchemaOutputFlag.Get()
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", output, err)
+	}
+	utils.CL.Greenf("%s 已生成: %s\n", types.PrintPrefix, output)
+	return nil
+}
+
+// runConfigMigrate 将旧版本格式的配置文件升级为当前格式
+//
+// 参数:
+//   - cmd: migrate 命令
+//
+// 返回值:
+//   - error: 任一文件迁移失败时返回错误
+func runConfigMigrate(cmd qflag.Command) error {
+	files, err := resolveConfigFiles(cmd.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, file := range files {
+		changed, warnings, err := utils.MigrateConfigFile(file)
+		if err != nil {
+			failed++
+			utils.CL.Redf("%s ✗ %s\n", types.PrintPrefix, file)
+			fmt.Println(formatProblems(err))
+			continue
+		}
+		if !changed {
+			utils.CL.Greenf("%s ✓ %s 已是最新格式\n", types.PrintPrefix, file)
+			continue
+		}
+		utils.CL.Greenf("%s ✓ %s 已升级到版本 %d\n", types.PrintPrefix, file, types.CurrentConfigVersion)
+		for _, warning := range warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个配置文件迁移失败", failed, len(files))
+	}
+	return nil
+}
+
+// resolveConfigFiles 将命令行参数解析为配置文件列表
+//
+// 参数:
+//   - args: 任务名称或配置文件路径
+//
+// 返回值:
+//   - []string: 配置文件路径列表, 未指定参数时为 gobf/ 目录下的所有配置文件, gobf/ 不存在时为 gob.toml
+//   - error: 找不到配置文件时返回错误
+func resolveConfigFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		file, err := resolveConfigPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	if len(files) > 0 {
+		return files, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join("gobf", "*.toml"))
+	if err != nil {
+		return nil, fmt.Errorf("查找 gobf 目录下的配置文件失败: %w", err)
+	}
+	if len(matches) > 0 {
+		return matches, nil
+	}
+	if _, err := os.Stat(types.GobBuildFile); err != nil {
+		return nil, fmt.Errorf("未找到配置文件, 请先运行 'gob --init' 或 'gob --generate-config'")
+	}
+	return []string{types.GobBuildFile}, nil
+}
+
+// formatProblems 将errors.Join合并的错误格式化为缩进的列表
+//
+// 参数:
+//   - err: 错误信息
+//
+// 返回值:
+//   - string: 每行一个问题的列表
+func formatProblems(err error) string {
+	var problems []string
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			problems = append(problems, strings.Split(e.Error(), "\n")...)
+		}
+	} else {
+		problems = strings.Split(err.Error(), "\n")
+	}
+	return "  - " + strings.Join(problems, "\n  - ")
+}
+
+// resolveConfigPath 将任务名称或文件路径解析为配置文件路径
+//
+// 参数:
+//   - arg: 任务名称(在 gobf/ 目录下按前缀查找)或配置文件路径, 为空时使用 gob.toml
+//
+// 返回值:
+//   - string: 配置文件路径
+//   - error: 找不到配置文件时返回错误
+func resolveConfigPath(arg string) (string, error) {
+	if arg == "" {
+		arg = types.GobBuildFile
+	}
+
+	// 优先作为文件路径
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		return arg, nil
+	}
+
+	// 作为任务名称在 gobf/ 目录下查找
+	matchedFile, err := utils.FindConfigByPrefix(arg, "gobf")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join("gobf", matchedFile), nil
+}
+
+// annotateOrigins 为序列化后的配置的每个值标注来源文件
+//
+// 参数:
+//   - content: toml.Marshal 输出的配置内容
+//   - origins: 每个配置路径的来源文件
+//
+// 返回值:
+//   - string: 去掉字段说明注释并在每个值后标注来源的配置内容
+func annotateOrigins(content string, origins map[string]string) string {
+	var sb strings.Builder
+	table := ""
+	blank := false
+
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			// 省略字段说明注释
+			continue
+		case trimmed == "":
+			// 合并连续的空行
+			if !blank {
+				sb.WriteString("\n")
+			}
+			blank = true
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			table = strings.Trim(trimmed, "[]")
+			sb.WriteString(line + "\n")
+			blank = false
+			continue
+		}
+		blank = false
+
+		key, _, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			sb.WriteString(line + "\n")
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `'"`)
+		if table != "" {
+			key = table + "." + key
+		}
+
+		origin := utils.ConfigOrigin(origins, key)
+		if origin == "" {
+			origin = "默认值"
+		}
+		fmt.Fprintf(&sb, "%s  # %s\n", line, origin)
+	}
+	return sb.String()
+}
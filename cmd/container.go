@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// containerDir 返回容器镜像的输出目录
+//
+// 参数:
+//   - config: 配置对象
+//
+// 返回值:
+//   - string: 配置的目录, 未配置时为输出目录下的image目录
+func containerDir(config *types.GobConfig) string {
+	if config.Container.Dir != "" {
+		return config.Container.Dir
+	}
+	return filepath.Join(config.Build.Output.Dir, types.DefaultContainerDir)
+}
+
+// prepareContainer 渲染镜像名称和标签, 并初始化镜像目录
+//
+// 参数:
+//   - v: verman对象, 提供模板中的Git元数据
+//   - config: 配置对象
+//
+// 返回值:
+//   - string: 镜像名称
+//   - []string: 去重后的镜像标签
+//   - error: 渲染失败或名称、标签无效时返回错误
+//
+// 注意:
+//   - 在构建开始前调用, 避免所有目标构建完成后才发现镜像名称错误
+func prepareContainer(v *verman.Info, config *types.GobConfig) (string, []string, error) {
+	cfg := config.Container
+	data := releaseTemplateData(v, config)
+
+	nameTmpl := cfg.Name
+	if nameTmpl == "" {
+		nameTmpl = config.Build.Output.Name
+	}
+	name, err := utils.RenderTemplate(nameTmpl, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("渲染镜像名称失败: %w", err)
+	}
+	rendered, err := utils.RenderTemplates(cfg.Tags, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("渲染镜像标签失败: %w", err)
+	}
+	var tags []string
+	for _, tag := range rendered {
+		if tag = strings.TrimSpace(tag); !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	if err := utils.ValidateImageReference(name, tags); err != nil {
+		return "", nil, err
+	}
+	if err := utils.PrepareImageLayout(containerDir(config)); err != nil {
+		return "", nil, err
+	}
+	return name, tags, nil
+}
+
+// buildImage 为linux目标构建容器镜像
+//
+// 参数:
+//   - bc: 构建上下文
+//   - data: 模板数据, 用于渲染可执行文件名、入口命令和镜像标签
+//   - outputPath: 可执行文件路径
+//
+// 返回值:
+//   - *types.OCIDescriptor: 镜像清单的描述符
+//   - error: 错误信息
+//
+// 注意:
+//   - 镜像标签中总是写入Git版本和提交哈希, 与是否启用Git信息注入无关
+func buildImage(bc *types.BuildContext, data *types.TemplateData, outputPath string) (*types.OCIDescriptor, error) {
+	cfg := bc.Config.Container
+	d := *data
+	d.Git = utils.GitTemplateData(bc.VerMan, bc.Config)
+
+	binName, err := utils.RenderTemplate(bc.Config.Build.Output.Name, &d)
+	if err != nil {
+		return nil, fmt.Errorf("渲染输出文件名失败: %w", err)
+	}
+	entrypoint, err := utils.RenderTemplates(cfg.Entrypoint, &d)
+	if err != nil {
+		return nil, fmt.Errorf("渲染入口命令失败: %w", err)
+	}
+	cmdArgs, err := utils.RenderTemplates(cfg.Cmd, &d)
+	if err != nil {
+		return nil, fmt.Errorf("渲染默认参数失败: %w", err)
+	}
+
+	created := time.Now()
+	labels := map[string]string{
+		"org.opencontainers.image.created":  created.UTC().Format(time.RFC3339),
+		"org.opencontainers.image.version":  bc.VerMan.GitVersion,
+		"org.opencontainers.image.revision": bc.VerMan.GitCommit,
+	}
+	for k, v := range cfg.Labels {
+		if labels[k], err = utils.RenderTemplate(v, &d); err != nil {
+			return nil, fmt.Errorf("渲染镜像标签 %s 失败: %w", k, err)
+		}
+	}
+
+	// arm 镜像的架构变体取自 GOARM, 未设置时为Go的默认值7
+	var variant string
+	if bc.SysArch == "arm" {
+		goarm, _, _ := strings.Cut(d.Env["GOARM"], ",")
+		if goarm == "" {
+			goarm = "7"
+		}
+		variant = "v" + goarm
+	}
+
+	return utils.BuildContainerImage(cfg, types.ImageSpec{
+		Arch:       bc.SysArch,
+		Variant:    variant,
+		Binary:     outputPath,
+		BinName:    binName,
+		LayoutDir:  containerDir(bc.Config),
+		Labels:     labels,
+		Created:    created,
+		Entrypoint: entrypoint,
+		Cmd:        cmdArgs,
+	})
+}
+
+// writeContainerImages 将各linux目标的镜像组合为多架构镜像
+//
+// 参数:
+//   - config: 配置对象
+//   - name: 镜像名称
+//   - tags: 镜像标签
+//   - results: 构建结果
+//
+// 返回值:
+//   - error: 错误信息
+func writeContainerImages(config *types.GobConfig, name string, tags []string, results []types.BuildResult) error {
+	var manifests []types.OCIDescriptor
+	var platforms []string
+	for _, r := range results {
+		if r.Image != nil {
+			manifests = append(manifests, *r.Image)
+			platforms = append(platforms, r.Platform+"/"+r.Arch)
+		}
+	}
+	if len(manifests) == 0 {
+		utils.CL.Yellowf("%s 没有linux目标, 跳过生成容器镜像\n", types.PrintPrefix)
+		return nil
+	}
+
+	paths, err := utils.WriteContainerImages(config.Container.Format, containerDir(config), name, tags, manifests)
+	if err != nil {
+		return fmt.Errorf("生成容器镜像失败: %w", err)
+	}
+	for _, p := range paths {
+		utils.CL.Greenf("%s 已生成容器镜像: %s\n", types.PrintPrefix, p)
+	}
+	utils.CL.Greenf("%s 镜像 %s:%s (%s)\n", types.PrintPrefix, name, strings.Join(tags, ", "), strings.Join(platforms, ", "))
+	return nil
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// readDockerManifest 读取 docker load 格式tar中的 manifest.json 及镜像配置
+func readDockerManifest(t *testing.T, file string) (repoTags []string, config map[string]any) {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files[hdr.Name], err = io.ReadAll(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var manifest []struct {
+		Config   string
+		RepoTags []string
+	}
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil || len(manifest) != 1 {
+		t.Fatalf("解析 %s 的 manifest.json 失败: %v", file, err)
+	}
+	if err := json.Unmarshal(files[manifest[0].Config], &config); err != nil {
+		t.Fatal(err)
+	}
+	return manifest[0].RepoTags, config
+}
+
+func TestBuildBatchContainer(t *testing.T) {
+	config := newScriptBatchConfig(t, "echo bin > \"$1\"\n")
+	config.Build.Target.Matrix = append(config.Build.Target.Matrix,
+		types.MatrixEntry{GOOS: "linux", GOARCH: "arm", Env: map[string]string{"GOARM": "6"}},
+		types.MatrixEntry{GOOS: "windows", GOARCH: "amd64"},
+	)
+	config.Container.Enabled = true
+	config.Container.Format = "docker"
+	config.Container.Tags = []string{"{{semver .Git.Version}}", "latest", "latest"}
+	config.Container.Labels = map[string]string{"org.opencontainers.image.title": "{{.Git.AppName}}"}
+	v := &verman.Info{AppName: "myapp", GitVersion: "v1.2.0", GitCommit: "a1b2c3d"}
+
+	if err := buildBatch(context.Background(), v, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只为linux目标构建镜像, arm 的架构变体取自 GOARM
+	dir := filepath.Join(config.Build.Output.Dir, types.DefaultContainerDir)
+	for _, platform := range []string{"amd64", "arm64", "riscv64", "armv6"} {
+		file := filepath.Join(dir, "myapp_linux_"+platform+".tar")
+		repoTags, imageConfig := readDockerManifest(t, file)
+		if !reflect.DeepEqual(repoTags, []string{"myapp:1.2.0", "myapp:latest"}) {
+			t.Errorf("%s: 镜像标签应去重, got %v", platform, repoTags)
+		}
+		variant, _ := imageConfig["variant"].(string)
+		if arch := imageConfig["architecture"].(string) + variant; arch != platform {
+			t.Errorf("%s: 镜像架构 = %s", platform, arch)
+		}
+		labels := imageConfig["config"].(map[string]any)["Labels"].(map[string]any)
+		if labels["org.opencontainers.image.version"] != "v1.2.0" || labels["org.opencontainers.image.revision"] != "a1b2c3d" || labels["org.opencontainers.image.title"] != "myapp" {
+			t.Errorf("%s: 镜像标签(label)不正确: %v", platform, labels)
+		}
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 4 {
+		t.Errorf("期望生成4个镜像文件, got %v, %v", entries, err)
+	}
+}
+
+func TestPrepareContainer(t *testing.T) {
+	v := &verman.Info{GitVersion: "v1.2.0"}
+	tests := []struct {
+		name     string
+		image    string
+		tags     []string
+		wantName string
+		wantTags []string
+		wantErr  string
+	}{
+		{"默认使用输出文件名", "", []string{"{{semver .Git.Version}}", "latest"}, "myapp", []string{"1.2.0", "latest"}, ""},
+		{"标签去重", "registry.example.com/team/myapp", []string{" latest", "latest "}, "registry.example.com/team/myapp", []string{"latest"}, ""},
+		{"名称无效", "Team/MyApp", []string{"latest"}, "", nil, "镜像名称格式无效"},
+		{"标签无效", "", []string{"{{.Git.Version}}+dirty"}, "", nil, "镜像标签格式无效"},
+		{"模板错误", "{{.Missing", []string{"latest"}, "", nil, "渲染镜像名称失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newScriptBatchConfig(t, "")
+			config.Container.Name = tt.image
+			config.Container.Tags = tt.tags
+
+			name, tags, err := prepareContainer(v, config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("期望错误包含 %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || name != tt.wantName || !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("prepareContainer() = %q, %v, %v, 期望 %q, %v", name, tags, err, tt.wantName, tt.wantTags)
+			}
+			if _, err := os.Stat(filepath.Join(containerDir(config), "oci-layout")); err != nil {
+				t.Errorf("应初始化镜像目录: %v", err)
+			}
+		})
+	}
+}
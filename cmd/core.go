@@ -2,13 +2,18 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"gitee.com/MM-Q/comprx"
 	"gitee.com/MM-Q/gob/internal/types"
@@ -20,31 +25,19 @@ import (
 // executeCommands 执行命令列表
 //
 // 参数:
+//   - ctx: 取消上下文, 取消时终止正在执行的命令
 //   - commands: 要执行的命令列表
 //   - exitOnError: 命令执行失败时是否退出程序
 //   - config: 配置对象
-//   - envs: 环境变量列表
+//   - envs: 完整的环境变量列表(已包含配置文件和目标专属的环境变量)
 //
 // 返回值:
 //   - error: 错误信息
-func executeCommands(commands []string, exitOnError bool, config *types.GobConfig, envs []string) error {
+func executeCommands(ctx context.Context, commands []string, exitOnError bool, config *types.GobConfig, envs []string) error {
 	if len(commands) == 0 {
 		return nil
 	}
 
-	// 准备环境变量
-	var cmdEnvs []string
-	if len(envs) > 0 {
-		cmdEnvs = append(cmdEnvs, envs...)
-	}
-
-	// 添加配置文件中的环境变量
-	if len(config.Env) > 0 {
-		for k, v := range config.Env {
-			cmdEnvs = append(cmdEnvs, fmt.Sprintf("%s=%s", k, v))
-		}
-	}
-
 	// 获取工作目录
 	workDir := config.Build.WorkDir
 	if workDir == "" {
@@ -58,16 +51,22 @@ func executeCommands(commands []string, exitOnError bool, config *types.GobConfi
 			continue
 		}
 
+		// 上下文已取消时不再执行后续命令
+		if ctx.Err() != nil {
+			return fmt.Errorf("执行命令 '%s' 前已取消: %w", cmd, ctx.Err())
+		}
+
 		// 执行命令
 		var err error
 		if runtime.GOOS == "windows" {
-			err = shellx.NewCmdStr(cmd).WithEnvs(cmdEnvs).WithWorkDir(workDir).WithShell(shellx.ShellPowerShell).Exec()
+			err = bindProcessTree(shellx.NewCmdStr(cmd).WithContext(ctx).WithEnvs(envs).WithWorkDir(workDir).WithShell(shellx.ShellPowerShell)).Exec()
 		} else {
-			err = shellx.NewCmdStr(cmd).WithEnvs(cmdEnvs).WithWorkDir(workDir).WithShell(shellx.ShellSh).Exec()
+			err = bindProcessTree(shellx.NewCmdStr(cmd).WithContext(ctx).WithEnvs(envs).WithWorkDir(workDir).WithShell(shellx.ShellSh)).Exec()
 		}
 
 		if err != nil {
-			if exitOnError {
+			// 被取消的命令无论错误策略如何都终止执行
+			if exitOnError || ctx.Err() != nil {
 				return fmt.Errorf("执行命令 '%s' 失败: %w", cmd, err)
 			} else {
 				// 打印错误但继续执行
@@ -83,118 +82,160 @@ func executeCommands(commands []string, exitOnError bool, config *types.GobConfi
 // buildSingle 执行单个平台和架构的构建
 //
 // 参数:
-//   - ctx: 构建上下文, 包含所有构建所需的参数
+//   - ctx: 取消上下文, 取消时终止正在执行的构建命令
+//   - bc: 构建上下文, 包含所有构建所需的参数
 //
 // 返回值:
+//   - types.BuildOutput: 构建产物, 启用归档或zip时为归档文件, 否则为可执行文件; 未启用安装包或非linux目标时没有安装包
 //   - error: 错误信息
-func buildSingle(ctx *types.BuildContext) error {
-	// 1. 执行构建前命令
-	if ctx.Config.Build.PreBuild.Enabled {
-		if err := executeCommands(ctx.Config.Build.PreBuild.Commands, ctx.Config.Build.PreBuild.ExitOnError, ctx.Config, ctx.Env); err != nil {
-			return fmt.Errorf("构建前命令执行失败: %w", err)
-		}
-	}
+func buildSingle(ctx context.Context, bc *types.BuildContext) (types.BuildOutput, error) {
+	// 获取构建命令和钩子命令共用的环境变量
+	envs := buildEnvs(bc)
 
-	// 2. 获取构建命令 - 创建副本避免修改全局模板
-	buildCmds := make([]string, len(ctx.Config.Build.Command.Build))
-	copy(buildCmds, ctx.Config.Build.Command.Build)
+	// 创建模板数据, 用于渲染输出文件名、链接器标志、编译命令和构建前后命令
+	data := utils.NewTemplateData(bc, envs)
 
 	// 生成输出路径
 	// 确定版本号: 如果启用了Git信息注入, 则使用Git版本; 否则使用空字符串 (不包含版本号)
 	var version string
-	if ctx.Config.Build.Git.Inject {
-		version = ctx.VerMan.GitVersion
+	if bc.Config.Build.Git.Inject {
+		version = bc.VerMan.GitVersion
 	} else {
 		version = "" // 当未启用Git信息注入时, 不包含版本号
 	}
-	outputPath := filepath.Join(ctx.Config.Build.Output.Dir, utils.GenOutputName(ctx.Config.Build.Output.Name, ctx.Config.Build.Output.Simple, version, ctx.SysPlatform, ctx.SysArch, ctx.Config.Build.Target.Batch))
+	outputName, err := utils.RenderTemplate(bc.OutputName, data)
+	if err != nil {
+		return types.BuildOutput{}, fmt.Errorf("渲染输出文件名失败: %w", err)
+	}
+	if outputName == "" {
+		appName, err := utils.RenderTemplate(bc.Config.Build.Output.Name, data)
+		if err != nil {
+			return types.BuildOutput{}, fmt.Errorf("渲染输出文件名失败: %w", err)
+		}
+		outputName = utils.GenOutputName(appName, bc.Config.Build.Output.Simple, version, bc.SysPlatform, bc.SysArch, bc.Config.Build.Target.Batch)
+	} else if bc.SysPlatform == "windows" && filepath.Ext(outputName) != ".exe" {
+		outputName += ".exe" // 矩阵条目指定的文件名在windows下补全.exe后缀
+	}
+	outputPath := filepath.Join(bc.Config.Build.Output.Dir, outputName)
 
-	// 动态替换命令中的占位符
-	for i, cmd := range buildCmds {
-		switch cmd {
-		case "{{ldflags}}": // 替换链接器标志
-			if ctx.Config.Build.Git.Inject {
-				// 如果启用了Git信息注入, 则替换链接器标志
-				buildCmds[i] = fmt.Sprintf("\"%s\"", replaceGitPlaceholders(ctx.Config.Build.Git.Ldflags, ctx.VerMan))
-			} else {
-				// 否则使用默认链接器标志
-				buildCmds[i] = fmt.Sprintf("\"%s\"", ctx.Config.Build.Compiler.Ldflags)
-			}
+	// 计算链接器标志, 目标专属的链接器标志追加在全局标志之后
+	// generate 模式通过生成的源文件注入Git信息, 仍使用编译器的链接器标志
+	ldflags := bc.Config.Build.Compiler.Ldflags
+	if bc.Config.Build.Git.Inject && bc.Config.Build.Git.Mode != types.GitModeGenerate {
+		ldflags = bc.Config.Build.Git.Ldflags
+	}
+	if bc.Ldflags != "" {
+		ldflags = strings.TrimSpace(ldflags + " " + bc.Ldflags)
+	}
+	if ldflags, err = utils.RenderTemplate(ldflags, data); err != nil {
+		return types.BuildOutput{}, fmt.Errorf("渲染链接器标志失败: %w", err)
+	}
+	data.Ldflags = ldflags
+	data.Output = outputPath
+
+	// 在构建前渲染已启用的构建前后命令, 避免模板错误在编译完成后才暴露; 未启用的命令不渲染
+	var preCommands, postCommands []string
+	if bc.Config.Build.PreBuild.Enabled {
+		if preCommands, err = utils.RenderTemplates(bc.Config.Build.PreBuild.Commands, data); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("渲染构建前命令失败: %w", err)
+		}
+	}
+	if bc.Config.Build.PostBuild.Enabled {
+		if postCommands, err = utils.RenderTemplates(bc.Config.Build.PostBuild.Commands, data); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("渲染构建后命令失败: %w", err)
+		}
+	}
 
-		case "{{output}}": // 替换输出路径
-			buildCmds[i] = outputPath
-		case "{{if UseVendor}}-mod=vendor{{end}}": // 条件添加vendor标志
-			if ctx.Config.Build.Source.UseVendor {
-				buildCmds[i] = "-mod=vendor" // 添加vendor标志
-			} else {
-				buildCmds[i] = "-mod=readonly" // 添加readonly标志
-			}
-		case "{{mainFile}}": // 替换入口文件
-			buildCmds[i] = ctx.Config.Build.Source.MainFile
+	// 1. 执行构建前命令
+	if bc.Config.Build.PreBuild.Enabled {
+		if err := executeCommands(ctx, preCommands, bc.Config.Build.PreBuild.ExitOnError, bc.Config, envs); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("构建前命令执行失败: %w", err)
 		}
 	}
 
+	// 2. 渲染编译命令中的占位符, 未显式使用构建标签的命令自动插入 {{tags}}
+	buildCmds, err := utils.RenderTemplates(ensureTagsPlaceholder(bc.Config.Build.Command.Build), data)
+	if err != nil {
+		return types.BuildOutput{}, fmt.Errorf("渲染编译命令失败: %w", err)
+	}
+	if len(buildCmds) == 0 {
+		return types.BuildOutput{}, fmt.Errorf("编译命令为空")
+	}
+
 	// 在输出目录下检查即将生成的可执行文件是否存在, 存在则删除
 	if _, err := os.Stat(outputPath); err == nil {
 		if err := os.Remove(outputPath); err != nil {
-			return fmt.Errorf("删除 %s 失败: %v, 请手动删除该文件后重试", outputPath, err)
+			return types.BuildOutput{}, fmt.Errorf("删除 %s 失败: %v, 请手动删除该文件后重试", outputPath, err)
 		}
 	}
 
-	// 获取环境变量
-	envs := ctx.Env
+	// 3. 执行构建命令
+	// shellx 设置上下文后会忽略 WithTimeout, 因此将超时合并到上下文中
+	cmdCtx, cancel := withTimeout(ctx, bc.Config.Build.TimeoutDuration)
+	defer cancel()
 
-	// 如果指定了环境变量, 则添加环境变量
-	if len(ctx.Config.Env) > 0 {
-		for k, v := range ctx.Config.Env {
-			envs = append(envs, fmt.Sprintf("%s=%s", k, v))
+	var buildErr error
+	if runtime.GOOS == "windows" {
+		buildErr = bindProcessTree(shellx.NewCmds(buildCmds).WithContext(cmdCtx).WithEnvs(envs).WithShell(shellx.ShellPowerShell)).Exec()
+	} else {
+		buildErr = bindProcessTree(shellx.NewCmds(buildCmds).WithContext(cmdCtx).WithEnvs(envs).WithShell(shellx.ShellSh)).Exec()
+	}
+	if buildErr != nil {
+		// 构建失败或被中断时删除可能写了一半的输出文件
+		if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+			utils.CL.Yellowf("%s 删除未完成的输出文件 %s 失败: %v\n", types.PrintPrefix, outputPath, err)
 		}
+		return types.BuildOutput{}, buildErr
 	}
 
-	// 获取Go代理
-	GOPROXY := fmt.Sprintf("GOPROXY=%s", ctx.Config.Build.Compiler.Proxy)
-
-	// 添加Go代理
-	envs = append(envs, GOPROXY)
-
-	// 检查是否启用CGO
-	if ctx.Config.Build.Compiler.EnableCgo {
-		envs = append(envs, "CGO_ENABLED=1")
-	} else {
-		envs = append(envs, "CGO_ENABLED=0")
+	// 4. 执行构建后命令
+	if bc.Config.Build.PostBuild.Enabled {
+		if err := executeCommands(ctx, postCommands, bc.Config.Build.PostBuild.ExitOnError, bc.Config, envs); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("构建后命令执行失败: %w", err)
+		}
 	}
 
-	// 3. 执行构建命令
-	if runtime.GOOS == "windows" {
-		if buildErr := shellx.NewCmds(buildCmds).WithTimeout(ctx.Config.Build.TimeoutDuration).WithEnvs(envs).WithShell(shellx.ShellPowerShell).Exec(); buildErr != nil {
-			return buildErr
+	// 5. 为linux目标生成安装包和容器镜像, 须在归档删除可执行文件之前执行
+	out := types.BuildOutput{Artifact: outputPath, Binary: filepath.Base(outputPath)}
+	if bc.Config.Package.Linux.Enabled && bc.SysPlatform == "linux" {
+		if out.Packages, err = buildPackages(bc, data, outputPath); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("生成安装包失败: %w", err)
 		}
-	} else {
-		if buildErr := shellx.NewCmds(buildCmds).WithTimeout(ctx.Config.Build.TimeoutDuration).WithEnvs(envs).WithShell(shellx.ShellSh).Exec(); buildErr != nil {
-			return buildErr
+	}
+	if bc.Config.Container.Enabled && bc.SysPlatform == "linux" {
+		if out.Image, err = buildImage(bc, data, outputPath); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("构建容器镜像失败: %w", err)
 		}
 	}
 
-	// 4. 执行构建后命令
-	if ctx.Config.Build.PostBuild.Enabled {
-		if err := executeCommands(ctx.Config.Build.PostBuild.Commands, ctx.Config.Build.PostBuild.ExitOnError, ctx.Config, ctx.Env); err != nil {
-			return fmt.Errorf("构建后命令执行失败: %w", err)
+	// 6. 生成SBOM, 须在安装移动和归档删除可执行文件之前执行
+	if bc.Config.Build.SBOM.Enabled {
+		if out.SBOMs, err = writeSBOMs(bc, outputPath); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("生成SBOM失败: %w", err)
 		}
 	}
 
 	// 如果启用了安装选项, 则执行安装
-	if ctx.Config.Install.Install {
-		if err := installExecutable(outputPath, ctx.Config); err != nil {
-			return fmt.Errorf("安装失败: %w", err)
+	if bc.Config.Install.Install {
+		if err := installExecutable(outputPath, bc.Config); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("安装失败: %w", err)
 		}
-		return nil
+		return out, nil
+	}
+
+	// 打包归档文件, SBOM同时放入归档文件
+	if bc.Config.Build.Output.Archive.Enabled {
+		if out.Artifact, out.Binary, err = archiveOutput(bc, data, outputPath, out.SBOMs); err != nil {
+			return types.BuildOutput{}, fmt.Errorf("打包归档文件失败: %w", err)
+		}
+		return out, nil
 	}
 
 	// 在buildSingle函数中添加zip打包逻辑
-	if ctx.Config.Build.Output.Zip {
+	if bc.Config.Build.Output.Zip {
 		// 检查输出路径是否存在, 不存在则跳过
 		if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-			return fmt.Errorf("编译后的可执行文件不存在: %w", err)
+			return types.BuildOutput{}, fmt.Errorf("编译后的可执行文件不存在: %w", err)
 		}
 
 		// 处理文件名
@@ -203,118 +244,871 @@ func buildSingle(ctx *types.BuildContext) error {
 
 		// 删除目标zip文件, 避免重复打包
 		if err := os.RemoveAll(zipPath); err != nil {
-			return fmt.Errorf("删除历史zip文件失败: %w", err)
+			return types.BuildOutput{}, fmt.Errorf("删除历史zip文件失败: %w", err)
 		}
 
-		// 打包zip文件
+		// 打包zip文件, 失败时删除未完成的zip文件
 		if err := comprx.Pack(zipPath, outputPath); err != nil {
-			return fmt.Errorf("压缩zip文件失败: %w", err)
+			_ = os.Remove(zipPath)
+			return types.BuildOutput{}, fmt.Errorf("压缩zip文件失败: %w", err)
 		}
 
 		// 删除原始文件
 		if err := os.RemoveAll(outputPath); err != nil {
-			return fmt.Errorf("删除编译生成的文件 %s 失败: %w", outputPath, err)
+			return types.BuildOutput{}, fmt.Errorf("删除编译生成的文件 %s 失败: %w", outputPath, err)
+		}
+		out.Artifact = zipPath
+		return out, nil
+	}
+	return out, nil
+}
+
+// buildPackages 为linux目标生成安装包
+//
+// 参数:
+//   - bc: 构建上下文
+//   - data: 模板数据, 用于渲染包名和版本号
+//   - outputPath: 可执行文件路径
+//
+// 返回值:
+//   - []string: 生成的安装包路径
+//   - error: 错误信息
+//
+// 注意:
+//   - 可执行文件以 build.output.name 的渲染结果为文件名安装
+//   - 未设置版本号时使用Git版本
+func buildPackages(bc *types.BuildContext, data *types.TemplateData, outputPath string) ([]string, error) {
+	cfg := bc.Config.Package.Linux
+
+	binName, err := utils.RenderTemplate(bc.Config.Build.Output.Name, data)
+	if err != nil {
+		return nil, fmt.Errorf("渲染输出文件名失败: %w", err)
+	}
+	name := binName
+	if cfg.Name != "" {
+		if name, err = utils.RenderTemplate(cfg.Name, data); err != nil {
+			return nil, fmt.Errorf("渲染包名失败: %w", err)
+		}
+	}
+	version := bc.VerMan.GitVersion
+	if cfg.Version != "" {
+		if version, err = utils.RenderTemplate(cfg.Version, data); err != nil {
+			return nil, fmt.Errorf("渲染版本号失败: %w", err)
+		}
+	}
+
+	return utils.BuildLinuxPackages(cfg, types.PackageSpec{
+		Name:    name,
+		Version: version,
+		Arch:    bc.SysArch,
+		Binary:  outputPath,
+		BinName: binName,
+		OutDir:  bc.Config.Build.Output.Dir,
+		MTime:   time.Now(),
+	})
+}
+
+// archiveOutput 将可执行文件及附加文件打包为归档文件, 启用变更日志时一并放入
+//
+// 参数:
+//   - bc: 构建上下文
+//   - data: 模板数据, 用于渲染归档文件名
+//   - outputPath: 可执行文件路径
+//   - sboms: 与可执行文件一起放入归档文件的SBOM文件路径, gz格式不放入
+//
+// 返回值:
+//   - string: 归档文件路径
+//   - string: 可执行文件在归档中的相对路径
+//   - error: 错误信息
+//
+// 注意:
+//   - 打包成功后删除原始的可执行文件
+func archiveOutput(bc *types.BuildContext, data *types.TemplateData, outputPath string, sboms []string) (string, string, error) {
+	cfg := bc.Config.Build.Output.Archive
+
+	// 确定归档格式, 可按平台覆盖
+	format := utils.ArchiveFormat(cfg, bc.SysPlatform)
+	ext, err := utils.ArchiveExt(format)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 归档文件名默认使用输出文件名(不含.exe)
+	name := strings.TrimSuffix(filepath.Base(outputPath), ".exe")
+	if cfg.Name != "" {
+		if name, err = utils.RenderTemplate(cfg.Name, data); err != nil {
+			return "", "", fmt.Errorf("渲染归档文件名失败: %w", err)
+		}
+		if strings.TrimSpace(name) == "" {
+			return "", "", fmt.Errorf("归档文件名渲染结果为空")
+		}
+	}
+	archivePath := filepath.Join(bc.Config.Build.Output.Dir, name+ext)
+
+	// 收集可执行文件和附加文件
+	entries := []types.ArchiveEntry{{Src: outputPath, Name: filepath.Base(outputPath)}}
+	if format != "gz" { // gz 只能压缩单个文件, SBOM和变更日志仅保留在输出目录
+		for _, sbom := range sboms {
+			entries = append(entries, types.ArchiveEntry{Src: sbom, Name: filepath.Base(sbom)})
+		}
+		if changelog := bc.Config.Changelog; changelog.Enabled {
+			entries = append(entries, types.ArchiveEntry{Src: filepath.Join(bc.Config.Build.Output.Dir, changelog.File), Name: changelog.File})
+		}
+	}
+	extra, err := utils.CollectArchiveFiles(cfg.Files)
+	if err != nil {
+		return "", "", err
+	}
+	entries = append(entries, extra...)
+
+	// 放入与归档文件同名的顶层目录
+	if cfg.WrapInDirectory {
+		for i := range entries {
+			entries[i].Name = name + "/" + entries[i].Name
 		}
 	}
+
+	if err := utils.CreateArchive(archivePath, format, entries); err != nil {
+		return "", "", err
+	}
+
+	// gz 解压后的文件名为去掉.gz后缀的归档文件名
+	binary := entries[0].Name
+	if format == "gz" {
+		binary = name
+	}
+
+	// 删除原始文件
+	if err := os.Remove(outputPath); err != nil {
+		return "", "", fmt.Errorf("删除编译生成的文件 %s 失败: %w", outputPath, err)
+	}
+	return archivePath, binary, nil
+}
+
+// gobVersion gob自身的版本, 须在获取项目的Git元数据覆盖 verman.V 之前读取
+var gobVersion = verman.V.GitVersion
+
+// writeSBOMs 为可执行文件生成SBOM
+//
+// 参数:
+//   - bc: 构建上下文
+//   - outputPath: 可执行文件路径
+//
+// 返回值:
+//   - []string: 生成的SBOM文件路径
+//   - error: 错误信息
+//
+// 注意:
+//   - 主模块的版本使用Git版本, 未获取到Git版本时使用可执行文件中记录的版本
+func writeSBOMs(bc *types.BuildContext, outputPath string) ([]string, error) {
+	paths, fallback, err := utils.WriteSBOMs(types.SBOMSpec{
+		Binary:      outputPath,
+		Version:     bc.VerMan.GitVersion,
+		ToolVersion: gobVersion,
+		Created:     time.Now(),
+	}, bc.Config.Build.SBOM.Formats)
+	if err != nil {
+		return nil, err
+	}
+	if fallback {
+		utils.CL.Yellowf("%s %s 中没有构建信息, SBOM的依赖取自 go.mod\n", types.PrintPrefix, filepath.Base(outputPath))
+	}
+	return paths, nil
+}
+
+// writeChangelog 在输出目录下生成当前版本的变更日志
+//
+// 参数:
+//   - v: verman对象
+//   - config: 配置对象
+//
+// 返回值:
+//   - error: 错误信息
+func writeChangelog(v *verman.Info, config *types.GobConfig) error {
+	changelog, err := utils.GenerateChangelog(config.Build.TimeoutDuration, releaseTemplateData(v, config), "")
+	if err != nil {
+		return fmt.Errorf("生成变更日志失败: %w", err)
+	}
+	path := filepath.Join(config.Build.Output.Dir, config.Changelog.File)
+	if err := os.WriteFile(path, []byte(changelog), 0644); err != nil {
+		return fmt.Errorf("写入变更日志失败: %w", err)
+	}
+	utils.CL.Greenf("%s 已生成变更日志: %s\n", types.PrintPrefix, path)
 	return nil
 }
 
+// buildEnvs 生成构建命令和钩子命令使用的环境变量
+//
+// 参数:
+//   - bc: 构建上下文
+//
+// 返回值:
+//   - []string: 环境变量列表, 后出现的同名变量优先
+//
+// 注意:
+//   - 优先级从低到高: 系统环境变量及GOOS/GOARCH < [env] < GOPROXY/CGO_ENABLED < 目标专属环境变量
+func buildEnvs(bc *types.BuildContext) []string {
+	envs := make([]string, 0, len(bc.Env)+len(bc.Config.Env)+len(bc.TargetEnv)+2)
+	envs = append(envs, bc.Env...)
+
+	// 如果指定了环境变量, 则添加环境变量
+	for k, v := range bc.Config.Env {
+		envs = append(envs, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	// 添加Go代理
+	envs = append(envs, fmt.Sprintf("GOPROXY=%s", bc.Config.Build.Compiler.Proxy))
+
+	// 检查是否启用CGO
+	if bc.Config.Build.Compiler.EnableCgo {
+		envs = append(envs, "CGO_ENABLED=1")
+	} else {
+		envs = append(envs, "CGO_ENABLED=0")
+	}
+
+	// 目标专属的环境变量最后添加, 可覆盖CGO_ENABLED等全局设置
+	return append(envs, bc.TargetEnv...)
+}
+
+// envList 将环境变量映射转换为 KEY=VALUE 形式的列表
+//
+// 参数:
+//   - env: 环境变量映射
+//
+// 返回值:
+//   - []string: 按键排序的环境变量列表
+func envList(env map[string]string) []string {
+	keys := slices.Sorted(maps.Keys(env))
+	envs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		envs = append(envs, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	return envs
+}
+
+// ensureTagsPlaceholder 确保编译命令中包含构建标签占位符
+//
+// 参数:
+//   - buildCmds: 编译命令模板
+//
+// 返回值:
+//   - []string: 编译命令模板的副本
+//
+// 注意:
+//   - 命令中已使用 {{tags}} 或 .Target.Tags 时原样返回
+//   - 否则将 {{tags}} 插入到 build 子命令之后, 找不到时插入到命令名之后
+//   - {{tags}} 在无构建标签时渲染为空, 渲染后会被移除
+func ensureTagsPlaceholder(buildCmds []string) []string {
+	cmds := slices.Clone(buildCmds)
+	for _, cmd := range cmds {
+		if strings.Contains(cmd, "{{tags}}") || strings.Contains(cmd, ".Target.Tags") {
+			return cmds
+		}
+	}
+
+	idx := slices.Index(cmds, "build")
+	if idx < 0 {
+		idx = 0
+	}
+	return slices.Insert(cmds, min(idx+1, len(cmds)), "{{tags}}")
+}
+
 // buildBatch 执行批量构建
 //
 // 参数:
+//   - ctx: 取消上下文, 取消时终止所有正在构建的目标
 //   - v: verman对象
 //   - config: 配置对象
 //
 // 返回值:
-//   - error: 错误信息
-func buildBatch(v *verman.Info, config *types.GobConfig) error {
-	var wg sync.WaitGroup                                  // 用于同步goroutine
-	var printMutex sync.Mutex                              // 用于同步打印输出
-	maxConcurrency := runtime.NumCPU()                     // 使用CPU核心数作为默认并发数
+//   - error: 存在未成功的目标时返回*types.BuildError, 全部成功时返回nil
+func buildBatch(ctx context.Context, v *verman.Info, config *types.GobConfig) error {
+	var wg sync.WaitGroup      // 用于同步goroutine
+	var printMutex sync.Mutex  // 用于同步打印输出
+	var resultMutex sync.Mutex // 用于同步构建结果
+
+	// 并发数优先使用配置的jobs, 未配置时使用CPU核心数
+	maxConcurrency := config.Build.Target.Jobs
+	if maxConcurrency <= 0 {
+		maxConcurrency = runtime.NumCPU()
+	}
 	concurrencyChan := make(chan struct{}, maxConcurrency) // 控制并发数量的信号量
 
+	// 派生可取消的上下文, 启用fail_fast时用于取消其余目标
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 按调度顺序记录每个目标的构建结果
+	var results []types.BuildResult
+
 	// 获取根环境变量
 	rootEnvs := os.Environ()
 
 	// 根环境变量长度
 	rootEnvLen := len(rootEnvs)
 
-	// 遍历平台
-	for _, platform := range config.Build.Target.Platforms {
-		// 遍历架构
-		for _, arch := range config.Build.Target.Architectures {
-			// 跳过不支持的darwin/386和darwin/arm组合
-			if platform == "darwin" && (arch == "386" || arch == "arm") {
-				continue
-			}
+	// 查询当前工具链支持的目标平台, 失败时跳过校验
+	dist, err := utils.LoadDistTargets(config.Build.TimeoutDuration)
+	if err != nil {
+		utils.CL.Yellowf("%s 无法获取工具链支持的目标平台, 跳过目标校验: %v\n", types.PrintPrefix, err)
+	}
 
-			// 如果开启了仅构建当前平台, 则跳过其他平台
-			if config.Build.Target.CurrentPlatformOnly {
-				if platform != runtime.GOOS || arch != runtime.GOARCH {
-					printMutex.Lock()
-					// 仅在批量模式下打印跳过信息
-					if config.Build.Target.Batch {
-						utils.CL.Greenf("%s 跳过非当前平台: %s/%s\n", types.PrintPrefix, platform, arch)
-					}
-					printMutex.Unlock()
-					continue
+	// 解析构建目标(平台×架构组合、目标平台模式及构建矩阵)
+	targets, skipped, err := utils.ResolveTargets(config, dist)
+	if err != nil {
+		return err
+	}
+
+	// 启用上传时预先读取凭据
+	s3Client, httpUploader, err := prepareUploads(config)
+	if err != nil {
+		return err
+	}
+
+	// 启用签名时预先读取私钥
+	signer, err := prepareSigner(config)
+	if err != nil {
+		return err
+	}
+
+	// 启用容器镜像时预先渲染镜像名称和标签
+	var imageName string
+	var imageTags []string
+	if config.Container.Enabled {
+		if imageName, imageTags, err = prepareContainer(v, config); err != nil {
+			return err
+		}
+	}
+
+	// 删除上次构建留下的校验和文件和产物清单, 避免本次构建失败时被误用
+	if err := removeStaleOutputs(config); err != nil {
+		return err
+	}
+
+	// 生成变更日志, 各目标打包归档文件时放入
+	if config.Changelog.Enabled {
+		if err := writeChangelog(v, config); err != nil {
+			return err
+		}
+	}
+
+	// 仅在批量模式下打印跳过信息
+	if config.Build.Target.Batch {
+		for _, s := range skipped {
+			utils.CL.Yellowf("%s 跳过不支持的目标: %s\n", types.PrintPrefix, s)
+		}
+	}
+
+	// 遍历构建目标
+	for _, target := range targets {
+		platform, arch := target.GOOS, target.GOARCH
+		name := utils.TargetName(target)
+
+		// 如果开启了仅构建当前平台, 则跳过其他平台
+		if config.Build.Target.CurrentPlatformOnly {
+			if platform != runtime.GOOS || arch != runtime.GOARCH {
+				printMutex.Lock()
+				// 仅在批量模式下打印跳过信息
+				if config.Build.Target.Batch {
+					utils.CL.Greenf("%s 跳过非当前平台: %s\n", types.PrintPrefix, name)
 				}
+				printMutex.Unlock()
+				continue
 			}
+		}
 
-			// 获取并发信号量
-			concurrencyChan <- struct{}{}
+		// 登记目标, 未执行的目标保持取消状态
+		resultMutex.Lock()
+		idx := len(results)
+		results = append(results, types.BuildResult{Name: name, Platform: platform, Arch: arch, Status: types.BuildStatusCanceled})
+		resultMutex.Unlock()
 
-			// 启动goroutine执行并行构建
-			wg.Go(func() {
-				defer func() {
-					<-concurrencyChan // 释放并发信号量
-				}()
+		// 获取并发信号量, 上下文取消后不再启动新的目标
+		select {
+		case concurrencyChan <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		if ctx.Err() != nil {
+			<-concurrencyChan
+			continue
+		}
 
-				defer func() {
-					if err := recover(); err != nil {
-						fmt.Printf("%s panic: %v\nstack: %s\n", types.PrintPrefix, err, debug.Stack())
-					}
-				}()
-
-				// 拷贝根环境变量
-				envs := make([]string, rootEnvLen)
-				copy(envs, rootEnvs)
-
-				// 设置平台和架构
-				GOOS := fmt.Sprintf("GOOS=%s", platform)
-				GOARCH := fmt.Sprintf("GOARCH=%s", arch)
-
-				// 添加环境变量
-				envs = append(envs, GOOS, GOARCH)
-
-				// 构建上下文
-				ctx := &types.BuildContext{
-					VerMan:      v,        // VerMan对象
-					Env:         envs,     // 环境变量
-					SysPlatform: platform, // 平台
-					SysArch:     arch,     // 架构
-					Config:      config,   // 配置
+		// 启动goroutine执行并行构建
+		wg.Go(func() {
+			defer func() {
+				<-concurrencyChan // 释放并发信号量
+			}()
+
+			startTime := time.Now()
+
+			// 记录构建结果并打印单个目标状态
+			record := func(out types.BuildOutput, buildErr error) {
+				result := types.BuildResult{
+					BuildOutput: out,
+					Name:        name,
+					Platform:    platform,
+					Arch:        arch,
+					Status:      types.BuildStatusSuccess,
+					Duration:    time.Since(startTime),
+					Err:         buildErr,
 				}
 
-				// 直接调用构建函数并处理错误
-				if buildErr := buildSingle(ctx); buildErr != nil {
+				switch {
+				case buildErr == nil:
 					printMutex.Lock()
-					utils.CL.Redf("%s build %s/%s ✗ %v\n", types.PrintPrefix, platform, arch, buildErr)
+					utils.CL.Greenf("%s build %s ✓\n", types.PrintPrefix, name)
 					printMutex.Unlock()
-				} else {
+				case ctx.Err() != nil:
+					// 上下文已被中断信号或其他目标的失败取消
+					result.Status = types.BuildStatusCanceled
 					printMutex.Lock()
-					utils.CL.Greenf("%s build %s/%s ✓\n", types.PrintPrefix, platform, arch)
+					utils.CL.Yellowf("%s build %s - 已取消\n", types.PrintPrefix, name)
 					printMutex.Unlock()
+				default:
+					result.Status = types.BuildStatusFailed
+					printMutex.Lock()
+					utils.CL.Redf("%s build %s ✗ %v\n", types.PrintPrefix, name, buildErr)
+					printMutex.Unlock()
+
+					// 启用fail_fast时取消其余目标
+					if config.Build.Target.FailFast {
+						cancel()
+					}
 				}
-			})
-		}
+
+				resultMutex.Lock()
+				results[idx] = result
+				resultMutex.Unlock()
+			}
+
+			defer func() {
+				if err := recover(); err != nil {
+					fmt.Printf("%s panic: %v\nstack: %s\n", types.PrintPrefix, err, debug.Stack())
+					record(types.BuildOutput{}, fmt.Errorf("panic: %v", err))
+				}
+			}()
+
+			// 拷贝根环境变量
+			envs := make([]string, rootEnvLen)
+			copy(envs, rootEnvs)
+
+			// 设置平台和架构
+			GOOS := fmt.Sprintf("GOOS=%s", platform)
+			GOARCH := fmt.Sprintf("GOARCH=%s", arch)
+
+			// 添加环境变量
+			envs = append(envs, GOOS, GOARCH)
+
+			// 构建上下文
+			bc := &types.BuildContext{
+				VerMan:      v,                   // VerMan对象
+				Env:         envs,                // 环境变量
+				SysPlatform: platform,            // 平台
+				SysArch:     arch,                // 架构
+				Config:      config,              // 配置
+				TargetEnv:   envList(target.Env), // 目标专属环境变量
+				Ldflags:     target.Ldflags,      // 目标专属链接器标志
+				Tags:        target.Tags,         // 目标专属构建标签
+				OutputName:  target.Output,       // 目标专属输出文件名
+			}
+
+			// 直接调用构建函数并记录结果
+			record(buildSingle(ctx, bc))
+		})
 	}
 
 	// 等待所有goroutine完成
 	wg.Wait()
+
+	// 批量模式下打印构建汇总
+	if config.Build.Target.Batch {
+		printBuildSummary(results)
+	}
+
+	if err := types.NewBuildError(results); err != nil {
+		if config.Build.Checksum.Enabled {
+			utils.CL.Yellowf("%s 存在未成功的目标, 跳过生成校验和文件\n", types.PrintPrefix)
+		}
+		if config.Sign.Enabled {
+			utils.CL.Yellowf("%s 存在未成功的目标, 跳过生成签名文件\n", types.PrintPrefix)
+		}
+		if config.Container.Enabled {
+			utils.CL.Yellowf("%s 存在未成功的目标, 跳过生成容器镜像\n", types.PrintPrefix)
+		}
+		if config.Publish.Manifests.Enabled {
+			utils.CL.Yellowf("%s 存在未成功的目标, 跳过生成包管理器清单\n", types.PrintPrefix)
+		}
+		if config.Build.Manifest.Enabled {
+			utils.CL.Yellowf("%s 存在未成功的目标, 跳过生成产物清单\n", types.PrintPrefix)
+		}
+		if config.Publish.S3.Enabled || config.Publish.HTTP.Enabled {
+			utils.CL.Yellowf("%s 存在未成功的目标, 跳过上传构建产物\n", types.PrintPrefix)
+		}
+		return err
+	}
+
+	// 所有目标构建成功后生成校验和文件
+	var checksumPath string
+	if config.Build.Checksum.Enabled {
+		if checksumPath, err = writeChecksums(config, results); err != nil {
+			return err
+		}
+	}
+
+	// 为校验和文件和构建产物签名
+	var signatures map[string]string
+	if signer != nil {
+		if signatures, err = writeSignatures(config, signer, results, checksumPath); err != nil {
+			return err
+		}
+	}
+
+	// 组合各linux目标的容器镜像
+	if config.Container.Enabled {
+		if err := writeContainerImages(config, imageName, imageTags, results); err != nil {
+			return err
+		}
+	}
+
+	// 根据产物生成包管理器清单
+	if config.Publish.Manifests.Enabled {
+		if err := writePublishManifests(v, config, results); err != nil {
+			return err
+		}
+	}
+
+	// 产物清单和上传使用同一份产物列表
+	uploads := config.Publish.S3.Enabled || config.Publish.HTTP.Enabled
+	if !config.Build.Manifest.Enabled && !uploads {
+		return nil
+	}
+	artifacts, err := collectArtifacts(config, results, checksumPath, signatures)
+	if err != nil {
+		return fmt.Errorf("收集构建产物失败: %w", err)
+	}
+
+	// 生成描述所有产物的清单
+	if config.Build.Manifest.Enabled {
+		if err := writeManifest(v, config, artifacts); err != nil {
+			return err
+		}
+	}
+
+	// 上传到对象存储或HTTP服务
+	if uploads {
+		if err := uploadArtifacts(ctx, v, config, s3Client, httpUploader, artifacts); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// removeStaleOutputs 删除输出目录中上次构建生成的校验和文件和产物清单
+//
+// 参数:
+//   - config: 配置对象
+//
+// 返回值:
+//   - error: 删除失败时返回错误
+//
+// 注意:
+//   - 只删除已启用的校验和文件和产物清单, 本次构建成功后重新生成
+func removeStaleOutputs(config *types.GobConfig) error {
+	var names []string
+	if config.Build.Checksum.Enabled {
+		names = append(names, config.Build.Checksum.File)
+	}
+	if config.Build.Manifest.Enabled {
+		names = append(names, config.Build.Manifest.File)
+	}
+	for _, name := range names {
+		path := filepath.Join(config.Build.Output.Dir, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除上次构建的 %s 失败: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// writeChecksums 为所有目标的构建产物生成校验和文件
+//
+// 参数:
+//   - config: 配置对象
+//   - results: 构建结果
+//
+// 返回值:
+//   - string: 校验和文件路径, 没有构建产物时为空
+//   - error: 错误信息
+func writeChecksums(config *types.GobConfig, results []types.BuildResult) (string, error) {
+	var artifacts []string
+	for _, r := range results {
+		if r.Artifact != "" {
+			artifacts = append(artifacts, r.Artifact)
+		}
+		artifacts = append(artifacts, r.Packages...)
+		artifacts = append(artifacts, r.SBOMs...)
+	}
+	if len(artifacts) == 0 {
+		return "", nil
+	}
+
+	checksum := config.Build.Checksum
+	path, err := utils.WriteChecksumFile(config.Build.Output.Dir, checksum.File, checksum.Algorithm, artifacts)
+	if err != nil {
+		return "", fmt.Errorf("生成校验和文件失败: %w", err)
+	}
+	utils.CL.Greenf("%s 已生成校验和文件: %s (%s, %d 个文件)\n", types.PrintPrefix, path, checksum.Algorithm, len(artifacts))
+	return path, nil
+}
+
+// writeManifest 生成描述本次构建所有产物的清单
+//
+// 参数:
+//   - v: verman对象, 启用Git信息注入时写入Git元数据
+//   - config: 配置对象
+//   - artifacts: 本次构建生成的所有文件
+//
+// 返回值:
+//   - error: 错误信息
+func writeManifest(v *verman.Info, config *types.GobConfig, artifacts []types.Artifact) error {
+	dir := config.Build.Output.Dir
+	manifest := &types.ArtifactManifest{
+		Config:      filepath.ToSlash(config.ConfigFile),
+		OutputDir:   filepath.ToSlash(dir),
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Artifacts:   artifacts,
+	}
+	if config.Build.Git.Inject {
+		manifest.Git = &types.ArtifactGit{
+			AppName:    v.AppName,
+			Version:    v.GitVersion,
+			Commit:     v.GitCommit,
+			CommitTime: v.GitCommitTime,
+			TreeState:  v.GitTreeState,
+			BuildTime:  v.BuildTime,
+		}
+	}
+
+	path, err := utils.WriteArtifactManifest(dir, config.Build.Manifest.File, manifest)
+	if err != nil {
+		return fmt.Errorf("生成产物清单失败: %w", err)
+	}
+	utils.CL.Greenf("%s 已生成产物清单: %s (%d 个文件)\n", types.PrintPrefix, path, len(manifest.Artifacts))
+	return nil
+}
+
+// collectArtifacts 收集本次构建生成的所有文件
+//
+// 参数:
+//   - config: 配置对象
+//   - results: 构建结果
+//   - checksumPath: 校验和文件路径, 为空时表示未生成校验和文件
+//   - signatures: 被签名的文件路径到签名文件路径的映射, 未启用签名时为nil
+//
+// 返回值:
+//   - []types.Artifact: 各目标的产物和安装包, 以及校验和文件, 签名文件紧跟在被签名的文件之后
+//   - error: 读取文件失败时返回错误
+func collectArtifacts(config *types.GobConfig, results []types.BuildResult, checksumPath string, signatures map[string]string) ([]types.Artifact, error) {
+	dir := config.Build.Output.Dir
+	artifacts := []types.Artifact{}
+
+	// add 添加文件及其签名文件, 签名文件与被签名的文件属于同一目标
+	add := func(path string, kind types.ArtifactKind, r *types.BuildResult) error {
+		for _, p := range []string{path, signatures[path]} {
+			if p == "" {
+				continue
+			}
+			artifact, err := utils.NewArtifact(dir, p, kind)
+			if err != nil {
+				return err
+			}
+			if r != nil {
+				artifact.Target, artifact.OS, artifact.Arch = r.Target(), r.Platform, r.Arch
+			}
+			artifacts = append(artifacts, artifact)
+			kind = types.ArtifactKindSignature
+		}
+		return nil
+	}
+
+	// 启用归档或zip打包(安装时不打包)时产物为归档文件, 否则为可执行文件
+	kind := types.ArtifactKindBinary
+	if config.Build.Output.Archive.Enabled || (config.Build.Output.Zip && !config.Install.Install) {
+		kind = types.ArtifactKindArchive
+	}
+
+	for i := range results {
+		r := &results[i]
+		if r.Artifact == "" {
+			continue
+		}
+		if err := add(r.Artifact, kind, r); err != nil {
+			return nil, err
+		}
+		for _, pkg := range r.Packages {
+			if err := add(pkg, types.ArtifactKindPackage, r); err != nil {
+				return nil, err
+			}
+		}
+		for _, sbom := range r.SBOMs {
+			if err := add(sbom, types.ArtifactKindSBOM, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if checksumPath != "" {
+		if err := add(checksumPath, types.ArtifactKindChecksum, nil); err != nil {
+			return nil, err
+		}
+	}
+	return artifacts, nil
+}
+
+// writePublishManifests 根据构建产物生成包管理器清单
+//
+// 参数:
+//   - v: verman对象, 提供下载地址和版本号模板中的Git元数据
+//   - config: 配置对象
+//   - results: 构建结果
+//
+// 返回值:
+//   - error: 错误信息
+//
+// 注意:
+//   - 没有适用产物的清单格式会被跳过, 如未构建windows目标时跳过scoop和winget
+func writePublishManifests(v *verman.Info, config *types.GobConfig, results []types.BuildResult) error {
+	cfg := config.Publish.Manifests
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(config.Build.Output.Dir, types.DefaultPublishManifestsDir)
+	}
+
+	data := releaseTemplateData(v, config)
+
+	// 软件名称默认使用输出文件名, 版本号默认从Git版本推导
+	nameTmpl, versionTmpl := cfg.Name, cfg.Version
+	if nameTmpl == "" {
+		nameTmpl = config.Build.Output.Name
+	}
+	if versionTmpl == "" {
+		versionTmpl = "{{semver .Git.Version}}"
+	}
+	name, err := utils.RenderTemplate(nameTmpl, data)
+	if err != nil {
+		return fmt.Errorf("渲染软件名称失败: %w", err)
+	}
+	version, err := utils.RenderTemplate(versionTmpl, data)
+	if err != nil {
+		return fmt.Errorf("渲染版本号失败: %w", err)
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		return fmt.Errorf("包管理器清单的软件名称为空")
+	}
+	if version = strings.TrimSpace(version); version == "" {
+		return fmt.Errorf("包管理器清单的版本号为空")
+	}
+
+	spec := &types.ReleaseSpec{
+		Name:        name,
+		Version:     version,
+		Description: cfg.Description,
+		Homepage:    cfg.Homepage,
+		License:     cfg.License,
+	}
+	archive := config.Build.Output.Archive
+	for _, r := range results {
+		if r.Artifact == "" {
+			continue
+		}
+
+		data.Target = types.TemplateTarget{OS: r.Platform, Arch: r.Arch}
+		data.Artifact = filepath.Base(r.Artifact)
+		url, err := utils.RenderTemplate(cfg.URL, data)
+		if err != nil {
+			return fmt.Errorf("渲染下载地址失败: %w", err)
+		}
+		sum, err := utils.FileChecksum(r.Artifact, "sha256")
+		if err != nil {
+			return fmt.Errorf("计算 %s 的校验和失败: %w", r.Artifact, err)
+		}
+
+		// 与产物清单一致: 启用归档或zip打包(安装时不打包)时产物为归档文件
+		format := ""
+		switch {
+		case archive.Enabled:
+			format = utils.ArchiveFormat(archive, r.Platform)
+		case config.Build.Output.Zip && !config.Install.Install:
+			format = "zip"
+		}
+
+		spec.Assets = append(spec.Assets, types.ReleaseAsset{
+			OS:     r.Platform,
+			Arch:   r.Arch,
+			URL:    url,
+			SHA256: sum,
+			Format: format,
+			Binary: r.Binary,
+		})
+	}
+
+	for _, format := range cfg.Formats {
+		paths, err := utils.WritePublishManifest(format, spec, cfg, dir)
+		if errors.Is(err, utils.ErrNoReleaseAssets) {
+			utils.CL.Yellowf("%s 没有适用于 %s 的构建产物, 跳过生成该清单\n", types.PrintPrefix, format)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("生成 %s 清单失败: %w", format, err)
+		}
+		for _, p := range paths {
+			utils.CL.Greenf("%s 已生成 %s 清单: %s\n", types.PrintPrefix, format, p)
+		}
+	}
+	return nil
+}
+
+// releaseTemplateData 创建与构建目标无关的模板数据, 用于渲染发布相关的配置
+//
+// 参数:
+//   - v: verman对象
+//   - config: 配置对象
+//
+// 返回值:
+//   - *types.TemplateData: 模板数据, Git元数据总是填充, Target 由调用方按需设置
+func releaseTemplateData(v *verman.Info, config *types.GobConfig) *types.TemplateData {
+	data := &types.TemplateData{
+		Git:      utils.GitTemplateData(v, config),
+		Env:      map[string]string{},
+		Vars:     config.VarValues,
+		Config:   config,
+		MainFile: config.Build.Source.MainFile,
+	}
+	for _, env := range os.Environ() {
+		if k, val, ok := strings.Cut(env, "="); ok {
+			data.Env[k] = val
+		}
+	}
+	return data
+}
+
+// withTimeout 为上下文附加超时时间
+//
+// 参数:
+//   - ctx: 父上下文
+//   - timeout: 超时时间, 小于等于0时不设置超时
+//
+// 返回值:
+//   - context.Context: 派生的上下文
+//   - context.CancelFunc: 取消函数
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // installExecutable 将可执行文件安装到指定路径或GOPATH/bin目录
 //
 // 参数:
@@ -374,11 +1168,17 @@ func loadAndValidateConfig(config *types.GobConfig, configFilePath string) error
 	// 加载配置文件
 	loadedConfig, err := utils.LoadConfig(configFilePath)
 	if err != nil {
-		return fmt.Errorf("加载构建文件 %s 失败: %v", configFilePath, err)
+		return fmt.Errorf("加载构建文件 %s 失败:\n%s", configFilePath, formatProblems(err))
+	}
+
+	// 校验配置, 一次性报告所有问题
+	if err := utils.ValidateConfig(loadedConfig); err != nil {
+		return fmt.Errorf("构建文件 %s 校验失败:\n%s", configFilePath, formatProblems(err))
 	}
 
 	// 将加载的配置复制到传入的config指针
 	*config = *loadedConfig
+	config.ConfigFile = configFilePath
 
 	// 如果启用了安装选项, 则处理安装路径
 	if config.Install.Install {
@@ -403,33 +1203,6 @@ func loadAndValidateConfig(config *types.GobConfig, configFilePath string) error
 	return nil
 }
 
-// replaceGitPlaceholders 将链接器标志中的占位符替换为实际的Git元数据
-//
-// 参数:
-//   - ldflags: 包含占位符的链接器标志字符串
-//   - v: 包含Git元数据的结构体
-//
-// 返回值:
-//   - string: 替换后的链接器标志字符串
-func replaceGitPlaceholders(ldflags string, v *verman.Info) string {
-	// 定义占位符映射关系
-	placeholders := map[string]string{
-		"{{AppName}}":       v.AppName,
-		"{{GitVersion}}":    v.GitVersion,
-		"{{GitCommit}}":     v.GitCommit,
-		"{{GitCommitTime}}": v.GitCommitTime,
-		"{{BuildTime}}":     v.BuildTime,
-		"{{GitTreeState}}":  v.GitTreeState,
-	}
-
-	// 替换所有占位符
-	for placeholder, value := range placeholders {
-		ldflags = strings.ReplaceAll(ldflags, placeholder, value)
-	}
-
-	return ldflags
-}
-
 // listBuildTasks 列出可用的构建任务
 //
 // 返回值:
@@ -495,7 +1268,7 @@ func listBuildTasks() error {
 // 返回值:
 //   - string: 描述信息
 func extractTaskDescription(configPath string) string {
-	// 读取配置文件的第一行
+	// 读取配置文件的首行注释
 	file, err := os.Open(configPath)
 	if err != nil {
 		return "Build task"
@@ -503,14 +1276,20 @@ func extractTaskDescription(configPath string) string {
 	defer func() { _ = file.Close() }()
 
 	scanner := bufio.NewScanner(file)
-	if scanner.Scan() {
+	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
+		// 跳过 #:schema 等编辑器指令
+		if strings.HasPrefix(line, "#:") {
+			continue
+		}
+
 		// 如果第一行以 # 开头，去除 # 符号
 		if strings.HasPrefix(line, "#") {
 			description := strings.TrimPrefix(line, "#")
 			return strings.TrimSpace(description)
 		}
+		break
 	}
 
 	return "Build task"
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// newTestBuildContext 创建使用 touch 模拟编译的构建上下文
+func newTestBuildContext(t *testing.T) *types.BuildContext {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用 sh 的 touch 命令模拟编译")
+	}
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = t.TempDir()
+	config.Build.Output.Name = "myapp"
+	config.Build.Output.Simple = true
+	config.Build.Command.Build = []string{"touch", "{{output}}"}
+	return &types.BuildContext{Config: config, SysPlatform: runtime.GOOS, SysArch: runtime.GOARCH}
+}
+
+func TestBuildBatchJobs(t *testing.T) {
+	// 每个目标记录开始时正在运行的目标数
+	state := t.TempDir()
+	script := fmt.Sprintf(`touch %[1]s/running.$$
+ls %[1]s | grep -c '^running' >> %[1]s/counts
+sleep 0.3
+rm %[1]s/running.$$
+touch "$1"
+`, state)
+
+	for _, tt := range []struct {
+		jobs     int
+		parallel bool
+	}{{1, false}, {3, true}} {
+		config := newScriptBatchConfig(t, script)
+		config.Build.Target.Jobs = tt.jobs
+		os.Remove(filepath.Join(state, "counts"))
+		if err := buildBatch(context.Background(), &verman.Info{}, config); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(state, "counts"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		peak := 0
+		for _, line := range strings.Fields(string(data)) {
+			n, _ := strconv.Atoi(line)
+			peak = max(peak, n)
+		}
+		if peak > tt.jobs || (peak > 1) != tt.parallel {
+			t.Errorf("jobs=%d 时同时运行的目标数最多为 %d", tt.jobs, peak)
+		}
+	}
+}
+
+func TestBuildBatchCanceledContext(t *testing.T) {
+	config := newScriptBatchConfig(t, "touch \"$1\"\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := buildBatch(ctx, &verman.Info{}, config)
+	var buildErr *types.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("上下文已取消时期望返回 *types.BuildError, got %v", err)
+	}
+	for _, r := range buildErr.Results {
+		if r.Status != types.BuildStatusCanceled {
+			t.Errorf("%s 的状态 = %s, 期望已取消", r.Target(), r.Status)
+		}
+	}
+	if entries, _ := os.ReadDir(config.Build.Output.Dir); len(entries) != 0 {
+		t.Errorf("上下文已取消时不应启动任何目标, 输出目录中有 %d 个文件", len(entries))
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("超时时间大于0时应设置截止时间")
+	}
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("期望超时, got %v", ctx.Err())
+	}
+
+	ctx, cancel = withTimeout(context.Background(), 0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("超时时间为0时不应设置截止时间")
+	}
+	cancel()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("调用取消函数后期望已取消, got %v", ctx.Err())
+	}
+}
+
+func TestBuildSingleMatrixOverrides(t *testing.T) {
+	bc := newTestBuildContext(t)
+	bc.SysPlatform, bc.SysArch = "windows", "arm64"
+	bc.Config.Build.Compiler.Ldflags = "-s -w"
+	bc.Config.Build.Command.Build = []string{"echo", "{{ldflags}}", "$CC", ">", "{{output}}"}
+	bc.Config.Env = map[string]string{"CC": "gcc"}
+	bc.TargetEnv = []string{"CC=clang"}
+	bc.Ldflags = "-X main.arch={{.Target.Arch}}"
+	bc.Tags = []string{"netgo", "osusergo"}
+	bc.OutputName = "myapp-{{.Target.Arch}}"
+
+	out, err := buildSingle(context.Background(), bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(out.Artifact) != "myapp-arm64.exe" {
+		t.Errorf("矩阵条目的输出文件名应按模板渲染并补全.exe后缀, got %s", out.Artifact)
+	}
+	data, err := os.ReadFile(out.Artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "-tags=netgo,osusergo -s -w -X main.arch=arm64 clang\n"; got != want {
+		t.Errorf("编译命令 = %q, 期望 %q", got, want)
+	}
+}
+
+func TestBuildEnvs(t *testing.T) {
+	bc := &types.BuildContext{
+		Config:    utils.GetDefaultConfig(),
+		Env:       []string{"PATH=/bin", "GOOS=linux"},
+		TargetEnv: []string{"CGO_ENABLED=1", "CC=clang"},
+	}
+	bc.Config.Env = map[string]string{"CC": "gcc"}
+	bc.Config.Build.Compiler.Proxy = "https://goproxy.cn"
+
+	// 后出现的同名变量优先
+	envs := make(map[string]string)
+	for _, env := range buildEnvs(bc) {
+		k, v, _ := strings.Cut(env, "=")
+		envs[k] = v
+	}
+	want := map[string]string{"PATH": "/bin", "GOOS": "linux", "CC": "clang", "GOPROXY": "https://goproxy.cn", "CGO_ENABLED": "1"}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("环境变量 = %v, 期望 %v", envs, want)
+	}
+	if got := envList(map[string]string{"B": "2", "A": "1"}); !reflect.DeepEqual(got, []string{"A=1", "B=2"}) {
+		t.Errorf("envList 应按键排序, got %v", got)
+	}
+}
+
+func TestEnsureTagsPlaceholder(t *testing.T) {
+	tests := []struct {
+		cmds, want []string
+	}{
+		{[]string{"go", "build", "-o", "{{output}}"}, []string{"go", "build", "{{tags}}", "-o", "{{output}}"}},
+		{[]string{"garble", "-literals", "build"}, []string{"garble", "-literals", "build", "{{tags}}"}},
+		{[]string{"make", "all"}, []string{"make", "{{tags}}", "all"}},
+		{[]string{"go", "build", "{{tags}}"}, []string{"go", "build", "{{tags}}"}},
+		{[]string{"go", "build", `{{if .Target.Tags}}-tags=x{{end}}`}, []string{"go", "build", `{{if .Target.Tags}}-tags=x{{end}}`}},
+	}
+	for _, tt := range tests {
+		original := slices.Clone(tt.cmds)
+		if got := ensureTagsPlaceholder(tt.cmds); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ensureTagsPlaceholder(%q) = %q, 期望 %q", tt.cmds, got, tt.want)
+		}
+		if !reflect.DeepEqual(tt.cmds, original) {
+			t.Errorf("ensureTagsPlaceholder 不应修改原命令: %q", tt.cmds)
+		}
+	}
+}
+
+func TestBuildSingleSkipsDisabledHooks(t *testing.T) {
+	bc := newTestBuildContext(t)
+	bc.Config.Build.PreBuild.Enabled = false
+	bc.Config.Build.PreBuild.Commands = []string{"echo {{.Nope"}
+	bc.Config.Build.PostBuild.Enabled = false
+	bc.Config.Build.PostBuild.Commands = []string{"echo {{.Nope}}"}
+
+	out, err := buildSingle(context.Background(), bc)
+	if err != nil {
+		t.Fatalf("未启用的构建前后命令不应被渲染: %v", err)
+	}
+	if _, err := os.Stat(out.Artifact); err != nil {
+		t.Errorf("未生成输出文件: %v", err)
+	}
+}
+
+func TestBuildSingleRendersEnabledHooks(t *testing.T) {
+	bc := newTestBuildContext(t)
+	marker := filepath.Join(bc.Config.Build.Output.Dir, "post.txt")
+	bc.Config.Build.PostBuild.Enabled = true
+	bc.Config.Build.PostBuild.ExitOnError = true
+	bc.Config.Build.PostBuild.Commands = []string{"echo {{.Target.OS}} > " + marker}
+
+	if _, err := buildSingle(context.Background(), bc); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("构建后命令未执行: %v", err)
+	}
+	if got := string(data); got != runtime.GOOS+"\n" {
+		t.Errorf("构建后命令渲染结果 = %q", got)
+	}
+
+	bc.Config.Build.PreBuild.Enabled = true
+	bc.Config.Build.PreBuild.Commands = []string{"echo {{.Nope"}
+	if _, err := buildSingle(context.Background(), bc); err == nil {
+		t.Error("已启用的构建前命令模板无效时期望返回错误")
+	}
+}
+
+func TestBuildSingleArchive(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("README.md", []byte("readme"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format, name string
+		wrap         bool
+		wantArtifact string
+		wantBinary   string
+	}{
+		{"tar.gz", "", false, "myapp.tar.gz", "myapp"},
+		{"zip", "{{.Target.OS}}-{{.Target.Arch}}", true, runtime.GOOS + "-" + runtime.GOARCH + ".zip", runtime.GOOS + "-" + runtime.GOARCH + "/myapp"},
+		{"gz", "myapp-bin", false, "myapp-bin.gz", "myapp-bin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			bc := newTestBuildContext(t)
+			cfg := &bc.Config.Build.Output.Archive
+			cfg.Enabled = true
+			cfg.Format = tt.format
+			cfg.FormatOverrides = nil
+			cfg.Name = tt.name
+			cfg.WrapInDirectory = tt.wrap
+			if tt.format != "gz" {
+				cfg.Files = []string{"README.md"}
+			}
+
+			out, err := buildSingle(context.Background(), bc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Base(out.Artifact) != tt.wantArtifact || out.Binary != tt.wantBinary {
+				t.Errorf("产物 = %s, 可执行文件 = %s, 期望 %s, %s", filepath.Base(out.Artifact), out.Binary, tt.wantArtifact, tt.wantBinary)
+			}
+			if _, err := os.Stat(filepath.Join(bc.Config.Build.Output.Dir, "myapp")); !os.IsNotExist(err) {
+				t.Error("打包后应删除原始的输出文件")
+			}
+		})
+	}
+
+	bc := newTestBuildContext(t)
+	bc.Config.Build.Output.Archive.Enabled = true
+	bc.Config.Build.Output.Archive.Format = "gz"
+	bc.Config.Build.Output.Archive.FormatOverrides = nil
+	bc.Config.Build.Output.Archive.Files = []string{"README.md"}
+	if _, err := buildSingle(context.Background(), bc); err == nil {
+		t.Error("gz 格式附带其他文件时期望返回错误")
+	}
+}
+
+func TestBuildSingleSBOM(t *testing.T) {
+	// touch 生成的文件中没有构建信息, 依赖取自当前目录的 go.mod
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("go.mod", []byte("module example.com/myapp\n\ngo 1.22\n\nrequire example.com/dep v1.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bc := newTestBuildContext(t)
+	bc.VerMan = &verman.Info{GitVersion: "v1.2.0"}
+	bc.Config.Build.SBOM.Enabled = true
+	bc.Config.Build.SBOM.Formats = []string{"spdx", "cyclonedx"}
+	bc.Config.Build.Output.Archive.Enabled = true
+	bc.Config.Build.Output.Archive.Format = "tar.gz"
+	bc.Config.Build.Output.Archive.FormatOverrides = nil
+
+	out, err := buildSingle(context.Background(), bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := bc.Config.Build.Output.Dir
+	want := []string{filepath.Join(dir, "myapp.sbom.spdx.json"), filepath.Join(dir, "myapp.sbom.cdx.json")}
+	if !reflect.DeepEqual(out.SBOMs, want) {
+		t.Fatalf("SBOM = %v, 期望 %v", out.SBOMs, want)
+	}
+	// SBOM在打包删除可执行文件之前生成, 并保留在输出目录中
+	data, err := os.ReadFile(out.SBOMs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"pkg:golang/example.com/myapp@v1.2.0"`, `"pkg:golang/example.com/dep@v1.0.0"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("SBOM应包含 %s, got:\n%s", s, data)
+		}
+	}
+}
+
+func TestWritePublishManifests(t *testing.T) {
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	config.Build.Output.Archive.Enabled = true
+	cfg := &config.Publish.Manifests
+	cfg.Enabled = true
+	cfg.URL = "https://example.com/{{.Git.Version}}/{{.Artifact}}"
+	cfg.Winget.Publisher = "Example"
+	cfg.License = "MIT"
+
+	linux, linuxSum := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz", "linux")
+	results := []types.BuildResult{
+		{Platform: "linux", Arch: "amd64", BuildOutput: types.BuildOutput{Artifact: linux, Binary: "myapp_linux_amd64/myapp"}},
+		{Platform: "linux", Arch: "arm64", Status: types.BuildStatusFailed},
+	}
+	v := &verman.Info{GitVersion: "v1.2.0"}
+
+	// 未构建windows目标时跳过scoop和winget
+	if err := writePublishManifests(v, config, results); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, types.DefaultPublishManifestsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "myapp.rb" {
+		t.Fatalf("期望只生成 myapp.rb, got %v", entries)
+	}
+	formula, err := os.ReadFile(filepath.Join(dir, types.DefaultPublishManifestsDir, "myapp.rb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 版本号默认从Git版本推导, 下载地址按产物渲染
+	for _, want := range []string{
+		`version "1.2.0"`,
+		`url "https://example.com/v1.2.0/myapp_linux_amd64.tar.gz"`,
+		`sha256 "` + linuxSum + `"`,
+		`bin.install "myapp" => "myapp"`,
+	} {
+		if !strings.Contains(string(formula), want) {
+			t.Errorf("formula应包含 %q, got:\n%s", want, formula)
+		}
+	}
+
+	cfg.Name = "  "
+	if err := writePublishManifests(v, config, results); err == nil || !strings.Contains(err.Error(), "软件名称为空") {
+		t.Errorf("软件名称为空时期望返回错误, got %v", err)
+	}
+	cfg.Name = ""
+	if err := writePublishManifests(&verman.Info{}, config, results); err == nil || !strings.Contains(err.Error(), "版本号为空") {
+		t.Errorf("没有Git版本时期望返回错误, got %v", err)
+	}
+}
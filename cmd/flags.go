@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"gitee.com/MM-Q/qflag"
 )
 
@@ -19,4 +22,90 @@ var (
 	nameFlag *qflag.StringFlag
 	// mainFileFlag --main, -m 指定入口文件
 	mainFileFlag *qflag.StringFlag
+	// jobsFlag --jobs, -j 批量构建的并发目标数（覆盖配置文件中的 jobs）
+	jobsFlag *qflag.IntFlag
+
+	// configResolvedFlag config show --resolved, -r 显示包含默认值的完整配置及来源
+	configResolvedFlag *qflag.BoolFlag
+	// configSchemaOutputFlag config schema --output, -o 将JSON Schema写入指定文件
+	configSchemaOutputFlag *qflag.StringFlag
+
+	// verifyFileFlag verify --file, -f 校验和文件名
+	verifyFileFlag *qflag.StringFlag
+	// verifyAlgorithmFlag verify --algorithm, -a 校验算法
+	verifyAlgorithmFlag *qflag.StringFlag
+	// verifySignatureFlag verify --signature, -s 同时校验签名文件
+	verifySignatureFlag *qflag.BoolFlag
+	// verifyKeyFlag verify --key, -k 校验签名使用的公钥文件
+	verifyKeyFlag *qflag.StringFlag
+
+	// publishTagFlag publish --tag, -t 发布对应的标签
+	publishTagFlag *qflag.StringFlag
+	// publishDryRunFlag publish --dry-run, -n 只显示将要执行的操作
+	publishDryRunFlag *qflag.BoolFlag
+
+	// changelogTagFlag changelog --tag, -t 生成变更日志的标签
+	changelogTagFlag *qflag.StringFlag
+	// changelogOutputFlag changelog --output, -o 将变更日志写入指定文件
+	changelogOutputFlag *qflag.StringFlag
+
+	// bumpPreidFlag release bump --preid, -p 预发布标识
+	bumpPreidFlag *qflag.StringFlag
+	// bumpDryRunFlag release bump --dry-run, -n 只显示新版本和标签信息
+	bumpDryRunFlag *qflag.BoolFlag
+	// bumpNoBuildFlag release bump --no-build, -nb 创建标签后不执行构建任务
+	bumpNoBuildFlag *qflag.BoolFlag
 )
+
+// parseTrailingFlags 解析位置参数之后的标志
+//
+// 参数:
+//   - cmd: 已解析的命令
+//
+// 返回值:
+//   - []string: 去掉标志后的位置参数
+//   - error: 存在未知标志或缺少标志值时返回错误
+//
+// 注意:
+//   - 标准库的标志解析在遇到第一个位置参数时停止, 该函数用于支持 gob config show dev --resolved 这种写法
+//   - -- 之后的参数全部作为位置参数
+func parseTrailingFlags(cmd qflag.Command) ([]string, error) {
+	var positional []string
+	args := cmd.Args()
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f, ok := cmd.GetFlag(name)
+		if !ok {
+			return nil, fmt.Errorf("未知的标志: %s", arg)
+		}
+
+		// 布尔标志可以省略值, 其余标志的值可以作为下一个参数
+		if !hasValue {
+			if f.Type() == qflag.FlagTypeBool {
+				value = "true"
+			} else {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("标志 %s 缺少值", arg)
+				}
+				i++
+				value = args[i]
+			}
+		}
+		if err := f.Set(value); err != nil {
+			return nil, fmt.Errorf("标志 %s 的值无效: %w", arg, err)
+		}
+	}
+
+	return positional, nil
+}
@@ -143,14 +143,20 @@ func runInit() error {
 		MainFile:    mainFileFlag.Get(),
 	}
 
-	// 生成配置文件
-	configs := []string{"dev", "install", "release"}
+	// 生成配置文件, base 为其他任务通过 extends 继承的基础配置
+	configs := []string{"base", "dev", "install", "release"}
 	for _, name := range configs {
 		if err := renderAndWriteConfig(data, gobfDir, name); err != nil {
 			return err
 		}
 	}
 
+	// 生成配置文件引用的JSON Schema
+	if err := utils.WriteSchemaFile(gobfDir); err != nil {
+		return err
+	}
+	utils.CL.Greenf("%s 已生成: %s\n", types.PrintPrefix, filepath.Join(gobfDir, types.SchemaFile))
+
 	utils.CL.Greenf("%s 初始化完成！已生成 gobf/ 目录及配置文件\n", types.PrintPrefix)
 	return nil
 }
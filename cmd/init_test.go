@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/utils"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// readTOML 读取TOML文件为文档
+func readTOML(t *testing.T, path string) map[string]any {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := make(map[string]any)
+	if err := toml.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("解析 %s 失败: %v", path, err)
+	}
+	return doc
+}
+
+// assertOnlyDifferences 检查任务文件中的每个值都与基础配置不同
+func assertOnlyDifferences(t *testing.T, name string, task, base map[string]any, prefix string) {
+	t.Helper()
+	for key, value := range task {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if path == "version" || path == "extends" {
+			continue
+		}
+		if table, ok := value.(map[string]any); ok {
+			baseTable, _ := base[key].(map[string]any)
+			assertOnlyDifferences(t, name, table, baseTable, path)
+			continue
+		}
+		if reflect.DeepEqual(value, base[key]) {
+			t.Errorf("%s.toml 中的 %s 与 base.toml 相同, 应从基础配置继承", name, path)
+		}
+	}
+}
+
+func TestInitTemplates(t *testing.T) {
+	// 入口文件相对于工作目录校验
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := "gobf"
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := InitData{ProjectName: "myapp", MainFile: "main.go"}
+	names := []string{"base", "dev", "install", "release"}
+	for _, name := range names {
+		if err := renderAndWriteConfig(data, dir, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base := readTOML(t, filepath.Join(dir, "base.toml"))
+	for _, name := range names {
+		path := filepath.Join(dir, name+".toml")
+		config, err := utils.LoadConfig(path)
+		if err != nil {
+			t.Fatalf("加载 %s 失败: %v", path, err)
+		}
+		if err := utils.ValidateConfig(config); err != nil {
+			t.Errorf("%s 校验失败: %v", path, err)
+		}
+		if config.Build.Output.Name != "myapp" || config.Build.Source.MainFile != "main.go" {
+			t.Errorf("%s 未继承项目名称和入口文件: %q %q", path, config.Build.Output.Name, config.Build.Source.MainFile)
+		}
+		if name == "base" {
+			continue
+		}
+
+		task := readTOML(t, path)
+		if task["extends"] != "base.toml" {
+			t.Errorf("%s 应继承 base.toml, extends = %v", path, task["extends"])
+		}
+		assertOnlyDifferences(t, name, task, base, "")
+	}
+
+	install, err := utils.LoadConfig(filepath.Join(dir, "install.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !install.Install.Install || !install.Build.Target.CurrentPlatformOnly || !install.Build.Git.Inject {
+		t.Errorf("install 任务的配置不正确: %+v", install.Install)
+	}
+	release, err := utils.LoadConfig(filepath.Join(dir, "release.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !release.Build.Target.Batch || release.Build.Output.Simple || !release.Build.Git.Inject {
+		t.Errorf("release 任务的配置不正确: %+v", release.Build.Target)
+	}
+	if !release.Build.Output.Zip || release.Build.Output.Archive.Enabled {
+		t.Errorf("release 任务应保持 zip 打包, 归档配置默认注释: %+v", release.Build.Output)
+	}
+	if release.Build.Checksum.Enabled {
+		t.Error("release 任务的校验和配置应默认注释")
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	config.Build.Output.Archive.Enabled = true
+
+	archive, archiveSum := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz", "archive")
+	archiveSig, _ := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz.minisig", "sig")
+	pkg, pkgSum := writeTestFile(t, dir, "pkg/myapp_1.0.0_amd64.deb", "deb")
+	sbom, _ := writeTestFile(t, dir, "myapp_linux_amd64.spdx.json", "{}")
+	checksum, checksumSum := writeTestFile(t, dir, "checksums.txt", "sums")
+	results := []types.BuildResult{
+		{Platform: "linux", Arch: "amd64", Status: types.BuildStatusSuccess, BuildOutput: types.BuildOutput{
+			Artifact: archive, Packages: []string{pkg}, SBOMs: []string{sbom},
+		}},
+		{Platform: "windows", Arch: "amd64", Status: types.BuildStatusFailed},
+	}
+
+	artifacts, err := collectArtifacts(config, results, checksum, map[string]string{archive: archiveSig})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		path, kind, target string
+	}
+	var got []entry
+	for _, a := range artifacts {
+		got = append(got, entry{a.Path, string(a.Kind), a.Target})
+	}
+	want := []entry{
+		{"myapp_linux_amd64.tar.gz", "archive", "linux/amd64"},
+		{"myapp_linux_amd64.tar.gz.minisig", "signature", "linux/amd64"},
+		{"pkg/myapp_1.0.0_amd64.deb", "package", "linux/amd64"},
+		{"myapp_linux_amd64.spdx.json", "sbom", "linux/amd64"},
+		{"checksums.txt", "checksum", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("产物列表不正确:\n got %v\nwant %v", got, want)
+	}
+
+	if a := artifacts[0]; a.SHA256 != archiveSum || a.Size != int64(len("archive")) || a.OS != "linux" || a.Arch != "amd64" || a.Name != "myapp_linux_amd64.tar.gz" {
+		t.Errorf("归档文件条目不正确: %+v", a)
+	}
+	if a := artifacts[2]; a.SHA256 != pkgSum || a.Name != "myapp_1.0.0_amd64.deb" {
+		t.Errorf("安装包条目不正确: %+v", a)
+	}
+	if a := artifacts[4]; a.SHA256 != checksumSum || a.OS != "" || a.Arch != "" {
+		t.Errorf("校验和文件条目不正确: %+v", a)
+	}
+}
+
+func TestCollectArtifactsBinaryKind(t *testing.T) {
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	binary, _ := writeTestFile(t, dir, "myapp", "bin")
+	results := []types.BuildResult{{Platform: "linux", Arch: "arm64", BuildOutput: types.BuildOutput{Artifact: binary}}}
+
+	artifacts, err := collectArtifacts(config, results, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Kind != types.ArtifactKindBinary {
+		t.Errorf("未打包时产物应为可执行文件, got %+v", artifacts)
+	}
+
+	config.Build.Output.Zip = true
+	if artifacts, err = collectArtifacts(config, results, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if artifacts[0].Kind != types.ArtifactKindArchive {
+		t.Errorf("zip打包时产物应为归档文件, got %s", artifacts[0].Kind)
+	}
+
+	outside, _ := writeTestFile(t, t.TempDir(), "other", "x")
+	results[0].Artifact = outside
+	if _, err := collectArtifacts(config, results, "", nil); err == nil {
+		t.Error("产物不在输出目录中时期望返回错误")
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	config.ConfigFile = filepath.Join("gobf", "release.toml")
+	v := &verman.Info{AppName: "myapp", GitVersion: "v1.2.0", GitCommit: "a1b2c3d", GitCommitTime: "2025-01-01", GitTreeState: "clean", BuildTime: "2025-01-02"}
+	artifacts := []types.Artifact{{Name: "myapp", Path: "myapp", Kind: types.ArtifactKindBinary}}
+
+	if err := writeManifest(v, config, artifacts); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := utils.ReadArtifactManifest(dir, config.Build.Manifest.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Git != nil {
+		t.Errorf("未启用Git信息注入时不应写入Git元数据, got %+v", manifest.Git)
+	}
+	if manifest.Config != "gobf/release.toml" || !reflect.DeepEqual(manifest.Artifacts, artifacts) {
+		t.Errorf("产物清单不正确: %+v", manifest)
+	}
+
+	config.Build.Git.Inject = true
+	if err := writeManifest(v, config, artifacts); err != nil {
+		t.Fatal(err)
+	}
+	if manifest, err = utils.ReadArtifactManifest(dir, config.Build.Manifest.File); err != nil {
+		t.Fatal(err)
+	}
+	want := &types.ArtifactGit{AppName: "myapp", Version: "v1.2.0", Commit: "a1b2c3d", CommitTime: "2025-01-01", TreeState: "clean", BuildTime: "2025-01-02"}
+	if !reflect.DeepEqual(manifest.Git, want) {
+		t.Errorf("Git元数据不正确:\n got %+v\nwant %+v", manifest.Git, want)
+	}
+}
+
+func TestRemoveStaleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	checksum, _ := writeTestFile(t, dir, config.Build.Checksum.File, "old")
+	manifest, _ := writeTestFile(t, dir, config.Build.Manifest.File, "{}")
+
+	if config.Build.Manifest.Enabled {
+		t.Error("产物清单应默认关闭")
+	}
+	if err := removeStaleOutputs(config); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{checksum, manifest} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("未启用时不应删除 %s: %v", path, err)
+		}
+	}
+
+	config.Build.Checksum.Enabled = true
+	config.Build.Manifest.Enabled = true
+	if err := removeStaleOutputs(config); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{checksum, manifest} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("上次构建的 %s 应被删除", path)
+		}
+	}
+	if err := removeStaleOutputs(config); err != nil {
+		t.Errorf("文件不存在时不应返回错误: %v", err)
+	}
+}
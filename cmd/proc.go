@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"gitee.com/MM-Q/shellx"
+)
+
+// bindProcessTree 让命令在上下文取消时终止整个进程树
+//
+// 参数:
+//   - c: 已完成配置(上下文、环境变量、shell)的命令对象
+//
+// 返回值:
+//   - *shellx.Command: 同一个命令对象, 便于链式调用
+//
+// 注意:
+//   - 命令通过shell执行, 仅终止shell进程会遗留正在运行的编译器, 因此需要终止整个进程树
+//   - 必须在所有 WithXxx 配置之后调用, 因为底层 exec.Cmd 会在此时创建
+func bindProcessTree(c *shellx.Command) *shellx.Command {
+	setProcessTree(c.Cmd())
+	return c
+}
+
+// notifyContext 返回收到指定信号时取消的上下文, 并记录收到的信号
+//
+// 参数:
+//   - parent: 父上下文
+//   - sigs: 要监听的信号
+//
+// 返回值:
+//   - context.Context: 收到信号时取消的上下文
+//   - func() os.Signal: 返回收到的信号, 未收到信号时返回nil
+//   - func(): 停止监听信号并释放资源
+//
+// 注意:
+//   - 收到首个信号后即停止监听, 恢复默认行为, 再次收到信号时进程将直接退出
+func notifyContext(parent context.Context, sigs ...os.Signal) (context.Context, func() os.Signal, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	var received atomic.Value
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-ch:
+			received.Store(sig)
+			signal.Stop(ch)
+			cancel()
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			cancel()
+		})
+	}
+	signalFunc := func() os.Signal {
+		sig, _ := received.Load().(os.Signal)
+		return sig
+	}
+	return ctx, signalFunc, stop
+}
+
+// signalExitCode 返回因信号中断时的退出码
+//
+// 参数:
+//   - sig: 收到的信号
+//
+// 返回值:
+//   - int: 按shell惯例为128加信号编号, 如SIGINT为130、SIGTERM为143, 无法识别时为130
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok && s > 0 {
+		return 128 + int(s)
+	}
+	return 128 + 2
+}
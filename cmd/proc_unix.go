@@ -0,0 +1,20 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessTree 将命令放入独立的进程组, 取消时向整个进程组发送SIGKILL
+//
+// 参数:
+//   - cmd: 底层的 exec.Cmd 对象
+func setProcessTree(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// 负的PID表示整个进程组
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
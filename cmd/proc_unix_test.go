@@ -0,0 +1,121 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// processAlive 检查进程是否仍在运行, 已退出但未被回收的进程视为已终止
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	// 状态字段位于进程名的右括号之后
+	_, rest, _ := strings.Cut(string(stat), ") ")
+	return !strings.HasPrefix(rest, "Z")
+}
+
+func TestBuildBatchCancelKillsProcessTree(t *testing.T) {
+	// shell 启动的子进程模拟正在运行的编译器
+	state := t.TempDir()
+	pidFile := filepath.Join(state, "pid")
+	config := newScriptBatchConfig(t, fmt.Sprintf("sleep 30 &\necho $! > %s\nwait\n", pidFile))
+	config.Build.Target.Architectures = config.Build.Target.Architectures[:1]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			if data, err := os.ReadFile(pidFile); err == nil && strings.HasSuffix(string(data), "\n") {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	err := buildBatch(ctx, &verman.Info{}, config)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("取消后构建应立即结束, 耗时 %s", elapsed)
+	}
+	var buildErr *types.BuildError
+	if !errors.As(err, &buildErr) || buildErr.Results[0].Status != types.BuildStatusCanceled {
+		t.Fatalf("期望目标被取消, got %v", err)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("取消后 shell 启动的子进程仍在运行")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNotifyContext(t *testing.T) {
+	ctx, receivedSignal, stop := notifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if receivedSignal() != nil {
+		t.Fatal("未收到信号时期望返回nil")
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("收到SIGTERM后上下文应被取消")
+	}
+	if sig := receivedSignal(); sig != syscall.SIGTERM {
+		t.Errorf("收到的信号 = %v, 期望 SIGTERM", sig)
+	}
+
+	// 停止监听时取消上下文, 不记录信号
+	ctx, receivedSignal, stop = notifyContext(context.Background(), syscall.SIGUSR1)
+	stop()
+	if ctx.Err() == nil || receivedSignal() != nil {
+		t.Errorf("停止监听后上下文应已取消且没有收到信号, got %v, %v", ctx.Err(), receivedSignal())
+	}
+}
+
+func TestSignalExitCode(t *testing.T) {
+	tests := map[os.Signal]int{
+		os.Interrupt:    130,
+		syscall.SIGTERM: 143,
+		syscall.SIGHUP:  129,
+		os.Kill:         137,
+	}
+	for sig, want := range tests {
+		if got := signalExitCode(sig); got != want {
+			t.Errorf("signalExitCode(%v) = %d, 期望 %d", sig, got, want)
+		}
+	}
+}
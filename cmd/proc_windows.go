@@ -0,0 +1,18 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// setProcessTree 取消时通过 taskkill 终止命令的整个进程树
+//
+// 参数:
+//   - cmd: 底层的 exec.Cmd 对象
+func setProcessTree(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+}
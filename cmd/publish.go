@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/qflag"
+	"gitee.com/MM-Q/verman"
+)
+
+// describeSuffix 匹配 git describe 在标签之后的提交上追加的后缀, 如 -3-g1a2b3c4
+var describeSuffix = regexp.MustCompile(`-\d+-g[0-9a-f]+$`)
+
+// newPublishCmd 创建 publish 子命令
+//
+// 返回值:
+//   - *qflag.Cmd: publish 子命令
+func newPublishCmd() *qflag.Cmd {
+	publishCmd := qflag.NewCmd("publish", "pub", qflag.ContinueOnError)
+	publishTagFlag = publishCmd.String("tag", "t", "发布对应的标签, 覆盖配置文件中的 tag", "")
+	publishDryRunFlag = publishCmd.Bool("dry-run", "n", "只显示将要创建的发布和上传的文件, 不访问托管平台", false)
+	publishCmdOpts := &qflag.CmdOpts{
+		Desc:        "在 GitHub、Gitea 或 Gitee 上创建或更新当前标签的发布, 并上传产物清单中的所有文件",
+		UsageSyntax: "gob publish [options] [task|file]",
+		UseChinese:  true,
+		RunFunc:     runPublish,
+		Examples: map[string]string{
+			"发布 release 任务的构建产物": "gob publish release",
+			"预览将要上传的文件":          "gob publish release --dry-run",
+			"发布到指定标签":            "gob publish release --tag v1.2.0",
+		},
+		Notes: []string{
+			"发布配置位于构建文件的 [publish.release] 部分, 访问令牌从 token_env 指定的环境变量读取",
+			"上传的文件来自输出目录下的产物清单, 请先执行批量构建",
+			"已存在同名附件时先删除再上传, 可以重复执行",
+			"publish.release.changelog 为 true 时使用 [changelog] 生成的变更日志作为发布说明",
+		},
+	}
+	if err := publishCmd.ApplyOpts(publishCmdOpts); err != nil {
+		panic(err)
+	}
+	return publishCmd
+}
+
+// runPublish 创建或更新发布并上传构建产物
+//
+// 参数:
+//   - cmd: publish 命令
+//
+// 返回值:
+//   - error: 任一文件上传失败时返回错误
+func runPublish(cmd qflag.Command) error {
+	args, err := parseTrailingFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("只能指定一个构建文件")
+	}
+	var arg string
+	if len(args) == 1 {
+		arg = args[0]
+	}
+
+	configPath, err := resolveConfigPath(arg)
+	if err != nil {
+		return err
+	}
+	config := &types.GobConfig{}
+	if err := loadAndValidateConfig(config, configPath); err != nil {
+		return err
+	}
+	utils.CL.SetColor(config.Build.UI.Color)
+
+	cfg := config.Publish.Release
+	if err := utils.ValidateReleaseConfig(cfg); err != nil {
+		return fmt.Errorf("构建文件 %s 的发布配置无效:\n%s", configPath, formatProblems(err))
+	}
+
+	// 标签、标题和发布说明的模板需要Git元数据和自定义变量
+	if err := utils.GetGitMetaData(config.Build.TimeoutDuration, verman.V, config); err != nil {
+		return fmt.Errorf("Git信息获取失败: %w", err)
+	}
+	if len(config.Vars) > 0 {
+		values, err := utils.ResolveVars(config)
+		if err != nil {
+			return fmt.Errorf("自定义变量解析失败: %w", err)
+		}
+		config.VarValues = values
+	}
+
+	req, err := releaseRequest(verman.V, config)
+	if err != nil {
+		return err
+	}
+	files, err := releaseFiles(verman.V, config)
+	if err != nil {
+		return err
+	}
+
+	repo := cfg.Owner + "/" + cfg.Repo
+	utils.CL.Greenf("%s 发布 %s 的标签 %s 到 %s (%s)\n", types.PrintPrefix, repo, req.Tag, cfg.Provider, utils.ReleaseAPIURL(cfg))
+	if publishDryRunFlag.Get() {
+		utils.CL.Greenf("%s 标题: %s, 草稿: %t, 预发布: %t\n", types.PrintPrefix, req.Name, req.Draft, req.Prerelease)
+		if req.Notes != "" {
+			utils.CL.Greenf("%s 发布说明:\n%s\n", types.PrintPrefix, strings.TrimRight(req.Notes, "\n"))
+		}
+		for _, f := range files {
+			utils.CL.Greenf("%s 将上传: %s\n", types.PrintPrefix, f)
+		}
+		return nil
+	}
+
+	tokenEnv := utils.ReleaseTokenEnv(cfg)
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return fmt.Errorf("环境变量 %s 未设置, 无法访问 %s", tokenEnv, cfg.Provider)
+	}
+	if req.Commitish, err = utils.GetGitHeadCommit(config.Build.TimeoutDuration); err != nil {
+		return fmt.Errorf("Git信息获取失败: %w", err)
+	}
+
+	client, err := utils.NewReleaseClient(cfg, token)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rel, created, err := client.EnsureRelease(ctx, req)
+	if err != nil {
+		return err
+	}
+	if created {
+		utils.CL.Greenf("%s 已创建发布: %s\n", types.PrintPrefix, req.Tag)
+	} else {
+		utils.CL.Greenf("%s 已更新发布: %s\n", types.PrintPrefix, req.Tag)
+	}
+
+	failed := 0
+	for _, f := range files {
+		replaced, err := client.UploadAsset(ctx, rel, f)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("发布已中断")
+			}
+			failed++
+			utils.CL.Redf("%s ✗ %s: %v\n", types.PrintPrefix, filepath.Base(f), err)
+			continue
+		}
+		if replaced {
+			utils.CL.Greenf("%s ✓ %s (替换已有附件)\n", types.PrintPrefix, filepath.Base(f))
+		} else {
+			utils.CL.Greenf("%s ✓ %s\n", types.PrintPrefix, filepath.Base(f))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个文件上传失败", failed, len(files))
+	}
+	if rel.HTMLURL != "" {
+		utils.CL.Greenf("%s 发布地址: %s\n", types.PrintPrefix, rel.HTMLURL)
+	}
+	utils.CL.Greenf("%s 已上传全部 %d 个文件\n", types.PrintPrefix, len(files))
+	return nil
+}
+
+// releaseRequest 渲染发布的标签、标题和说明
+//
+// 参数:
+//   - v: verman对象, 提供模板中的Git元数据
+//   - config: 配置对象
+//
+// 返回值:
+//   - types.ReleaseRequest: 发布信息, 不包含提交哈希
+//   - error: 渲染失败、生成变更日志失败或当前提交没有标签时返回错误
+func releaseRequest(v *verman.Info, config *types.GobConfig) (types.ReleaseRequest, error) {
+	cfg := config.Publish.Release
+	data := releaseTemplateData(v, config)
+
+	tag := publishTagFlag.Get()
+	if tag == "" && cfg.Tag != "" {
+		rendered, err := utils.RenderTemplate(cfg.Tag, data)
+		if err != nil {
+			return types.ReleaseRequest{}, fmt.Errorf("渲染发布标签失败: %w", err)
+		}
+		tag = rendered
+	}
+	if tag == "" && config.GitSemver.Tag != "" && !config.GitSemver.IsSnapshot {
+		tag = config.GitSemver.Tag
+	}
+	if tag == "" {
+		// git describe 的输出只有在当前提交恰好是标签且工作区干净时才是标签名
+		version := config.GitSemver.Describe
+		if version == v.GitCommit || strings.HasSuffix(version, "-dirty") || describeSuffix.MatchString(version) {
+			return types.ReleaseRequest{}, fmt.Errorf("当前提交没有标签或工作区有未提交的修改 (git describe: %s), 请先创建标签或通过 --tag 指定", version)
+		}
+		tag = config.Build.Git.TagPrefix + version
+	}
+	tag = strings.TrimSpace(tag)
+
+	name := tag
+	if cfg.Name != "" {
+		rendered, err := utils.RenderTemplate(cfg.Name, data)
+		if err != nil {
+			return types.ReleaseRequest{}, fmt.Errorf("渲染发布标题失败: %w", err)
+		}
+		name = strings.TrimSpace(rendered)
+	}
+
+	var notes string
+	if cfg.NotesFile != "" {
+		content, err := os.ReadFile(cfg.NotesFile)
+		if err != nil {
+			return types.ReleaseRequest{}, fmt.Errorf("读取发布说明文件失败: %w", err)
+		}
+		notes = string(content)
+	} else if cfg.Notes != "" {
+		rendered, err := utils.RenderTemplate(cfg.Notes, data)
+		if err != nil {
+			return types.ReleaseRequest{}, fmt.Errorf("渲染发布说明失败: %w", err)
+		}
+		notes = rendered
+	} else if cfg.Changelog {
+		// 标签只存在于托管平台时(由平台创建), 生成当前版本的变更日志
+		changelogTag := tag
+		exists, err := utils.GitTagExists(config.Build.TimeoutDuration, tag)
+		if err != nil {
+			return types.ReleaseRequest{}, err
+		}
+		if !exists {
+			changelogTag = ""
+		}
+		if notes, err = utils.GenerateChangelog(config.Build.TimeoutDuration, data, changelogTag); err != nil {
+			return types.ReleaseRequest{}, err
+		}
+	}
+
+	return types.ReleaseRequest{
+		Tag:        tag,
+		Name:       name,
+		Notes:      notes,
+		Draft:      cfg.Draft,
+		Prerelease: cfg.Prerelease,
+	}, nil
+}
+
+// releaseFiles 返回产物清单中的所有文件, 并确认文件在构建之后未被修改
+//
+// 参数:
+//   - v: verman对象, 用于检查产物清单是否来自当前版本
+//   - config: 配置对象
+//
+// 返回值:
+//   - []string: 文件路径
+//   - error: 产物清单不存在、为空或文件与清单不一致时返回错误
+func releaseFiles(v *verman.Info, config *types.GobConfig) ([]string, error) {
+	dir := config.Build.Output.Dir
+	manifest, err := utils.ReadArtifactManifest(dir, config.Build.Manifest.File)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Artifacts) == 0 {
+		return nil, fmt.Errorf("产物清单 %s 中没有任何文件", filepath.Join(dir, config.Build.Manifest.File))
+	}
+	if manifest.Git != nil && manifest.Git.Version != v.GitVersion {
+		utils.CL.Yellowf("%s 产物清单中的Git版本 %s 与当前版本 %s 不一致\n", types.PrintPrefix, manifest.Git.Version, v.GitVersion)
+	}
+
+	files := make([]string, 0, len(manifest.Artifacts))
+	for _, a := range manifest.Artifacts {
+		path := filepath.Join(dir, filepath.FromSlash(a.Path))
+		sum, err := utils.FileChecksum(path, "sha256")
+		if err != nil {
+			return nil, fmt.Errorf("读取构建产物 %s 失败: %w", path, err)
+		}
+		if sum != a.SHA256 {
+			return nil, fmt.Errorf("构建产物 %s 与产物清单中的校验和不一致, 请重新构建", path)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/qflag"
+	"gitee.com/MM-Q/shellx"
+	"gitee.com/MM-Q/verman"
+)
+
+// newReleaseCmd 创建 release 子命令及其下属命令
+//
+// 返回值:
+//   - *qflag.Cmd: release 子命令
+func newReleaseCmd() *qflag.Cmd {
+	// bump 子命令: 递增版本号并创建标签
+	bumpCmd := qflag.NewCmd("bump", "b", qflag.ContinueOnError)
+	bumpPreidFlag = bumpCmd.String("preid", "p", "预发布标识, 如 rc; 与 major/minor/patch 一起使用时生成预发布版本", "")
+	bumpDryRunFlag = bumpCmd.Bool("dry-run", "n", "只显示新版本号和标签信息, 不修改仓库", false)
+	bumpNoBuildFlag = bumpCmd.Bool("no-build", "nb", "创建标签后不执行构建任务", false)
+	bumpCmdOpts := &qflag.CmdOpts{
+		Desc:        "根据最高的语义化版本标签计算新版本号, 创建附注标签并执行构建任务",
+		UsageSyntax: "gob release bump <major|minor|patch|prerelease> [options] [task|file]",
+		UseChinese:  true,
+		RunFunc:     runReleaseBump,
+		Examples: map[string]string{
+			"递增修订号并执行 gob.toml 构建":   "gob release bump patch",
+			"递增次版本号并执行 release 任务":   "gob release bump minor release",
+			"创建 2.0.0-rc.1 预发布版本":    "gob release bump major --preid rc release",
+			"递增预发布序号 (rc.1 -> rc.2)": "gob release bump prerelease release",
+			"预览新版本号和标签信息":            "gob release bump minor --dry-run",
+		},
+		Notes: []string{
+			"工作区有未提交的修改时拒绝执行",
+			"标签前缀取自构建文件的 build.git.tag_prefix, 配置了 [bump] version_file 时先写入新版本号并提交",
+			"标签信息包含上一个版本之后的提交标题, 创建的标签不会自动推送",
+			"prerelease 未指定 --preid 时沿用当前的预发布标识, 当前为正式版本时使用 rc",
+		},
+	}
+	if err := bumpCmd.ApplyOpts(bumpCmdOpts); err != nil {
+		panic(err)
+	}
+
+	releaseCmd := qflag.NewCmd("release", "rel", qflag.ContinueOnError)
+	releaseCmdOpts := &qflag.CmdOpts{
+		Desc:        "管理版本号和版本标签",
+		UsageSyntax: "gob release <command> [options]",
+		UseChinese:  true,
+		RunFunc:     runRelease,
+		SubCmds:     []qflag.Command{bumpCmd},
+	}
+	if err := releaseCmd.ApplyOpts(releaseCmdOpts); err != nil {
+		panic(err)
+	}
+
+	return releaseCmd
+}
+
+// runRelease 将 release 命令分发到下属子命令
+//
+// 参数:
+//   - cmd: release 命令
+//
+// 返回值:
+//   - error: 错误信息
+func runRelease(cmd qflag.Command) error {
+	if cmd.NArg() == 0 {
+		cmd.PrintHelp()
+		return nil
+	}
+
+	subCmd, ok := cmd.GetSubCmd(cmd.Arg(0))
+	if !ok {
+		return fmt.Errorf("未知的 release 子命令: %s", cmd.Arg(0))
+	}
+	return subCmd.Run()
+}
+
+// runReleaseBump 递增版本号, 创建附注标签并执行构建任务
+//
+// 参数:
+//   - cmd: bump 命令
+//
+// 返回值:
+//   - error: 错误信息
+func runReleaseBump(cmd qflag.Command) error {
+	args, err := parseTrailingFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("用法: gob release bump <%s> [task|file]", strings.Join(utils.BumpParts(), "|"))
+	}
+	part := args[0]
+	if !slices.Contains(utils.BumpParts(), part) {
+		return fmt.Errorf("无效的版本递增部分 %q, 可用: %s", part, strings.Join(utils.BumpParts(), "、"))
+	}
+	var arg string
+	if len(args) == 2 {
+		arg = args[1]
+	}
+
+	configPath, err := resolveConfigPath(arg)
+	if err != nil {
+		return err
+	}
+	config := &types.GobConfig{}
+	if err := loadAndValidateConfig(config, configPath); err != nil {
+		return err
+	}
+	utils.CL.SetColor(config.Build.UI.Color)
+
+	timeout := config.Build.TimeoutDuration
+	if err := utils.GetGitMetaData(timeout, verman.V, config); err != nil {
+		return fmt.Errorf("Git信息获取失败: %w", err)
+	}
+	if verman.V.GitTreeState == "dirty" {
+		return fmt.Errorf("工作区有未提交的修改, 请先提交或暂存后再创建版本标签")
+	}
+
+	// 计算新版本号, 标签沿用当前标签是否带 v 的写法
+	current := config.GitSemver
+	next, err := utils.BumpSemver(current.Version, part, bumpPreid(part, current.Version))
+	if err != nil {
+		return err
+	}
+	if current.Tag != "" && utils.CompareSemver(next, current.Version) <= 0 {
+		return fmt.Errorf("新版本 %s 不高于当前版本 %s, 请指定其他预发布标识", next, current.Version)
+	}
+	prefix := config.Build.Git.TagPrefix
+	version := "v" + next.String()
+	if current.Tag != "" && !strings.HasPrefix(strings.TrimPrefix(current.Tag, prefix), "v") {
+		version = next.String()
+	}
+	tag := prefix + version
+
+	exists, err := utils.GitTagExists(timeout, tag)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("标签 %s 已存在", tag)
+	}
+	message, err := utils.ReleaseTagMessage(timeout, current.Tag, tag)
+	if err != nil {
+		return err
+	}
+
+	from := current.Tag
+	if from == "" {
+		from = "(无版本标签)"
+	}
+	utils.CL.Greenf("%s 版本: %s -> %s\n", types.PrintPrefix, from, tag)
+	if bumpDryRunFlag.Get() {
+		if config.Bump.VersionFile != "" {
+			utils.CL.Greenf("%s 将写入版本文件: %s\n", types.PrintPrefix, config.Bump.VersionFile)
+		}
+		utils.CL.Greenf("%s 标签信息:\n%s", types.PrintPrefix, message)
+		return nil
+	}
+
+	// 写入版本文件并提交, 然后创建附注标签
+	var files []string
+	if file := config.Bump.VersionFile; file != "" {
+		if err := utils.WriteVersionFile(file, config.Bump.VersionVar, version); err != nil {
+			return err
+		}
+		utils.CL.Greenf("%s 已写入版本文件: %s\n", types.PrintPrefix, file)
+		files = append(files, file)
+	}
+	if err := utils.CreateReleaseTag(timeout, tag, message, files); err != nil {
+		return err
+	}
+	utils.CL.Greenf("%s 已创建标签: %s, 推送: git push --follow-tags\n", types.PrintPrefix, tag)
+
+	if bumpNoBuildFlag.Get() {
+		return nil
+	}
+
+	// 在新进程中执行构建任务, 使Git元数据反映新创建的标签
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取gob路径失败: %w", err)
+	}
+	utils.CL.Greenf("%s 执行构建任务: %s\n", types.PrintPrefix, configPath)
+	if err := shellx.NewCmds([]string{exe, configPath}).WithShell(shellx.ShellNone).WithStdout(os.Stdout).WithStderr(os.Stderr).Exec(); err != nil {
+		return fmt.Errorf("构建任务执行失败, 标签 %s 已创建: %w", tag, err)
+	}
+	return nil
+}
+
+// bumpPreid 返回新版本使用的预发布标识
+//
+// 参数:
+//   - part: 版本递增部分
+//   - current: 当前版本号
+//
+// 返回值:
+//   - string: 命令行指定的预发布标识; prerelease 未指定时沿用当前预发布版本的标识, 当前为正式版本时为 rc
+func bumpPreid(part string, current types.Semver) string {
+	if preid := bumpPreidFlag.Get(); preid != "" || part != "prerelease" {
+		return preid
+	}
+	if current.Prerelease != "" {
+		id, _, _ := strings.Cut(current.Prerelease, ".")
+		if _, err := strconv.Atoi(id); err != nil {
+			return id
+		}
+	}
+	return "rc"
+}
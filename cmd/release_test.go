@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// runBumpArgs 以给定参数执行 gob release bump
+func runBumpArgs(t *testing.T, args ...string) error {
+	t.Helper()
+	bump, ok := newReleaseCmd().GetSubCmd("bump")
+	if !ok {
+		t.Fatal("找不到 bump 子命令")
+	}
+	if err := bump.ParseOnly(args); err != nil {
+		t.Fatal(err)
+	}
+	return runReleaseBump(bump)
+}
+
+// newBumpRepo 在临时目录中创建带 v1.0.0 标签和版本文件的Git仓库, 并切换到该目录
+//
+// 返回值:
+//   - func(args ...string) string: 在仓库中执行git命令并返回去除首尾空白的输出
+func newBumpRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未找到 git")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "gob")
+	t.Setenv("GIT_AUTHOR_EMAIL", "gob@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "gob")
+	t.Setenv("GIT_COMMITTER_EMAIL", "gob@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s 失败: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	files := map[string]string{
+		"gob.toml":   "[build.git]\nsources = [\"git\"]\n\n[bump]\nversion_file = \"version.go\"\n",
+		"main.go":    "package main\n\nfunc main() {}\n",
+		"version.go": "package main\n\nconst Version = \"v1.0.0\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "feat: add x")
+	return git
+}
+
+func TestReleaseBump(t *testing.T) {
+	git := newBumpRepo(t)
+
+	// 预览时不修改仓库
+	if err := runBumpArgs(t, "minor", "--dry-run"); err != nil {
+		t.Fatal(err)
+	}
+	if got := git("tag", "--list"); got != "v1.0.0" {
+		t.Errorf("--dry-run 不应创建标签, got %q", got)
+	}
+	if data, _ := os.ReadFile("version.go"); !strings.Contains(string(data), `"v1.0.0"`) {
+		t.Errorf("--dry-run 不应修改版本文件, got %q", data)
+	}
+
+	// 工作区有修改时拒绝执行
+	if err := os.WriteFile("version.go", []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runBumpArgs(t, "minor", "--no-build"); err == nil || !strings.Contains(err.Error(), "未提交的修改") {
+		t.Errorf("工作区有修改时期望返回错误, got %v", err)
+	}
+	git("checkout", "--", "version.go")
+
+	// 写入版本文件并提交, 然后创建附注标签
+	if err := runBumpArgs(t, "minor", "--no-build"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("version.go"); string(data) != "package main\n\nconst Version = \"v1.1.0\"\n" {
+		t.Errorf("版本文件内容 = %q", data)
+	}
+	if got := git("log", "-1", "--format=%s"); got != "chore(release): v1.1.0" {
+		t.Errorf("版本文件的提交信息 = %q", got)
+	}
+	if got := git("cat-file", "-t", "v1.1.0"); got != "tag" {
+		t.Errorf("期望创建附注标签, got %s", got)
+	}
+	if got := git("tag", "-l", "--format=%(contents)", "v1.1.0"); got != "Release v1.1.0\n\n- feat: add x" {
+		t.Errorf("标签信息 = %q", got)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		tag  string // 期望创建的标签, 为空时期望返回错误
+		want string // 期望的错误信息
+	}{
+		{"递增预发布序号", []string{"prerelease", "--no-build"}, "v1.1.1-rc.1", ""},
+		{"沿用当前预发布标识", []string{"prerelease", "--no-build"}, "v1.1.1-rc.2", ""},
+		{"新版本不高于当前版本", []string{"prerelease", "--preid", "beta", "--no-build"}, "", "不高于当前版本"},
+		{"预发布版本的正式版本", []string{"patch", "--no-build"}, "v1.1.1", ""},
+		{"指定预发布标识", []string{"major", "-p", "alpha", "--no-build"}, "v2.0.0-alpha.1", ""},
+		{"无效的递增部分", []string{"build"}, "", "无效的版本递增部分"},
+		{"缺少递增部分", nil, "", "用法"},
+		{"未知的标志", []string{"patch", "--push"}, "", "未知的标志"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runBumpArgs(t, tt.args...)
+			if tt.tag == "" {
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("期望错误包含 %q, got %v", tt.want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := git("describe", "--tags", "--exact-match"); got != tt.tag {
+				t.Errorf("当前提交的标签 = %s, 期望 %s", got, tt.tag)
+			}
+		})
+	}
+
+	// 标签已存在于未合并的分支时拒绝覆盖
+	git("checkout", "-q", "-b", "side")
+	git("commit", "-q", "--allow-empty", "-m", "side")
+	git("tag", "v2.0.0-alpha.2")
+	git("checkout", "-q", "main")
+	if err := runBumpArgs(t, "prerelease", "--no-build"); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Errorf("标签已存在时期望返回错误, got %v", err)
+	}
+}
+
+func TestReleaseBumpPrefix(t *testing.T) {
+	git := newBumpRepo(t)
+	if err := os.WriteFile("gob.toml", []byte("[build.git]\nsources = [\"git\"]\ntag_prefix = \"cli/\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "-q", "-am", "chore: prefix")
+	git("tag", "cli/2.3.0")
+
+	// 标签沿用当前标签不带 v 的写法, 不配置版本文件时直接在当前提交上创建标签
+	head := git("rev-parse", "HEAD")
+	if err := runBumpArgs(t, "patch", "--no-build"); err != nil {
+		t.Fatal(err)
+	}
+	if got := git("rev-list", "-n", "1", "cli/2.3.1"); got != head {
+		t.Errorf("标签 cli/2.3.1 应指向当前提交 %s, got %s", head, got)
+	}
+	if got := git("tag", "-l", "--format=%(contents)", "cli/2.3.1"); got != "Release cli/2.3.1" {
+		t.Errorf("没有新提交时标签信息只包含首行, got %q", got)
+	}
+}
@@ -2,10 +2,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"sync"
+	"syscall"
 	"time"
 
 	"gitee.com/MM-Q/gob/internal/types"
@@ -21,6 +24,7 @@ func InitAndRun() {
 	forceFlag = qflag.Root.Bool("force", "f", "强制操作 (覆盖已存在文件)", false)
 	listFlag = qflag.Root.Bool("list", "l", "列出可用的构建任务", false)
 	runFlag = qflag.Root.String("run", "r", "运行指定的构建任务 (自动在 gobf/ 目录下查找)", "")
+	jobsFlag = qflag.Root.Int("jobs", "j", "批量构建的并发目标数, 覆盖配置文件中的 jobs (0 表示使用配置)", 0)
 
 	// 初始化相关标志
 	initFlag = qflag.Root.Bool("init", "i", "初始化gob构建文件", false)
@@ -43,8 +47,17 @@ func InitAndRun() {
 			"生成默认配置文件 (gob.toml)":      fmt.Sprintf("%s --generate-config", qflag.Root.Name()),
 			"列出可用的构建任务":                fmt.Sprintf("%s --list", qflag.Root.Name()),
 			"运行指定的构建任务（快捷方式）":          fmt.Sprintf("%s --run dev", qflag.Root.Name()),
+			"限制批量构建的并发目标数":             fmt.Sprintf("%s --jobs 4 --run release", qflag.Root.Name()),
 			"使用指定配置文件构建":               fmt.Sprintf("%s gobf/dev.toml", qflag.Root.Name()),
 			"使用默认配置文件构建":               qflag.Root.Name(),
+			"查看任务合并后的配置及来源":            fmt.Sprintf("%s config show release --resolved", qflag.Root.Name()),
+			"校验 gobf/ 目录下的所有配置文件":      fmt.Sprintf("%s config validate", qflag.Root.Name()),
+			"生成配置文件的JSON Schema":       fmt.Sprintf("%s config schema -o gobf/gob.schema.json", qflag.Root.Name()),
+			"将旧版本的配置文件升级为当前格式":         fmt.Sprintf("%s config migrate", qflag.Root.Name()),
+			"根据校验和文件校验构建产物":            fmt.Sprintf("%s verify output", qflag.Root.Name()),
+			"发布构建产物到代码托管平台":            fmt.Sprintf("%s publish release", qflag.Root.Name()),
+			"递增次版本号, 创建标签并执行发布构建":      fmt.Sprintf("%s release bump minor release", qflag.Root.Name()),
+			"根据提交历史生成当前版本的变更日志":        fmt.Sprintf("%s changelog", qflag.Root.Name()),
 		},
 	}
 
@@ -54,6 +67,12 @@ func InitAndRun() {
 		os.Exit(1)
 	}
 
+	// 注册子命令
+	if err := qflag.AddSubCmds(newConfigCmd(), newVerifyCmd(), newPublishCmd(), newReleaseCmd(), newChangelogCmd()); err != nil {
+		utils.CL.PrintError(err)
+		os.Exit(1)
+	}
+
 	// 设置命令行工具运行函数
 	qflag.Root.SetRun(run)
 
@@ -161,44 +180,106 @@ func run(cmd qflag.Command) error {
 	utils.CL.SetColor(config.Build.UI.Color)
 	utils.CL.Greenf("%s 配置文件: %s\n", types.PrintPrefix, configFilePath)
 
-	// 第一阶段：执行检查和准备阶段
-	utils.CL.Greenf("%s 开始构建准备\n", types.PrintPrefix)
-	if err := utils.CheckBaseEnv(config); err != nil {
-		utils.CL.PrintErrorf("%v\n", err)
+	// 命令行指定的并发数优先于配置文件
+	if jobsFlag.Get() < 0 {
+		utils.CL.PrintError("--jobs 不能为负数")
 		os.Exit(1)
 	}
+	if jobsFlag.Get() > 0 {
+		config.Build.Target.Jobs = jobsFlag.Get()
+	}
 
-	// 检查批量构建和安装选项是否同时启用
-	if config.Build.Target.Batch && config.Install.Install {
-		utils.CL.PrintError("不能同时使用批量构建和安装选项")
+	// 如果不是批量模式, 强制设置为仅构建当前平台
+	if !config.Build.Target.Batch {
+		config.Build.Target.CurrentPlatformOnly = true
+	}
+
+	// 执行检查和准备阶段, os.Exit 不执行延迟函数, 退出前需要先删除或恢复生成的版本文件
+	restore, err := prepareBuild(verman.V, config)
+	if err != nil {
+		utils.CL.PrintErrorf("%v\n", err)
 		os.Exit(1)
 	}
+	defer restore()
+
+	// 收到中断或终止信号时取消构建, 终止正在运行的编译器
+	ctx, receivedSignal, stop := notifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 检查安装和zip选项是否同时启用
-	if config.Install.Install && config.Build.Output.Zip {
-		utils.CL.PrintError("不能同时使用安装和zip选项")
+	// 执行构建
+	if err := buildBatch(ctx, verman.V, config); err != nil {
+		restore()
+		if sig := receivedSignal(); sig != nil {
+			utils.CL.Yellowf("%s 构建已中断: %v\n", types.PrintPrefix, sig)
+			os.Exit(signalExitCode(sig))
+		}
+		utils.CL.PrintError(err.Error())
 		os.Exit(1)
 	}
 
-	// 第二阶段: 根据参数获取git信息
-	if config.Build.Git.Inject {
+	return nil
+}
+
+// prepareBuild 执行构建前的检查和准备
+//
+// 参数:
+//   - v: verman对象, 用于存储获取到的Git元数据
+//   - config: 配置对象
+//
+// 返回值:
+//   - func(): 删除或恢复 generate 模式生成的版本文件, 可重复调用
+//   - error: 任一步骤失败时返回错误, 此时已生成的版本文件已被删除或恢复
+//
+// 注意:
+//   - generate 模式的版本文件在 go vet 静态检查之前生成, 否则检查会因引用未定义的常量而失败
+func prepareBuild(v *verman.Info, config *types.GobConfig) (func(), error) {
+	utils.CL.Greenf("%s 开始构建准备\n", types.PrintPrefix)
+
+	// 根据参数获取git信息, 生成安装包、容器镜像、SBOM、变更日志、包管理器清单或上传产物时同样需要Git版本
+	pkg, publish := config.Package.Linux, config.Publish
+	if config.Build.Git.Inject || (pkg.Enabled && pkg.Version == "") || config.Container.Enabled || config.Build.SBOM.Enabled ||
+		config.Changelog.Enabled || publish.Manifests.Enabled || publish.S3.Enabled || publish.HTTP.Enabled {
 		utils.CL.Greenf("%s 获取Git元数据\n", types.PrintPrefix)
-		if err := utils.GetGitMetaData(config.Build.TimeoutDuration, verman.V, config); err != nil {
-			utils.CL.PrintErrorf("Git信息获取失败: %v\n", err)
-			os.Exit(1)
+		if err := utils.GetGitMetaData(config.Build.TimeoutDuration, v, config); err != nil {
+			// 启用Git信息注入时降级为 unknown 继续构建, 否则报错退出
+			if !config.Build.Git.Inject {
+				return nil, fmt.Errorf("Git信息获取失败: %w", err)
+			}
+			utils.CL.Yellowf("%s Git信息获取失败, 注入的Git元数据使用 %s: %v\n", types.PrintPrefix, types.UnknownGitValue, err)
+			utils.SetUnknownGitMetaData(v, config)
 		}
 	}
 
-	// 如果不是批量模式, 强制设置为仅构建当前平台
-	if !config.Build.Target.Batch {
-		config.Build.Target.CurrentPlatformOnly = true
+	// generate 模式生成版本文件, 所有目标共用, 构建结束后删除或恢复原文件
+	restore := func() {}
+	if config.Build.Git.Inject && config.Build.Git.Mode == types.GitModeGenerate {
+		path, restoreSource, err := utils.WriteGitVersionSource(v, config)
+		if err != nil {
+			return nil, err
+		}
+		restore = sync.OnceFunc(func() {
+			if err := restoreSource(); err != nil {
+				utils.CL.Yellowf("%s %v\n", types.PrintPrefix, err)
+			}
+		})
+		utils.CL.Greenf("%s 已生成版本文件: %s\n", types.PrintPrefix, path)
 	}
 
-	// 执行构建
-	if err := buildBatch(verman.V, config); err != nil {
-		utils.CL.PrintError(err.Error())
-		os.Exit(1)
+	if err := utils.CheckBaseEnv(config); err != nil {
+		restore()
+		return nil, err
 	}
 
-	return nil
+	// 解析用户自定义变量
+	if len(config.Vars) > 0 {
+		utils.CL.Greenf("%s 解析自定义变量\n", types.PrintPrefix)
+		values, err := utils.ResolveVars(config)
+		if err != nil {
+			restore()
+			return nil, fmt.Errorf("自定义变量解析失败: %w", err)
+		}
+		config.VarValues = values
+	}
+
+	return restore, nil
 }
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// newGenerateModule 在临时目录中创建带标签的Git仓库和引用生成常量的Go模块, 并切换到该目录
+func newGenerateModule(t *testing.T, mainSrc string) *types.GobConfig {
+	t.Helper()
+	for _, tool := range []string{"go", "git"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("未找到 %s", tool)
+		}
+	}
+	t.Chdir(t.TempDir())
+	// 避免继承测试环境的 -mod=vendor 等设置
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "off")
+
+	files := map[string]string{
+		"go.mod":     "module example.com/hello\n\ngo 1.21\n",
+		"main.go":    mainSrc,
+		".gitignore": "output/\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=gob", "-c", "user.email=gob@example.com", "commit", "-q", "-m", "init"},
+		{"tag", "v1.2.3"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s 失败: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	config := utils.GetDefaultConfig()
+	config.Build.Source.MainFile = "main.go"
+	config.Build.Output.Name = "hello"
+	config.Build.Output.Simple = true
+	config.Build.Target.CurrentPlatformOnly = true
+	config.Build.Git.Inject = true
+	config.Build.Git.Mode = types.GitModeGenerate
+	return config
+}
+
+func TestBuildGenerateMode(t *testing.T) {
+	config := newGenerateModule(t, `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(AppName, GitVersion, GitTreeState)
+}
+`)
+	v := &verman.Info{}
+
+	// go vet 在生成版本文件后执行, 否则会因 GitVersion 未定义而失败
+	restore, err := prepareBuild(v, config)
+	if err != nil {
+		t.Fatalf("构建准备失败: %v", err)
+	}
+	if err := buildBatch(context.Background(), v, config); err != nil {
+		restore()
+		t.Fatalf("构建失败: %v", err)
+	}
+	restore()
+	if _, err := os.Stat(types.GitVersionSourceFile); !os.IsNotExist(err) {
+		t.Errorf("构建结束后应删除 %s", types.GitVersionSourceFile)
+	}
+
+	binary := filepath.Join(config.Build.Output.Dir, "hello")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	out, err := exec.Command(binary).Output()
+	if err != nil {
+		t.Fatalf("运行构建产物失败: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "hello v1.2.3 clean" {
+		t.Errorf("构建产物输出 = %q, 期望 %q", got, "hello v1.2.3 clean")
+	}
+}
+
+func TestPrepareBuildRestoresVersionSource(t *testing.T) {
+	// go vet 报告 Printf 参数错误, 检查失败
+	config := newGenerateModule(t, `package main
+
+import "fmt"
+
+func main() {
+	fmt.Printf("%d\n", GitVersion)
+}
+`)
+	original := "package main\n\nconst GitVersion = \"dev\"\n"
+	if err := os.WriteFile(types.GitVersionSourceFile, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := prepareBuild(&verman.Info{}, config); err == nil {
+		t.Fatal("静态检查失败时期望返回错误")
+	}
+	data, err := os.ReadFile(types.GitVersionSourceFile)
+	if err != nil || string(data) != original {
+		t.Errorf("检查失败后应恢复原版本文件, got %q, %v", data, err)
+	}
+}
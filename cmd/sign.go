@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+)
+
+// prepareSigner 读取签名使用的私钥
+//
+// 参数:
+//   - config: 配置对象
+//
+// 返回值:
+//   - utils.Signer: 签名器, 未启用签名时为nil
+//   - error: 私钥无法读取、缺少口令或口令错误时返回错误
+//
+// 注意:
+//   - 在构建开始前调用, 避免所有目标构建完成后才发现私钥不可用
+func prepareSigner(config *types.GobConfig) (utils.Signer, error) {
+	if !config.Sign.Enabled {
+		return nil, nil
+	}
+	signer, err := utils.NewSigner(config.Sign)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	utils.CL.Greenf("%s 签名密钥: %s (%s)\n", types.PrintPrefix, signer.KeyID(), config.Sign.Method)
+	return signer, nil
+}
+
+// writeSignatures 为校验和文件和构建产物生成签名文件
+//
+// 参数:
+//   - config: 配置对象
+//   - signer: 签名器
+//   - results: 构建结果
+//   - checksumPath: 校验和文件路径, 为空时表示未生成校验和文件
+//
+// 返回值:
+//   - map[string]string: 被签名的文件路径到签名文件路径的映射
+//   - error: 任一文件签名失败时返回错误
+func writeSignatures(config *types.GobConfig, signer utils.Signer, results []types.BuildResult, checksumPath string) (map[string]string, error) {
+	var files []string
+	if config.Sign.Artifacts {
+		for _, r := range results {
+			if r.Artifact != "" {
+				files = append(files, r.Artifact)
+			}
+			files = append(files, r.Packages...)
+			files = append(files, r.SBOMs...)
+		}
+	}
+	if config.Sign.Checksum && checksumPath != "" {
+		files = append(files, checksumPath)
+	}
+
+	signatures := make(map[string]string, len(files))
+	for _, f := range files {
+		sigPath, err := utils.SignFile(signer, f)
+		if err != nil {
+			return nil, err
+		}
+		signatures[f] = sigPath
+	}
+	if len(signatures) > 0 {
+		utils.CL.Greenf("%s 已生成 %d 个签名文件 (%s)\n", types.PrintPrefix, len(signatures), signer.Extension())
+	}
+	return signatures, nil
+}
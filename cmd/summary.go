@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+)
+
+// printBuildSummary 打印批量构建的汇总表格
+//
+// 参数:
+//   - results: 所有目标的构建结果
+func printBuildSummary(results []types.BuildResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	// 表头和每行的单元格
+	header := []string{"目标", "状态", "耗时", "错误"}
+	rows := make([][]string, 0, len(results))
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Status == types.BuildStatusSuccess {
+			succeeded++
+		}
+
+		// 错误信息仅保留第一行, 避免命令输出破坏表格
+		errMsg := ""
+		if r.Err != nil && r.Status == types.BuildStatusFailed {
+			errMsg, _, _ = strings.Cut(strings.TrimSpace(r.Err.Error()), "\n")
+		}
+
+		duration := "-"
+		if r.Duration > 0 {
+			duration = fmt.Sprintf("%.2fs", r.Duration.Seconds())
+		}
+
+		rows = append(rows, []string{r.Target(), statusText(r.Status), duration, errMsg})
+	}
+
+	// 计算每列的显示宽度(最后一列不需要对齐)
+	widths := make([]int, len(header)-1)
+	for i := range widths {
+		widths[i] = displayWidth(header[i])
+		for _, row := range rows {
+			widths[i] = max(widths[i], displayWidth(row[i]))
+		}
+	}
+
+	// 格式化单行
+	formatRow := func(cells []string) string {
+		var sb strings.Builder
+		for i, cell := range cells {
+			if i < len(widths) {
+				sb.WriteString(cell)
+				sb.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+2))
+				continue
+			}
+			sb.WriteString(cell)
+		}
+		return strings.TrimRight(sb.String(), " ")
+	}
+
+	utils.CL.Greenf("%s 构建汇总: %d/%d 个目标成功\n", types.PrintPrefix, succeeded, len(results))
+	fmt.Printf("  %s\n", formatRow(header))
+	for i, r := range results {
+		line := "  " + formatRow(rows[i])
+		switch r.Status {
+		case types.BuildStatusSuccess:
+			utils.CL.Green(line)
+		case types.BuildStatusCanceled:
+			utils.CL.Yellow(line)
+		default:
+			utils.CL.Red(line)
+		}
+	}
+}
+
+// statusText 返回构建状态的显示文本
+func statusText(status types.BuildStatus) string {
+	switch status {
+	case types.BuildStatusSuccess:
+		return "✓ " + string(status)
+	case types.BuildStatusFailed:
+		return "✗ " + string(status)
+	default:
+		return "- " + string(status)
+	}
+}
+
+// displayWidth 计算字符串在终端中的显示宽度(中文等宽字符计为2)
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || unicode.In(r, unicode.Hangul, unicode.Hiragana, unicode.Katakana) {
+			width += 2
+			continue
+		}
+		width++
+	}
+	return width
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// newScriptBatchConfig 创建使用 sh 脚本模拟编译的批量构建配置, 目标为 linux 的 amd64、arm64 和 riscv64
+//
+// 注意:
+//   - 脚本的第一个参数为输出路径
+func newScriptBatchConfig(t *testing.T, script string) *types.GobConfig {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用 sh 脚本模拟编译")
+	}
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "build.sh")
+	if err := os.WriteFile(scriptPath, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = filepath.Join(dir, "output")
+	config.Build.Output.Name = "myapp"
+	config.Build.Command.Build = []string{"sh", scriptPath, "{{output}}"}
+	config.Build.Target.Batch = true
+	config.Build.Target.Platforms = []string{"linux"}
+	config.Build.Target.Architectures = []string{"amd64", "arm64", "riscv64"}
+	if err := os.MkdirAll(config.Build.Output.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+// newFailingBatchConfig 创建批量构建配置, 指定架构的目标构建失败
+func newFailingBatchConfig(t *testing.T, failing ...string) *types.GobConfig {
+	t.Helper()
+	return newScriptBatchConfig(t, fmt.Sprintf("case \" $GOARCH \" in *\" %s \"*) echo boom >&2; exit 3;; esac\ntouch \"$1\"\n", strings.Join(failing, " ")))
+}
+
+// targetStatuses 返回每个目标的构建状态
+func targetStatuses(results []types.BuildResult) map[string]types.BuildStatus {
+	statuses := make(map[string]types.BuildStatus, len(results))
+	for _, r := range results {
+		statuses[r.Target()] = r.Status
+	}
+	return statuses
+}
+
+func TestBuildBatchReportsFailures(t *testing.T) {
+	config := newFailingBatchConfig(t, "arm64")
+
+	err := buildBatch(context.Background(), &verman.Info{}, config)
+	var buildErr *types.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("存在失败目标时期望返回 *types.BuildError, got %v", err)
+	}
+	if got, want := err.Error(), "1/3 个目标构建失败: linux/arm64"; got != want {
+		t.Errorf("错误信息 = %q, 期望 %q", got, want)
+	}
+
+	statuses := targetStatuses(buildErr.Results)
+	if statuses["linux/amd64"] != types.BuildStatusSuccess || statuses["linux/arm64"] != types.BuildStatusFailed || statuses["linux/riscv64"] != types.BuildStatusSuccess {
+		t.Errorf("未启用 fail_fast 时其余目标应继续构建, got %v", statuses)
+	}
+	if failed := buildErr.Failed(); len(failed) != 1 || failed[0].Err == nil {
+		t.Errorf("Failed() 应返回带错误的失败目标, got %+v", failed)
+	}
+}
+
+func TestBuildBatchFailFast(t *testing.T) {
+	config := newFailingBatchConfig(t, "amd64")
+	config.Build.Target.FailFast = true
+	config.Build.Target.Jobs = 1
+
+	err := buildBatch(context.Background(), &verman.Info{}, config)
+	var buildErr *types.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("期望返回 *types.BuildError, got %v", err)
+	}
+	statuses := targetStatuses(buildErr.Results)
+	want := map[string]types.BuildStatus{
+		"linux/amd64":   types.BuildStatusFailed,
+		"linux/arm64":   types.BuildStatusCanceled,
+		"linux/riscv64": types.BuildStatusCanceled,
+	}
+	for target, status := range want {
+		if statuses[target] != status {
+			t.Errorf("%s 的状态 = %s, 期望 %s", target, statuses[target], status)
+		}
+	}
+	if got := err.Error(); got != "1/3 个目标构建失败: linux/amd64 (2 个目标已取消)" {
+		t.Errorf("错误信息 = %q", got)
+	}
+}
+
+func TestBuildBatchSuccess(t *testing.T) {
+	config := newFailingBatchConfig(t)
+	if err := buildBatch(context.Background(), &verman.Info{}, config); err != nil {
+		t.Fatalf("全部目标成功时期望返回nil, got %v", err)
+	}
+}
+
+func TestBuildErrorUnwrap(t *testing.T) {
+	errBoom := errors.New("boom")
+	err := types.NewBuildError([]types.BuildResult{
+		{Platform: "linux", Arch: "amd64", Status: types.BuildStatusSuccess},
+		{Name: "linux/arm (armv6)", Platform: "linux", Arch: "arm", Status: types.BuildStatusFailed, Err: errBoom},
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("errors.Is 应能找到目标的原始错误")
+	}
+	if got := err.Error(); got != "1/2 个目标构建失败: linux/arm (armv6)" {
+		t.Errorf("错误信息应使用目标名称, got %q", got)
+	}
+
+	if err := types.NewBuildError([]types.BuildResult{{Status: types.BuildStatusSuccess}}); err != nil {
+		t.Errorf("全部成功时期望返回nil, got %v", err)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := map[string]int{
+		"":             0,
+		"linux/amd64":  11,
+		"目标":           4,
+		"✓ 成功":         6,
+		"1.23s":        5,
+		"テスト":          6,
+		"linux/arm 构建": 14,
+	}
+	for s, want := range tests {
+		if got := displayWidth(s); got != want {
+			t.Errorf("displayWidth(%q) = %d, 期望 %d", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// prepareUploads 创建上传使用的客户端
+//
+// 参数:
+//   - config: 配置对象
+//
+// 返回值:
+//   - *utils.S3Client: 对象存储客户端, 未启用或为演练模式时为nil
+//   - *utils.HTTPUploader: HTTP上传器, 未启用或为演练模式时为nil
+//   - error: 凭据的环境变量未设置时返回错误
+//
+// 注意:
+//   - 在构建开始前调用, 避免所有目标构建完成后才发现缺少凭据
+func prepareUploads(config *types.GobConfig) (*utils.S3Client, *utils.HTTPUploader, error) {
+	var s3Client *utils.S3Client
+	var httpUploader *utils.HTTPUploader
+	var err error
+	if cfg := config.Publish.S3; cfg.Enabled && !cfg.DryRun {
+		if s3Client, err = utils.NewS3Client(cfg); err != nil {
+			return nil, nil, fmt.Errorf("publish.s3: %w", err)
+		}
+	}
+	if cfg := config.Publish.HTTP; cfg.Enabled && !cfg.DryRun {
+		if httpUploader, err = utils.NewHTTPUploader(cfg); err != nil {
+			return nil, nil, fmt.Errorf("publish.http: %w", err)
+		}
+	}
+	return s3Client, httpUploader, nil
+}
+
+// uploadArtifacts 将构建产物上传到对象存储和HTTP服务
+//
+// 参数:
+//   - ctx: 上下文, 取消时停止上传
+//   - v: verman对象, 提供路径模板中的Git元数据
+//   - config: 配置对象
+//   - s3Client: 对象存储客户端, 为nil时只显示将要上传的对象
+//   - httpUploader: HTTP上传器, 为nil时只显示将要上传的地址
+//   - artifacts: 本次构建生成的所有文件
+//
+// 返回值:
+//   - error: 任一文件上传失败时返回错误
+func uploadArtifacts(ctx context.Context, v *verman.Info, config *types.GobConfig, s3Client *utils.S3Client, httpUploader *utils.HTTPUploader, artifacts []types.Artifact) error {
+	if cfg := config.Publish.S3; cfg.Enabled {
+		keys, err := renderUploadPaths(v, config, cfg.Path, artifacts)
+		if err != nil {
+			return fmt.Errorf("渲染对象路径失败: %w", err)
+		}
+		for i, key := range keys {
+			keys[i] = joinNonEmpty(key)
+		}
+
+		err = uploadEach(ctx, config, artifacts, keys, "对象存储", cfg.DryRun, func(key string) (string, error) {
+			u, err := utils.S3ObjectURL(cfg, key)
+			if err != nil {
+				return "", err
+			}
+			return u.String(), nil
+		}, func(key, path string) error {
+			return s3Client.Upload(ctx, key, path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg := config.Publish.HTTP; cfg.Enabled {
+		urls, err := renderUploadPaths(v, config, cfg.URL, artifacts)
+		if err != nil {
+			return fmt.Errorf("渲染上传地址失败: %w", err)
+		}
+		for i, target := range urls {
+			if u, err := url.Parse(target); err == nil {
+				u.Path = "/" + joinNonEmpty(u.Path)
+				u.RawPath = ""
+				urls[i] = u.String()
+			}
+		}
+
+		err = uploadEach(ctx, config, artifacts, urls, "HTTP服务", cfg.DryRun, func(target string) (string, error) {
+			return target, nil
+		}, func(target, path string) error {
+			return httpUploader.Upload(ctx, target, path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinNonEmpty 去掉路径中的空段, 与平台无关的产物(如校验和文件)的目标为空, 渲染后可能出现连续的/
+func joinNonEmpty(p string) string {
+	return strings.Join(slices.DeleteFunc(strings.Split(p, "/"), func(s string) bool { return s == "" }), "/")
+}
+
+// renderUploadPaths 为每个产物渲染上传路径
+//
+// 参数:
+//   - v: verman对象
+//   - config: 配置对象
+//   - tmpl: 路径模板, 可通过 {{.Artifact}} 引用产物文件名, {{.Target}} 引用产物的目标
+//   - artifacts: 构建产物
+//
+// 返回值:
+//   - []string: 与产物一一对应的路径
+//   - error: 渲染失败时返回错误
+func renderUploadPaths(v *verman.Info, config *types.GobConfig, tmpl string, artifacts []types.Artifact) ([]string, error) {
+	data := releaseTemplateData(v, config)
+	paths := make([]string, 0, len(artifacts))
+	for _, a := range artifacts {
+		data.Target = types.TemplateTarget{OS: a.OS, Arch: a.Arch}
+		data.Artifact = a.Name
+		path, err := utils.RenderTemplate(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, strings.TrimSpace(path))
+	}
+	return paths, nil
+}
+
+// uploadEach 逐个上传产物并打印结果
+//
+// 参数:
+//   - ctx: 上下文
+//   - config: 配置对象
+//   - artifacts: 构建产物
+//   - targets: 与产物一一对应的上传目标
+//   - dest: 上传目的地的名称, 用于输出
+//   - dryRun: 只显示将要上传的地址
+//   - describe: 返回上传目标对应的地址, 用于输出
+//   - upload: 上传单个文件
+//
+// 返回值:
+//   - error: 任一文件上传失败或上传被中断时返回错误
+func uploadEach(ctx context.Context, config *types.GobConfig, artifacts []types.Artifact, targets []string, dest string, dryRun bool, describe func(string) (string, error), upload func(target, path string) error) error {
+	failed := 0
+	for i, a := range artifacts {
+		location, err := describe(targets[i])
+		if err != nil {
+			return fmt.Errorf("上传到%s失败: %w", dest, err)
+		}
+		if dryRun {
+			utils.CL.Greenf("%s [dry-run] %s -> %s\n", types.PrintPrefix, a.Path, location)
+			continue
+		}
+
+		path := filepath.Join(config.Build.Output.Dir, filepath.FromSlash(a.Path))
+		if err := upload(targets[i], path); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("上传到%s已中断", dest)
+			}
+			failed++
+			utils.CL.Redf("%s ✗ %s: %v\n", types.PrintPrefix, a.Name, err)
+			continue
+		}
+		utils.CL.Greenf("%s ✓ %s -> %s\n", types.PrintPrefix, a.Name, location)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个文件上传到%s失败", failed, len(artifacts), dest)
+	}
+	if !dryRun {
+		utils.CL.Greenf("%s 已上传 %d 个文件到%s\n", types.PrintPrefix, len(artifacts), dest)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/verman"
+)
+
+// newUploadTestConfig 创建包含构建产物和校验和文件的配置, 返回产物列表
+func newUploadTestConfig(t *testing.T) (*types.GobConfig, []types.Artifact) {
+	t.Helper()
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	writeTestFile(t, dir, "myapp_linux_amd64.tar.gz", "linux")
+	writeTestFile(t, dir, "SHA256SUMS", "sums")
+	return config, []types.Artifact{
+		{Name: "myapp_linux_amd64.tar.gz", Path: "myapp_linux_amd64.tar.gz", Kind: types.ArtifactKindArchive, OS: "linux", Arch: "amd64"},
+		{Name: "SHA256SUMS", Path: "SHA256SUMS", Kind: types.ArtifactKindChecksum},
+	}
+}
+
+func TestUploadArtifactsHTTP(t *testing.T) {
+	var mu sync.Mutex
+	uploaded := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		uploaded[r.URL.Path] = string(body)
+		if strings.HasSuffix(r.URL.Path, "SHA256SUMS") && r.Header.Get("Authorization") == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	config, artifacts := newUploadTestConfig(t)
+	config.Publish.HTTP.Enabled = true
+	config.Publish.HTTP.Retries = 0
+	config.Publish.HTTP.URL = srv.URL + "/files/{{.Git.Version}}/{{.Target.OS}}/{{.Artifact}}"
+	v := &verman.Info{GitVersion: "v1.2.0"}
+
+	// 与平台无关的产物渲染后的空路径段被去掉
+	_, uploader, err := prepareUploads(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uploadArtifacts(context.Background(), v, config, nil, uploader, artifacts)
+	if err == nil || err.Error() != "1/2 个文件上传到HTTP服务失败" {
+		t.Errorf("期望报告1个文件上传失败, got %v", err)
+	}
+	want := map[string]string{"/files/v1.2.0/linux/myapp_linux_amd64.tar.gz": "linux", "/files/v1.2.0/SHA256SUMS": "sums"}
+	if !reflect.DeepEqual(uploaded, want) {
+		t.Errorf("上传的文件 = %v, 期望 %v", uploaded, want)
+	}
+
+	t.Setenv("GOB_TEST_UPLOAD_TOKEN", "token")
+	config.Publish.HTTP.TokenEnv = "GOB_TEST_UPLOAD_TOKEN"
+	if _, uploader, err = prepareUploads(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadArtifacts(context.Background(), v, config, nil, uploader, artifacts); err != nil {
+		t.Errorf("设置令牌后期望全部上传成功: %v", err)
+	}
+}
+
+func TestUploadArtifactsDryRun(t *testing.T) {
+	config, artifacts := newUploadTestConfig(t)
+	config.Publish.S3.Enabled = true
+	config.Publish.S3.DryRun = true
+	config.Publish.S3.Endpoint = "https://minio.example.com"
+	config.Publish.S3.Bucket = "dist"
+	config.Publish.HTTP.Enabled = true
+	config.Publish.HTTP.DryRun = true
+	config.Publish.HTTP.URL = "https://upload.example.com/{{.Artifact}}"
+	// 演练模式不需要凭据
+	t.Setenv(config.Publish.S3.AccessKeyEnv, "")
+
+	s3Client, uploader, err := prepareUploads(config)
+	if err != nil || s3Client != nil || uploader != nil {
+		t.Fatalf("演练模式不应创建客户端, got %v, %v, %v", s3Client, uploader, err)
+	}
+	if err := uploadArtifacts(context.Background(), &verman.Info{GitVersion: "v1.2.0"}, config, nil, nil, artifacts); err != nil {
+		t.Errorf("演练模式不应访问网络: %v", err)
+	}
+
+	config.Publish.S3.DryRun = false
+	if _, _, err := prepareUploads(config); err == nil || !strings.Contains(err.Error(), "publish.s3: 环境变量 AWS_ACCESS_KEY_ID 未设置") {
+		t.Errorf("缺少凭据时期望在构建前报错, got %v", err)
+	}
+}
+
+func TestRenderUploadPaths(t *testing.T) {
+	config, artifacts := newUploadTestConfig(t)
+	paths, err := renderUploadPaths(&verman.Info{GitVersion: "v1.2.0"}, config, types.DefaultS3Path, artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.2.0/myapp_linux_amd64.tar.gz", "v1.2.0/SHA256SUMS"}; !slices.Equal(paths, want) {
+		t.Errorf("对象路径 = %v, 期望 %v", paths, want)
+	}
+
+	tests := map[string]string{
+		"v1.2.0//SHA256SUMS":  "v1.2.0/SHA256SUMS",
+		"/v1.2.0/linux/app":   "v1.2.0/linux/app",
+		"v1.2.0/linux//app//": "v1.2.0/linux/app",
+		"":                    "",
+	}
+	for p, want := range tests {
+		if got := joinNonEmpty(p); got != want {
+			t.Errorf("joinNonEmpty(%q) = %q, 期望 %q", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+	"gitee.com/MM-Q/qflag"
+)
+
+// newVerifyCmd 创建 verify 子命令
+//
+// 返回值:
+//   - *qflag.Cmd: verify 子命令
+func newVerifyCmd() *qflag.Cmd {
+	verifyCmd := qflag.NewCmd("verify", "vf", qflag.ContinueOnError)
+	verifyFileFlag = verifyCmd.String("file", "f", "校验和文件名, 相对于输出目录", types.DefaultChecksumFile)
+	verifyAlgorithmFlag = verifyCmd.String("algorithm", "a", "校验算法: sha256、sha512、blake2b, 默认根据校验和长度推断", "")
+	verifySignatureFlag = verifyCmd.Bool("signature", "s", "同时校验校验和文件和构建产物旁的签名文件(.sig或.asc)", false)
+	verifyKeyFlag = verifyCmd.String("key", "k", "校验签名使用的公钥文件: minisign/signify公钥或OpenPGP公钥", "")
+	verifyCmdOpts := &qflag.CmdOpts{
+		Desc:        "根据校验和文件校验输出目录中的构建产物",
+		UsageSyntax: "gob verify [options] [dir]",
+		UseChinese:  true,
+		RunFunc:     runVerify,
+		Examples: map[string]string{
+			"校验 output 目录": "gob verify output",
+			"指定校验和文件和算法":   "gob verify output --file SHA512SUMS --algorithm sha512",
+			"同时校验签名":       "gob verify output --signature --key minisign.pub",
+		},
+		Notes: []string{
+			"未指定目录时校验 output 目录",
+			"任一文件缺失或校验和不匹配时以非零退出码退出",
+			"校验签名时, 校验和文件已签名即可证明其中所有文件的来源, 否则每个文件都必须有签名文件",
+		},
+	}
+	if err := verifyCmd.ApplyOpts(verifyCmdOpts); err != nil {
+		panic(err)
+	}
+	return verifyCmd
+}
+
+// runVerify 根据校验和文件校验构建产物
+//
+// 参数:
+//   - cmd: verify 命令
+//
+// 返回值:
+//   - error: 任一文件校验失败时返回错误
+func runVerify(cmd qflag.Command) error {
+	args, err := parseTrailingFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("只能指定一个目录")
+	}
+
+	dir := types.DefaultOutputDir
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("目录 %s 不存在", dir)
+	}
+
+	algorithm := strings.ToLower(verifyAlgorithmFlag.Get())
+	if algorithm != "" && !slices.Contains(utils.ChecksumAlgorithms(), algorithm) {
+		return fmt.Errorf("不支持的校验算法 %q, 可用的算法: %s", algorithm, strings.Join(utils.ChecksumAlgorithms(), "、"))
+	}
+
+	manifest := filepath.Join(dir, verifyFileFlag.Get())
+	entries, err := utils.ParseChecksumFile(manifest)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("校验和文件 %s 中没有任何条目", manifest)
+	}
+
+	// 校验和文件的签名有效时, 其中的校验和可以证明所有文件的来源
+	var verifier utils.Verifier
+	manifestSigned := false
+	if verifySignatureFlag.Get() {
+		if verifyKeyFlag.Get() == "" {
+			return fmt.Errorf("校验签名需要通过 --key 指定公钥文件")
+		}
+		if verifier, err = utils.NewVerifier(verifyKeyFlag.Get()); err != nil {
+			return err
+		}
+		if _, err := os.Stat(manifest + verifier.Extension()); err == nil {
+			if err := utils.VerifyFileSignature(verifier, manifest); err != nil {
+				return fmt.Errorf("校验和文件 %s 的签名校验失败: %w", manifest, err)
+			}
+			manifestSigned = true
+			utils.CL.Greenf("%s ✓ %s (签名)\n", types.PrintPrefix, filepath.Base(manifest))
+		}
+	}
+
+	failed := 0
+	for _, entry := range entries {
+		if err := utils.VerifyChecksum(dir, entry, algorithm); err != nil {
+			failed++
+			utils.CL.Redf("%s ✗ %s: %v\n", types.PrintPrefix, entry.Name, err)
+			continue
+		}
+		if verifier != nil {
+			path := filepath.Join(dir, entry.Name)
+			_, statErr := os.Stat(path + verifier.Extension())
+			if statErr == nil || !manifestSigned {
+				if err := utils.VerifyFileSignature(verifier, path); err != nil {
+					failed++
+					utils.CL.Redf("%s ✗ %s: %v\n", types.PrintPrefix, entry.Name, err)
+					continue
+				}
+			}
+		}
+		utils.CL.Greenf("%s ✓ %s\n", types.PrintPrefix, entry.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个文件校验失败", failed, len(entries))
+	}
+	utils.CL.Greenf("%s 全部 %d 个文件校验通过\n", types.PrintPrefix, len(entries))
+	if verifier != nil {
+		utils.CL.Greenf("%s 签名校验通过, 公钥: %s\n", types.PrintPrefix, verifier.KeyID())
+	}
+	return nil
+}
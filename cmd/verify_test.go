@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/gob/internal/utils"
+)
+
+// 签名测试密钥的口令均为 test-passphrase
+const testSignDir = "../internal/utils/testdata/sign"
+
+// writeTestFile 在目录中写入文件, 返回文件路径和内容的sha256
+func writeTestFile(t *testing.T, dir, name, content string) (string, string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return path, hex.EncodeToString(sum[:])
+}
+
+// runVerifyArgs 以给定参数执行 gob verify
+func runVerifyArgs(t *testing.T, args ...string) error {
+	t.Helper()
+	cmd := newVerifyCmd()
+	if err := cmd.ParseOnly(args); err != nil {
+		t.Fatal(err)
+	}
+	return runVerify(cmd)
+}
+
+// signTestOutputs 在临时输出目录中生成构建产物和校验和文件, 并按配置签名
+func signTestOutputs(t *testing.T, method, key string, signChecksum, signArtifacts bool) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	config.Sign = types.SignConfig{
+		Enabled:       true,
+		Method:        method,
+		Checksum:      signChecksum,
+		Artifacts:     signArtifacts,
+		KeyFile:       filepath.Join(testSignDir, key),
+		PassphraseEnv: "GOB_TEST_PASSPHRASE",
+		Prehash:       true,
+	}
+	t.Setenv("GOB_TEST_PASSPHRASE", "test-passphrase")
+
+	linux, _ := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz", "linux")
+	windows, _ := writeTestFile(t, dir, "myapp_windows_amd64.zip", "windows")
+	results := []types.BuildResult{
+		{Platform: "linux", Arch: "amd64", BuildOutput: types.BuildOutput{Artifact: linux}},
+		{Platform: "windows", Arch: "amd64", BuildOutput: types.BuildOutput{Artifact: windows}},
+	}
+	checksum, err := utils.WriteChecksumFile(dir, types.DefaultChecksumFile, "sha256", []string{linux, windows})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := prepareSigner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeSignatures(config, signer, results, checksum); err != nil {
+		t.Fatal(err)
+	}
+	return dir, []string{linux, windows, checksum}
+}
+
+func TestVerifySignatureRoundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		key, pub      string
+		signChecksum  bool
+		signArtifacts bool
+	}{
+		{"minisign签名校验和文件", "minisign", "minisign.key", "minisign.pub", true, false},
+		{"minisign签名所有文件", "minisign", "minisign.key", "minisign.pub", true, true},
+		{"openpgp签名校验和文件", "openpgp", "openpgp-ed25519.key", "openpgp-ed25519.pub", true, false},
+		{"openpgp签名构建产物", "openpgp", "openpgp-rsa.key", "openpgp-rsa.pub", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, files := signTestOutputs(t, tt.method, tt.key, tt.signChecksum, tt.signArtifacts)
+			pub := filepath.Join(testSignDir, tt.pub)
+
+			if err := runVerifyArgs(t, dir, "--signature", "--key", pub); err != nil {
+				t.Fatalf("校验签名失败: %v", err)
+			}
+			if err := runVerifyArgs(t, dir, "--signature"); err == nil || !strings.Contains(err.Error(), "--key") {
+				t.Errorf("未指定公钥时期望提示 --key, got %v", err)
+			}
+
+			// 篡改构建产物后同时更新校验和文件, 只有签名能发现
+			if err := os.WriteFile(files[0], []byte("tampered"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := runVerifyArgs(t, dir, "--signature", "--key", pub); err == nil {
+				t.Error("构建产物被篡改后期望校验失败")
+			}
+			if _, err := utils.WriteChecksumFile(dir, types.DefaultChecksumFile, "sha256", files[:2]); err != nil {
+				t.Fatal(err)
+			}
+			if err := runVerifyArgs(t, dir); err != nil {
+				t.Fatalf("不校验签名时, 更新后的校验和文件应校验通过: %v", err)
+			}
+			if err := runVerifyArgs(t, dir, "--signature", "--key", pub); err == nil {
+				t.Error("构建产物和校验和文件均被篡改后期望签名校验失败")
+			}
+		})
+	}
+}
+
+func TestVerifySignatureWrongKey(t *testing.T) {
+	dir, _ := signTestOutputs(t, "openpgp", "openpgp-ed25519.key", true, false)
+	for _, pub := range []string{"openpgp-rsa.pub", "minisign.pub"} {
+		if err := runVerifyArgs(t, dir, "--signature", "--key", filepath.Join(testSignDir, pub)); err == nil {
+			t.Errorf("使用 %s 校验期望失败", pub)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	linux, _ := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz", "linux")
+	windows, _ := writeTestFile(t, dir, "myapp_windows_amd64.zip", "windows")
+	for _, algorithm := range utils.ChecksumAlgorithms() {
+		if _, err := utils.WriteChecksumFile(dir, strings.ToUpper(algorithm)+"SUMS", algorithm, []string{linux, windows}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, dir, "EMPTYSUMS", "# 没有条目\n")
+	writeTestFile(t, dir, "MISSINGSUMS", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  missing.zip\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want string // 期望的错误信息, 为空时期望校验通过
+	}{
+		{"默认校验和文件", []string{dir, "--file", "SHA256SUMS"}, ""},
+		{"推断sha512", []string{dir, "--file", "SHA512SUMS"}, ""},
+		{"推断blake2b", []string{dir, "--file", "BLAKE2BSUMS"}, ""},
+		{"指定算法", []string{dir, "--file", "BLAKE2BSUMS", "--algorithm", "BLAKE2B"}, ""},
+		{"算法不一致", []string{dir, "--file", "SHA512SUMS", "--algorithm", "blake2b"}, "2/2 个文件校验失败"},
+		{"不支持的算法", []string{dir, "--algorithm", "md5"}, `不支持的校验算法 "md5"`},
+		{"文件缺失", []string{dir, "--file", "MISSINGSUMS"}, "1/1 个文件校验失败"},
+		{"没有条目", []string{dir, "--file", "EMPTYSUMS"}, "没有任何条目"},
+		{"校验和文件不存在", []string{dir, "--file", "NOSUCHSUMS"}, "打开校验和文件失败"},
+		{"目录不存在", []string{filepath.Join(dir, "missing")}, "不存在"},
+		{"多个目录", []string{dir, dir}, "只能指定一个目录"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runVerifyArgs(t, tt.args...)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("期望校验通过, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("期望错误包含 %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// 篡改构建产物后校验失败
+	if err := os.WriteFile(linux, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runVerifyArgs(t, dir, "--file", "SHA256SUMS"); err == nil || !strings.Contains(err.Error(), "1/2 个文件校验失败") {
+		t.Errorf("构建产物被篡改后期望校验失败, got %v", err)
+	}
+}
+
+func TestWriteChecksums(t *testing.T) {
+	dir := t.TempDir()
+	config := utils.GetDefaultConfig()
+	config.Build.Output.Dir = dir
+	config.Build.Checksum.File = "SHA512SUMS"
+	config.Build.Checksum.Algorithm = "sha512"
+
+	if path, err := writeChecksums(config, []types.BuildResult{{Status: types.BuildStatusFailed}}); err != nil || path != "" {
+		t.Fatalf("没有构建产物时不应生成校验和文件, got %q, %v", path, err)
+	}
+
+	artifact, _ := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz", "linux")
+	deb, _ := writeTestFile(t, dir, "myapp_1.0.0_amd64.deb", "deb")
+	sbom, _ := writeTestFile(t, dir, "myapp_linux_amd64.tar.gz.spdx.json", "{}")
+	results := []types.BuildResult{{
+		Platform:    "linux",
+		Arch:        "amd64",
+		BuildOutput: types.BuildOutput{Artifact: artifact, Packages: []string{deb}, SBOMs: []string{sbom}},
+	}}
+	path, err := writeChecksums(config, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "SHA512SUMS") {
+		t.Errorf("校验和文件路径 = %s", path)
+	}
+	entries, err := utils.ParseChecksumFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || len(entries[0].Sum) != 128 {
+		t.Errorf("校验和文件应包含构建产物、安装包和SBOM的sha512校验和, got %+v", entries)
+	}
+	if err := runVerifyArgs(t, dir, "--file", "SHA512SUMS", "--algorithm", "sha512"); err != nil {
+		t.Errorf("生成的校验和文件应校验通过: %v", err)
+	}
+}
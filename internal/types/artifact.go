@@ -0,0 +1,44 @@
+package types
+
+// ArtifactKind 表示构建产物的类型
+type ArtifactKind string
+
+const (
+	ArtifactKindBinary    ArtifactKind = "binary"    // 可执行文件
+	ArtifactKindArchive   ArtifactKind = "archive"   // 归档文件
+	ArtifactKindChecksum  ArtifactKind = "checksum"  // 校验和文件
+	ArtifactKindPackage   ArtifactKind = "package"   // 系统安装包
+	ArtifactKindSignature ArtifactKind = "signature" // 签名文件
+	ArtifactKindSBOM      ArtifactKind = "sbom"      // 软件物料清单
+)
+
+// Artifact 表示产物清单中的单个文件
+type Artifact struct {
+	Name   string       `json:"name"`             // 文件名
+	Path   string       `json:"path"`             // 相对于输出目录的路径, 使用/分隔
+	Kind   ArtifactKind `json:"kind"`             // 产物类型
+	Target string       `json:"target,omitempty"` // 目标名称, 与平台无关的产物(如校验和文件)为空
+	OS     string       `json:"os,omitempty"`     // 目标平台
+	Arch   string       `json:"arch,omitempty"`   // 目标架构
+	Size   int64        `json:"size"`             // 文件大小(字节)
+	SHA256 string       `json:"sha256"`           // 文件的sha256校验和
+}
+
+// ArtifactGit 表示产物清单中的Git元数据, 取自注入到可执行文件中的 verman.Info
+type ArtifactGit struct {
+	AppName    string `json:"app_name"`             // 应用名称
+	Version    string `json:"version"`              // Git版本
+	Commit     string `json:"commit"`               // 提交哈希
+	CommitTime string `json:"commit_time"`          // 提交时间
+	TreeState  string `json:"tree_state"`           // 工作区状态: clean、dirty
+	BuildTime  string `json:"build_time,omitempty"` // 构建时间
+}
+
+// ArtifactManifest 表示输出目录下的产物清单(artifacts.json)
+type ArtifactManifest struct {
+	Config      string       `json:"config"`        // 生成产物的配置文件路径
+	OutputDir   string       `json:"output_dir"`    // 输出目录, 产物路径相对于该目录
+	GeneratedAt string       `json:"generated_at"`  // 生成时间, RFC 3339格式
+	Git         *ArtifactGit `json:"git,omitempty"` // Git元数据, 未启用Git信息注入时为空
+	Artifacts   []Artifact   `json:"artifacts"`     // 本次构建生成的所有文件
+}
@@ -0,0 +1,26 @@
+package types
+
+// ChangelogCommit 表示变更日志中的一个提交
+type ChangelogCommit struct {
+	Hash      string // 完整提交哈希
+	ShortHash string // 7位提交哈希
+	Author    string // 作者
+	Type      string // 约定式提交类型, 如 feat; 不符合约定式提交格式时为空
+	Scope     string // 范围, 如 feat(cli): 中的 cli
+	Subject   string // 去掉类型和范围后的描述; 不符合约定式提交格式时为完整标题
+	Breaking  bool   // 类型后带 ! 或正文包含 BREAKING CHANGE: 的不兼容变更
+}
+
+// ChangelogGroup 表示变更日志中包含提交的一个部分
+type ChangelogGroup struct {
+	Title   string            // 部分标题
+	Commits []ChangelogCommit // 归入该部分的提交, 按提交时间从新到旧排列
+}
+
+// Changelog 表示两个版本之间的变更日志, 对应模板中的 {{.Changelog.*}}
+type Changelog struct {
+	Tag         string           // 本次版本的标签; 快照版本时为Git版本
+	PreviousTag string           // 上一个版本的标签, 为空时包含全部提交
+	Date        string           // 标签所在提交的日期, 快照版本时为当天, 格式为 2006-01-02
+	Sections    []ChangelogGroup // 包含提交的部分, 按配置顺序排列
+}
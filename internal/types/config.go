@@ -7,9 +7,31 @@ import (
 // GobConfig 表示gob构建工具的完整配置结构
 // 对应gob.toml配置文件的结构
 type GobConfig struct {
-	Build   BuildConfig       `toml:"build" comment:"构建配置"`
-	Install InstallConfig     `toml:"install" comment:"安装配置"`
-	Env     map[string]string `toml:"env" comment:"环境变量配置"` // 默认值为空映射
+	Version   int               `toml:"version" comment:"配置文件格式版本, 旧版本的配置在加载时自动迁移"`         // 默认值为CurrentConfigVersion
+	Extends   string            `toml:"extends,omitempty" comment:"继承的基础配置文件, 相对于当前文件所在目录"` // 默认值为空
+	Build     BuildConfig       `toml:"build" comment:"构建配置"`
+	Install   InstallConfig     `toml:"install" comment:"安装配置"`
+	Package   PackageConfig     `toml:"package" comment:"系统安装包配置"`
+	Container ContainerConfig   `toml:"container" comment:"容器镜像配置"`
+	Sign      SignConfig        `toml:"sign" comment:"签名配置"`
+	Bump      BumpConfig        `toml:"bump" comment:"版本递增配置 (gob release bump)"`
+	Changelog ChangelogConfig   `toml:"changelog" comment:"变更日志配置 (gob changelog)"`
+	Publish   PublishConfig     `toml:"publish" comment:"发布配置"`
+	Env       map[string]string `toml:"env" comment:"环境变量配置"`                                        // 默认值为空映射
+	Vars      map[string]any    `toml:"vars,omitempty" comment:"用户自定义变量, 可在模板中通过 {{.Vars.NAME}} 引用"` // 默认值为空映射
+
+	VarValues  map[string]string `toml:"-"` // 内部使用的变量解析结果，不导出到TOML
+	ConfigFile string            `toml:"-"` // 内部使用的配置文件路径，不导出到TOML
+	GitSemver  GitSemver         `toml:"-"` // 内部使用的语义化版本信息，获取Git元数据时填充，不导出到TOML
+}
+
+// VarSource 表示[vars]中单个变量的取值来源
+// 字符串等标量直接作为静态值, 表形式可使用 value、env、default、cmd 键
+type VarSource struct {
+	Value   string // 静态值
+	Env     string // 从该环境变量取值
+	Cmd     string // 在启动时执行该命令, 使用去除首尾空白的标准输出作为值
+	Default string // env或cmd的结果为空时使用的默认值
 }
 
 // BuildConfig 表示构建相关的配置项
@@ -25,6 +47,9 @@ type BuildConfig struct {
 	WorkDir   string          `toml:"work_dir" comment:"构建工作目录，默认为当前目录"` // 构建工作目录
 	PreBuild  PreBuildConfig  `toml:"pre_build" comment:"构建前执行配置"`       // 构建前执行配置
 	PostBuild PostBuildConfig `toml:"post_build" comment:"构建后执行配置"`      // 构建后执行配置
+	Checksum  ChecksumConfig  `toml:"checksum" comment:"校验和配置"`          // 校验和配置
+	Manifest  ManifestConfig  `toml:"manifest" comment:"产物清单配置"`         // 产物清单配置
+	SBOM      SBOMConfig      `toml:"sbom" comment:"SBOM配置"`             // SBOM配置
 
 	TimeoutDuration time.Duration `toml:"-"` // 内部使用的Duration类型，不导出到TOML
 }
@@ -32,10 +57,22 @@ type BuildConfig struct {
 // OutputConfig 表示输出相关的配置项
 // 对应gob.toml中的[build.output]部分
 type OutputConfig struct {
-	Dir    string `toml:"dir" comment:"输出目录"`                  // 默认值为"output"
-	Name   string `toml:"name" comment:"输出文件名"`                // 默认值为"gob"
-	Simple bool   `toml:"simple" comment:"使用简单名称（不包含平台和架构信息）"` // 默认值为false
-	Zip    bool   `toml:"zip" comment:"将输出文件打包为zip"`           // 默认值为false
+	Dir     string        `toml:"dir" comment:"输出目录"`                  // 默认值为"output"
+	Name    string        `toml:"name" comment:"输出文件名, 支持模板语法"`        // 默认值为"gob"
+	Simple  bool          `toml:"simple" comment:"使用简单名称（不包含平台和架构信息）"` // 默认值为false
+	Zip     bool          `toml:"zip" comment:"将输出文件打包为zip"`           // 默认值为false
+	Archive ArchiveConfig `toml:"archive" comment:"归档配置"`
+}
+
+// ArchiveConfig 表示归档相关的配置项
+// 对应gob.toml中的[build.output.archive]部分
+type ArchiveConfig struct {
+	Enabled         bool              `toml:"enabled" comment:"将输出文件及附加文件打包为归档文件, 打包后删除原始的输出文件"`                         // 默认值为false
+	Format          string            `toml:"format" comment:"归档格式: zip、tar、tar.gz、tgz、gz(仅包含输出文件)"`                     // 默认值为"tar.gz"
+	FormatOverrides map[string]string `toml:"format_overrides" comment:"按目标平台覆盖归档格式, 如 { windows = 'zip' }"`             // 默认值为{ windows = "zip" }
+	Name            string            `toml:"name" comment:"归档文件名(不含扩展名), 支持模板语法, 为空时使用输出文件名(不含.exe)"`                   // 默认值为空
+	WrapInDirectory bool              `toml:"wrap_in_directory" comment:"将所有文件放入与归档文件同名的顶层目录"`                           // 默认值为false
+	Files           []string          `toml:"files" comment:"额外打包的文件、目录或通配符, 如 README.md、LICENSE、completions/*, 保留相对路径"` // 默认值为空
 }
 
 // SourceConfig 表示源码相关的配置项
@@ -48,15 +85,20 @@ type SourceConfig struct {
 // GitConfig 表示Git相关的配置项
 // 对应gob.toml中的[build.git]部分
 type GitConfig struct {
-	Inject  bool   `toml:"inject" comment:"在编译时注入git信息"`                                                                                                                                                  // 默认值为false
-	Ldflags string `toml:"ldflags" comment:"指定包含Git信息的链接器标志, 支持占位符: {{AppName}} (应用名称)、{{GitVersion}} (Git版本)、{{GitCommit}} (提交哈希)、{{GitCommitTime}} (提交时间)、{{BuildTime}} (构建时间)、{{GitTreeState}} (树状态)"` // 默认值为DefaultGitLDFlags
+	Inject    bool     `toml:"inject" comment:"在编译时注入git信息, 无法获取Git元数据时注入 unknown 并给出警告"`                                                                                                                                                                 // 默认值为false
+	Ldflags   string   `toml:"ldflags" comment:"指定包含Git信息的链接器标志, 支持模板语法, 如 {{.Git.Version}}、{{.Git.Commit}}, 兼容占位符: {{AppName}} (应用名称)、{{GitVersion}} (Git版本)、{{GitCommit}} (提交哈希)、{{GitCommitTime}} (提交时间)、{{BuildTime}} (构建时间)、{{GitTreeState}} (树状态)"` // 默认值为DefaultGitLDFlags
+	TagPrefix string   `toml:"tag_prefix" comment:"版本标签的前缀, 用于在同一仓库中区分多个模块的版本, 如 cli/ 匹配 cli/v1.2.3, Git版本中不包含该前缀"`                                                                                                                                       // 默认值为空
+	Snapshot  string   `toml:"snapshot" comment:"当前提交不是版本标签或工作区有未提交的修改时使用的版本号模板, 如 v{{.Git.NextPatch}}-dev.{{.Git.CommitsSinceTag}}+{{.Git.Commit}}, 为空时使用 git describe 的输出"`                                                                             // 默认值为空
+	Sources   []string `toml:"sources" comment:"Git元数据的来源, 按顺序使用第一个可用的来源: env(GOB_GIT_* 环境变量)、git(git命令)、repo(直接读取.git目录, 无需git命令)、file(.gob-version或VERSION文件)"`                                                                                         // 默认值为DefaultGitSources
+	Mode      string   `toml:"mode" comment:"Git信息的注入方式: ldflags(通过链接器标志 -X 注入)、generate(构建前在 package 目录中生成 zz_gob_version.go 常量文件, 构建结束后删除或恢复原文件)"`                                                                                                      // 默认值为DefaultGitMode
+	Package   string   `toml:"package" comment:"generate 模式下生成版本文件的包目录, 为空时使用入口文件所在的目录"`                                                                                                                                                                  // 默认值为空
 }
 
 // CompilerConfig 表示编译器相关的配置项
 // 对应gob.toml中的[build.compiler]部分
 type CompilerConfig struct {
 	EnableCgo bool   `toml:"enable_cgo" comment:"启用CGO"`                     // 默认值为false
-	Ldflags   string `toml:"ldflags" comment:"指定链接器标志"`                      // 默认值为"-s -w"
+	Ldflags   string `toml:"ldflags" comment:"指定链接器标志, 支持模板语法"`              // 默认值为"-s -w"
 	Proxy     string `toml:"proxy" comment:"设置Go代理"`                         // 默认值为"https://goproxy.cn,https://goproxy.io,direct"
 	SkipCheck bool   `toml:"skip_check" comment:"跳过构建前检查"`                   // 默认值为false
 	Timeout   string `toml:"timeout" comment:"构建超时时间(支持单位: ns/us/ms/s/m/h)"` // 默认值为60s
@@ -65,16 +107,53 @@ type CompilerConfig struct {
 // TargetConfig 表示目标平台相关的配置项
 // 对应gob.toml中的[build.target]部分
 type TargetConfig struct {
-	Batch               bool     `toml:"batch" comment:"批量编译模式"`                      // 默认值为false
-	CurrentPlatformOnly bool     `toml:"current_platform_only" comment:"仅编译当前平台"`     // 默认值为false
-	Platforms           []string `toml:"platforms" comment:"支持的目标平台列表，多个平台用逗号分隔"`     // 默认值为["darwin", "linux", "windows"]
-	Architectures       []string `toml:"architectures" comment:"支持的目标架构列表，多个架构用逗号分隔"` // 默认值为["amd64", "arm64"]
+	Batch               bool          `toml:"batch" comment:"批量编译模式"`                                               // 默认值为false
+	CurrentPlatformOnly bool          `toml:"current_platform_only" comment:"仅编译当前平台"`                              // 默认值为false
+	Platforms           []string      `toml:"platforms" comment:"支持的目标平台列表，多个平台用逗号分隔"`                              // 默认值为["darwin", "linux", "windows"]
+	Architectures       []string      `toml:"architectures" comment:"支持的目标架构列表，多个架构用逗号分隔"`                          // 默认值为["amd64", "arm64"]
+	Targets             []string      `toml:"targets" comment:"目标平台模式列表, 支持通配符和排除, 如 linux/*、*/arm64、!windows/386"` // 默认值为空
+	Jobs                int           `toml:"jobs" comment:"批量构建的并发目标数, 0 表示使用CPU核心数"`                              // 默认值为0
+	FailFast            bool          `toml:"fail_fast" comment:"任一目标构建失败时立即取消其余目标"`                                // 默认值为false
+	Matrix              []MatrixEntry `toml:"matrix" comment:"按目标覆盖的构建矩阵, 与平台和架构列表中相同的组合会被覆盖"`                      // 默认值为空
+}
+
+// MatrixEntry 表示构建矩阵中的单个目标
+// 对应gob.toml中的[[build.target.matrix]]部分
+type MatrixEntry struct {
+	GOOS    string            `toml:"goos" comment:"目标平台"`                                      // 必填
+	GOARCH  string            `toml:"goarch" comment:"目标架构"`                                    // 必填
+	Env     map[string]string `toml:"env" comment:"该目标额外的环境变量, 如 CC、CGO_ENABLED、GOARM、GOAMD64"` // 优先级高于[env]
+	Ldflags string            `toml:"ldflags" comment:"追加到全局链接器标志之后的链接器标志, 支持模板语法"`             // 默认值为空
+	Tags    []string          `toml:"tags" comment:"该目标使用的构建标签"`                                // 默认值为空
+	Output  string            `toml:"output" comment:"该目标的输出文件名, 为空时按全局规则生成, 支持模板语法"`           // 默认值为空
 }
 
 // CommandConfig 表示命令相关的配置项
 // 对应gob.toml中的[build.command]部分
 type CommandConfig struct {
-	Build []string `toml:"build" comment:"编译命令模板，支持占位符: {{ldflags}} (链接器标志)、{{output}} (输出路径)、{{if UseVendor}}-mod=vendor{{end}} (条件包含vendor)、{{mainFile}} (入口文件), 多个命令用逗号分隔"` // 默认值为GoBuildCmd.Cmds
+	Build []string `toml:"build" comment:"编译命令模板, 每个元素均按模板语法渲染, 支持占位符: {{ldflags}} (链接器标志)、{{output}} (输出路径)、{{if UseVendor}}-mod=vendor{{end}} (条件包含vendor)、{{mainFile}} (入口文件)、{{tags}} (构建标签), 多个命令用逗号分隔"` // 默认值为GoBuildCmd.Cmds
+}
+
+// ChecksumConfig 表示校验和相关的配置项
+// 对应gob.toml中的[build.checksum]部分
+type ChecksumConfig struct {
+	Enabled   bool   `toml:"enabled" comment:"所有目标构建完成后为构建产物(归档文件或可执行文件)生成校验和文件"` // 默认值为false
+	Algorithm string `toml:"algorithm" comment:"校验算法: sha256、sha512、blake2b"`     // 默认值为"sha256"
+	File      string `toml:"file" comment:"校验和文件名, 位于输出目录下"`                      // 默认值为"checksums.txt"
+}
+
+// SBOMConfig 表示软件物料清单(SBOM)相关的配置项
+// 对应gob.toml中的[build.sbom]部分
+type SBOMConfig struct {
+	Enabled bool     `toml:"enabled" comment:"为每个可执行文件生成SBOM, 模块信息读取自可执行文件中的构建信息"`                      // 默认值为false
+	Formats []string `toml:"formats" comment:"SBOM格式: spdx(.sbom.spdx.json)、cyclonedx(.sbom.cdx.json)"` // 默认值为["spdx"]
+}
+
+// ManifestConfig 表示产物清单相关的配置项
+// 对应gob.toml中的[build.manifest]部分
+type ManifestConfig struct {
+	Enabled bool   `toml:"enabled" comment:"所有目标构建完成后在输出目录下生成JSON格式的产物清单, 描述本次构建生成的所有文件, gob publish 依赖该文件"` // 默认值为false
+	File    string `toml:"file" comment:"产物清单文件名, 位于输出目录下"`                                                  // 默认值为"artifacts.json"
 }
 
 // UIConfig 表示UI相关的配置项
@@ -87,7 +166,7 @@ type UIConfig struct {
 // 对应gob.toml中的[build.pre_build]部分
 type PreBuildConfig struct {
 	Enabled     bool     `toml:"enabled" comment:"是否启用构建前命令"`                                   // 是否启用构建前命令
-	Commands    []string `toml:"commands" comment:"构建前执行的命令列表"`                                 // 构建前执行的命令列表
+	Commands    []string `toml:"commands" comment:"构建前执行的命令列表, 支持模板语法"`                         // 构建前执行的命令列表
 	ExitOnError bool     `toml:"exit_on_error" comment:"命令执行失败时是否退出程序，true=退出，false=继续执行但打印错误"` // 错误处理策略
 }
 
@@ -95,10 +174,203 @@ type PreBuildConfig struct {
 // 对应gob.toml中的[build.post_build]部分
 type PostBuildConfig struct {
 	Enabled     bool     `toml:"enabled" comment:"是否启用构建后命令"`                                   // 是否启用构建后命令
-	Commands    []string `toml:"commands" comment:"构建后执行的命令列表"`                                 // 构建后执行的命令列表
+	Commands    []string `toml:"commands" comment:"构建后执行的命令列表, 支持模板语法"`                         // 构建后执行的命令列表
 	ExitOnError bool     `toml:"exit_on_error" comment:"命令执行失败时是否退出程序，true=退出，false=继续执行但打印错误"` // 错误处理策略
 }
 
+// PackageConfig 表示系统安装包相关的配置项
+// 对应gob.toml中的[package]部分
+type PackageConfig struct {
+	Linux LinuxPackageConfig `toml:"linux" comment:"Linux安装包配置"`
+}
+
+// LinuxPackageConfig 表示Linux安装包相关的配置项
+// 对应gob.toml中的[package.linux]部分
+type LinuxPackageConfig struct {
+	Enabled     bool                       `toml:"enabled" comment:"为linux目标生成安装包"`                                  // 默认值为false
+	Formats     []string                   `toml:"formats" comment:"安装包格式: deb、rpm、apk"`                             // 默认值为["deb", "rpm"]
+	Name        string                     `toml:"name" comment:"包名, 支持模板语法, 为空时使用输出文件名(build.output.name)"`         // 默认值为空
+	Version     string                     `toml:"version" comment:"版本号, 支持模板语法, 为空时从Git版本推导"`                       // 默认值为空
+	Release     string                     `toml:"release" comment:"打包修订号, 同一版本重新打包时递增"`                             // 默认值为"1"
+	Maintainer  string                     `toml:"maintainer" comment:"维护者, 如 'Name <mail@example.com>'"`            // 默认值为空
+	Vendor      string                     `toml:"vendor" comment:"供应商"`                                             // 默认值为空
+	Homepage    string                     `toml:"homepage" comment:"项目主页"`                                          // 默认值为空
+	License     string                     `toml:"license" comment:"许可证, 如 MIT"`                                     // 默认值为空
+	Description string                     `toml:"description" comment:"包描述, 第一行作为摘要"`                               // 默认值为空
+	Section     string                     `toml:"section" comment:"deb软件包分类"`                                       // 默认值为"utils"
+	Priority    string                     `toml:"priority" comment:"deb软件包优先级"`                                     // 默认值为"optional"
+	BinDir      string                     `toml:"bin_dir" comment:"可执行文件的安装目录"`                                     // 默认值为"/usr/bin"
+	Depends     []string                   `toml:"depends" comment:"依赖的软件包, 如 'git' 或 'libc6 >= 2.17'"`              // 默认值为空
+	Recommends  []string                   `toml:"recommends" comment:"推荐安装的软件包"`                                    // 默认值为空
+	Conflicts   []string                   `toml:"conflicts" comment:"冲突的软件包"`                                       // 默认值为空
+	Provides    []string                   `toml:"provides" comment:"提供的虚拟包"`                                        // 默认值为空
+	Replaces    []string                   `toml:"replaces" comment:"替代的软件包"`                                        // 默认值为空
+	Overrides   map[string]PackageOverride `toml:"overrides" comment:"按安装包格式覆盖包关系, 如 [package.linux.overrides.rpm]"` // 默认值为空
+	Files       []PackageFile              `toml:"files" comment:"附加文件的安装路径映射"`                                      // 默认值为空
+	Scripts     PackageScripts             `toml:"scripts" comment:"安装和卸载脚本"`
+}
+
+// PackageOverride 表示按安装包格式覆盖的包关系, 设置的字段替换[package.linux]中的同名字段
+type PackageOverride struct {
+	Depends    []string `toml:"depends" comment:"依赖的软件包"`      // 默认值为空
+	Recommends []string `toml:"recommends" comment:"推荐安装的软件包"` // 默认值为空
+	Conflicts  []string `toml:"conflicts" comment:"冲突的软件包"`    // 默认值为空
+	Provides   []string `toml:"provides" comment:"提供的虚拟包"`     // 默认值为空
+	Replaces   []string `toml:"replaces" comment:"替代的软件包"`     // 默认值为空
+}
+
+// PackageFile 表示安装包中的一组文件映射
+// 对应gob.toml中的[[package.linux.files]]部分
+type PackageFile struct {
+	Src    string `toml:"src" comment:"源文件、目录或通配符"`                      // 默认值为空
+	Dst    string `toml:"dst" comment:"安装路径, 以/结尾或匹配多个文件时作为目录"`          // 默认值为空
+	Mode   string `toml:"mode" comment:"八进制文件权限, 如 '0644', 为空时使用源文件的权限"` // 默认值为空
+	Config bool   `toml:"config" comment:"标记为配置文件, 升级或卸载时保留用户的修改"`       // 默认值为false
+}
+
+// PackageScripts 表示安装包的维护脚本, 均为脚本文件路径
+// 对应gob.toml中的[package.linux.scripts]部分
+type PackageScripts struct {
+	PreInstall  string `toml:"pre_install" comment:"安装前执行的脚本文件"`  // 默认值为空
+	PostInstall string `toml:"post_install" comment:"安装后执行的脚本文件"` // 默认值为空
+	PreRemove   string `toml:"pre_remove" comment:"卸载前执行的脚本文件"`   // 默认值为空
+	PostRemove  string `toml:"post_remove" comment:"卸载后执行的脚本文件"`  // 默认值为空
+}
+
+// ContainerConfig 表示容器镜像相关的配置项
+// 对应gob.toml中的[container]部分
+type ContainerConfig struct {
+	Enabled    bool              `toml:"enabled" comment:"为linux目标构建多架构OCI容器镜像, 无需Docker守护进程"`                       // 默认值为false
+	Format     string            `toml:"format" comment:"输出格式: oci(OCI镜像布局目录)、docker(每个架构一个可通过 docker load 导入的tar)"` // 默认值为"oci"
+	Dir        string            `toml:"dir" comment:"镜像输出目录, 为空时使用输出目录下的image目录"`                                   // 默认值为空
+	Name       string            `toml:"name" comment:"镜像名称, 支持模板语法, 如 registry.example.com/team/app, 为空时使用输出文件名"`   // 默认值为空
+	Tags       []string          `toml:"tags" comment:"镜像标签, 支持模板语法"`                                                // 默认值为["{{semver .Git.Version}}", "latest"]
+	Base       string            `toml:"base" comment:"基础镜像tar文件(docker save 或 OCI归档), 为空时基于空镜像(scratch)"`           // 默认值为空
+	BinDir     string            `toml:"bin_dir" comment:"可执行文件在镜像中的目录"`                                             // 默认值为"/usr/local/bin"
+	Entrypoint []string          `toml:"entrypoint" comment:"入口命令, 支持模板语法, 为空时使用镜像中的可执行文件"`                          // 默认值为空
+	Cmd        []string          `toml:"cmd" comment:"默认参数, 支持模板语法"`                                                 // 默认值为空
+	Workdir    string            `toml:"workdir" comment:"工作目录, 为空时沿用基础镜像"`                                          // 默认值为空
+	User       string            `toml:"user" comment:"运行用户, 如 65532:65532, 为空时沿用基础镜像"`                              // 默认值为空
+	Env        map[string]string `toml:"env" comment:"镜像环境变量, 覆盖基础镜像中的同名变量"`                                         // 默认值为空映射
+	Labels     map[string]string `toml:"labels" comment:"镜像标签(label), 值支持模板语法, 自动写入Git版本和提交哈希"`                      // 默认值为空映射
+}
+
+// SignConfig 表示签名相关的配置项
+// 对应gob.toml中的[sign]部分
+type SignConfig struct {
+	Enabled       bool   `toml:"enabled" comment:"批量构建成功后为校验和文件或构建产物生成分离签名, 签名文件位于被签名文件旁"`                                 // 默认值为false
+	Method        string `toml:"method" comment:"签名方式: minisign(ed25519, 生成.sig文件, 兼容minisign和signify)、openpgp(生成.asc文件)"` // 默认值为"minisign"
+	Checksum      bool   `toml:"checksum" comment:"为校验和文件签名, 需要启用 build.checksum"`                                         // 默认值为true
+	Artifacts     bool   `toml:"artifacts" comment:"为每个构建产物和安装包签名"`                                                        // 默认值为false
+	KeyFile       string `toml:"key_file" comment:"私钥文件路径: minisign私钥或ASCII armor格式的OpenPGP私钥"`                            // 默认值为空
+	KeyEnv        string `toml:"key_env" comment:"存放私钥内容的环境变量名, 设置后忽略 key_file"`                                           // 默认值为空
+	PassphraseEnv string `toml:"passphrase_env" comment:"存放私钥口令的环境变量名, 私钥未加密时可不设置该变量"`                                     // 默认值为"GOB_SIGN_PASSPHRASE"
+	Prehash       bool   `toml:"prehash" comment:"minisign: 对文件的BLAKE2b-512哈希签名(minisign的默认格式), 关闭时生成与signify兼容的签名"`       // 默认值为true
+}
+
+// BumpConfig 表示递增版本号并创建标签的配置项
+// 对应gob.toml中的[bump]部分
+type BumpConfig struct {
+	VersionFile string `toml:"version_file" comment:"创建标签前写入新版本号的文件: .go文件替换 version_var 的值, 其他文件整体替换为版本号, 为空时不写入"` // 默认值为空
+	VersionVar  string `toml:"version_var" comment:"version_file 为.go文件时要替换的常量或变量名"`                                // 默认值为"Version"
+}
+
+// ChangelogConfig 表示根据提交历史生成变更日志的配置项
+// 对应gob.toml中的[changelog]部分
+type ChangelogConfig struct {
+	Enabled  bool               `toml:"enabled" comment:"构建时在输出目录下生成变更日志, 并放入归档文件"`                                                                   // 默认值为false
+	File     string             `toml:"file" comment:"变更日志文件名, 位于输出目录下"`                                                                              // 默认值为"CHANGELOG.md"
+	Sections []ChangelogSection `toml:"sections" comment:"按约定式提交类型分组的部分, 按配置顺序输出, 没有提交的部分省略"`                                                         // 默认值为DefaultChangelogSections
+	Exclude  []string           `toml:"exclude" comment:"排除提交标题匹配任一正则表达式的提交, 如 '^chore\\(release\\)'"`                                                // 默认值为空
+	Template string             `toml:"template" comment:"变更日志模板, 支持模板语法, 通过 {{.Changelog.Tag}}、{{.Changelog.Sections}} 等引用变更日志, 为空时使用内置的Markdown模板"` // 默认值为空
+}
+
+// ChangelogSection 表示变更日志中的一个部分
+type ChangelogSection struct {
+	Title string   `toml:"title" comment:"部分标题"`                                                     // 必填
+	Types []string `toml:"types" comment:"归入该部分的提交类型, 如 feat、fix; breaking 表示不兼容变更, * 表示未归入其他部分的提交"` // 必填
+}
+
+// PublishConfig 表示发布相关的配置项
+// 对应gob.toml中的[publish]部分
+type PublishConfig struct {
+	Manifests PublishManifestsConfig `toml:"manifests" comment:"包管理器清单配置"`
+	Release   ReleaseConfig          `toml:"release" comment:"代码托管平台发布配置, 由 gob publish 使用"`
+	S3        S3UploadConfig         `toml:"s3" comment:"S3兼容对象存储上传配置"`
+	HTTP      HTTPUploadConfig       `toml:"http" comment:"HTTP PUT上传配置"`
+}
+
+// PublishManifestsConfig 表示包管理器清单的配置项
+// 对应gob.toml中的[publish.manifests]部分
+type PublishManifestsConfig struct {
+	Enabled     bool         `toml:"enabled" comment:"批量构建成功后根据产物生成包管理器清单"`                      // 默认值为false
+	Formats     []string     `toml:"formats" comment:"清单格式: homebrew、scoop、winget"`              // 默认值为["homebrew", "scoop", "winget"]
+	Dir         string       `toml:"dir" comment:"清单输出目录, 为空时使用输出目录下的manifests目录"`               // 默认值为空
+	URL         string       `toml:"url" comment:"产物下载地址模板, 可通过 {{.Artifact}} 引用产物文件名"`          // 默认值为空
+	Name        string       `toml:"name" comment:"软件名称, 支持模板语法, 为空时使用输出文件名(build.output.name)"` // 默认值为空
+	Version     string       `toml:"version" comment:"版本号, 支持模板语法, 为空时从Git版本推导"`                 // 默认值为空
+	Description string       `toml:"description" comment:"软件描述"`                                 // 默认值为空
+	Homepage    string       `toml:"homepage" comment:"项目主页"`                                    // 默认值为空
+	License     string       `toml:"license" comment:"许可证, 如 MIT"`                               // 默认值为空
+	Winget      WingetConfig `toml:"winget" comment:"winget清单配置"`
+}
+
+// WingetConfig 表示winget清单的配置项
+// 对应gob.toml中的[publish.manifests.winget]部分
+type WingetConfig struct {
+	Publisher         string `toml:"publisher" comment:"发布者名称, 生成winget清单时必填"`          // 默认值为空
+	PackageIdentifier string `toml:"package_identifier" comment:"包标识符, 为空时使用 发布者.软件名称"` // 默认值为空
+	Locale            string `toml:"locale" comment:"默认语言区域, 如 en-US、zh-CN"`            // 默认值为"en-US"
+}
+
+// ReleaseConfig 表示代码托管平台发布的配置项
+// 对应gob.toml中的[publish.release]部分
+type ReleaseConfig struct {
+	Provider   string `toml:"provider" comment:"托管平台: github、gitea、gitee"`                                                               // 默认值为"github"
+	URL        string `toml:"url" comment:"API地址, 为空时使用平台默认值(github: https://api.github.com, gitee: https://gitee.com/api/v5), gitea必填"` // 默认值为空
+	Owner      string `toml:"owner" comment:"仓库所有者(用户或组织)"`                                                                              // 默认值为空
+	Repo       string `toml:"repo" comment:"仓库名称"`                                                                                       // 默认值为空
+	TokenEnv   string `toml:"token_env" comment:"存放访问令牌的环境变量名, 为空时使用 GITHUB_TOKEN、GITEA_TOKEN 或 GITEE_TOKEN"`                            // 默认值为空
+	Tag        string `toml:"tag" comment:"发布对应的标签, 支持模板语法, 为空时使用当前提交上的标签"`                                                              // 默认值为空
+	Name       string `toml:"name" comment:"发布标题, 支持模板语法, 为空时使用标签"`                                                                      // 默认值为空
+	Notes      string `toml:"notes" comment:"发布说明, 支持模板语法"`                                                                              // 默认值为空
+	NotesFile  string `toml:"notes_file" comment:"发布说明文件, 与notes不能同时设置"`                                                                 // 默认值为空
+	Changelog  bool   `toml:"changelog" comment:"使用根据提交历史生成的变更日志(见[changelog])作为发布说明, 与notes和notes_file不能同时设置"`                          // 默认值为false
+	Draft      bool   `toml:"draft" comment:"创建为草稿, gitee不支持"`                                                                           // 默认值为false
+	Prerelease bool   `toml:"prerelease" comment:"标记为预发布版本"`                                                                             // 默认值为false
+	Retries    int    `toml:"retries" comment:"请求失败(网络错误、429或5xx)时的重试次数"`                                                                // 默认值为3
+}
+
+// S3UploadConfig 表示上传到S3兼容对象存储的配置项
+// 对应gob.toml中的[publish.s3]部分
+type S3UploadConfig struct {
+	Enabled         bool   `toml:"enabled" comment:"批量构建成功后将产物清单中的文件上传到S3兼容的对象存储"`                                          // 默认值为false
+	DryRun          bool   `toml:"dry_run" comment:"只显示将要上传的对象, 不访问对象存储"`                                                   // 默认值为false
+	Endpoint        string `toml:"endpoint" comment:"服务地址, 如 https://s3.us-east-1.amazonaws.com、https://minio.example.com"` // 默认值为空
+	Region          string `toml:"region" comment:"区域, 用于请求签名"`                                                             // 默认值为"us-east-1"
+	Bucket          string `toml:"bucket" comment:"存储桶名称"`                                                                  // 默认值为空
+	Path            string `toml:"path" comment:"对象路径模板, 可通过 {{.Artifact}} 引用产物文件名, {{.Target.OS}}/{{.Target.Arch}} 引用目标"`  // 默认值为"{{.Git.Version}}/{{.Artifact}}"
+	PathStyle       bool   `toml:"path_style" comment:"使用路径形式的地址(endpoint/bucket/key), 为false时使用 bucket.endpoint/key"`      // 默认值为true
+	PartSize        int    `toml:"part_size" comment:"分片大小(MiB), 大于该大小的文件使用分片上传, 最小为5"`                                     // 默认值为64
+	AccessKeyEnv    string `toml:"access_key_env" comment:"存放访问密钥ID的环境变量名"`                                                 // 默认值为"AWS_ACCESS_KEY_ID"
+	SecretKeyEnv    string `toml:"secret_key_env" comment:"存放访问密钥的环境变量名"`                                                   // 默认值为"AWS_SECRET_ACCESS_KEY"
+	SessionTokenEnv string `toml:"session_token_env" comment:"存放临时会话令牌的环境变量名, 环境变量未设置时忽略"`                                  // 默认值为"AWS_SESSION_TOKEN"
+	Retries         int    `toml:"retries" comment:"请求失败(网络错误、429或5xx)时的重试次数"`                                              // 默认值为3
+}
+
+// HTTPUploadConfig 表示通过HTTP PUT上传的配置项
+// 对应gob.toml中的[publish.http]部分
+type HTTPUploadConfig struct {
+	Enabled     bool              `toml:"enabled" comment:"批量构建成功后将产物清单中的文件通过HTTP PUT上传"`                                        // 默认值为false
+	DryRun      bool              `toml:"dry_run" comment:"只显示将要上传的地址, 不发送请求"`                                                   // 默认值为false
+	URL         string            `toml:"url" comment:"上传地址模板, 可通过 {{.Artifact}} 引用产物文件名, {{.Target.OS}}/{{.Target.Arch}} 引用目标"` // 默认值为空
+	UsernameEnv string            `toml:"username_env" comment:"存放基本认证用户名的环境变量名, 为空时不使用基本认证"`                                    // 默认值为空
+	PasswordEnv string            `toml:"password_env" comment:"存放基本认证密码的环境变量名"`                                                 // 默认值为空
+	TokenEnv    string            `toml:"token_env" comment:"存放Bearer令牌的环境变量名, 设置后不使用基本认证"`                                      // 默认值为空
+	Headers     map[string]string `toml:"headers" comment:"附加的请求头"`                                                              // 默认值为空映射
+	Retries     int               `toml:"retries" comment:"请求失败(网络错误、429或5xx)时的重试次数"`                                            // 默认值为3
+}
+
 // InstallConfig 表示安装相关的配置项
 // 对应gob.toml中的[install]部分
 type InstallConfig struct {
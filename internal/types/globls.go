@@ -21,6 +21,34 @@ var DefaultPlatforms = []string{"darwin", "linux", "windows"}
 // DefaultArchs 默认支持的架构
 var DefaultArchs = []string{"amd64", "arm64"}
 
+// DefaultGitSources Git元数据的默认来源及回退顺序
+var DefaultGitSources = []string{"env", "git", "repo", "file"}
+
+// GitVersionFiles 没有Git仓库时读取版本信息的文件, 按顺序使用第一个存在的文件
+var GitVersionFiles = []string{".gob-version", "VERSION"}
+
+// DefaultChangelogSections 变更日志默认包含的部分
+var DefaultChangelogSections = []ChangelogSection{
+	{Title: "Breaking Changes", Types: []string{"breaking"}},
+	{Title: "Features", Types: []string{"feat"}},
+	{Title: "Bug Fixes", Types: []string{"fix"}},
+	{Title: "Performance Improvements", Types: []string{"perf"}},
+}
+
+// KnownPlatforms go tool dist list 中的所有目标平台, 用于JSON Schema的枚举
+var KnownPlatforms = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+
+// KnownArchs go tool dist list 中的所有目标架构, 用于JSON Schema的枚举
+var KnownArchs = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+
+// DistTarget 表示 go tool dist list -json 输出的单个目标平台
+type DistTarget struct {
+	GOOS         string `json:"GOOS"`         // 目标平台
+	GOARCH       string `json:"GOARCH"`       // 目标架构
+	CgoSupported bool   `json:"CgoSupported"` // 是否支持CGO
+	FirstClass   bool   `json:"FirstClass"`   // 是否为一级支持的平台
+}
+
 // 默认配置
 const (
 	// DefaultGoProxy 默认的Go代理
@@ -41,11 +69,106 @@ const (
 	// DefaultGitLDFlags 默认启用的Git元数据链接器标志
 	DefaultGitLDFlags = "-X 'gitee.com/MM-Q/verman.appName={{AppName}}' -X 'gitee.com/MM-Q/verman.gitVersion={{GitVersion}}' -X 'gitee.com/MM-Q/verman.gitCommit={{GitCommit}}' -X 'gitee.com/MM-Q/verman.gitCommitTime={{GitCommitTime}}' -X 'gitee.com/MM-Q/verman.buildTime={{BuildTime}}' -X 'gitee.com/MM-Q/verman.gitTreeState={{GitTreeState}}' -s -w"
 
+	// CurrentConfigVersion 当前的配置文件格式版本, 未声明 version 的配置文件视为版本1
+	CurrentConfigVersion = 2
+
+	// DefaultArchiveFormat 默认的归档格式
+	DefaultArchiveFormat = "tar.gz"
+
+	// DefaultChecksumAlgorithm 默认的校验算法
+	DefaultChecksumAlgorithm = "sha256"
+
+	// DefaultChecksumFile 默认的校验和文件名
+	DefaultChecksumFile = "checksums.txt"
+
+	// DefaultManifestFile 默认的产物清单文件名
+	DefaultManifestFile = "artifacts.json"
+
+	// DefaultPackageRelease 默认的安装包修订号
+	DefaultPackageRelease = "1"
+
+	// DefaultPackageBinDir 安装包中可执行文件的默认安装目录
+	DefaultPackageBinDir = "/usr/bin"
+
+	// DefaultContainerFormat 默认的容器镜像输出格式
+	DefaultContainerFormat = "oci"
+
+	// DefaultContainerDir 容器镜像在输出目录下的默认子目录
+	DefaultContainerDir = "image"
+
+	// DefaultContainerBinDir 可执行文件在容器镜像中的默认目录
+	DefaultContainerBinDir = "/usr/local/bin"
+
+	// DefaultPublishManifestsDir 包管理器清单在输出目录下的默认子目录
+	DefaultPublishManifestsDir = "manifests"
+
+	// DefaultWingetLocale winget清单的默认语言区域
+	DefaultWingetLocale = "en-US"
+
+	// DefaultReleaseProvider 默认的发布托管平台
+	DefaultReleaseProvider = "github"
+
+	// DefaultReleaseRetries 发布请求失败时的默认重试次数
+	DefaultReleaseRetries = 3
+
+	// DefaultS3Region 对象存储的默认区域
+	DefaultS3Region = "us-east-1"
+
+	// DefaultS3Path 对象路径的默认模板
+	DefaultS3Path = "{{.Git.Version}}/{{.Artifact}}"
+
+	// DefaultS3PartSize 分片上传的默认分片大小(MiB)
+	DefaultS3PartSize = 64
+
+	// MinS3PartSize S3要求的最小分片大小(MiB), 最后一个分片除外
+	MinS3PartSize = 5
+
+	// DefaultSignMethod 默认的签名方式
+	DefaultSignMethod = "minisign"
+
+	// DefaultSignPassphraseEnv 存放私钥口令的默认环境变量
+	DefaultSignPassphraseEnv = "GOB_SIGN_PASSPHRASE"
+
+	// GitEnvPrefix 覆盖Git元数据的环境变量前缀, 如 GOB_GIT_VERSION、GOB_GIT_COMMIT
+	GitEnvPrefix = "GOB_GIT_"
+
+	// UnknownGitValue 无法获取Git元数据时使用的值
+	UnknownGitValue = "unknown"
+
+	// DefaultGitMode 默认的Git信息注入方式
+	DefaultGitMode = "ldflags"
+
+	// GitModeGenerate 生成Go源文件的Git信息注入方式
+	GitModeGenerate = "generate"
+
+	// GitVersionSourceFile generate 模式下生成的Go源文件名
+	GitVersionSourceFile = "zz_gob_version.go"
+
+	// DefaultChangelogFile 默认的变更日志文件名
+	DefaultChangelogFile = "CHANGELOG.md"
+
+	// DefaultChangelogTemplate 内置的Markdown变更日志模板
+	DefaultChangelogTemplate = `## {{.Changelog.Tag}} ({{.Changelog.Date}})
+{{range .Changelog.Sections}}
+### {{.Title}}
+
+{{range .Commits}}- {{if .Scope}}**{{.Scope}}:** {{end}}{{.Subject}} ({{.ShortHash}})
+{{end}}{{end}}`
+
+	// SchemaFile 生成的JSON Schema文件名, 与配置文件位于同一目录
+	SchemaFile = "gob.schema.json"
+
+	// SchemaDirective 配置文件首行的Schema指令, 供支持Schema的TOML编辑器插件使用
+	SchemaDirective = "#:schema ./" + SchemaFile + "\n"
+
 	// ConfigFileHeaderComment 配置文件头注释
 	ConfigFileHeaderComment = "# gob 构建工具配置文件 \n# 项目地址: https://gitee.com/MM-Q/gob.git\n\n"
 
 	// EnvExample 环境变量示例
 	EnvExample = "# 示例:\n# GOOS = \"linux\"\n# GOARCH = \"amd64\"\n# CGO_ENABLED = \"1\"\n"
+
+	// VarsExample 用户自定义变量示例
+	VarsExample = "\n# 用户自定义变量, 可在模板中通过 {{.Vars.NAME}} 引用\n[vars]\n# 示例:\n# channel = \"stable\"\n# build = { env = \"BUILD_NUMBER\", default = \"0\" }\n# date = { cmd = \"date -u +%Y%m%d\" }\n"
 )
 
 // 定义命令结构体类型
@@ -66,12 +189,79 @@ var GitVersionCmd = CommandGroup{
 	[]string{"git", "describe", "--tags", "--always", "--dirty"},
 }
 
+// 获取当前提交可达的所有标签的命令
+var GitMergedTagsCmd = CommandGroup{
+	"获取git标签",
+	[]string{"git", "tag", "--merged", "HEAD"},
+}
+
+// 统计提交数的命令, 执行时追加版本范围参数
+var GitCommitCountCmd = CommandGroup{
+	"统计git提交数",
+	[]string{"git", "rev-list", "--count"},
+}
+
+// 获取提交标题的命令, 执行时追加版本范围参数
+var GitLogSubjectsCmd = CommandGroup{
+	"获取git提交标题",
+	[]string{"git", "log", "--no-merges", "--format=%s"},
+}
+
+// 获取提交详情的命令, 执行时追加版本范围参数
+// 每个提交依次输出完整哈希、作者、标题和正文, 字段以\x1f分隔, 提交以\x1e结尾
+var GitLogCommitsCmd = CommandGroup{
+	"获取git提交记录",
+	[]string{"git", "log", "--no-merges", "--format=%H%x1f%an%x1f%s%x1f%b%x1e"},
+}
+
+// 获取指定提交可达的所有标签的命令, 执行时追加提交或标签名
+var GitTagsMergedCmd = CommandGroup{
+	"获取git标签",
+	[]string{"git", "tag", "--merged"},
+}
+
+// 获取提交日期的命令, 执行时追加提交或标签名
+var GitCommitDateCmd = CommandGroup{
+	"获取git提交日期",
+	[]string{"git", "log", "-1", "--format=%cs"},
+}
+
+// 查询标签是否存在的命令, 执行时追加标签名
+var GitListTagCmd = CommandGroup{
+	"查询git标签",
+	[]string{"git", "tag", "--list"},
+}
+
+// 暂存文件的命令, 执行时追加文件路径
+var GitAddCmd = CommandGroup{
+	"暂存文件",
+	[]string{"git", "add", "--"},
+}
+
+// 提交暂存区的命令, 执行时追加提交信息
+var GitCommitCmd = CommandGroup{
+	"提交修改",
+	[]string{"git", "commit", "-m"},
+}
+
+// 创建附注标签的命令, 执行时追加标签名和 -m 标签信息
+var GitTagAnnotatedCmd = CommandGroup{
+	"创建git标签",
+	[]string{"git", "tag", "-a"},
+}
+
 // 获取git提交哈希值的命令
 var GitCommitHashCmd = CommandGroup{
 	"获取git提交哈希值",
 	[]string{"git", "rev-parse", "--short", "HEAD"},
 }
 
+// 获取完整git提交哈希值的命令
+var GitFullCommitHashCmd = CommandGroup{
+	"获取完整git提交哈希值",
+	[]string{"git", "rev-parse", "HEAD"},
+}
+
 // 获取git提交时间的命令
 var GitCommitTimeCmd = CommandGroup{
 	"获取git提交时间",
@@ -96,6 +286,12 @@ var GitIsInsideWorkTreeCmd = CommandGroup{
 	[]string{"git", "rev-parse", "--is-inside-work-tree"},
 }
 
+// 获取当前Go工具链支持的目标平台列表的命令
+var GoDistListCmd = CommandGroup{
+	"获取Go工具链支持的目标平台",
+	[]string{"go", "tool", "dist", "list", "-json"},
+}
+
 // 执行清理 go 测试缓存的命令
 var GoCleanTestCacheCmd = CommandGroup{
 	"清理 go 测试缓存",
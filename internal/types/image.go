@@ -0,0 +1,41 @@
+package types
+
+import "time"
+
+// OCI镜像规范中使用的媒体类型
+const (
+	MediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"     // 镜像索引
+	MediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"  // 镜像清单
+	MediaTypeOCIConfig   = "application/vnd.oci.image.config.v1+json"    // 镜像配置
+	MediaTypeOCILayer    = "application/vnd.oci.image.layer.v1.tar"      // 未压缩的层
+	MediaTypeOCILayerGz  = "application/vnd.oci.image.layer.v1.tar+gzip" // gzip压缩的层
+)
+
+// OCIDescriptor 表示OCI规范中指向一个内容的描述符
+type OCIDescriptor struct {
+	MediaType   string            `json:"mediaType"`             // 媒体类型
+	Digest      string            `json:"digest"`                // 内容摘要, 如 sha256:...
+	Size        int64             `json:"size"`                  // 内容大小(字节)
+	Platform    *OCIPlatform      `json:"platform,omitempty"`    // 镜像清单适用的平台
+	Annotations map[string]string `json:"annotations,omitempty"` // 注解
+}
+
+// OCIPlatform 表示镜像适用的平台
+type OCIPlatform struct {
+	OS           string `json:"os"`                // 操作系统
+	Architecture string `json:"architecture"`      // 架构
+	Variant      string `json:"variant,omitempty"` // 架构变体, 如 arm 的 v7
+}
+
+// ImageSpec 表示为单个目标构建容器镜像所需的信息
+type ImageSpec struct {
+	Arch       string            // 目标架构(GOARCH)
+	Variant    string            // 架构变体, 如 v7
+	Binary     string            // 可执行文件路径
+	BinName    string            // 可执行文件在镜像中的文件名
+	LayoutDir  string            // 写入镜像内容的OCI布局目录
+	Labels     map[string]string // 渲染后的镜像标签
+	Created    time.Time         // 镜像创建时间, 同时作为层中文件的修改时间
+	Entrypoint []string          // 渲染后的入口命令, 为空时使用镜像中的可执行文件
+	Cmd        []string          // 渲染后的默认参数
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BuildStatus 表示单个目标的构建状态
+type BuildStatus string
+
+const (
+	BuildStatusSuccess  BuildStatus = "成功" // 构建成功
+	BuildStatusFailed   BuildStatus = "失败" // 构建失败
+	BuildStatusCanceled BuildStatus = "取消" // 构建被取消(fail_fast 或中断)
+)
+
+// BuildOutput 表示单个目标生成的文件
+type BuildOutput struct {
+	Artifact string         // 构建产物路径(归档文件或可执行文件), 未生成时为空
+	Binary   string         // 可执行文件在产物中的相对路径, 产物为可执行文件时为其文件名
+	Packages []string       // 生成的安装包路径
+	SBOMs    []string       // 生成的SBOM文件路径
+	Image    *OCIDescriptor // 容器镜像清单的描述符, 未构建镜像时为nil
+}
+
+// BuildResult 表示单个目标平台和架构的构建结果
+type BuildResult struct {
+	BuildOutput
+
+	Name     string        // 目标名称, 为空时使用 "平台/架构"
+	Platform string        // 目标平台
+	Arch     string        // 目标架构
+	Status   BuildStatus   // 构建状态
+	Duration time.Duration // 构建耗时
+	Err      error         // 构建错误, 成功时为nil
+}
+
+// Target 返回目标名称, 未设置名称时返回 "平台/架构"
+func (r BuildResult) Target() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s/%s", r.Platform, r.Arch)
+}
+
+// BuildError 批量构建的聚合错误, 包含所有目标的构建结果
+type BuildError struct {
+	Results []BuildResult // 所有目标的构建结果
+}
+
+// Failed 返回构建失败或被取消的目标结果
+func (e *BuildError) Failed() []BuildResult {
+	var failed []BuildResult
+	for _, r := range e.Results {
+		if r.Status != BuildStatusSuccess {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Error 实现error接口, 汇总失败和被取消的目标
+func (e *BuildError) Error() string {
+	var failed []string
+	canceled := 0
+	for _, r := range e.Failed() {
+		if r.Status == BuildStatusCanceled {
+			canceled++
+			continue
+		}
+		failed = append(failed, r.Target())
+	}
+
+	msg := fmt.Sprintf("%d/%d 个目标构建失败", len(failed), len(e.Results))
+	if len(failed) > 0 {
+		msg += ": " + strings.Join(failed, ", ")
+	}
+	if canceled > 0 {
+		msg += fmt.Sprintf(" (%d 个目标已取消)", canceled)
+	}
+	return msg
+}
+
+// Unwrap 返回所有失败目标的原始错误, 支持 errors.Is/errors.As
+func (e *BuildError) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
+
+// NewBuildError 根据构建结果创建聚合错误
+//
+// 参数:
+//   - results: 所有目标的构建结果
+//
+// 返回值:
+//   - error: 存在未成功的目标时返回*BuildError, 否则返回nil
+func NewBuildError(results []BuildResult) error {
+	e := &BuildError{Results: results}
+	if len(e.Failed()) == 0 {
+		return nil
+	}
+	return e
+}
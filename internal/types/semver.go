@@ -0,0 +1,33 @@
+package types
+
+import "fmt"
+
+// Semver 表示语义化版本号, 不包含 v 前缀
+type Semver struct {
+	Major      int    // 主版本号
+	Minor      int    // 次版本号
+	Patch      int    // 修订号
+	Prerelease string // 预发布标识, 如 rc.1
+	Build      string // 构建元数据, 不参与版本比较
+}
+
+// String 返回 1.2.3-rc.1+build 格式的版本号
+func (v Semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// GitSemver 表示从Git标签解析出的版本信息
+type GitSemver struct {
+	Tag             string // 当前提交可达的最高语义化版本标签(含前缀), 没有标签时为空
+	Version         Semver // 标签对应的版本号, 没有标签时为 0.0.0
+	CommitsSinceTag int    // 标签之后的提交数, 没有标签时为全部提交数
+	IsSnapshot      bool   // 当前提交不是版本标签或工作区有未提交的修改
+	Describe        string // git describe 的输出(已去除标签前缀)
+}
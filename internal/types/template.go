@@ -0,0 +1,45 @@
+package types
+
+// TemplateData 模板渲染的数据模型
+// 编译命令、链接器标志、输出文件名和构建前后命令均使用该模型渲染
+type TemplateData struct {
+	Target    TemplateTarget    // 当前构建目标
+	Git       TemplateGit       // Git元数据, 未启用Git信息注入时为空
+	Env       map[string]string // 构建使用的环境变量(系统环境变量、[env]及目标专属环境变量合并后的结果)
+	Vars      map[string]string // 用户自定义变量
+	Config    *GobConfig        // 完整配置
+	MainFile  string            // 入口文件
+	Ldflags   string            // 渲染后的链接器标志, 渲染链接器标志本身时为空
+	Output    string            // 输出文件路径, 渲染输出文件名本身时为空
+	Artifact  string            // 产物文件名, 仅在渲染包管理器清单的下载地址和上传路径时可用
+	Changelog *Changelog        // 变更日志, 仅在渲染变更日志模板时可用
+}
+
+// TemplateTarget 模板中的构建目标信息, 对应 {{.Target.*}}
+type TemplateTarget struct {
+	OS   string   // 目标平台, 如 linux
+	Arch string   // 目标架构, 如 amd64
+	Tags []string // 目标专属的构建标签
+}
+
+// TemplateGit 模板中的Git元数据, 对应 {{.Git.*}}
+type TemplateGit struct {
+	AppName    string // 应用程序名称
+	Version    string // git版本号, 即 git describe 的输出
+	Commit     string // git提交哈希值
+	CommitTime string // git提交时间
+	BuildTime  string // 构建时间
+	TreeState  string // git树状态(clean/dirty)
+
+	Tag             string // 最高的语义化版本标签(含前缀), 没有标签时为空
+	Semver          string // 标签对应的版本号, 不含前缀和v, 如 1.2.3-rc.1; 没有标签时为 0.0.0
+	Major           int    // 主版本号
+	Minor           int    // 次版本号
+	Patch           int    // 修订号
+	Prerelease      string // 预发布标识, 如 rc.1
+	CommitsSinceTag int    // 标签之后的提交数, 没有标签时为全部提交数
+	IsSnapshot      bool   // 当前提交不是版本标签或工作区有未提交的修改
+	NextMajor       string // 下一个主版本号, 如 2.0.0
+	NextMinor       string // 下一个次版本号, 如 1.3.0
+	NextPatch       string // 下一个修订号, 如 1.2.4; 预发布版本的下一个修订号为对应的正式版本
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"gitee.com/MM-Q/verman"
 )
 
@@ -11,4 +13,94 @@ type BuildContext struct {
 	SysPlatform string       // 系统平台
 	SysArch     string       // 系统架构
 	Config      *GobConfig   // 配置对象
+	TargetEnv   []string     // 目标专属的环境变量, 优先级最高
+	Ldflags     string       // 目标专属的附加链接器标志
+	Tags        []string     // 目标专属的构建标签
+	OutputName  string       // 目标专属的输出文件名, 为空时按全局规则生成
+}
+
+// ArchiveEntry 表示归档文件中的一个条目
+type ArchiveEntry struct {
+	Src  string // 源文件或目录路径
+	Name string // 在归档文件中的路径, 使用/分隔
+}
+
+// ChecksumEntry 表示校验和文件中的一行
+type ChecksumEntry struct {
+	Sum  string // 十六进制校验和
+	Name string // 相对于校验和文件所在目录的文件路径, 使用/分隔
+}
+
+// PackageSpec 表示为单个目标生成安装包所需的信息
+type PackageSpec struct {
+	Name    string    // 包名
+	Version string    // 原始版本号, 如 v1.2.0, 由各安装包格式按自身规则转换
+	Arch    string    // 目标架构(GOARCH)
+	Binary  string    // 可执行文件路径
+	BinName string    // 可执行文件安装后的文件名
+	OutDir  string    // 安装包输出目录
+	MTime   time.Time // 安装包中文件的修改时间
+}
+
+// SBOMSpec 表示为单个可执行文件生成SBOM所需的信息
+type SBOMSpec struct {
+	Binary      string    // 可执行文件路径, SBOM文件写入同一目录
+	Version     string    // 主模块的版本, 为空时使用可执行文件中记录的版本
+	ToolVersion string    // gob的版本, 写入SBOM的生成工具信息
+	Created     time.Time // SBOM的生成时间
+}
+
+// ReleaseSpec 表示生成包管理器清单所需的信息
+type ReleaseSpec struct {
+	Name        string         // 软件名称, 同时作为安装后的命令名
+	Version     string         // 版本号, 如 1.2.0
+	Description string         // 软件描述
+	Homepage    string         // 项目主页
+	License     string         // 许可证
+	Assets      []ReleaseAsset // 各目标的下载产物
+}
+
+// ReleaseAsset 表示包管理器清单引用的单个下载产物
+type ReleaseAsset struct {
+	OS     string // 目标平台
+	Arch   string // 目标架构
+	URL    string // 下载地址
+	SHA256 string // 产物的sha256校验和
+	Format string // 归档格式, 产物为可执行文件时为空
+	Binary string // 可执行文件在产物中的相对路径, 使用/分隔
+}
+
+// ReleaseRequest 表示在托管平台上创建或更新发布所需的信息
+type ReleaseRequest struct {
+	Tag        string // 标签
+	Name       string // 发布标题
+	Notes      string // 发布说明
+	Commitish  string // 标签不存在时用于创建标签的提交
+	Draft      bool   // 是否为草稿
+	Prerelease bool   // 是否为预发布版本
+}
+
+// HostedRelease 表示托管平台上已存在的发布
+type HostedRelease struct {
+	ID        int64         `json:"id"`         // 发布ID
+	TagName   string        `json:"tag_name"`   // 标签
+	Name      string        `json:"name"`       // 发布标题
+	Draft     bool          `json:"draft"`      // 是否为草稿
+	HTMLURL   string        `json:"html_url"`   // 发布页面地址, gitee为空
+	UploadURL string        `json:"upload_url"` // 附件上传地址模板, 仅github返回
+	Assets    []HostedAsset `json:"assets"`     // 附件, gitee需单独查询
+}
+
+// HostedAsset 表示托管平台发布中的附件
+type HostedAsset struct {
+	ID   int64  `json:"id"`   // 附件ID
+	Name string `json:"name"` // 文件名
+}
+
+// ConfigLayers 表示合并 extends 继承链后的配置
+type ConfigLayers struct {
+	Doc      map[string]any    // 合并后的配置文档(不含 extends 键)
+	Origins  map[string]string // 每个值的来源文件, 键为以点分隔的配置路径, 如 build.target.platforms
+	Files    []string          // 继承链上的配置文件, 从最底层的基础配置到当前配置
+	Warnings []string          // 迁移旧版本配置格式时产生的提示, 每条提示包含文件路径
 }
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// apkScripts apk维护脚本在控制段中的文件名
+var apkScripts = []struct{ key, name string }{
+	{"pre_install", ".pre-install"},
+	{"post_install", ".post-install"},
+	{"pre_remove", ".pre-deinstall"},
+	{"post_remove", ".post-deinstall"},
+}
+
+// writeAPK 生成Alpine apk安装包
+//
+// 参数:
+//   - p: 安装包内容
+//   - dir: 输出目录
+//
+// 返回值:
+//   - string: 安装包路径, 文件名为 包名-版本-r修订号.架构.apk
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - apk由控制段和数据段两个gzip流拼接而成, 控制段的.PKGINFO记录数据段的sha256
+//   - 生成的安装包未签名, 需使用 apk add --allow-untrusted 安装
+func writeAPK(p *linuxPackage, dir string) (string, error) {
+	data, err := apkData(p)
+	if err != nil {
+		return "", err
+	}
+	control, err := apkControl(p, data)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s-r%s.%s.apk", p.name, p.version, p.release, p.arch)
+	return writePackageFile(dir, name, func(w io.Writer) error {
+		if _, err := w.Write(control); err != nil {
+			return err
+		}
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// apkData 生成数据段
+//
+// 参数:
+//   - p: 安装包内容
+//
+// 返回值:
+//   - []byte: gzip压缩的tar, 文件带有apk-tools使用的sha1校验和
+//   - error: 读取或写入失败时返回错误
+func apkData(p *linuxPackage) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for _, dir := range packageDirs(p.files) {
+		if err := writeTarDir(tw, tar.FormatPAX, strings.TrimPrefix(dir, "/"), p.mtime); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range p.files {
+		sum, err := fileDigest(f.src, sha1.New())
+		if err != nil {
+			return nil, err
+		}
+		pax := map[string]string{"APK-TOOLS.checksum.SHA1": sum}
+		if err := writeTarFile(tw, tar.FormatPAX, strings.TrimPrefix(f.dst, "/"), f, p.mtime, pax); err != nil {
+			return nil, fmt.Errorf("打包 %s 失败: %w", f.src, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// apkControl 生成控制段
+//
+// 参数:
+//   - p: 安装包内容
+//   - data: 数据段
+//
+// 返回值:
+//   - []byte: gzip压缩的tar, 包含.PKGINFO和维护脚本
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - 控制段的tar不写入结束块, 以便与数据段拼接
+func apkControl(p *linuxPackage, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	dataHash := sha256.Sum256(data)
+	if err := writeTarBytes(tw, tar.FormatPAX, ".PKGINFO", 0o644, p.mtime, []byte(apkPkgInfo(p, hex.EncodeToString(dataHash[:])))); err != nil {
+		return nil, err
+	}
+	for _, script := range apkScripts {
+		if content, ok := p.scripts[script.key]; ok {
+			if err := writeTarBytes(tw, tar.FormatPAX, script.name, 0o755, p.mtime, []byte(content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// apkPkgInfo 生成.PKGINFO的内容
+func apkPkgInfo(p *linuxPackage, dataHash string) string {
+	var sb strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%s = %s\n", name, value)
+		}
+	}
+
+	sb.WriteString("# Generated by gob\n")
+	field("pkgname", p.name)
+	field("pkgver", p.version+"-r"+p.release)
+	field("pkgdesc", p.summary())
+	field("url", p.cfg.Homepage)
+	field("builddate", fmt.Sprint(p.mtime.Unix()))
+	field("packager", p.cfg.Maintainer)
+	field("size", fmt.Sprint(p.installedSize()))
+	field("arch", p.arch)
+	field("origin", p.name)
+	field("maintainer", p.cfg.Maintainer)
+	field("license", p.cfg.License)
+	for _, dep := range p.depends {
+		field("depend", dep.name+dep.op+dep.version)
+	}
+	for _, dep := range p.conflicts {
+		field("depend", "!"+dep.name+dep.op+dep.version)
+	}
+	for _, dep := range p.provides {
+		field("provides", dep.name+dep.op+dep.version)
+	}
+	for _, dep := range p.replaces {
+		field("replaces", dep.name)
+	}
+	field("datahash", dataHash)
+	return sb.String()
+}
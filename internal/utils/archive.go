@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// archiveExts 支持的归档格式及其扩展名
+var archiveExts = map[string]string{
+	"zip":    ".zip",
+	"tar":    ".tar",
+	"tar.gz": ".tar.gz",
+	"tgz":    ".tgz",
+	"gz":     ".gz",
+}
+
+// ArchiveFormats 返回支持的归档格式
+//
+// 返回值:
+//   - []string: 排序后的归档格式列表
+func ArchiveFormats() []string {
+	formats := make([]string, 0, len(archiveExts))
+	for format := range archiveExts {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// ArchiveFormat 返回指定平台使用的归档格式
+//
+// 参数:
+//   - cfg: 归档配置
+//   - goos: 目标平台
+//
+// 返回值:
+//   - string: 归档格式, format_overrides 中配置了该平台时优先使用
+func ArchiveFormat(cfg types.ArchiveConfig, goos string) string {
+	if format, ok := cfg.FormatOverrides[goos]; ok && format != "" {
+		return format
+	}
+	if cfg.Format == "" {
+		return types.DefaultArchiveFormat
+	}
+	return cfg.Format
+}
+
+// ArchiveExt 返回归档格式对应的扩展名
+//
+// 参数:
+//   - format: 归档格式
+//
+// 返回值:
+//   - string: 扩展名, 如 .tar.gz
+//   - error: 格式不支持时返回错误
+func ArchiveExt(format string) (string, error) {
+	ext, ok := archiveExts[format]
+	if !ok {
+		return "", fmt.Errorf("不支持的归档格式 %q, 可用的格式: %s", format, strings.Join(ArchiveFormats(), "、"))
+	}
+	return ext, nil
+}
+
+// CollectArchiveFiles 展开附加文件的通配符
+//
+// 参数:
+//   - patterns: 文件、目录或 filepath.Glob 通配符列表
+//
+// 返回值:
+//   - []types.ArchiveEntry: 归档条目, 相对路径保持不变, 绝对路径或位于当前目录之外的文件使用文件名
+//   - error: 通配符无效或没有匹配的文件时返回错误
+func CollectArchiveFiles(patterns []string) ([]types.ArchiveEntry, error) {
+	var entries []types.ArchiveEntry
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("附加文件通配符 %q 无效: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("附加文件 %q 没有匹配的文件", pattern)
+		}
+
+		for _, match := range matches {
+			name := filepath.ToSlash(filepath.Clean(match))
+			if filepath.IsAbs(match) || name == ".." || strings.HasPrefix(name, "../") {
+				name = filepath.Base(match)
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			entries = append(entries, types.ArchiveEntry{Src: match, Name: name})
+		}
+	}
+	return entries, nil
+}
+
+// CreateArchive 将条目写入归档文件
+//
+// 参数:
+//   - dst: 归档文件路径, 已存在时覆盖
+//   - format: 归档格式
+//   - entries: 归档条目, 目录会递归打包
+//
+// 返回值:
+//   - error: 格式不支持或写入失败时返回错误, 失败时删除未完成的归档文件
+//
+// 注意:
+//   - gz 格式只能压缩单个文件
+func CreateArchive(dst, format string, entries []types.ArchiveEntry) (err error) {
+	if _, err := ArchiveExt(format); err != nil {
+		return err
+	}
+	if format == "gz" && len(entries) != 1 {
+		return fmt.Errorf("gz 格式只能压缩单个文件, 打包多个文件请使用 tar.gz 或 zip")
+	}
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("创建归档文件 %s 失败: %w", dst, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("写入归档文件 %s 失败: %w", dst, closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	switch format {
+	case "zip":
+		return writeZip(file, entries)
+	case "tar":
+		return writeTar(file, entries)
+	case "tar.gz", "tgz":
+		gw := gzip.NewWriter(file)
+		if err := writeTar(gw, entries); err != nil {
+			return err
+		}
+		return gw.Close()
+	default:
+		gw := gzip.NewWriter(file)
+		gw.Name = path.Base(entries[0].Name)
+		if err := copyFile(gw, entries[0].Src); err != nil {
+			return err
+		}
+		return gw.Close()
+	}
+}
+
+// walkArchiveEntries 遍历条目中的所有文件和目录
+//
+// 参数:
+//   - entries: 归档条目
+//   - fn: 对每个文件或目录调用, name 为其在归档文件中的路径
+//
+// 返回值:
+//   - error: 遍历失败或fn返回错误时返回错误
+func walkArchiveEntries(entries []types.ArchiveEntry, fn func(src, name string, info fs.FileInfo) error) error {
+	for _, entry := range entries {
+		err := filepath.Walk(entry.Src, func(src string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(entry.Src, src)
+			if err != nil {
+				return err
+			}
+			return fn(src, path.Join(entry.Name, filepath.ToSlash(rel)), info)
+		})
+		if err != nil {
+			return fmt.Errorf("打包 %s 失败: %w", entry.Src, err)
+		}
+	}
+	return nil
+}
+
+// writeZip 以zip格式写入归档条目
+func writeZip(w io.Writer, entries []types.ArchiveEntry) error {
+	zw := zip.NewWriter(w)
+	err := walkArchiveEntries(entries, func(src, name string, info fs.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+			_, err := zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return copyFile(fw, src)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeTar 以tar格式写入归档条目
+func writeTar(w io.Writer, entries []types.ArchiveEntry) error {
+	tw := tar.NewWriter(w)
+	err := walkArchiveEntries(entries, func(src, name string, info fs.FileInfo) error {
+		// 仅打包普通文件和目录
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return copyFile(tw, src)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// copyFile 将文件内容写入w
+func copyFile(w io.Writer, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+func TestArchiveFormat(t *testing.T) {
+	cfg := types.ArchiveConfig{Format: "tar", FormatOverrides: map[string]string{"windows": "zip", "darwin": ""}}
+	tests := map[string]string{"windows": "zip", "linux": "tar", "darwin": "tar"}
+	for goos, want := range tests {
+		if got := ArchiveFormat(cfg, goos); got != want {
+			t.Errorf("ArchiveFormat(%s) = %s, 期望 %s", goos, got, want)
+		}
+	}
+	if got := ArchiveFormat(types.ArchiveConfig{}, "linux"); got != types.DefaultArchiveFormat {
+		t.Errorf("未配置格式时应使用默认格式, got %s", got)
+	}
+
+	for format, want := range map[string]string{"zip": ".zip", "tar.gz": ".tar.gz", "tgz": ".tgz", "gz": ".gz"} {
+		if ext, err := ArchiveExt(format); err != nil || ext != want {
+			t.Errorf("ArchiveExt(%s) = %s, %v", format, ext, err)
+		}
+	}
+	if _, err := ArchiveExt("rar"); err == nil || !strings.Contains(err.Error(), "gz、tar、tar.gz、tgz、zip") {
+		t.Errorf("不支持的格式期望列出可用的格式, got %v", err)
+	}
+}
+
+func TestCollectArchiveFiles(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	for _, name := range []string{"work/README.md", "work/LICENSE", "work/completions/gob.bash", "work/completions/gob.zsh", "shared/NOTICE"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(work)
+
+	abs := filepath.Join(root, "shared", "NOTICE")
+	entries, err := CollectArchiveFiles([]string{"README.md", "completions/*.bash", "completions", "../shared/NOTICE", abs, "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []types.ArchiveEntry{
+		{Src: "README.md", Name: "README.md"},
+		{Src: filepath.Join("completions", "gob.bash"), Name: "completions/gob.bash"},
+		{Src: "completions", Name: "completions"},
+		{Src: filepath.Join("..", "shared", "NOTICE"), Name: "NOTICE"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("归档条目 = %+v\n期望 %+v", entries, want)
+	}
+
+	if _, err := CollectArchiveFiles([]string{"CHANGELOG.md"}); err == nil || !strings.Contains(err.Error(), "没有匹配的文件") {
+		t.Errorf("没有匹配的文件时期望返回错误, got %v", err)
+	}
+	if _, err := CollectArchiveFiles([]string{"[a-"}); err == nil {
+		t.Error("通配符无效时期望返回错误")
+	}
+}
+
+// newArchiveEntries 创建可执行文件和附加目录, 返回归档条目
+func newArchiveEntries(t *testing.T) []types.ArchiveEntry {
+	t.Helper()
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "myapp")
+	if err := os.WriteFile(binary, []byte("binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	docs := filepath.Join(dir, "docs")
+	if err := os.MkdirAll(filepath.Join(docs, "man"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "man", "myapp.1"), []byte("man"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return []types.ArchiveEntry{{Src: binary, Name: "myapp_1.0/myapp"}, {Src: docs, Name: "myapp_1.0/docs"}}
+}
+
+func TestCreateArchive(t *testing.T) {
+	want := map[string]string{
+		"myapp_1.0/myapp":            "binary",
+		"myapp_1.0/docs/":            "",
+		"myapp_1.0/docs/man/":        "",
+		"myapp_1.0/docs/man/myapp.1": "man",
+	}
+
+	for _, format := range []string{"tar", "tar.gz", "tgz", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			entries := newArchiveEntries(t)
+			dst := filepath.Join(t.TempDir(), "myapp"+archiveExts[format])
+			if err := CreateArchive(dst, format, entries); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			modes := make(map[string]os.FileMode)
+			switch format {
+			case "zip":
+				zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, f := range zr.File {
+					rc, err := f.Open()
+					if err != nil {
+						t.Fatal(err)
+					}
+					content, _ := io.ReadAll(rc)
+					rc.Close()
+					got[f.Name] = string(content)
+					modes[f.Name] = f.Mode().Perm()
+				}
+			case "tar":
+				for name, content := range readTarFiles(t, bytes.NewReader(data)) {
+					got[name] = string(content)
+				}
+			default:
+				for name, content := range readTarGz(t, data) {
+					got[name] = string(content)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("归档内容 = %v\n期望 %v", got, want)
+			}
+			if format == "zip" && modes["myapp_1.0/myapp"] != 0o755 {
+				t.Errorf("zip中的可执行文件应保留可执行权限, got %v", modes["myapp_1.0/myapp"])
+			}
+		})
+	}
+}
+
+func TestCreateArchiveGzip(t *testing.T) {
+	entries := newArchiveEntries(t)
+	dst := filepath.Join(t.TempDir(), "myapp_linux_amd64.gz")
+	if err := CreateArchive(dst, "gz", entries[:1]); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(gr)
+	if err != nil || string(content) != "binary" || gr.Name != "myapp" {
+		t.Errorf("gz内容 = %q, 文件名 = %q, %v", content, gr.Name, err)
+	}
+
+	if err := CreateArchive(dst, "gz", entries); err == nil || !strings.Contains(err.Error(), "只能压缩单个文件") {
+		t.Errorf("gz 格式打包多个文件期望返回错误, got %v", err)
+	}
+}
+
+func TestCreateArchiveRemovesPartialFile(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "myapp.tar.gz")
+	entries := []types.ArchiveEntry{{Src: filepath.Join(t.TempDir(), "missing"), Name: "missing"}}
+	if err := CreateArchive(dst, "tar.gz", entries); err == nil {
+		t.Fatal("文件不存在时期望返回错误")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("失败时应删除未完成的归档文件")
+	}
+	if err := CreateArchive(dst, "rar", newArchiveEntries(t)); err == nil {
+		t.Error("不支持的格式期望返回错误")
+	}
+}
+
+// readTarFiles 读取tar中的所有普通文件, 内容为 nil 的键表示目录
+func readTarFiles(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("读取tar失败: %v", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			files[hdr.Name] = nil
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = content
+	}
+}
+
+// readTarGz 解压并读取tar.gz
+func readTarGz(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return readTarFiles(t, gr)
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestBlake2b(t *testing.T) {
+	// 1000 字节的输入跨越多个分组, 期望值由 Python hashlib.blake2b 计算
+	long := make([]byte, 1000)
+	for i := range long {
+		long[i] = byte(i % 251)
+	}
+
+	tests := []struct {
+		name  string
+		size  int
+		input []byte
+		want  string
+	}{
+		// RFC 7693 附录A
+		{"RFC 7693 abc", 64, []byte("abc"), "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{"空输入", 64, nil, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{"整个分组", 64, make([]byte, 128), "865939e120e6805438478841afb739ae4250cf372653078a065cdcfffca4caf798e6d462b65d658fc165782640eded70963449ae1500fb0f24981d7727e22c41"},
+		{"多个分组", 64, long, "c11e1c0340bd7e5a1b275f1230c962fad215ecb1391486e74e31b960a2f2996381a5fad092da06841d5f26e38f6ecfeaf441acbcd1c2de61aef121e7927175f5"},
+		{"BLAKE2b-256 abc", 32, []byte("abc"), "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319"},
+		{"BLAKE2b-256 多个分组", 32, long, "b372d0608f720c8c3dd41e9c8eecb10143b41abe520b616607e754bf79c08331"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newBlake2b(tt.size)
+			h.Write(tt.input)
+			if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+				t.Errorf("一次写入: got %s, 期望 %s", got, tt.want)
+			}
+
+			// 分多次写入, 长度不与分组对齐
+			h.Reset()
+			for data := tt.input; len(data) > 0; {
+				n := min(len(data), 37)
+				h.Write(data[:n])
+				data = data[n:]
+			}
+			if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+				t.Errorf("分多次写入: got %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlake2bHash(t *testing.T) {
+	h := newBlake2b512()
+	if h.Size() != 64 || h.BlockSize() != 128 || newBlake2b256().Size() != 32 {
+		t.Errorf("Size/BlockSize 不正确: %d %d", h.Size(), h.BlockSize())
+	}
+
+	// Sum 不改变哈希状态, 并追加到参数之后
+	h.Write([]byte("ab"))
+	prefix := []byte("x")
+	if sum := h.Sum(prefix); len(sum) != 65 || sum[0] != 'x' {
+		t.Errorf("Sum 应追加到参数之后, got %d 字节", len(sum))
+	}
+	h.Write([]byte("c"))
+	if got := hex.EncodeToString(h.Sum(nil)); got[:16] != "ba80a53f981c4d0d" {
+		t.Errorf("调用 Sum 之后继续写入的结果不正确: %s", got)
+	}
+}
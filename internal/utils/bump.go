@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// WriteVersionFile 将新版本号写入版本文件
+//
+// 参数:
+//   - path: 版本文件路径
+//   - varName: .go文件中要替换的常量或变量名
+//   - version: 新版本号
+//
+// 返回值:
+//   - error: 文件无法读写或.go文件中找不到该常量或变量时返回错误
+//
+// 注意:
+//   - .go文件只替换第一处 varName = "..." 或 varName string = "..." 的字符串值, 其余内容保持不变
+//   - 其他文件整体替换为版本号加换行符, 文件不存在时创建
+func WriteVersionFile(path, varName, version string) error {
+	if filepath.Ext(path) != ".go" {
+		if err := os.WriteFile(path, []byte(version+"\n"), 0o644); err != nil {
+			return fmt.Errorf("写入版本文件失败: %w", err)
+		}
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取版本文件失败: %w", err)
+	}
+	re := regexp.MustCompile(`(\b` + regexp.QuoteMeta(varName) + `(?:\s+string)?\s*=\s*)("(?:[^"\\\n]|\\.)*"|` + "`[^`]*`)")
+	loc := re.FindSubmatchIndex(data)
+	if loc == nil {
+		return fmt.Errorf("%s 中找不到 %s = \"...\" 形式的版本号定义", path, varName)
+	}
+
+	var sb strings.Builder
+	sb.Write(data[:loc[4]])
+	sb.WriteString(fmt.Sprintf("%q", version))
+	sb.Write(data[loc[5]:])
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("读取版本文件失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("写入版本文件失败: %w", err)
+	}
+	return nil
+}
+
+// GitTagExists 判断标签是否已存在
+//
+// 参数:
+//   - timeout: 命令的超时时间
+//   - tag: 标签名
+//
+// 返回值:
+//   - bool: 标签存在时返回true
+//   - error: git命令执行失败时返回错误
+func GitTagExists(timeout time.Duration, tag string) (bool, error) {
+	out, err := gitOutput(timeout, types.GitListTagCmd, tag)
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// ReleaseTagMessage 生成附注标签的信息
+//
+// 参数:
+//   - timeout: 命令的超时时间
+//   - prevTag: 上一个版本标签, 为空时包含全部提交
+//   - tag: 新的版本标签
+//
+// 返回值:
+//   - string: 首行为 "Release 标签", 之后逐行列出上一个版本之后的提交标题(不含合并提交)
+//   - error: git命令执行失败时返回错误
+func ReleaseTagMessage(timeout time.Duration, prevTag, tag string) (string, error) {
+	subjects, err := gitOutput(timeout, types.GitLogSubjectsCmd, gitRange(prevTag))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Release " + tag + "\n")
+	if subjects != "" {
+		sb.WriteString("\n")
+		for _, subject := range strings.Split(subjects, "\n") {
+			sb.WriteString("- " + subject + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// CreateReleaseTag 提交版本文件并创建附注标签
+//
+// 参数:
+//   - timeout: 命令的超时时间
+//   - tag: 标签名
+//   - message: 标签信息
+//   - files: 需要在创建标签前提交的文件, 为空时直接在当前提交上创建标签
+//
+// 返回值:
+//   - error: 错误信息
+func CreateReleaseTag(timeout time.Duration, tag, message string, files []string) error {
+	if len(files) > 0 {
+		if _, err := gitOutput(timeout, types.GitAddCmd, files...); err != nil {
+			return err
+		}
+		if _, err := gitOutput(timeout, types.GitCommitCmd, "chore(release): "+tag); err != nil {
+			return err
+		}
+	}
+	_, err := gitOutput(timeout, types.GitTagAnnotatedCmd, tag, "-m", message)
+	return err
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBumpSemver(t *testing.T) {
+	tests := []struct {
+		in, part, preid string
+		want            string // 期望的新版本, 为空时期望返回错误
+	}{
+		{"1.2.3", "major", "", "2.0.0"},
+		{"1.2.3", "minor", "", "1.3.0"},
+		{"1.2.3", "patch", "", "1.2.4"},
+		{"1.3.0-rc.2", "minor", "", "1.3.0"},
+		{"1.2.3", "minor", "rc", "1.3.0-rc.1"},
+		{"1.2.3", "major", "beta", "2.0.0-beta.1"},
+		{"1.3.0-rc.2", "minor", "rc", "1.4.0-rc.1"},
+		{"1.3.0-rc.2", "patch", "rc", "1.3.1-rc.1"},
+		{"1.2.3", "prerelease", "rc", "1.2.4-rc.1"},
+		{"1.2.4-rc.1", "prerelease", "rc", "1.2.4-rc.2"},
+		{"1.2.4-rc.9", "prerelease", "rc", "1.2.4-rc.10"},
+		{"1.2.4-rc.1", "prerelease", "beta", "1.2.4-beta.1"},
+		{"1.2.4-rc", "prerelease", "rc", "1.2.4-rc.1"},
+		{"1.2.4-rc.x", "prerelease", "rc", "1.2.4-rc.1"},
+		{"1.2.3", "prerelease", "", ""},
+		{"1.2.3", "minor", "r_c", ""},
+		{"1.2.3", "minor", "01", ""},
+		{"1.2.3", "build", "", ""},
+		{"1.2.3", "build", "rc", ""},
+	}
+	for _, tt := range tests {
+		v, _ := ParseSemver(tt.in)
+		got, err := BumpSemver(v, tt.part, tt.preid)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("BumpSemver(%s, %s, %q) 期望返回错误, got %s", tt.in, tt.part, tt.preid, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("BumpSemver(%s, %s, %q) = %s, %v, 期望 %s", tt.in, tt.part, tt.preid, got, err, tt.want)
+		}
+	}
+}
+
+func TestWriteVersionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		varName string
+		content string // 原文件内容, 为空时文件不存在
+		want    string // 期望的文件内容, 为空时期望返回错误
+	}{
+		{
+			name: "常量", file: "version.go", varName: "Version",
+			content: "package main\n\nconst Version = \"v1.0.0\"\n",
+			want:    "package main\n\nconst Version = \"v1.1.0\"\n",
+		},
+		{
+			name: "带类型的变量", file: "version.go", varName: "Version",
+			content: "package main\n\nvar Version string = \"dev\" // 版本号\n",
+			want:    "package main\n\nvar Version string = \"v1.1.0\" // 版本号\n",
+		},
+		{
+			name: "原始字符串", file: "version.go", varName: "Version",
+			content: "package main\n\nconst Version = `1.0.0`\n",
+			want:    "package main\n\nconst Version = \"v1.1.0\"\n",
+		},
+		{
+			name: "转义引号", file: "version.go", varName: "Version",
+			content: "package main\n\nconst Version = \"a\\\"b\"\n",
+			want:    "package main\n\nconst Version = \"v1.1.0\"\n",
+		},
+		{
+			name: "只替换第一处且匹配完整名称", file: "version.go", varName: "Version",
+			content: "package main\n\nconst (\n\tAppVersion = \"x\"\n\tVersion    = \"1\"\n)\n\nvar Old = Version\nconst Version2 = \"2\"\n",
+			want:    "package main\n\nconst (\n\tAppVersion = \"x\"\n\tVersion    = \"v1.1.0\"\n)\n\nvar Old = Version\nconst Version2 = \"2\"\n",
+		},
+		{
+			name: "自定义变量名", file: "version.go", varName: "appVersion",
+			content: "package main\n\nvar appVersion = \"1.0.0\"\n",
+			want:    "package main\n\nvar appVersion = \"v1.1.0\"\n",
+		},
+		{
+			name: "找不到变量", file: "version.go", varName: "Version",
+			content: "package main\n\nconst AppVersion = \"1\"\n",
+		},
+		{name: ".go文件不存在", file: "version.go", varName: "Version"},
+		{name: "其他文件", file: "VERSION", content: "v1.0.0\n", want: "v1.1.0\n"},
+		{name: "其他文件不存在时创建", file: "VERSION", want: "v1.1.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := WriteVersionFile(path, tt.varName, "v1.1.0")
+			if tt.want == "" {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				if data, _ := os.ReadFile(path); string(data) != tt.content {
+					t.Errorf("失败时不应修改文件, got %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.want {
+				t.Errorf("文件内容 = %q, 期望 %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseTag(t *testing.T) {
+	git := newTestGitRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "init")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "feat: a")
+	git("checkout", "-q", "-b", "topic")
+	git("commit", "-q", "--allow-empty", "-m", "fix: b")
+	git("checkout", "-q", "main")
+	git("merge", "-q", "--no-ff", "-m", "Merge branch 'topic'", "topic")
+
+	message, err := ReleaseTagMessage(testGitTimeout, "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(message, "\n")
+	if lines[0] != "Release v1.1.0" || len(lines) != 5 || !strings.Contains(message, "\n- feat: a\n") || !strings.Contains(message, "\n- fix: b\n") {
+		t.Errorf("标签信息应包含上一个版本之后除合并提交外的提交标题, got %q", message)
+	}
+	if message, err := ReleaseTagMessage(testGitTimeout, "", "v1.1.0"); err != nil || !strings.Contains(message, "\n- init\n") {
+		t.Errorf("没有上一个版本时应包含全部提交, got %q, %v", message, err)
+	}
+
+	// 提交版本文件后创建附注标签
+	if err := os.WriteFile("VERSION", []byte("v1.1.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateReleaseTag(testGitTimeout, "v1.1.0", message, []string{"VERSION"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := git("log", "-1", "--format=%s"); got != "chore(release): v1.1.0" {
+		t.Errorf("版本文件的提交信息 = %q", got)
+	}
+	if got := git("status", "--porcelain"); got != "" {
+		t.Errorf("版本文件应已提交, got %q", got)
+	}
+	if got := git("cat-file", "-t", "v1.1.0"); got != "tag" {
+		t.Errorf("期望创建附注标签, got %s", got)
+	}
+	if got := git("tag", "-l", "--format=%(contents)", "v1.1.0"); got != strings.TrimSpace(message) {
+		t.Errorf("标签信息 = %q, 期望 %q", got, message)
+	}
+
+	// 不提交文件时在当前提交上创建标签
+	head := git("rev-parse", "HEAD")
+	if message, err := ReleaseTagMessage(testGitTimeout, "v1.1.0", "v1.1.1"); err != nil || message != "Release v1.1.1\n" {
+		t.Errorf("没有新提交时标签信息只包含首行, got %q, %v", message, err)
+	}
+	if err := CreateReleaseTag(testGitTimeout, "v1.1.1", "Release v1.1.1\n", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := git("rev-list", "-n", "1", "v1.1.1"); got != head {
+		t.Errorf("标签应指向当前提交 %s, got %s", head, got)
+	}
+
+	for tag, want := range map[string]bool{"v1.1.0": true, "v1.0.0": true, "v1.1": false, "v2.0.0": false} {
+		if got, err := GitTagExists(testGitTimeout, tag); err != nil || got != want {
+			t.Errorf("GitTagExists(%s) = %v, %v, 期望 %v", tag, got, err, want)
+		}
+	}
+	if err := CreateReleaseTag(testGitTimeout, "v1.1.1", "again", nil); err == nil {
+		t.Error("标签已存在时期望返回错误")
+	}
+}
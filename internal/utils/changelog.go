@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// conventionalCommitRegexp 匹配约定式提交的标题, 如 feat(cli)!: 描述
+var conventionalCommitRegexp = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?:\s*(.+)$`)
+
+// breakingFooterRegexp 匹配提交正文中的不兼容变更说明
+var breakingFooterRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+
+// GenerateChangelog 根据两个版本之间的提交生成变更日志
+//
+// 参数:
+//   - timeout: git命令的超时时间
+//   - data: 模板数据, 提供配置、Git元数据和自定义变量
+//   - tag: 本次版本的标签, 为空时使用当前提交的版本标签; 当前提交不是版本标签时生成最高版本标签之后的快照版本变更日志
+//
+// 返回值:
+//   - string: 渲染后的变更日志
+//   - error: git命令执行失败、排除规则无效或模板渲染失败时返回错误
+//
+// 注意:
+//   - 上一个版本为可达的标签中低于本次版本的最高语义化版本标签, 正式版本跳过预发布版本的标签, 因此包含其所有预发布版本的提交
+func GenerateChangelog(timeout time.Duration, data *types.TemplateData, tag string) (string, error) {
+	cfg := data.Config.Changelog
+	exclude := make([]*regexp.Regexp, 0, len(cfg.Exclude))
+	for _, pattern := range cfg.Exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("changelog.exclude: 无效的正则表达式 %q: %w", pattern, err)
+		}
+		exclude = append(exclude, re)
+	}
+
+	// 当前提交是版本标签时(不论工作区是否有修改)生成该标签的变更日志
+	sv := data.Config.GitSemver
+	if tag == "" && sv.Tag != "" && sv.CommitsSinceTag == 0 {
+		tag = sv.Tag
+	}
+
+	changelog := &types.Changelog{}
+	end := "HEAD"
+	if tag != "" {
+		prev, err := previousTag(timeout, data.Config.Build.Git.TagPrefix, tag)
+		if err != nil {
+			return "", err
+		}
+		end = "refs/tags/" + tag
+		date, err := gitOutput(timeout, types.GitCommitDateCmd, end)
+		if err != nil {
+			return "", err
+		}
+		changelog.Tag, changelog.PreviousTag, changelog.Date = tag, prev, date
+	} else {
+		changelog.Tag, changelog.PreviousTag, changelog.Date = data.Git.Version, sv.Tag, time.Now().Format(time.DateOnly)
+	}
+
+	rng := end
+	if changelog.PreviousTag != "" {
+		rng = "refs/tags/" + changelog.PreviousTag + ".." + end
+	}
+	out, err := gitOutput(timeout, types.GitLogCommitsCmd, rng)
+	if err != nil {
+		return "", err
+	}
+
+	var commits []types.ChangelogCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		var body string
+		if len(fields) == 4 {
+			body = fields[3]
+		}
+		if excluded(exclude, fields[2]) {
+			continue
+		}
+		commits = append(commits, parseCommit(fields[0], fields[1], fields[2], body))
+	}
+	changelog.Sections = groupCommits(cfg.Sections, commits)
+
+	text := cfg.Template
+	if text == "" {
+		text = types.DefaultChangelogTemplate
+	}
+	d := *data
+	d.Changelog = changelog
+	rendered, err := RenderTemplate(text, &d)
+	if err != nil {
+		return "", fmt.Errorf("渲染变更日志失败: %w", err)
+	}
+	return strings.TrimSpace(rendered) + "\n", nil
+}
+
+// previousTag 返回指定标签的上一个版本标签
+//
+// 参数:
+//   - timeout: git命令的超时时间
+//   - prefix: 标签前缀, 只有以该前缀开头的标签参与比较
+//   - tag: 本次版本的标签
+//
+// 返回值:
+//   - string: 上一个版本的标签, 没有时为空
+//   - error: git命令执行失败时返回错误
+func previousTag(timeout time.Duration, prefix, tag string) (string, error) {
+	out, err := gitOutput(timeout, types.GitTagsMergedCmd, "refs/tags/"+tag)
+	if err != nil {
+		return "", err
+	}
+	current, isSemver := ParseSemver(strings.TrimPrefix(tag, prefix))
+
+	var prev string
+	var prevVersion types.Semver
+	for _, t := range strings.Fields(out) {
+		name, ok := strings.CutPrefix(t, prefix)
+		if t == tag || !ok {
+			continue
+		}
+		v, ok := ParseSemver(name)
+		if !ok {
+			continue
+		}
+		if isSemver && (CompareSemver(v, current) >= 0 || (current.Prerelease == "" && v.Prerelease != "")) {
+			continue
+		}
+		if prev == "" || CompareSemver(v, prevVersion) > 0 {
+			prev, prevVersion = t, v
+		}
+	}
+	return prev, nil
+}
+
+// parseCommit 按约定式提交格式解析提交
+//
+// 参数:
+//   - hash: 完整提交哈希
+//   - author: 作者
+//   - subject: 提交标题
+//   - body: 提交正文
+//
+// 返回值:
+//   - types.ChangelogCommit: 提交信息, 不符合约定式提交格式时类型为空, 描述为完整标题
+func parseCommit(hash, author, subject, body string) types.ChangelogCommit {
+	c := types.ChangelogCommit{
+		Hash:      hash,
+		ShortHash: hash[:min(7, len(hash))],
+		Author:    author,
+		Subject:   subject,
+		Breaking:  breakingFooterRegexp.MatchString(body),
+	}
+	if m := conventionalCommitRegexp.FindStringSubmatch(subject); m != nil {
+		c.Type = strings.ToLower(m[1])
+		c.Scope = m[2]
+		c.Breaking = c.Breaking || m[3] == "!"
+		c.Subject = m[4]
+	}
+	return c
+}
+
+// groupCommits 将提交归入变更日志的各个部分
+//
+// 参数:
+//   - sections: 配置的部分
+//   - commits: 提交列表
+//
+// 返回值:
+//   - []types.ChangelogGroup: 包含提交的部分, 按配置顺序排列
+//
+// 注意:
+//   - 不兼容变更优先归入包含 breaking 的部分, 其次按类型归入, 未归入任何部分的提交归入包含 * 的部分, 否则忽略
+func groupCommits(sections []types.ChangelogSection, commits []types.ChangelogCommit) []types.ChangelogGroup {
+	groups := make([]types.ChangelogGroup, len(sections))
+	for i, s := range sections {
+		groups[i].Title = s.Title
+	}
+
+	sectionOf := func(typ string) int {
+		for i, s := range sections {
+			for _, t := range s.Types {
+				if strings.EqualFold(t, typ) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	for _, c := range commits {
+		idx := -1
+		if c.Breaking {
+			idx = sectionOf("breaking")
+		}
+		if idx < 0 && c.Type != "" {
+			idx = sectionOf(c.Type)
+		}
+		if idx < 0 {
+			idx = sectionOf("*")
+		}
+		if idx >= 0 {
+			groups[idx].Commits = append(groups[idx].Commits, c)
+		}
+	}
+
+	nonEmpty := groups[:0]
+	for _, g := range groups {
+		if len(g.Commits) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+	return nonEmpty
+}
+
+// excluded 判断提交标题是否匹配任一排除规则
+func excluded(patterns []*regexp.Regexp, subject string) bool {
+	for _, re := range patterns {
+		if re.MatchString(subject) {
+			return true
+		}
+	}
+	return false
+}
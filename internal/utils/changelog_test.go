@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+func TestParseCommit(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		subject, body string
+		want          types.ChangelogCommit
+	}{
+		{"feat: add flag", "", types.ChangelogCommit{Type: "feat", Subject: "add flag"}},
+		{"Fix(cli): handle empty args", "", types.ChangelogCommit{Type: "fix", Scope: "cli", Subject: "handle empty args"}},
+		{"refactor(api)!: drop v1", "", types.ChangelogCommit{Type: "refactor", Scope: "api", Subject: "drop v1", Breaking: true}},
+		{"feat!:no space", "", types.ChangelogCommit{Type: "feat", Subject: "no space", Breaking: true}},
+		{"feat: new config", "详细说明\n\nBREAKING CHANGE: 移除旧配置", types.ChangelogCommit{Type: "feat", Subject: "new config", Breaking: true}},
+		{"feat: new config", "BREAKING-CHANGE: 移除旧配置", types.ChangelogCommit{Type: "feat", Subject: "new config", Breaking: true}},
+		{"feat: mention", "说明 BREAKING CHANGE: 不在行首", types.ChangelogCommit{Type: "feat", Subject: "mention"}},
+		{"update deps", "", types.ChangelogCommit{Subject: "update deps"}},
+		{"fix(a)(b): nested", "", types.ChangelogCommit{Subject: "fix(a)(b): nested"}},
+		{"Merge branch 'main'", "BREAKING CHANGE: x", types.ChangelogCommit{Subject: "Merge branch 'main'", Breaking: true}},
+	}
+	for _, tt := range tests {
+		got := parseCommit(hash, "gob", tt.subject, tt.body)
+		want := tt.want
+		want.Hash, want.ShortHash, want.Author = hash, "0123456", "gob"
+		if got != want {
+			t.Errorf("parseCommit(%q, %q) = %+v, 期望 %+v", tt.subject, tt.body, got, want)
+		}
+	}
+
+	if got := parseCommit("abc", "", "fix: x", ""); got.ShortHash != "abc" {
+		t.Errorf("哈希不足7位时 ShortHash 应为完整哈希, got %q", got.ShortHash)
+	}
+}
+
+func TestGroupCommits(t *testing.T) {
+	commits := []types.ChangelogCommit{
+		{Type: "feat", Subject: "a"},
+		{Type: "fix", Subject: "b"},
+		{Type: "feat", Subject: "c", Breaking: true},
+		{Type: "docs", Subject: "d"},
+		{Subject: "e"},
+		{Type: "FEAT", Subject: "f"},
+	}
+	subjects := func(groups []types.ChangelogGroup) map[string][]string {
+		m := make(map[string][]string, len(groups))
+		for _, g := range groups {
+			for _, c := range g.Commits {
+				m[g.Title] = append(m[g.Title], c.Subject)
+			}
+		}
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		sections []types.ChangelogSection
+		titles   []string
+		want     map[string][]string
+	}{
+		{
+			name:     "默认部分忽略未归入的提交",
+			sections: types.DefaultChangelogSections,
+			titles:   []string{"Breaking Changes", "Features", "Bug Fixes"},
+			want: map[string][]string{
+				"Breaking Changes": {"c"},
+				"Features":         {"a", "f"},
+				"Bug Fixes":        {"b"},
+			},
+		},
+		{
+			name: "没有breaking部分时按类型归入",
+			sections: []types.ChangelogSection{
+				{Title: "Other", Types: []string{"*"}},
+				{Title: "Features", Types: []string{"Feat"}},
+			},
+			titles: []string{"Other", "Features"},
+			want: map[string][]string{
+				"Other":    {"b", "d", "e"},
+				"Features": {"a", "c", "f"},
+			},
+		},
+		{
+			name: "多个类型归入同一部分",
+			sections: []types.ChangelogSection{
+				{Title: "Changes", Types: []string{"feat", "fix", "breaking"}},
+				{Title: "Docs", Types: []string{"docs"}},
+			},
+			titles: []string{"Changes", "Docs"},
+			want: map[string][]string{
+				"Changes": {"a", "b", "c", "f"},
+				"Docs":    {"d"},
+			},
+		},
+		{name: "没有部分", titles: []string{}, want: map[string][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := groupCommits(tt.sections, commits)
+			titles := make([]string, 0, len(groups))
+			for _, g := range groups {
+				titles = append(titles, g.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("部分 = %v, 期望 %v", titles, tt.titles)
+			}
+			if got := subjects(groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("提交分组 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newChangelogTestRepo 创建用于生成变更日志的Git仓库, 提交日期固定为 2024-05-01
+//
+// 返回值:
+//   - func(string) string: 返回提交标题对应的7位提交哈希
+//
+// 注意:
+//   - 提交依次为: init (v1.0.0, cli/v0.9.0), feat(cli): add flag, fix: crash (v1.1.0-rc.1),
+//     perf: faster (nightly), chore(release): v1.1.0 (v1.1.0), feat!: next, update deps
+func newChangelogTestRepo(t *testing.T) func(string) string {
+	t.Helper()
+	git := newTestGitRepo(t)
+	t.Setenv("GIT_AUTHOR_DATE", "2024-05-01T12:00:00Z")
+	t.Setenv("GIT_COMMITTER_DATE", "2024-05-01T12:00:00Z")
+
+	hashes := make(map[string]string)
+	commit := func(subject string, tags ...string) {
+		git("commit", "-q", "--allow-empty", "-m", subject)
+		hashes[subject] = git("rev-parse", "--short=7", "HEAD")
+		for _, tag := range tags {
+			git("tag", tag)
+		}
+	}
+	commit("init", "v1.0.0", "cli/v0.9.0")
+	commit("feat(cli): add flag")
+	commit("fix: crash", "v1.1.0-rc.1")
+	commit("perf: faster", "nightly")
+	commit("chore(release): v1.1.0", "v1.1.0")
+	commit("feat!: next")
+	commit("update deps")
+	return func(subject string) string { return hashes[subject] }
+}
+
+func TestPreviousTag(t *testing.T) {
+	newChangelogTestRepo(t)
+
+	tests := []struct {
+		prefix, tag, want string
+	}{
+		{"", "v1.1.0", "v1.0.0"},
+		{"", "v1.1.0-rc.1", "v1.0.0"},
+		{"", "v1.0.0", ""},
+		{"", "nightly", "v1.1.0-rc.1"},
+		{"cli/", "cli/v0.9.0", ""},
+	}
+	for _, tt := range tests {
+		got, err := previousTag(testGitTimeout, tt.prefix, tt.tag)
+		if err != nil || got != tt.want {
+			t.Errorf("previousTag(%q, %q) = %q, %v, 期望 %q", tt.prefix, tt.tag, got, err, tt.want)
+		}
+	}
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	hash := newChangelogTestRepo(t)
+
+	release := "## v1.1.0 (2024-05-01)\n\n" +
+		"### Features\n\n- **cli:** add flag (" + hash("feat(cli): add flag") + ")\n\n" +
+		"### Bug Fixes\n\n- crash (" + hash("fix: crash") + ")\n\n" +
+		"### Performance Improvements\n\n- faster (" + hash("perf: faster") + ")\n"
+	snapshotTemplate := "{{.Changelog.Tag}} {{.Changelog.PreviousTag}} {{.Changelog.Date}}{{range .Changelog.Sections}}|{{.Title}}:{{range .Commits}}{{.Subject}};{{end}}{{end}}"
+
+	tests := []struct {
+		name     string
+		tag      string
+		semver   types.GitSemver
+		sections []types.ChangelogSection
+		exclude  []string
+		template string
+		want     string // 期望的变更日志
+		errMsg   string // 期望的错误信息, 不为空时期望返回错误
+	}{
+		{
+			name: "正式版本跳过预发布版本", tag: "v1.1.0",
+			exclude: []string{`^chore\(release\)`},
+			want:    release,
+		},
+		{
+			name:   "当前提交是版本标签",
+			semver: types.GitSemver{Tag: "v1.1.0", Version: types.Semver{Major: 1, Minor: 1}},
+			// 未排除的 chore 提交不属于任何默认部分
+			want: release,
+		},
+		{
+			name: "预发布版本", tag: "v1.1.0-rc.1",
+			template: snapshotTemplate,
+			want:     "v1.1.0-rc.1 v1.0.0 2024-05-01|Features:add flag;|Bug Fixes:crash;\n",
+		},
+		{
+			name:   "快照版本",
+			semver: types.GitSemver{Tag: "v1.1.0", Version: types.Semver{Major: 1, Minor: 1}, CommitsSinceTag: 2, IsSnapshot: true},
+			sections: []types.ChangelogSection{
+				{Title: "Breaking", Types: []string{"breaking"}},
+				{Title: "Other", Types: []string{"*"}},
+			},
+			template: snapshotTemplate,
+			want:     "v1.1.0-2-gabc1234 v1.1.0 " + time.Now().Format(time.DateOnly) + "|Breaking:next;|Other:update deps;\n",
+		},
+		{
+			name: "没有上一个版本", tag: "v1.0.0",
+			sections: []types.ChangelogSection{{Title: "All", Types: []string{"*"}}},
+			template: snapshotTemplate,
+			want:     "v1.0.0  2024-05-01|All:init;\n",
+		},
+		{name: "无效的排除规则", tag: "v1.1.0", exclude: []string{"("}, errMsg: "无效的正则表达式"},
+		{name: "无效的模板", tag: "v1.1.0", template: "{{.Nope}}", errMsg: "渲染变更日志失败"},
+		{name: "标签不存在", tag: "v9.9.9", errMsg: "v9.9.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetDefaultConfig()
+			config.GitSemver = tt.semver
+			config.Changelog.Exclude = tt.exclude
+			config.Changelog.Template = tt.template
+			if tt.sections != nil {
+				config.Changelog.Sections = tt.sections
+			}
+			data := &types.TemplateData{Git: types.TemplateGit{Version: "v1.1.0-2-gabc1234"}, Config: config}
+
+			got, err := GenerateChangelog(testGitTimeout, data, tt.tag)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("期望错误包含 %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("变更日志 = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// checksumHashes 支持的校验算法
+var checksumHashes = map[string]func() hash.Hash{
+	"sha256":  sha256.New,
+	"sha512":  sha512.New,
+	"blake2b": newBlake2b512,
+}
+
+// ChecksumAlgorithms 返回支持的校验算法
+//
+// 返回值:
+//   - []string: 排序后的校验算法列表
+func ChecksumAlgorithms() []string {
+	algorithms := make([]string, 0, len(checksumHashes))
+	for algorithm := range checksumHashes {
+		algorithms = append(algorithms, algorithm)
+	}
+	slices.Sort(algorithms)
+	return algorithms
+}
+
+// checksumAlgorithmsForLength 根据十六进制校验和的长度推断可能的校验算法
+func checksumAlgorithmsForLength(length int) []string {
+	var algorithms []string
+	for _, algorithm := range ChecksumAlgorithms() {
+		if checksumHashes[algorithm]().Size()*2 == length {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	return algorithms
+}
+
+// FileChecksum 计算文件的校验和
+//
+// 参数:
+//   - path: 文件路径
+//   - algorithm: 校验算法
+//
+// 返回值:
+//   - string: 十六进制校验和
+//   - error: 算法不支持或读取失败时返回错误
+func FileChecksum(path, algorithm string) (string, error) {
+	newHash, ok := checksumHashes[algorithm]
+	if !ok {
+		return "", fmt.Errorf("不支持的校验算法 %q, 可用的算法: %s", algorithm, strings.Join(ChecksumAlgorithms(), "、"))
+	}
+
+	return fileDigest(path, newHash())
+}
+
+// fileDigest 使用指定的哈希计算文件摘要
+//
+// 参数:
+//   - path: 文件路径
+//   - h: 哈希
+//
+// 返回值:
+//   - string: 十六进制摘要
+//   - error: 读取失败时返回错误
+func fileDigest(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksumFile 为构建产物生成校验和文件
+//
+// 参数:
+//   - dir: 校验和文件所在目录, 通常为输出目录
+//   - name: 校验和文件名
+//   - algorithm: 校验算法
+//   - artifacts: 构建产物路径
+//
+// 返回值:
+//   - string: 校验和文件路径
+//   - error: 计算或写入失败时返回错误
+//
+// 注意:
+//   - 每行格式为 "校验和  文件名", 文件名相对于校验和文件所在目录, 可直接使用 sha256sum -c 校验
+//   - 条目按文件名排序
+func WriteChecksumFile(dir, name, algorithm string, artifacts []string) (string, error) {
+	entries := make([]types.ChecksumEntry, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		rel, err := filepath.Rel(dir, artifact)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("构建产物 %s 不在输出目录 %s 中", artifact, dir)
+		}
+
+		sum, err := FileChecksum(artifact, algorithm)
+		if err != nil {
+			return "", fmt.Errorf("计算 %s 的校验和失败: %w", artifact, err)
+		}
+		entries = append(entries, types.ChecksumEntry{Sum: sum, Name: filepath.ToSlash(rel)})
+	}
+	slices.SortFunc(entries, func(a, b types.ChecksumEntry) int { return strings.Compare(a.Name, b.Name) })
+
+	var sb strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "%s  %s\n", entry.Sum, entry.Name)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return "", fmt.Errorf("写入校验和文件 %s 失败: %w", path, err)
+	}
+	return path, nil
+}
+
+// ParseChecksumFile 解析 sha256sum 格式的校验和文件
+//
+// 参数:
+//   - path: 校验和文件路径
+//
+// 返回值:
+//   - []types.ChecksumEntry: 校验和条目
+//   - error: 读取失败或格式无效时返回错误
+//
+// 注意:
+//   - 兼容二进制模式的 "校验和 *文件名" 格式, 忽略空行和以 # 开头的行
+func ParseChecksumFile(path string) ([]types.ChecksumEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开校验和文件失败: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []types.ChecksumEntry
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimPrefix(name, " "), "*")
+		if _, err := hex.DecodeString(sum); !ok || err != nil || name == "" {
+			return nil, fmt.Errorf("%s:%d: 格式无效, 应为 '校验和  文件名'", path, lineNo)
+		}
+		entries = append(entries, types.ChecksumEntry{Sum: strings.ToLower(sum), Name: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取校验和文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// VerifyChecksum 校验单个文件的校验和
+//
+// 参数:
+//   - dir: 校验和文件所在目录
+//   - entry: 校验和条目
+//   - algorithm: 校验算法, 为空时根据校验和长度推断
+//
+// 返回值:
+//   - error: 文件不存在或校验和不匹配时返回错误
+//
+// 注意:
+//   - sha512 和 blake2b 的校验和长度相同, 未指定算法时任一算法匹配即视为通过
+func VerifyChecksum(dir string, entry types.ChecksumEntry, algorithm string) error {
+	algorithms := []string{algorithm}
+	if algorithm == "" {
+		algorithms = checksumAlgorithmsForLength(len(entry.Sum))
+		if len(algorithms) == 0 {
+			return fmt.Errorf("无法根据长度 %d 推断校验算法", len(entry.Sum))
+		}
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(entry.Name))
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("文件不存在")
+	}
+
+	for _, algorithm := range algorithms {
+		sum, err := FileChecksum(path, algorithm)
+		if err != nil {
+			return err
+		}
+		if sum == entry.Sum {
+			return nil
+		}
+	}
+	return fmt.Errorf("校验和不匹配")
+}
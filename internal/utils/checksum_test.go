@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+func TestFileChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abc")
+	if err := os.WriteFile(path, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"sha256":  "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"sha512":  "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		"blake2b": "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+	}
+	for algorithm, want := range tests {
+		if got, err := FileChecksum(path, algorithm); err != nil || got != want {
+			t.Errorf("FileChecksum(%s) = %s, %v, 期望 %s", algorithm, got, err, want)
+		}
+	}
+	if _, err := FileChecksum(path, "md5"); err == nil || !strings.Contains(err.Error(), "blake2b、sha256、sha512") {
+		t.Errorf("不支持的算法期望列出可用的算法, got %v", err)
+	}
+	if _, err := FileChecksum(filepath.Join(t.TempDir(), "missing"), "sha256"); err == nil {
+		t.Error("文件不存在时期望返回错误")
+	}
+}
+
+// checksumArtifacts 校验和测试使用的构建产物, 未按文件名排序
+var checksumArtifacts = []struct{ name, content string }{
+	{"myapp_windows_amd64.zip", "windows"},
+	{"pkg/myapp_1.0.0_amd64.deb", "deb"},
+	{"myapp_linux_amd64.tar.gz", "linux"},
+}
+
+// writeChecksumArtifacts 在输出目录中写入构建产物, 返回与 checksumArtifacts 顺序一致的产物路径
+func writeChecksumArtifacts(t *testing.T, dir string) []string {
+	t.Helper()
+	var paths []string
+	for _, a := range checksumArtifacts {
+		path := filepath.Join(dir, filepath.FromSlash(a.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(a.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestWriteChecksumFile(t *testing.T) {
+	dir := t.TempDir()
+	artifacts := writeChecksumArtifacts(t, dir)
+
+	path, err := WriteChecksumFile(dir, types.DefaultChecksumFile, "sha256", artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按文件名排序, 子目录使用 / 分隔
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	want := sum("linux") + "  myapp_linux_amd64.tar.gz\n" +
+		sum("windows") + "  myapp_windows_amd64.zip\n" +
+		sum("deb") + "  pkg/myapp_1.0.0_amd64.deb\n"
+	if string(content) != want {
+		t.Errorf("校验和文件内容:\n%s\n期望:\n%s", content, want)
+	}
+
+	// 与 coreutils 的校验工具兼容
+	for algorithm, tool := range map[string]string{"sha256": "sha256sum", "sha512": "sha512sum", "blake2b": "b2sum"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			continue
+		}
+		if _, err := WriteChecksumFile(dir, "SUMS", algorithm, artifacts); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(tool, "-c", "SUMS")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("%s -c 校验失败: %v\n%s", tool, err, out)
+		}
+	}
+
+	outside := filepath.Join(t.TempDir(), "other")
+	if err := os.WriteFile(outside, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteChecksumFile(dir, "SUMS", "sha256", []string{outside}); err == nil || !strings.Contains(err.Error(), "不在输出目录") {
+		t.Errorf("产物不在输出目录中时期望返回错误, got %v", err)
+	}
+}
+
+func TestParseChecksumFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	content := "# 由其他工具生成\r\n" +
+		"BA7816BF8F01CFEA414140DE5DAE2223B00361A396177A9CB410FF61F20015AD  abc.txt\r\n" +
+		"\n" +
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad *bin/my app\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ParseChecksumFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	want := []types.ChecksumEntry{{Sum: sum, Name: "abc.txt"}, {Sum: sum, Name: "bin/my app"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("校验和条目 = %+v\n期望 %+v", entries, want)
+	}
+
+	for _, line := range []string{"not-hex  file", "abcd", "abcd  ", "abc  file"} {
+		if err := os.WriteFile(path, []byte("# ok\n"+line+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseChecksumFile(path); err == nil || !strings.Contains(err.Error(), "SHA256SUMS:2:") {
+			t.Errorf("行 %q 格式无效时期望返回带行号的错误, got %v", line, err)
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	dir := t.TempDir()
+	artifacts := writeChecksumArtifacts(t, dir)
+	deb := artifacts[1]
+
+	for _, algorithm := range ChecksumAlgorithms() {
+		sum, err := FileChecksum(deb, algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := types.ChecksumEntry{Sum: sum, Name: "pkg/myapp_1.0.0_amd64.deb"}
+		if err := VerifyChecksum(dir, entry, algorithm); err != nil {
+			t.Errorf("%s: %v", algorithm, err)
+		}
+		// 未指定算法时根据长度推断, sha512 和 blake2b 任一匹配即通过
+		if err := VerifyChecksum(dir, entry, ""); err != nil {
+			t.Errorf("%s: 推断算法后校验失败: %v", algorithm, err)
+		}
+	}
+
+	sum, _ := FileChecksum(deb, "sha256")
+	tests := []struct {
+		entry     types.ChecksumEntry
+		algorithm string
+		want      string
+	}{
+		{types.ChecksumEntry{Sum: sum, Name: "missing.zip"}, "", "文件不存在"},
+		{types.ChecksumEntry{Sum: strings.Repeat("0", 64), Name: "pkg/myapp_1.0.0_amd64.deb"}, "", "校验和不匹配"},
+		{types.ChecksumEntry{Sum: sum, Name: "pkg/myapp_1.0.0_amd64.deb"}, "sha512", "校验和不匹配"},
+		{types.ChecksumEntry{Sum: "abcd", Name: "pkg/myapp_1.0.0_amd64.deb"}, "", "无法根据长度 4 推断校验算法"},
+	}
+	for _, tt := range tests {
+		if err := VerifyChecksum(dir, tt.entry, tt.algorithm); err == nil || err.Error() != tt.want {
+			t.Errorf("VerifyChecksum(%+v, %q) = %v, 期望 %s", tt.entry, tt.algorithm, err, tt.want)
+		}
+	}
+}
@@ -18,6 +18,10 @@ import (
 //
 // 返回:
 //   - 解析后的Config结构体指针和可能的错误
+//
+// 注意:
+//   - 继承链上的每个文件都会严格校验未知的配置项, 语义校验由ValidateConfig完成
+//   - 旧版本格式的配置文件在加载时自动迁移并打印提示
 func LoadConfig(filePath string) (*types.GobConfig, error) {
 	// 创建默认配置结构体
 	config := GetDefaultConfig()
@@ -29,27 +33,32 @@ func LoadConfig(filePath string) (*types.GobConfig, error) {
 		return nil, fmt.Errorf("file '%s' is a directory", filePath)
 	}
 
-	// 读取文件内容
-	content, err := os.ReadFile(filePath)
+	// 加载配置文件及其继承的基础配置
+	layers, err := LoadConfigLayers(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	// 解析TOML内容到配置结构体
+	// 提示已自动迁移的旧版本配置
+	for _, warning := range layers.Warnings {
+		CL.Yellowf("%s %s\n", types.PrintPrefix, warning)
+	}
+	if len(layers.Warnings) > 0 {
+		CL.Yellowf("%s 配置文件使用了旧版本的格式, 可运行 'gob config migrate' 升级\n", types.PrintPrefix)
+	}
+
+	// 合并后的文档重新序列化, 再解析到配置结构体
+	content, err := toml.Marshal(layers.Doc)
+	if err != nil {
+		return nil, fmt.Errorf("序列化合并后的配置失败: %w", err)
+	}
 	if err := toml.Unmarshal(content, config); err != nil {
-		// 提取TOML解析错误的详细位置信息
-		if decodeErr, ok := err.(*toml.DecodeError); ok {
-			row, col := decodeErr.Position() // 获取行和列信息
-			return nil, fmt.Errorf("TOML解析错误 (行 %d, 列 %d): %v", row, col, decodeErr.Error())
-		}
 		return nil, fmt.Errorf("加载配置文件 %s 失败: %w", filePath, err)
 	}
 
-	// 解析timeout标志设置内部使用的timeoutDuration字段
-	var parseErr error
-	config.Build.TimeoutDuration, parseErr = time.ParseDuration(config.Build.Compiler.Timeout)
-	if parseErr != nil {
-		return nil, fmt.Errorf("解析timeout标志失败: %w", parseErr)
+	// 解析timeout标志设置内部使用的timeoutDuration字段, 格式错误由ValidateConfig报告
+	if timeout, parseErr := time.ParseDuration(config.Build.Compiler.Timeout); parseErr == nil {
+		config.Build.TimeoutDuration = timeout
 	}
 
 	return config, nil
@@ -67,20 +76,34 @@ func GetDefaultConfig() *types.GobConfig {
 	}
 
 	return &types.GobConfig{
+		Version: types.CurrentConfigVersion, // 当前的配置文件格式版本
 		Build: types.BuildConfig{
 			Output: types.OutputConfig{
 				Dir:    types.DefaultOutputDir, // 默认输出目录
 				Name:   types.DefaultAppName,   // 默认应用名称
 				Simple: false,                  // 默认不使用简单模式
 				Zip:    false,                  // 默认不压缩输出
+				Archive: types.ArchiveConfig{
+					Enabled:         false,                               // 默认不打包归档文件
+					Format:          types.DefaultArchiveFormat,          // 默认归档格式
+					FormatOverrides: map[string]string{"windows": "zip"}, // windows默认使用zip
+					Name:            "",                                  // 默认使用输出文件名
+					WrapInDirectory: false,                               // 默认不使用顶层目录
+					Files:           []string{},                          // 默认不打包附加文件
+				},
 			},
 			Source: types.SourceConfig{
 				MainFile:  types.DefaultMainFile, // 默认入口文件
 				UseVendor: false,                 // 默认不使用vendor目录
 			},
 			Git: types.GitConfig{
-				Inject:  false,                   // 默认不注入Git信息
-				Ldflags: types.DefaultGitLDFlags, // 默认Git链接器标志
+				Inject:    false,                   // 默认不注入Git信息
+				Ldflags:   types.DefaultGitLDFlags, // 默认Git链接器标志
+				TagPrefix: "",                      // 默认不使用标签前缀
+				Snapshot:  "",                      // 默认使用 git describe 的输出作为快照版本
+				Sources:   types.DefaultGitSources, // 默认依次尝试环境变量、git命令、.git目录和版本文件
+				Mode:      types.DefaultGitMode,    // 默认通过链接器标志注入
+				Package:   "",                      // 默认使用入口文件所在的目录
 			},
 			Compiler: types.CompilerConfig{
 				EnableCgo: false,                // 默认不启用CGO
@@ -94,6 +117,9 @@ func GetDefaultConfig() *types.GobConfig {
 				CurrentPlatformOnly: false,                  // 默认不仅编译当前平台
 				Platforms:           types.DefaultPlatforms, // 默认支持的目标平台
 				Architectures:       types.DefaultArchs,     // 默认支持的目标架构
+				Targets:             []string{},             // 默认不使用目标平台模式
+				Jobs:                0,                      // 默认使用CPU核心数作为并发数
+				FailFast:            false,                  // 默认不在首个失败时取消其余目标
 			},
 			Command: types.CommandConfig{
 				Build: types.GoBuildCmd.Cmds, // 默认编译命令模板
@@ -112,6 +138,19 @@ func GetDefaultConfig() *types.GobConfig {
 				Commands:    []string{}, // 默认空命令列表
 				ExitOnError: true,       // 默认遇到错误时退出
 			},
+			Checksum: types.ChecksumConfig{
+				Enabled:   false,                          // 默认不生成校验和文件
+				Algorithm: types.DefaultChecksumAlgorithm, // 默认校验算法
+				File:      types.DefaultChecksumFile,      // 默认校验和文件名
+			},
+			SBOM: types.SBOMConfig{
+				Enabled: false,            // 默认不生成SBOM
+				Formats: []string{"spdx"}, // 默认生成SPDX格式
+			},
+			Manifest: types.ManifestConfig{
+				Enabled: false,                     // 默认不生成产物清单
+				File:    types.DefaultManifestFile, // 默认产物清单文件名
+			},
 			TimeoutDuration: timeoutDuration, // 默认编译超时时间
 		},
 		Install: types.InstallConfig{
@@ -119,11 +158,86 @@ func GetDefaultConfig() *types.GobConfig {
 			InstallPath: "$GOPATH/bin", // 默认安装路径
 			Force:       false,         // 默认不强制安装（覆盖已存在文件）
 		},
+		Package: types.PackageConfig{
+			Linux: types.LinuxPackageConfig{
+				Enabled:    false,                              // 默认不生成安装包
+				Formats:    []string{"deb", "rpm"},             // 默认生成deb和rpm
+				Release:    types.DefaultPackageRelease,        // 默认打包修订号
+				Section:    "utils",                            // 默认deb分类
+				Priority:   "optional",                         // 默认deb优先级
+				BinDir:     types.DefaultPackageBinDir,         // 默认可执行文件安装目录
+				Depends:    []string{},                         // 默认无依赖
+				Recommends: []string{},                         // 默认无推荐
+				Conflicts:  []string{},                         // 默认无冲突
+				Provides:   []string{},                         // 默认不提供虚拟包
+				Replaces:   []string{},                         // 默认不替代其他包
+				Overrides:  map[string]types.PackageOverride{}, // 默认不按格式覆盖
+				Files:      []types.PackageFile{},              // 默认无附加文件
+			},
+		},
+		Container: types.ContainerConfig{
+			Enabled:    false,                                         // 默认不构建容器镜像
+			Format:     types.DefaultContainerFormat,                  // 默认输出OCI镜像布局
+			Tags:       []string{"{{semver .Git.Version}}", "latest"}, // 默认使用版本号和latest标签
+			BinDir:     types.DefaultContainerBinDir,                  // 默认可执行文件目录
+			Entrypoint: []string{},                                    // 默认以可执行文件为入口
+			Cmd:        []string{},                                    // 默认无参数
+			Env:        map[string]string{},                           // 默认无环境变量
+			Labels:     map[string]string{},                           // 默认仅写入Git标签
+		},
+		Sign: types.SignConfig{
+			Enabled:       false,                          // 默认不签名
+			Method:        types.DefaultSignMethod,        // 默认使用minisign格式
+			Checksum:      true,                           // 默认为校验和文件签名
+			Artifacts:     false,                          // 默认不为每个产物签名
+			PassphraseEnv: types.DefaultSignPassphraseEnv, // 默认口令环境变量
+			Prehash:       true,                           // 默认使用预哈希签名
+		},
+		Bump: types.BumpConfig{
+			VersionFile: "",        // 默认不写入版本文件
+			VersionVar:  "Version", // 默认替换Version常量
+		},
+		Changelog: types.ChangelogConfig{
+			Enabled:  false,                          // 默认构建时不生成变更日志
+			File:     types.DefaultChangelogFile,     // 默认变更日志文件名
+			Sections: types.DefaultChangelogSections, // 默认包含不兼容变更、新功能、问题修复和性能优化
+			Exclude:  []string{},                     // 默认不排除提交
+			Template: "",                             // 默认使用内置模板
+		},
+		Publish: types.PublishConfig{
+			Manifests: types.PublishManifestsConfig{
+				Enabled: false,                                   // 默认不生成包管理器清单
+				Formats: []string{"homebrew", "scoop", "winget"}, // 默认生成全部格式
+				Winget: types.WingetConfig{
+					Locale: types.DefaultWingetLocale, // 默认语言区域
+				},
+			},
+			Release: types.ReleaseConfig{
+				Provider: types.DefaultReleaseProvider, // 默认发布到github
+				Retries:  types.DefaultReleaseRetries,  // 默认重试次数
+			},
+			S3: types.S3UploadConfig{
+				Enabled:         false,                       // 默认不上传到对象存储
+				Region:          types.DefaultS3Region,       // 默认区域
+				Path:            types.DefaultS3Path,         // 默认按版本存放
+				PathStyle:       true,                        // 默认使用路径形式的地址, 兼容MinIO等自建服务
+				PartSize:        types.DefaultS3PartSize,     // 默认分片大小
+				AccessKeyEnv:    "AWS_ACCESS_KEY_ID",         // 默认访问密钥ID环境变量
+				SecretKeyEnv:    "AWS_SECRET_ACCESS_KEY",     // 默认访问密钥环境变量
+				SessionTokenEnv: "AWS_SESSION_TOKEN",         // 默认会话令牌环境变量
+				Retries:         types.DefaultReleaseRetries, // 默认重试次数
+			},
+			HTTP: types.HTTPUploadConfig{
+				Enabled: false,                       // 默认不通过HTTP上传
+				Headers: map[string]string{},         // 默认无附加请求头
+				Retries: types.DefaultReleaseRetries, // 默认重试次数
+			},
+		},
 		Env: make(map[string]string), // 默认环境变量
 	}
 }
 
-// GenerateDefaultConfig 生成默认的gob.toml配置文件
+// GenerateDefaultConfig 生成默认的gob.toml配置文件及其JSON Schema
 //
 // 参数值:
 //   - f: 是否强制覆盖已存在的配置文件
@@ -156,8 +270,8 @@ func GenerateDefaultConfig(f bool) error {
 	}
 
 	// 写入文件
-	// 先写入配置文件注释
-	comment := []byte(types.ConfigFileHeaderComment)
+	// 先写入Schema指令和配置文件注释
+	comment := []byte(types.SchemaDirective + types.ConfigFileHeaderComment)
 	if _, err := file.Write(comment); err != nil {
 		return fmt.Errorf("写入注释失败: %v", err)
 	}
@@ -172,7 +286,13 @@ func GenerateDefaultConfig(f bool) error {
 		return fmt.Errorf("写入示例配置失败: %v", err)
 	}
 
-	return nil
+	// 写入示例的变量配置
+	if _, err := file.Write([]byte(types.VarsExample)); err != nil {
+		return fmt.Errorf("写入示例配置失败: %v", err)
+	}
+
+	// 在配置文件旁生成JSON Schema, 供编辑器补全和校验
+	return WriteSchemaFile(".")
 }
 
 // FindConfigByPrefix 根据前缀查找配置文件
@@ -0,0 +1,826 @@
+package utils
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// OCI镜像布局中的固定文件名及注解
+const (
+	ociLayoutFile       = "oci-layout"                            // 布局版本文件
+	ociIndexFile        = "index.json"                            // 布局的顶层索引
+	ociBlobsDir         = "blobs"                                 // 内容目录
+	annotationRefName   = "org.opencontainers.image.ref.name"     // 镜像引用名称
+	annotationImageName = "io.containerd.image.name"              // containerd 和 docker load 使用的完整镜像名
+	dockerManifestFile  = "manifest.json"                         // docker save 格式的清单
+	ociLayoutContent    = `{"imageLayoutVersion":"1.0.0"}` + "\n" // 布局版本文件的内容
+)
+
+var (
+	// containerFormats 支持的容器镜像输出格式
+	containerFormats = []string{"docker", "oci"}
+
+	// imageNameRegexp 匹配镜像名称, 如 app、team/app、registry.example.com:5000/team/app
+	imageNameRegexp = regexp.MustCompile(`^(?:[a-zA-Z0-9.-]+(?::[0-9]+)?/)?[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+	// imageTagRegexp 匹配镜像标签
+	imageTagRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// imageConfig OCI镜像配置, config 保留基础镜像中未识别的字段
+type imageConfig struct {
+	Created      string           `json:"created,omitempty"`
+	Architecture string           `json:"architecture"`
+	Variant      string           `json:"variant,omitempty"`
+	OS           string           `json:"os"`
+	Config       map[string]any   `json:"config"`
+	RootFS       imageRootFS      `json:"rootfs"`
+	History      []map[string]any `json:"history,omitempty"`
+}
+
+// imageRootFS 镜像配置中的层列表
+type imageRootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// imageManifest OCI镜像清单
+type imageManifest struct {
+	SchemaVersion int                   `json:"schemaVersion"`
+	MediaType     string                `json:"mediaType,omitempty"`
+	Config        types.OCIDescriptor   `json:"config"`
+	Layers        []types.OCIDescriptor `json:"layers"`
+}
+
+// imageIndex OCI镜像索引
+type imageIndex struct {
+	SchemaVersion int                   `json:"schemaVersion"`
+	MediaType     string                `json:"mediaType,omitempty"`
+	Manifests     []types.OCIDescriptor `json:"manifests"`
+}
+
+// dockerManifestEntry docker save 格式清单中的单个镜像
+type dockerManifestEntry struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// ContainerFormats 返回支持的容器镜像输出格式
+//
+// 返回值:
+//   - []string: 排序后的输出格式列表
+func ContainerFormats() []string {
+	return slices.Clone(containerFormats)
+}
+
+// ValidateImageReference 校验镜像名称和标签
+//
+// 参数:
+//   - name: 镜像名称, 如 registry.example.com/team/app
+//   - tags: 镜像标签
+//
+// 返回值:
+//   - error: 名称或任一标签格式无效时返回错误
+func ValidateImageReference(name string, tags []string) error {
+	if !imageNameRegexp.MatchString(name) {
+		return fmt.Errorf("镜像名称格式无效 %q, 路径部分只能包含小写字母、数字和分隔符 . _ -", name)
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("镜像标签不能为空")
+	}
+	for _, tag := range tags {
+		if !imageTagRegexp.MatchString(tag) {
+			return fmt.Errorf("镜像标签格式无效 %q, 只能包含字母、数字和 . _ -, 且不超过128个字符", tag)
+		}
+	}
+	return nil
+}
+
+// PrepareImageLayout 初始化用于写入镜像内容的OCI布局目录
+//
+// 参数:
+//   - dir: 布局目录
+//
+// 返回值:
+//   - error: 清理或创建失败时返回错误
+//
+// 注意:
+//   - 删除上次构建留下的 blobs 目录和 index.json, 目录中的其他文件保持不变
+func PrepareImageLayout(dir string) error {
+	for _, name := range []string{ociBlobsDir, ociIndexFile} {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("清理镜像目录 %s 失败: %w", dir, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ociBlobsDir, "sha256"), 0755); err != nil {
+		return fmt.Errorf("创建镜像目录 %s 失败: %w", dir, err)
+	}
+	return os.WriteFile(filepath.Join(dir, ociLayoutFile), []byte(ociLayoutContent), 0644)
+}
+
+// BuildContainerImage 为单个linux目标构建镜像, 并将镜像内容写入OCI布局目录
+//
+// 参数:
+//   - cfg: 容器镜像配置
+//   - spec: 目标架构、可执行文件及渲染后的标签和命令
+//
+// 返回值:
+//   - *types.OCIDescriptor: 镜像清单的描述符, 包含平台信息
+//   - error: 读取基础镜像或写入失败时返回错误
+//
+// 注意:
+//   - 可执行文件单独作为一层追加在基础镜像的层之后
+func BuildContainerImage(cfg types.ContainerConfig, spec types.ImageSpec) (*types.OCIDescriptor, error) {
+	config := &imageConfig{Config: map[string]any{}}
+	var layers []types.OCIDescriptor
+	if cfg.Base != "" {
+		base, err := openImageArchive(cfg.Base)
+		if err != nil {
+			return nil, err
+		}
+		if config, layers, err = base.load(spec.LayoutDir, spec.Arch, spec.Variant); err != nil {
+			return nil, err
+		}
+	}
+	// 基础镜像缺少历史记录时不追加, 避免历史条目与层数不一致
+	addHistory := len(config.History) > 0 || len(layers) == 0
+
+	// 可执行文件层
+	layer, diffID, err := binaryLayer(cfg.BinDir, spec)
+	if err != nil {
+		return nil, fmt.Errorf("生成可执行文件层失败: %w", err)
+	}
+	layerDesc, err := writeBlob(spec.LayoutDir, types.MediaTypeOCILayerGz, layer)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, layerDesc)
+
+	// 镜像配置, 在基础镜像的配置上覆盖
+	created := spec.Created.UTC().Format("2006-01-02T15:04:05Z")
+	config.Created = created
+	config.OS = "linux"
+	config.Architecture = spec.Arch
+	config.Variant = spec.Variant
+	config.RootFS.Type = "layers"
+	config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+	applyImageConfig(config.Config, cfg, spec)
+	if addHistory {
+		config.History = append(config.History, map[string]any{
+			"created":    created,
+			"created_by": "gob",
+			"comment":    "add " + path.Join(cfg.BinDir, spec.BinName),
+		})
+	}
+
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("序列化镜像配置失败: %w", err)
+	}
+	configDesc, err := writeBlob(spec.LayoutDir, types.MediaTypeOCIConfig, configData)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := json.Marshal(imageManifest{
+		SchemaVersion: 2,
+		MediaType:     types.MediaTypeOCIManifest,
+		Config:        configDesc,
+		Layers:        layers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化镜像清单失败: %w", err)
+	}
+	desc, err := writeBlob(spec.LayoutDir, types.MediaTypeOCIManifest, manifest)
+	if err != nil {
+		return nil, err
+	}
+	desc.Platform = &types.OCIPlatform{OS: "linux", Architecture: spec.Arch, Variant: spec.Variant}
+	return &desc, nil
+}
+
+// applyImageConfig 将配置中的入口命令、环境变量和标签写入镜像运行配置
+func applyImageConfig(c map[string]any, cfg types.ContainerConfig, spec types.ImageSpec) {
+	entrypoint := spec.Entrypoint
+	if len(entrypoint) == 0 {
+		entrypoint = []string{path.Join(cfg.BinDir, spec.BinName)}
+	}
+	c["Entrypoint"] = entrypoint
+
+	// 与Dockerfile一致, 设置入口命令后不再沿用基础镜像的默认参数
+	delete(c, "Cmd")
+	if len(spec.Cmd) > 0 {
+		c["Cmd"] = spec.Cmd
+	}
+	if cfg.Workdir != "" {
+		c["WorkingDir"] = cfg.Workdir
+	}
+	if cfg.User != "" {
+		c["User"] = cfg.User
+	}
+
+	// 环境变量按名称覆盖, 保持基础镜像中的顺序
+	var env []string
+	if base, ok := c["Env"].([]any); ok {
+		for _, e := range base {
+			if s, ok := e.(string); ok {
+				name, _, _ := strings.Cut(s, "=")
+				if _, override := cfg.Env[name]; !override {
+					env = append(env, s)
+				}
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Env)) {
+		env = append(env, name+"="+cfg.Env[name])
+	}
+	if len(env) > 0 {
+		c["Env"] = env
+	}
+
+	labels := map[string]any{}
+	if base, ok := c["Labels"].(map[string]any); ok {
+		maps.Copy(labels, base)
+	}
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+	if len(labels) > 0 {
+		c["Labels"] = labels
+	}
+}
+
+// binaryLayer 生成包含可执行文件的层
+//
+// 参数:
+//   - binDir: 可执行文件在镜像中的目录
+//   - spec: 镜像信息
+//
+// 返回值:
+//   - []byte: gzip压缩的层内容
+//   - string: 未压缩层的摘要(diff_id)
+//   - error: 读取可执行文件失败时返回错误
+func binaryLayer(binDir string, spec types.ImageSpec) ([]byte, string, error) {
+	info, err := os.Stat(spec.Binary)
+	if err != nil {
+		return nil, "", err
+	}
+	file := packageFile{src: spec.Binary, dst: path.Join(binDir, spec.BinName), mode: 0o755, size: info.Size()}
+	mtime := spec.Created.Truncate(time.Second)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	diff := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(gw, diff))
+	for _, dir := range packageDirs([]packageFile{file}) {
+		if err := writeTarDir(tw, tar.FormatPAX, strings.TrimPrefix(dir, "/"), mtime); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writeTarFile(tw, tar.FormatPAX, strings.TrimPrefix(file.dst, "/"), file, mtime, nil); err != nil {
+		return nil, "", err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, "", err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "sha256:" + hex.EncodeToString(diff.Sum(nil)), nil
+}
+
+// writeBlob 将内容写入OCI布局的blobs目录
+//
+// 参数:
+//   - dir: 布局目录
+//   - mediaType: 内容的媒体类型
+//   - data: 内容
+//
+// 返回值:
+//   - types.OCIDescriptor: 内容的描述符
+//   - error: 写入失败时返回错误
+func writeBlob(dir, mediaType string, data []byte) (types.OCIDescriptor, error) {
+	digest, size, err := writeBlobFrom(dir, bytes.NewReader(data))
+	return types.OCIDescriptor{MediaType: mediaType, Digest: digest, Size: size}, err
+}
+
+// writeBlobFrom 将reader中的内容写入OCI布局的blobs目录
+//
+// 参数:
+//   - dir: 布局目录
+//   - r: 内容
+//
+// 返回值:
+//   - string: 内容摘要, 如 sha256:...
+//   - int64: 内容大小
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - 内容先写入临时文件再按摘要重命名, 多个目标并发写入相同内容时互不影响
+func writeBlobFrom(dir string, r io.Reader) (string, int64, error) {
+	blobs := filepath.Join(dir, ociBlobsDir, "sha256")
+	tmp, err := os.CreateTemp(blobs, ".tmp-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("写入镜像内容失败: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("写入镜像内容失败: %w", err)
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err := os.Rename(tmp.Name(), filepath.Join(blobs, sum)); err != nil {
+		return "", 0, fmt.Errorf("写入镜像内容失败: %w", err)
+	}
+	return "sha256:" + sum, size, nil
+}
+
+// blobPath 返回摘要对应的blob在布局中的相对路径
+func blobPath(digest string) string {
+	algorithm, sum, _ := strings.Cut(digest, ":")
+	return path.Join(ociBlobsDir, algorithm, sum)
+}
+
+// readBlob 读取布局目录中的blob
+func readBlob(dir string, desc types.OCIDescriptor) ([]byte, error) {
+	return os.ReadFile(filepath.Join(dir, filepath.FromSlash(blobPath(desc.Digest))))
+}
+
+// WriteContainerImages 将各目标的镜像组合为最终输出
+//
+// 参数:
+//   - format: 输出格式, oci 或 docker
+//   - dir: 已写入镜像内容的布局目录
+//   - name: 镜像名称
+//   - tags: 镜像标签
+//   - manifests: 各目标镜像清单的描述符
+//
+// 返回值:
+//   - []string: 生成的文件路径, oci 格式为布局目录, docker 格式为每个架构的tar文件
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - oci 格式的每个标签指向同一个包含所有架构的镜像索引
+//   - docker 格式写出后删除布局目录中的临时内容
+func WriteContainerImages(format, dir, name string, tags []string, manifests []types.OCIDescriptor) ([]string, error) {
+	if format == "docker" {
+		return writeDockerArchives(dir, name, tags, manifests)
+	}
+
+	data, err := json.Marshal(imageIndex{SchemaVersion: 2, MediaType: types.MediaTypeOCIIndex, Manifests: manifests})
+	if err != nil {
+		return nil, fmt.Errorf("序列化镜像索引失败: %w", err)
+	}
+	index, err := writeBlob(dir, types.MediaTypeOCIIndex, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeLayoutIndex(dir, tagDescriptors(index, name, tags)); err != nil {
+		return nil, err
+	}
+	return []string{dir}, nil
+}
+
+// tagDescriptors 为每个标签生成带有镜像名称注解的描述符
+func tagDescriptors(desc types.OCIDescriptor, name string, tags []string) []types.OCIDescriptor {
+	descs := make([]types.OCIDescriptor, 0, len(tags))
+	for _, tag := range tags {
+		d := desc
+		d.Annotations = map[string]string{
+			annotationRefName:   tag,
+			annotationImageName: name + ":" + tag,
+		}
+		descs = append(descs, d)
+	}
+	return descs
+}
+
+// writeLayoutIndex 写入布局目录的 index.json
+func writeLayoutIndex(dir string, manifests []types.OCIDescriptor) error {
+	data, err := json.MarshalIndent(imageIndex{SchemaVersion: 2, MediaType: types.MediaTypeOCIIndex, Manifests: manifests}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化镜像索引失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ociIndexFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入镜像索引失败: %w", err)
+	}
+	return nil
+}
+
+// writeDockerArchives 为每个架构生成可通过 docker load 导入的tar文件
+//
+// 参数:
+//   - dir: 已写入镜像内容的布局目录
+//   - name: 镜像名称
+//   - tags: 镜像标签
+//   - manifests: 各目标镜像清单的描述符
+//
+// 返回值:
+//   - []string: tar文件路径, 文件名为 镜像名称_linux_架构.tar
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - tar中同时包含 manifest.json 和OCI布局, 兼容旧版docker和containerd镜像存储
+func writeDockerArchives(dir, name string, tags []string, manifests []types.OCIDescriptor) ([]string, error) {
+	repoTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		repoTags = append(repoTags, name+":"+tag)
+	}
+
+	var paths []string
+	for _, desc := range manifests {
+		data, err := readBlob(dir, desc)
+		if err != nil {
+			return nil, fmt.Errorf("读取镜像清单失败: %w", err)
+		}
+		var manifest imageManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("解析镜像清单失败: %w", err)
+		}
+
+		entry := dockerManifestEntry{Config: blobPath(manifest.Config.Digest), RepoTags: repoTags}
+		blobs := []types.OCIDescriptor{desc, manifest.Config}
+		for _, layer := range manifest.Layers {
+			entry.Layers = append(entry.Layers, blobPath(layer.Digest))
+			blobs = append(blobs, layer)
+		}
+		dockerManifest, err := json.Marshal([]dockerManifestEntry{entry})
+		if err != nil {
+			return nil, fmt.Errorf("序列化镜像清单失败: %w", err)
+		}
+		index, err := json.Marshal(imageIndex{SchemaVersion: 2, MediaType: types.MediaTypeOCIIndex, Manifests: tagDescriptors(desc, name, tags)})
+		if err != nil {
+			return nil, fmt.Errorf("序列化镜像索引失败: %w", err)
+		}
+
+		platform := desc.Platform.OS + "_" + desc.Platform.Architecture + desc.Platform.Variant
+		file := filepath.Join(dir, path.Base(name)+"_"+platform+".tar")
+		if err := writeDockerArchive(file, dir, blobs, dockerManifest, index); err != nil {
+			return nil, err
+		}
+		paths = append(paths, file)
+	}
+
+	// 删除布局目录中的临时内容, 只保留tar文件
+	for _, name := range []string{ociBlobsDir, ociIndexFile, ociLayoutFile} {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return nil, fmt.Errorf("清理镜像目录 %s 失败: %w", dir, err)
+		}
+	}
+	return paths, nil
+}
+
+// writeDockerArchive 写入单个架构的 docker load tar文件
+func writeDockerArchive(file, dir string, blobs []types.OCIDescriptor, dockerManifest, index []byte) (err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("创建镜像文件 %s 失败: %w", file, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("写入镜像文件 %s 失败: %w", file, closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(file)
+		}
+	}()
+
+	tw := tar.NewWriter(f)
+	for _, entry := range []struct {
+		name    string
+		content []byte
+	}{
+		{ociLayoutFile, []byte(ociLayoutContent)},
+		{ociIndexFile, index},
+		{dockerManifestFile, dockerManifest},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entry.content); err != nil {
+			return err
+		}
+	}
+
+	written := map[string]bool{}
+	for _, blob := range blobs {
+		name := blobPath(blob.Digest)
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: blob.Size}); err != nil {
+			return err
+		}
+		if err := copyFile(tw, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// imageArchive 表示基础镜像tar文件, 支持 docker save 和OCI归档格式, 可以是gzip压缩的
+type imageArchive struct {
+	path  string
+	files map[string][]byte // 元数据文件的内容, 不读取超过 maxImageMetadataSize 的文件
+	links map[string]string // 符号链接到目标的映射
+}
+
+// maxImageMetadataSize 读入内存的元数据文件的最大大小
+const maxImageMetadataSize = 4 << 20
+
+// openImageArchive 打开基础镜像tar文件并读取其中的元数据
+//
+// 参数:
+//   - file: 基础镜像tar文件路径
+//
+// 返回值:
+//   - *imageArchive: 基础镜像
+//   - error: 读取失败时返回错误
+func openImageArchive(file string) (*imageArchive, error) {
+	a := &imageArchive{path: file, files: map[string][]byte{}, links: map[string]string{}}
+	err := a.walk(func(name string, header *tar.Header, r io.Reader) error {
+		switch {
+		case header.Typeflag == tar.TypeSymlink:
+			a.links[name] = path.Join(path.Dir(name), header.Linkname)
+		case header.Typeflag == tar.TypeReg && header.Size <= maxImageMetadataSize:
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			a.files[name] = data
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取基础镜像 %s 失败: %w", file, err)
+	}
+	return a, nil
+}
+
+// walk 依次读取tar中的每个条目
+func (a *imageArchive) walk(fn func(name string, header *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gr.Close() }()
+		r = gr
+	} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(path.Clean(strings.TrimPrefix(header.Name, "./")), header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// file 返回元数据文件的内容, 自动跟随符号链接
+func (a *imageArchive) file(name string) ([]byte, bool) {
+	for range 8 {
+		if data, ok := a.files[name]; ok {
+			return data, true
+		}
+		target, ok := a.links[name]
+		if !ok {
+			return nil, false
+		}
+		name = target
+	}
+	return nil, false
+}
+
+// load 查找指定架构的镜像, 并将其层写入布局目录
+//
+// 参数:
+//   - dir: 布局目录
+//   - arch: 目标架构(GOARCH)
+//   - variant: 架构变体, 为空时不比较
+//
+// 返回值:
+//   - *imageConfig: 基础镜像的配置
+//   - []types.OCIDescriptor: 写入布局目录的层描述符
+//   - error: 不包含该架构或读取失败时返回错误
+func (a *imageArchive) load(dir, arch, variant string) (*imageConfig, []types.OCIDescriptor, error) {
+	var (
+		config *imageConfig
+		layers []string
+		err    error
+	)
+	if index, ok := a.file(ociIndexFile); ok {
+		config, layers, err = a.findOCIImage(index, arch, variant)
+	} else if manifest, ok := a.file(dockerManifestFile); ok {
+		config, layers, err = a.findDockerImage(manifest, arch, variant)
+	} else {
+		return nil, nil, fmt.Errorf("基础镜像 %s 既不是 docker save 也不是OCI归档格式", a.path)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取基础镜像 %s 失败: %w", a.path, err)
+	}
+	if config == nil {
+		return nil, nil, fmt.Errorf("基础镜像 %s 不包含 linux/%s%s 平台", a.path, arch, variant)
+	}
+	if config.Config == nil {
+		config.Config = map[string]any{}
+	}
+	if len(config.RootFS.DiffIDs) != len(layers) {
+		return nil, nil, fmt.Errorf("基础镜像 %s 的层数与配置不一致", a.path)
+	}
+
+	descs, err := a.copyLayers(dir, layers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("复制基础镜像 %s 的层失败: %w", a.path, err)
+	}
+	return config, descs, nil
+}
+
+// matchPlatform 判断镜像配置是否适用于指定架构
+func matchPlatform(goos, arch, variant, wantArch, wantVariant string) bool {
+	return goos == "linux" && arch == wantArch && (variant == "" || wantVariant == "" || variant == wantVariant)
+}
+
+// findOCIImage 在OCI索引中查找指定架构的镜像, 支持嵌套索引
+func (a *imageArchive) findOCIImage(data []byte, arch, variant string) (*imageConfig, []string, error) {
+	var index imageIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, nil, fmt.Errorf("解析镜像索引失败: %w", err)
+	}
+	for _, desc := range index.Manifests {
+		if p := desc.Platform; p != nil && !matchPlatform(p.OS, p.Architecture, p.Variant, arch, variant) {
+			continue
+		}
+		blob, ok := a.file(blobPath(desc.Digest))
+		if !ok {
+			return nil, nil, fmt.Errorf("缺少内容 %s", desc.Digest)
+		}
+
+		if strings.HasSuffix(desc.MediaType, "index.v1+json") || strings.HasSuffix(desc.MediaType, "manifest.list.v2+json") {
+			config, layers, err := a.findOCIImage(blob, arch, variant)
+			if err != nil || config != nil {
+				return config, layers, err
+			}
+			continue
+		}
+
+		var manifest imageManifest
+		if err := json.Unmarshal(blob, &manifest); err != nil {
+			return nil, nil, fmt.Errorf("解析镜像清单失败: %w", err)
+		}
+		config, err := a.imageConfig(blobPath(manifest.Config.Digest), arch, variant)
+		if err != nil || config == nil {
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		layers := make([]string, 0, len(manifest.Layers))
+		for _, layer := range manifest.Layers {
+			layers = append(layers, blobPath(layer.Digest))
+		}
+		return config, layers, nil
+	}
+	return nil, nil, nil
+}
+
+// findDockerImage 在 docker save 的清单中查找指定架构的镜像
+func (a *imageArchive) findDockerImage(data []byte, arch, variant string) (*imageConfig, []string, error) {
+	var entries []dockerManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, fmt.Errorf("解析 %s 失败: %w", dockerManifestFile, err)
+	}
+	for _, entry := range entries {
+		config, err := a.imageConfig(path.Clean(entry.Config), arch, variant)
+		if err != nil {
+			return nil, nil, err
+		}
+		if config != nil {
+			layers := make([]string, 0, len(entry.Layers))
+			for _, layer := range entry.Layers {
+				layers = append(layers, path.Clean(layer))
+			}
+			return config, layers, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// imageConfig 读取镜像配置, 架构不匹配时返回nil
+func (a *imageArchive) imageConfig(name, arch, variant string) (*imageConfig, error) {
+	data, ok := a.file(name)
+	if !ok {
+		return nil, fmt.Errorf("缺少镜像配置 %s", name)
+	}
+	var config imageConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析镜像配置 %s 失败: %w", name, err)
+	}
+	if !matchPlatform(config.OS, config.Architecture, config.Variant, arch, variant) {
+		return nil, nil
+	}
+	return &config, nil
+}
+
+// copyLayers 将基础镜像的层写入布局目录
+//
+// 参数:
+//   - dir: 布局目录
+//   - layers: 层在tar中的路径
+//
+// 返回值:
+//   - []types.OCIDescriptor: 与layers顺序一致的层描述符, 媒体类型根据内容是否压缩确定
+//   - error: 缺少层或写入失败时返回错误
+func (a *imageArchive) copyLayers(dir string, layers []string) ([]types.OCIDescriptor, error) {
+	// 将符号链接解析为实际文件
+	wanted := map[string]*types.OCIDescriptor{}
+	resolved := make([]string, len(layers))
+	for i, name := range layers {
+		for range 8 {
+			target, ok := a.links[name]
+			if !ok {
+				break
+			}
+			name = target
+		}
+		resolved[i] = name
+		wanted[name] = nil
+	}
+
+	err := a.walk(func(name string, header *tar.Header, r io.Reader) error {
+		if desc, ok := wanted[name]; !ok || desc != nil || header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		br := bufio.NewReader(r)
+		mediaType := types.MediaTypeOCILayer
+		if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+			mediaType = types.MediaTypeOCILayerGz
+		}
+		digest, size, err := writeBlobFrom(dir, br)
+		if err != nil {
+			return err
+		}
+		// OCI归档中的层以摘要命名, 校验内容是否完整
+		if strings.HasPrefix(name, ociBlobsDir+"/") && name != blobPath(digest) {
+			return fmt.Errorf("层 %s 的摘要不匹配", name)
+		}
+		wanted[name] = &types.OCIDescriptor{MediaType: mediaType, Digest: digest, Size: size}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	descs := make([]types.OCIDescriptor, 0, len(layers))
+	for i, name := range resolved {
+		if wanted[name] == nil {
+			return nil, fmt.Errorf("缺少层 %s", layers[i])
+		}
+		descs = append(descs, *wanted[name])
+	}
+	return descs, nil
+}
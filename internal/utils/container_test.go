@@ -0,0 +1,492 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+func TestValidateImageReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		wantErr bool
+	}{
+		{"myapp", []string{"latest"}, false},
+		{"team/my-app", []string{"1.2.0", "v1.2.0-rc.1"}, false},
+		{"registry.example.com:5000/team/my_app", []string{"latest"}, false},
+		{"MyApp", []string{"latest"}, true},
+		{"team//app", []string{"latest"}, true},
+		{"app-", []string{"latest"}, true},
+		{"myapp", nil, true},
+		{"myapp", []string{"-latest"}, true},
+		{"myapp", []string{"1.2.0+build"}, true},
+		{"myapp", []string{strings.Repeat("a", 129)}, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateImageReference(tt.name, tt.tags); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateImageReference(%q, %q) = %v, 期望错误: %v", tt.name, tt.tags, err, tt.wantErr)
+		}
+	}
+}
+
+// testImageCreated 测试镜像的创建时间
+var testImageCreated = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// buildTestImage 在布局目录中为指定架构构建镜像, 可执行文件内容为 "binary-架构"
+func buildTestImage(t *testing.T, cfg types.ContainerConfig, dir, arch, variant string) types.OCIDescriptor {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "myapp")
+	if err := os.WriteFile(binary, []byte("binary-"+arch), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := BuildContainerImage(cfg, types.ImageSpec{
+		Arch:      arch,
+		Variant:   variant,
+		Binary:    binary,
+		BinName:   "myapp",
+		LayoutDir: dir,
+		Labels:    map[string]string{"org.opencontainers.image.version": "v1.2.0"},
+		Created:   testImageCreated,
+		Cmd:       []string{"serve"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *desc
+}
+
+// newTestContainerConfig 创建基于空镜像的容器镜像配置
+func newTestContainerConfig() types.ContainerConfig {
+	cfg := GetDefaultConfig().Container
+	cfg.Env = map[string]string{"B": "2", "A": "1"}
+	cfg.Workdir = "/data"
+	cfg.User = "65532:65532"
+	return cfg
+}
+
+// readTestBlob 读取布局目录中的blob, 并校验内容与摘要和大小一致
+func readTestBlob(t *testing.T, dir string, desc types.OCIDescriptor) []byte {
+	t.Helper()
+	data, err := readBlob(dir, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if digest := "sha256:" + hex.EncodeToString(sum[:]); digest != desc.Digest || int64(len(data)) != desc.Size {
+		t.Fatalf("blob %s 的内容与描述符不一致: %s, %d 字节", desc.Digest, digest, len(data))
+	}
+	return data
+}
+
+// unmarshalTestBlob 读取并解析布局目录中的JSON blob
+func unmarshalTestBlob(t *testing.T, dir string, desc types.OCIDescriptor, v any) {
+	t.Helper()
+	if err := json.Unmarshal(readTestBlob(t, dir, desc), v); err != nil {
+		t.Fatalf("解析 %s 失败: %v", desc.MediaType, err)
+	}
+}
+
+func TestBuildContainerImage(t *testing.T) {
+	dir := t.TempDir()
+	if err := PrepareImageLayout(dir); err != nil {
+		t.Fatal(err)
+	}
+	desc := buildTestImage(t, newTestContainerConfig(), dir, "arm", "v7")
+
+	if desc.MediaType != types.MediaTypeOCIManifest || !reflect.DeepEqual(desc.Platform, &types.OCIPlatform{OS: "linux", Architecture: "arm", Variant: "v7"}) {
+		t.Errorf("镜像清单描述符 = %+v", desc)
+	}
+	var manifest imageManifest
+	unmarshalTestBlob(t, dir, desc, &manifest)
+	if manifest.SchemaVersion != 2 || manifest.Config.MediaType != types.MediaTypeOCIConfig || len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != types.MediaTypeOCILayerGz {
+		t.Fatalf("镜像清单 = %+v", manifest)
+	}
+
+	// 可执行文件层包含各级目录和可执行文件, diff_id 为未压缩层的摘要
+	layer := readTestBlob(t, dir, manifest.Layers[0])
+	gr, err := gzip.NewReader(bytes.NewReader(layer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tarData bytes.Buffer
+	tr := tar.NewReader(io.TeeReader(gr, &tarData))
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "usr/local/bin/myapp" && (hdr.Mode != 0o755 || !hdr.ModTime.Equal(testImageCreated)) {
+			t.Errorf("可执行文件的权限或修改时间不正确: %o, %v", hdr.Mode, hdr.ModTime)
+		}
+	}
+	if want := []string{"usr/", "usr/local/", "usr/local/bin/", "usr/local/bin/myapp"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("层中的文件 = %v, 期望 %v", names, want)
+	}
+	if files := readTarGz(t, layer); string(files["usr/local/bin/myapp"]) != "binary-arm" {
+		t.Errorf("层中可执行文件的内容 = %q", files["usr/local/bin/myapp"])
+	}
+
+	var config imageConfig
+	unmarshalTestBlob(t, dir, manifest.Config, &config)
+	sum := sha256.Sum256(tarData.Bytes())
+	if want := []string{"sha256:" + hex.EncodeToString(sum[:])}; !reflect.DeepEqual(config.RootFS.DiffIDs, want) || config.RootFS.Type != "layers" {
+		t.Errorf("rootfs = %+v, 期望 diff_ids %v", config.RootFS, want)
+	}
+	if config.OS != "linux" || config.Architecture != "arm" || config.Variant != "v7" || config.Created != "2025-01-02T03:04:05Z" {
+		t.Errorf("镜像平台或创建时间不正确: %+v", config)
+	}
+	wantConfig := map[string]any{
+		"Entrypoint": []any{"/usr/local/bin/myapp"},
+		"Cmd":        []any{"serve"},
+		"Env":        []any{"A=1", "B=2"},
+		"WorkingDir": "/data",
+		"User":       "65532:65532",
+		"Labels":     map[string]any{"org.opencontainers.image.version": "v1.2.0"},
+	}
+	if !reflect.DeepEqual(config.Config, wantConfig) {
+		t.Errorf("运行配置 = %v\n期望 %v", config.Config, wantConfig)
+	}
+	if len(config.History) != 1 || config.History[0]["comment"] != "add /usr/local/bin/myapp" {
+		t.Errorf("历史记录 = %v", config.History)
+	}
+}
+
+func TestPrepareImageLayout(t *testing.T) {
+	dir := t.TempDir()
+	if err := PrepareImageLayout(dir); err != nil {
+		t.Fatal(err)
+	}
+	buildTestImage(t, newTestContainerConfig(), dir, "amd64", "")
+	if err := os.WriteFile(filepath.Join(dir, ociIndexFile), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 再次初始化时清理上次构建的内容, 保留其他文件
+	if err := PrepareImageLayout(dir); err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := os.ReadDir(filepath.Join(dir, ociBlobsDir, "sha256"))
+	if err != nil || len(blobs) != 0 {
+		t.Errorf("blobs目录应被清空, got %v, %v", blobs, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ociIndexFile)); !os.IsNotExist(err) {
+		t.Error("index.json 应被删除")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README")); err != nil {
+		t.Errorf("其他文件应保留: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, ociLayoutFile)); err != nil || string(data) != ociLayoutContent {
+		t.Errorf("oci-layout = %q, %v", data, err)
+	}
+}
+
+func TestWriteContainerImagesOCI(t *testing.T) {
+	dir := t.TempDir()
+	if err := PrepareImageLayout(dir); err != nil {
+		t.Fatal(err)
+	}
+	cfg := newTestContainerConfig()
+	manifests := []types.OCIDescriptor{
+		buildTestImage(t, cfg, dir, "amd64", ""),
+		buildTestImage(t, cfg, dir, "arm64", ""),
+	}
+
+	paths, err := WriteContainerImages("oci", dir, "registry.example.com/team/myapp", []string{"1.2.0", "latest"}, manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, []string{dir}) {
+		t.Errorf("oci 格式应返回布局目录, got %v", paths)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ociIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var layout imageIndex
+	if err := json.Unmarshal(data, &layout); err != nil {
+		t.Fatal(err)
+	}
+	if len(layout.Manifests) != 2 {
+		t.Fatalf("每个标签应对应一个描述符, got %+v", layout.Manifests)
+	}
+	for i, tag := range []string{"1.2.0", "latest"} {
+		desc := layout.Manifests[i]
+		want := map[string]string{annotationRefName: tag, annotationImageName: "registry.example.com/team/myapp:" + tag}
+		if !reflect.DeepEqual(desc.Annotations, want) || desc.Digest != layout.Manifests[0].Digest || desc.MediaType != types.MediaTypeOCIIndex {
+			t.Errorf("标签 %s 的描述符 = %+v", tag, desc)
+		}
+	}
+
+	// 所有标签指向同一个包含两个架构的镜像索引
+	var index imageIndex
+	unmarshalTestBlob(t, dir, layout.Manifests[0], &index)
+	if !reflect.DeepEqual(index.Manifests, manifests) {
+		t.Errorf("镜像索引 = %+v\n期望 %+v", index.Manifests, manifests)
+	}
+}
+
+// testDockerArchive 在临时目录中构建指定架构的镜像, 并以 docker 格式输出
+func testDockerArchive(t *testing.T, cfg types.ContainerConfig, arches ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := PrepareImageLayout(dir); err != nil {
+		t.Fatal(err)
+	}
+	var manifests []types.OCIDescriptor
+	for _, arch := range arches {
+		manifests = append(manifests, buildTestImage(t, cfg, dir, arch, ""))
+	}
+	paths, err := WriteContainerImages("docker", dir, "registry.example.com/team/myapp", []string{"1.2.0", "latest"}, manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestWriteContainerImagesDocker(t *testing.T) {
+	paths := testDockerArchive(t, newTestContainerConfig(), "amd64", "arm64")
+	dir := filepath.Dir(paths[0])
+	want := []string{filepath.Join(dir, "myapp_linux_amd64.tar"), filepath.Join(dir, "myapp_linux_arm64.tar")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("tar文件 = %v, 期望 %v", paths, want)
+	}
+
+	// 布局目录中只保留tar文件
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("布局目录中的临时内容应被删除, got %v", entries)
+	}
+
+	f, err := os.Open(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	files := readTarFiles(t, f)
+
+	var dockerManifest []dockerManifestEntry
+	if err := json.Unmarshal(files[dockerManifestFile], &dockerManifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(dockerManifest) != 1 || !reflect.DeepEqual(dockerManifest[0].RepoTags, []string{"registry.example.com/team/myapp:1.2.0", "registry.example.com/team/myapp:latest"}) {
+		t.Fatalf("manifest.json = %+v", dockerManifest)
+	}
+	entry := dockerManifest[0]
+	var config imageConfig
+	if err := json.Unmarshal(files[entry.Config], &config); err != nil {
+		t.Fatalf("读取镜像配置 %s 失败: %v", entry.Config, err)
+	}
+	if config.Architecture != "arm64" || len(entry.Layers) != 1 {
+		t.Errorf("镜像配置或层不正确: %+v, %v", config, entry.Layers)
+	}
+	if layer := readTarGz(t, files[entry.Layers[0]]); string(layer["usr/local/bin/myapp"]) != "binary-arm64" {
+		t.Errorf("层中可执行文件的内容 = %q", layer["usr/local/bin/myapp"])
+	}
+
+	// 同时包含OCI布局, 标签注解指向该架构的镜像清单
+	var index imageIndex
+	if err := json.Unmarshal(files[ociIndexFile], &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 2 || index.Manifests[0].Platform.Architecture != "arm64" || index.Manifests[1].Annotations[annotationImageName] != "registry.example.com/team/myapp:latest" {
+		t.Errorf("index.json = %+v", index)
+	}
+	if _, ok := files[blobPath(index.Manifests[0].Digest)]; !ok || string(files[ociLayoutFile]) != ociLayoutContent {
+		t.Error("tar中应包含镜像清单和 oci-layout")
+	}
+}
+
+// rewriteTestTar 复制tar文件, 跳过 skip 中的条目, 并按需gzip压缩
+func rewriteTestTar(t *testing.T, src string, skip []string, compress bool) string {
+	t.Helper()
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = in.Close() }()
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	gw := gzip.NewWriter(&buf)
+	if compress {
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	tr := tar.NewReader(in)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(skip, hdr.Name) {
+			continue
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "base.tar")
+	if err := os.WriteFile(dst, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestBuildContainerImageWithBase(t *testing.T) {
+	baseCfg := GetDefaultConfig().Container
+	baseCfg.BinDir = "/bin"
+	baseCfg.Env = map[string]string{"PATH": "/bin", "A": "base"}
+	baseCfg.User = "nobody"
+	bases := testDockerArchive(t, baseCfg, "amd64", "arm64")
+
+	tests := []struct {
+		name string
+		base string
+	}{
+		{"OCI布局", bases[1]},
+		{"docker save", rewriteTestTar(t, bases[1], []string{ociIndexFile, ociLayoutFile}, false)},
+		{"gzip压缩", rewriteTestTar(t, bases[1], nil, true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig().Container
+			cfg.Base = tt.base
+			cfg.Env = map[string]string{"A": "app"}
+			dir := t.TempDir()
+			if err := PrepareImageLayout(dir); err != nil {
+				t.Fatal(err)
+			}
+			desc := buildTestImage(t, cfg, dir, "arm64", "")
+
+			var manifest imageManifest
+			unmarshalTestBlob(t, dir, desc, &manifest)
+			var config imageConfig
+			unmarshalTestBlob(t, dir, manifest.Config, &config)
+			if len(manifest.Layers) != 2 || len(config.RootFS.DiffIDs) != 2 || len(config.History) != 2 {
+				t.Fatalf("可执行文件层应追加在基础镜像的层之后: %+v, %+v", manifest.Layers, config)
+			}
+			if base := readTarGz(t, readTestBlob(t, dir, manifest.Layers[0])); string(base["bin/myapp"]) != "binary-arm64" {
+				t.Errorf("基础镜像的层内容不正确: %v", base)
+			}
+
+			// 覆盖同名环境变量, 沿用基础镜像的用户, 不沿用默认参数
+			want := map[string]any{
+				"Entrypoint": []any{"/usr/local/bin/myapp"},
+				"Cmd":        []any{"serve"},
+				"Env":        []any{"PATH=/bin", "A=app"},
+				"User":       "nobody",
+				"Labels":     map[string]any{"org.opencontainers.image.version": "v1.2.0"},
+			}
+			if !reflect.DeepEqual(config.Config, want) {
+				t.Errorf("运行配置 = %v\n期望 %v", config.Config, want)
+			}
+		})
+	}
+}
+
+func TestBuildContainerImageBaseErrors(t *testing.T) {
+	base := testDockerArchive(t, GetDefaultConfig().Container, "amd64")[0]
+	notImage := filepath.Join(t.TempDir(), "not-image.tar")
+	if err := os.WriteFile(notImage, tarOf(t, map[string]string{"hello.txt": "hi"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		base string
+		arch string
+		want string
+	}{
+		{"架构不匹配", base, "arm64", "不包含 linux/arm64 平台"},
+		{"缺少层", rewriteTestTar(t, base, layerNames(t, base), false), "amd64", "缺少层"},
+		{"不是镜像", notImage, "amd64", "既不是 docker save 也不是OCI归档格式"},
+		{"文件不存在", filepath.Join(t.TempDir(), "missing.tar"), "amd64", "读取基础镜像"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig().Container
+			cfg.Base = tt.base
+			dir := t.TempDir()
+			if err := PrepareImageLayout(dir); err != nil {
+				t.Fatal(err)
+			}
+			binary := filepath.Join(t.TempDir(), "myapp")
+			if err := os.WriteFile(binary, nil, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			_, err := BuildContainerImage(cfg, types.ImageSpec{Arch: tt.arch, Binary: binary, BinName: "myapp", LayoutDir: dir})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("期望错误包含 %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// layerNames 返回 docker save 格式tar中所有层的路径
+func layerNames(t *testing.T, file string) []string {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var entries []dockerManifestEntry
+	if err := json.Unmarshal(readTarFiles(t, f)[dockerManifestFile], &entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries[0].Layers
+}
+
+// tarOf 生成包含指定文件的tar
+func tarOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
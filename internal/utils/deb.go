@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// debScripts deb维护脚本在control.tar中的文件名
+var debScripts = []struct{ key, name string }{
+	{"pre_install", "preinst"},
+	{"post_install", "postinst"},
+	{"pre_remove", "prerm"},
+	{"post_remove", "postrm"},
+}
+
+// writeDeb 生成deb安装包
+//
+// 参数:
+//   - p: 安装包内容
+//   - dir: 输出目录
+//
+// 返回值:
+//   - string: 安装包路径, 文件名为 包名_版本-修订号_架构.deb
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - deb为ar归档, 依次包含 debian-binary、control.tar.gz 和 data.tar.gz
+func writeDeb(p *linuxPackage, dir string) (string, error) {
+	data, md5sums, err := debData(p)
+	if err != nil {
+		return "", err
+	}
+	control, err := debControl(p, md5sums)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s_%s-%s_%s.deb", p.name, p.version, p.release, p.arch)
+	return writePackageFile(dir, name, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "!<arch>\n"); err != nil {
+			return err
+		}
+		for _, member := range []struct {
+			name    string
+			content []byte
+		}{
+			{"debian-binary", []byte("2.0\n")},
+			{"control.tar.gz", control},
+			{"data.tar.gz", data},
+		} {
+			if err := writeArMember(w, member.name, p.mtime, member.content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// debData 生成data.tar.gz并计算每个文件的md5
+//
+// 参数:
+//   - p: 安装包内容
+//
+// 返回值:
+//   - []byte: data.tar.gz的内容
+//   - []byte: md5sums文件的内容
+//   - error: 读取或写入失败时返回错误
+func debData(p *linuxPackage) ([]byte, []byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for _, dir := range packageDirs(p.files) {
+		if err := writeTarDir(tw, tar.FormatGNU, "."+dir, p.mtime); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var md5sums strings.Builder
+	for _, f := range p.files {
+		sum, err := fileDigest(f.src, md5.New())
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(&md5sums, "%s  %s\n", sum, strings.TrimPrefix(f.dst, "/"))
+
+		if err := writeTarFile(tw, tar.FormatGNU, "."+f.dst, f, p.mtime, nil); err != nil {
+			return nil, nil, fmt.Errorf("打包 %s 失败: %w", f.src, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), []byte(md5sums.String()), nil
+}
+
+// debControl 生成control.tar.gz
+//
+// 参数:
+//   - p: 安装包内容
+//   - md5sums: md5sums文件的内容
+//
+// 返回值:
+//   - []byte: control.tar.gz的内容
+//   - error: 写入失败时返回错误
+func debControl(p *linuxPackage, md5sums []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	if err := writeTarBytes(tw, tar.FormatGNU, "./control", 0o644, p.mtime, []byte(debControlFile(p))); err != nil {
+		return nil, err
+	}
+	if err := writeTarBytes(tw, tar.FormatGNU, "./md5sums", 0o644, p.mtime, md5sums); err != nil {
+		return nil, err
+	}
+
+	// 配置文件在升级时保留用户的修改
+	var conffiles strings.Builder
+	for _, f := range p.files {
+		if f.config {
+			conffiles.WriteString(f.dst + "\n")
+		}
+	}
+	if conffiles.Len() > 0 {
+		if err := writeTarBytes(tw, tar.FormatGNU, "./conffiles", 0o644, p.mtime, []byte(conffiles.String())); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, script := range debScripts {
+		if content, ok := p.scripts[script.key]; ok {
+			if err := writeTarBytes(tw, tar.FormatGNU, "./"+script.name, 0o755, p.mtime, []byte(content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// debControlFile 生成control文件的内容
+func debControlFile(p *linuxPackage) string {
+	var sb strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", name, value)
+		}
+	}
+
+	field("Package", p.name)
+	field("Version", p.version+"-"+p.release)
+	field("Section", p.cfg.Section)
+	field("Priority", p.cfg.Priority)
+	field("Architecture", p.arch)
+	field("Maintainer", p.cfg.Maintainer)
+	field("Installed-Size", fmt.Sprint((p.installedSize()+1023)/1024))
+	field("Depends", debRelations(p.depends))
+	field("Recommends", debRelations(p.recommends))
+	field("Conflicts", debRelations(p.conflicts))
+	field("Provides", debRelations(p.provides))
+	field("Replaces", debRelations(p.replaces))
+	field("Homepage", p.cfg.Homepage)
+
+	// 描述的第一行为摘要, 其余行以空格缩进, 空行写为 " ."
+	lines := strings.Split(strings.TrimSpace(p.cfg.Description), "\n")
+	sb.WriteString("Description: " + p.summary() + "\n")
+	for _, line := range lines[1:] {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			line = "."
+		}
+		sb.WriteString(" " + line + "\n")
+	}
+	return sb.String()
+}
+
+// debRelations 将包关系格式化为deb格式, 如 libc6 (>= 2.17), git
+func debRelations(deps []dependency) string {
+	parts := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if dep.op == "" {
+			parts = append(parts, dep.name)
+			continue
+		}
+		op := dep.op
+		switch op {
+		case "<":
+			op = "<<"
+		case ">":
+			op = ">>"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s %s)", dep.name, op, dep.version))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeArMember 写入ar归档中的一个成员
+//
+// 参数:
+//   - w: 输出
+//   - name: 成员名称, 不超过16个字符
+//   - mtime: 修改时间
+//   - content: 成员内容
+//
+// 返回值:
+//   - error: 写入失败时返回错误
+func writeArMember(w io.Writer, name string, mtime time.Time, content []byte) error {
+	header := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, mtime.Unix(), 0, 0, "100644", len(content))
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(content); err != nil {
+		return err
+	}
+	// 成员按2字节对齐
+	if len(content)%2 == 1 {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// LoadConfigLayers 加载配置文件及其通过 extends 继承的所有基础配置, 并按继承顺序合并
+//
+// 参数:
+//   - filePath: 配置文件路径
+//
+// 返回值:
+//   - *types.ConfigLayers: 合并后的配置文档、每个值的来源文件及继承链上的文件
+//   - error: 读取、解析失败、存在未知配置项或继承存在循环时返回错误
+//
+// 注意:
+//   - extends 的相对路径相对于声明它的文件所在目录
+//   - 表按键深度合并, 子配置中的键覆盖基础配置中的同名键, [env] 和 [vars] 同样按键合并
+//   - 数组(包括 [[build.target.matrix]] 等表数组)作为整体覆盖, 不做拼接
+//   - 继承链上的每个文件都会严格校验, 所有文件中的未知配置项和类型错误一次性返回
+//   - 每个文件在合并前单独升级为当前的配置格式
+func LoadConfigLayers(filePath string) (*types.ConfigLayers, error) {
+	var problems []error
+	layers, err := loadConfigLayer(filePath, nil, &problems)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return layers, nil
+}
+
+// loadConfigLayer 递归加载单个配置文件及其基础配置
+//
+// 参数:
+//   - filePath: 配置文件路径
+//   - chain: 当前继承链上的文件绝对路径, 用于检测循环继承
+//   - problems: 严格校验发现的问题, 会被原地追加
+//
+// 返回值:
+//   - *types.ConfigLayers: 合并后的配置
+//   - error: 无法继续加载时返回错误
+func loadConfigLayer(filePath string, chain []string, problems *[]error) (*types.ConfigLayers, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 的绝对路径失败: %w", filePath, err)
+	}
+
+	// 检测循环继承
+	if slices.Contains(chain, absPath) {
+		names := make([]string, 0, len(chain)+1)
+		for _, p := range append(chain, absPath) {
+			names = append(names, filepath.Base(p))
+		}
+		return nil, fmt.Errorf("配置文件继承存在循环: %s", strings.Join(names, " -> "))
+	}
+	chain = append(chain, absPath)
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件 %s 失败: %w", filePath, err)
+	}
+
+	doc := make(map[string]any)
+	if err := toml.Unmarshal(content, &doc); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, col := decodeErr.Position()
+			return nil, fmt.Errorf("%s:%d:%d: TOML解析错误: %v", filePath, row, col, decodeErr.Error())
+		}
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", filePath, err)
+	}
+
+	// 升级旧版本的配置格式
+	migration, err := migrateConfigDoc(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	// 严格校验未知的配置项和类型错误, 已迁移的旧配置项不报告
+	*problems = append(*problems, checkConfigKeys(filePath, content, migration.Moved)...)
+
+	// 取出 extends, 不参与合并
+	rawExtends, hasExtends := doc["extends"]
+	delete(doc, "extends")
+
+	own := &types.ConfigLayers{
+		Doc:     doc,
+		Origins: make(map[string]string),
+		Files:   []string{filePath},
+	}
+	for _, warning := range migration.Warnings {
+		own.Warnings = append(own.Warnings, fmt.Sprintf("%s: %s", filePath, warning))
+	}
+	collectOrigins(doc, "", filePath, own.Origins)
+	if !hasExtends {
+		return own, nil
+	}
+
+	base, ok := rawExtends.(string)
+	if !ok || strings.TrimSpace(base) == "" {
+		return nil, fmt.Errorf("%s: extends 必须是非空的文件路径字符串", filePath)
+	}
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(filepath.Dir(filePath), base)
+	}
+
+	merged, err := loadConfigLayer(base, chain, problems)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeConfigTables(merged.Doc, own.Doc, "", merged.Origins, own.Origins)
+	merged.Files = append(merged.Files, filePath)
+	merged.Warnings = append(merged.Warnings, own.Warnings...)
+	return merged, nil
+}
+
+// mergeConfigTables 将src深度合并到dst中, 并同步更新来源信息
+//
+// 参数:
+//   - dst: 目标表, 会被原地修改
+//   - src: 覆盖的表
+//   - prefix: 当前表的配置路径
+//   - dstOrigins: 目标表的来源信息, 会被原地修改
+//   - srcOrigins: 覆盖表的来源信息
+func mergeConfigTables(dst, src map[string]any, prefix string, dstOrigins, srcOrigins map[string]string) {
+	for _, key := range slices.Sorted(maps.Keys(src)) {
+		path := joinConfigPath(prefix, key)
+		srcTable, srcIsTable := src[key].(map[string]any)
+		dstTable, dstIsTable := dst[key].(map[string]any)
+
+		// 两侧都是表时递归合并
+		if srcIsTable && dstIsTable {
+			mergeConfigTables(dstTable, srcTable, path, dstOrigins, srcOrigins)
+			continue
+		}
+
+		// 其余情况整体覆盖, 清除被覆盖的值的来源
+		for p := range dstOrigins {
+			if p == path || strings.HasPrefix(p, path+".") {
+				delete(dstOrigins, p)
+			}
+		}
+		for p, file := range srcOrigins {
+			if p == path || strings.HasPrefix(p, path+".") {
+				dstOrigins[p] = file
+			}
+		}
+		dst[key] = src[key]
+	}
+}
+
+// collectOrigins 记录文档中每个值的来源文件
+//
+// 参数:
+//   - doc: 配置文档
+//   - prefix: 当前表的配置路径
+//   - file: 来源文件
+//   - origins: 来源信息, 会被原地修改
+//
+// 注意:
+//   - 数组作为整体记录, 不展开其中的元素
+func collectOrigins(doc map[string]any, prefix, file string, origins map[string]string) {
+	for key, value := range doc {
+		path := joinConfigPath(prefix, key)
+		if table, ok := value.(map[string]any); ok {
+			collectOrigins(table, path, file, origins)
+			continue
+		}
+		origins[path] = file
+	}
+}
+
+// joinConfigPath 拼接以点分隔的配置路径
+func joinConfigPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// ConfigOrigin 查找配置路径对应的来源文件
+//
+// 参数:
+//   - origins: 来源信息
+//   - path: 以点分隔的配置路径
+//
+// 返回值:
+//   - string: 来源文件, 未在任何文件中设置时返回空字符串
+//
+// 注意:
+//   - 数组中的表(如 build.target.matrix.env)按最近的已记录上级路径查找
+func ConfigOrigin(origins map[string]string, path string) string {
+	for {
+		if file, ok := origins[path]; ok {
+			return file
+		}
+		idx := strings.LastIndex(path, ".")
+		if idx < 0 {
+			return ""
+		}
+		path = path[:idx]
+	}
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeConfigTables(t *testing.T) {
+	dst := map[string]any{
+		"build": map[string]any{
+			"output": map[string]any{"dir": "output", "name": "base"},
+			"target": map[string]any{"platforms": []any{"linux", "windows"}, "batch": false},
+		},
+		"env":  map[string]any{"GOFLAGS": "-mod=mod", "GOAMD64": "v1"},
+		"vars": map[string]any{"channel": map[string]any{"env": "CHANNEL", "default": "stable"}},
+	}
+	src := map[string]any{
+		"build": map[string]any{
+			"output": map[string]any{"name": "child"},
+			"target": map[string]any{"platforms": []any{"darwin"}},
+		},
+		"env":  map[string]any{"GOAMD64": "v3", "CGO_ENABLED": "1"},
+		"vars": map[string]any{"channel": "beta"},
+	}
+	dstOrigins := make(map[string]string)
+	srcOrigins := make(map[string]string)
+	collectOrigins(dst, "", "base.toml", dstOrigins)
+	collectOrigins(src, "", "child.toml", srcOrigins)
+
+	mergeConfigTables(dst, src, "", dstOrigins, srcOrigins)
+
+	want := map[string]any{
+		"build": map[string]any{
+			"output": map[string]any{"dir": "output", "name": "child"},
+			"target": map[string]any{"platforms": []any{"darwin"}, "batch": false},
+		},
+		"env":  map[string]any{"GOFLAGS": "-mod=mod", "GOAMD64": "v3", "CGO_ENABLED": "1"},
+		"vars": map[string]any{"channel": "beta"},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("合并结果不正确:\n got %v\nwant %v", dst, want)
+	}
+
+	wantOrigins := map[string]string{
+		"build.output.dir":       "base.toml",
+		"build.output.name":      "child.toml",
+		"build.target.platforms": "child.toml",
+		"build.target.batch":     "base.toml",
+		"env.GOFLAGS":            "base.toml",
+		"env.GOAMD64":            "child.toml",
+		"env.CGO_ENABLED":        "child.toml",
+		"vars.channel":           "child.toml",
+	}
+	if !reflect.DeepEqual(dstOrigins, wantOrigins) {
+		t.Errorf("来源信息不正确:\n got %v\nwant %v", dstOrigins, wantOrigins)
+	}
+}
+
+func TestMergeConfigTablesReplacesTableArrays(t *testing.T) {
+	dst := map[string]any{"build": map[string]any{"target": map[string]any{
+		"matrix": []any{map[string]any{"goos": "linux"}, map[string]any{"goos": "windows"}},
+	}}}
+	src := map[string]any{"build": map[string]any{"target": map[string]any{
+		"matrix": []any{map[string]any{"goos": "darwin"}},
+	}}}
+	mergeConfigTables(dst, src, "", map[string]string{}, map[string]string{})
+
+	matrix := dst["build"].(map[string]any)["target"].(map[string]any)["matrix"].([]any)
+	if len(matrix) != 1 || matrix[0].(map[string]any)["goos"] != "darwin" {
+		t.Errorf("表数组应整体覆盖, got %v", matrix)
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"common/root.toml": "version = 2\n[build.output]\ndir = 'dist'\nname = 'root'\n[env]\nA = '1'\n",
+		"base.toml":        "version = 2\nextends = 'common/root.toml'\n[build.output]\nname = 'base'\n[env]\nB = '2'\n",
+		"release.toml":     "version = 2\nextends = 'base.toml'\n[build.target]\nbatch = true\n[env]\nA = '3'\n",
+	})
+	release := filepath.Join(dir, "release.toml")
+
+	layers, err := LoadConfigLayers(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := layers.Doc["extends"]; ok {
+		t.Error("extends 不应参与合并")
+	}
+	output := layers.Doc["build"].(map[string]any)["output"].(map[string]any)
+	if output["dir"] != "dist" || output["name"] != "base" {
+		t.Errorf("[build.output] 合并结果不正确: %v", output)
+	}
+	env := layers.Doc["env"].(map[string]any)
+	if env["A"] != "3" || env["B"] != "2" {
+		t.Errorf("[env] 合并结果不正确: %v", env)
+	}
+	if len(layers.Files) != 3 || layers.Files[2] != release {
+		t.Errorf("继承链应按基础配置到当前文件的顺序排列, got %v", layers.Files)
+	}
+	if origin := ConfigOrigin(layers.Origins, "build.output.dir"); !strings.HasSuffix(origin, "root.toml") {
+		t.Errorf("build.output.dir 的来源应为 root.toml, got %q", origin)
+	}
+}
+
+func TestLoadConfigLayersCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "继承自身",
+			files: map[string]string{"a.toml": "version = 2\nextends = 'a.toml'\n"},
+			want:  "a.toml -> a.toml",
+		},
+		{
+			name: "间接循环",
+			files: map[string]string{
+				"a.toml":     "version = 2\nextends = 'b.toml'\n",
+				"b.toml":     "version = 2\nextends = 'sub/c.toml'\n",
+				"sub/c.toml": "version = 2\nextends = '../a.toml'\n",
+			},
+			want: "a.toml -> b.toml -> c.toml -> a.toml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			_, err := LoadConfigLayers(filepath.Join(dir, "a.toml"))
+			if err == nil || !strings.Contains(err.Error(), "循环") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("期望循环继承错误 %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigLayersErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"extends 不是字符串", map[string]string{"a.toml": "version = 2\nextends = 1\n"}, "extends 必须是非空的文件路径字符串"},
+		{"extends 为空", map[string]string{"a.toml": "version = 2\nextends = ' '\n"}, "extends 必须是非空的文件路径字符串"},
+		{"基础配置不存在", map[string]string{"a.toml": "version = 2\nextends = 'missing.toml'\n"}, "missing.toml"},
+		{"基础配置中的未知配置项", map[string]string{
+			"a.toml":    "version = 2\nextends = 'base.toml'\n",
+			"base.toml": "version = 2\n[build.output]\nsimple_name = true\n",
+		}, "base.toml:3:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			_, err := LoadConfigLayers(filepath.Join(dir, "a.toml"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("期望包含 %q 的错误, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// GitModes 返回支持的Git信息注入方式
+//
+// 返回值:
+//   - []string: 注入方式
+func GitModes() []string {
+	return []string{types.DefaultGitMode, types.GitModeGenerate}
+}
+
+// GitVersionPackageDir 返回 generate 模式下生成版本文件的包目录
+//
+// 参数:
+//   - c: 配置对象
+//
+// 返回值:
+//   - string: build.git.package, 为空时为入口文件所在的目录, 入口为目录时为该目录
+func GitVersionPackageDir(c *types.GobConfig) string {
+	if c.Build.Git.Package != "" {
+		return filepath.Clean(c.Build.Git.Package)
+	}
+	mainFile := c.Build.Source.MainFile
+	if info, err := os.Stat(mainFile); err == nil && info.IsDir() {
+		return filepath.Clean(mainFile)
+	}
+	return filepath.Dir(mainFile)
+}
+
+// MainFileArg 返回编译命令中 {{mainFile}} 的值
+//
+// 参数:
+//   - c: 配置对象
+//
+// 返回值:
+//   - string: 入口文件; generate 模式下版本文件生成在入口文件所在的包中时为该包的目录, 如 ./cmd/app
+//
+// 注意:
+//   - 编译单个入口文件时不会包含同目录下的其他文件, 因此需要编译整个包目录
+func MainFileArg(c *types.GobConfig) string {
+	mainFile := c.Build.Source.MainFile
+	if !c.Build.Git.Inject || c.Build.Git.Mode != types.GitModeGenerate || filepath.Ext(mainFile) != ".go" {
+		return mainFile
+	}
+	dir := filepath.Dir(mainFile)
+	if GitVersionPackageDir(c) != dir {
+		return mainFile
+	}
+	if dir == "." || filepath.IsAbs(dir) {
+		return dir
+	}
+	return "./" + filepath.ToSlash(dir)
+}
+
+// WriteGitVersionSource 在包目录中生成包含Git元数据常量的Go源文件
+//
+// 参数:
+//   - v: verman对象, 提供Git元数据和构建时间
+//   - c: 配置对象
+//
+// 返回值:
+//   - string: 生成的文件路径
+//   - func() error: 删除生成的文件, 生成前已存在同名文件时恢复原内容
+//   - error: 包目录中没有Go源文件或文件写入失败时返回错误
+//
+// 注意:
+//   - 生成的常量为 AppName、GitVersion、GitCommit、GitCommitTime、BuildTime 和 GitTreeState, 包名与目录中的其他文件相同
+func WriteGitVersionSource(v *verman.Info, c *types.GobConfig) (string, func() error, error) {
+	dir := GitVersionPackageDir(c)
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("build.git.package: 读取包 %s 失败: %w", dir, err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gob; DO NOT EDIT.\n\npackage %s\n\n", pkg.Name)
+	buf.WriteString("// 构建时注入的Git元数据\nconst (\n")
+	for _, item := range []struct{ name, value string }{
+		{"AppName", v.AppName},
+		{"GitVersion", v.GitVersion},
+		{"GitCommit", v.GitCommit},
+		{"GitCommitTime", v.GitCommitTime},
+		{"BuildTime", v.BuildTime},
+		{"GitTreeState", v.GitTreeState},
+	} {
+		fmt.Fprintf(&buf, "\t%s = %s\n", item.name, strconv.Quote(item.value))
+	}
+	buf.WriteString(")\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", nil, fmt.Errorf("格式化版本文件失败: %w", err)
+	}
+
+	// 记录已存在的同名文件, 构建结束后恢复
+	path := filepath.Join(dir, types.GitVersionSourceFile)
+	var original []byte
+	var perm fs.FileMode = 0o644
+	info, err := os.Stat(path)
+	existed := err == nil
+	switch {
+	case existed:
+		if original, err = os.ReadFile(path); err != nil {
+			return "", nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+		}
+		perm = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, src, perm); err != nil {
+		return "", nil, fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+
+	restore := func() error {
+		if existed {
+			if err := os.WriteFile(path, original, perm); err != nil {
+				return fmt.Errorf("恢复 %s 失败: %w", path, err)
+			}
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("删除 %s 失败: %w", path, err)
+		}
+		return nil
+	}
+	return path, restore, nil
+}
@@ -0,0 +1,710 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// gitHashLen SHA-1对象哈希的字节数
+const gitHashLen = 20
+
+// errGitObjectNotFound 对象不存在, 浅克隆中边界提交的父提交属于这种情况
+var errGitObjectNotFound = errors.New("对象不存在")
+
+// gitObjectFormatRegexp 匹配仓库配置中的 SHA-256 对象格式
+var gitObjectFormatRegexp = regexp.MustCompile(`(?mi)^\s*objectformat\s*=\s*sha256\s*$`)
+
+// gitPackTypes 对象包中的对象类型编号
+var gitPackTypes = map[byte]string{1: "commit", 2: "tree", 3: "blob", 4: "tag"}
+
+// gitRepo 直接读取.git目录的只读仓库, 用于没有git命令的环境
+type gitRepo struct {
+	gitDir    string     // 当前工作树的git目录, 存放HEAD
+	commonDir string     // 存放对象和引用的目录, 除 git worktree 创建的工作树外与gitDir相同
+	packs     []*gitPack // 对象包
+}
+
+// gitPack 对象包及其索引
+type gitPack struct {
+	path    string   // .pack文件路径
+	hashes  []byte   // 按顺序排列的对象哈希, 每个20字节
+	offsets []uint64 // 与哈希对应的对象在.pack文件中的偏移量
+}
+
+// gitCommit 提交对象中用到的字段
+type gitCommit struct {
+	parents []string // 父提交哈希
+	time    string   // 提交时间, 格式与 git log --date=iso 相同
+}
+
+// openGitRepo 从指定目录开始向上查找并打开Git仓库
+//
+// 参数:
+//   - dir: 起始目录
+//
+// 返回值:
+//   - *gitRepo: 仓库
+//   - error: 找不到.git或仓库格式不受支持时返回错误
+//
+// 注意:
+//   - .git为文件时(git worktree 和子模块)读取其中 gitdir: 指向的目录
+//   - 仅支持SHA-1格式的仓库, 不读取 objects/info/alternates
+func openGitRepo(dir string) (*gitRepo, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for d := abs; ; d = filepath.Dir(d) {
+		path := filepath.Join(d, ".git")
+		info, err := os.Stat(path)
+		if err == nil {
+			gitDir := path
+			if !info.IsDir() {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return nil, err
+				}
+				target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+				if !ok {
+					return nil, fmt.Errorf("%s 不是有效的 gitdir 文件", path)
+				}
+				gitDir = resolveGitPath(d, strings.TrimSpace(target))
+			}
+			return newGitRepo(gitDir)
+		}
+		if filepath.Dir(d) == d {
+			return nil, fmt.Errorf("%s 及其上级目录中没有.git", abs)
+		}
+	}
+}
+
+// newGitRepo 打开git目录并读取对象包索引
+//
+// 参数:
+//   - gitDir: git目录
+//
+// 返回值:
+//   - *gitRepo: 仓库
+//   - error: 仓库格式不受支持或对象包索引无法读取时返回错误
+func newGitRepo(gitDir string) (*gitRepo, error) {
+	r := &gitRepo{gitDir: gitDir, commonDir: gitDir}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		r.commonDir = resolveGitPath(gitDir, strings.TrimSpace(string(data)))
+	}
+	if data, err := os.ReadFile(filepath.Join(r.commonDir, "config")); err == nil && gitObjectFormatRegexp.Match(data) {
+		return nil, fmt.Errorf("不支持SHA-256格式的仓库")
+	}
+
+	indexes, err := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		pack, err := readGitPackIndex(idx)
+		if err != nil {
+			return nil, err
+		}
+		r.packs = append(r.packs, pack)
+	}
+	return r, nil
+}
+
+// resolveGitPath 将相对路径解析为相对于指定目录的路径
+func resolveGitPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// metaData 读取当前提交的Git元数据
+//
+// 参数:
+//   - prefix: 标签前缀, 只有以该前缀开头的标签参与比较
+//
+// 返回值:
+//   - types.GitMetaData: Git元数据, 不包含应用名称和构建时间; 无法判断工作区是否有修改, 树状态为 unknown
+//   - error: 错误信息
+//
+// 注意:
+//   - Git版本的格式与 git describe --tags 相同, 标签为当前提交可达的最高语义化版本标签, 没有标签时为7位提交哈希
+func (r *gitRepo) metaData(prefix string) (types.GitMetaData, error) {
+	head, err := r.resolve("HEAD")
+	if err != nil {
+		return types.GitMetaData{}, err
+	}
+	commit, err := r.commit(head)
+	if err != nil {
+		return types.GitMetaData{}, fmt.Errorf("读取提交 %s 失败: %w", head, err)
+	}
+	meta := types.GitMetaData{
+		GitVersion:    head[:7],
+		GitCommit:     head[:7],
+		GitCommitTime: commit.time,
+		GitTreeState:  types.UnknownGitValue,
+	}
+
+	tags, err := r.tags()
+	if err != nil {
+		return types.GitMetaData{}, err
+	}
+	reachable, err := r.ancestors(head)
+	if err != nil {
+		return types.GitMetaData{}, err
+	}
+
+	// 选出当前提交可达的最高语义化版本标签
+	var tag, tagCommit string
+	var tagVersion types.Semver
+	for name, target := range tags {
+		v, ok := ParseSemver(strings.TrimPrefix(name, prefix))
+		if !ok || !strings.HasPrefix(name, prefix) || !reachable[target] {
+			continue
+		}
+		if c := CompareSemver(v, tagVersion); tag == "" || c > 0 || (c == 0 && name < tag) {
+			tag, tagCommit, tagVersion = name, target, v
+		}
+	}
+	if tag == "" {
+		return meta, nil
+	}
+
+	// 统计标签之后的提交数
+	tagged, err := r.ancestors(tagCommit)
+	if err != nil {
+		return types.GitMetaData{}, err
+	}
+	count := 0
+	for c := range reachable {
+		if !tagged[c] {
+			count++
+		}
+	}
+	meta.GitVersion = tag
+	if count > 0 {
+		meta.GitVersion = fmt.Sprintf("%s-%d-g%s", tag, count, head[:7])
+	}
+	return meta, nil
+}
+
+// resolve 将引用解析为对象哈希, 支持符号引用
+func (r *gitRepo) resolve(ref string) (string, error) {
+	name := ref
+	for range 10 {
+		target, err := r.readRef(name)
+		if err != nil {
+			return "", err
+		}
+		next, ok := strings.CutPrefix(target, "ref:")
+		if !ok {
+			if len(target) != 2*gitHashLen {
+				return "", fmt.Errorf("引用 %s 的内容无效: %q", name, target)
+			}
+			return target, nil
+		}
+		name = strings.TrimSpace(next)
+	}
+	return "", fmt.Errorf("引用 %s 的符号引用嵌套过深", ref)
+}
+
+// readRef 读取引用的内容, 依次查找工作树的git目录、公共目录和 packed-refs
+func (r *gitRepo) readRef(name string) (string, error) {
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	refs, _, err := r.packedRefs()
+	if err != nil {
+		return "", err
+	}
+	if hash, ok := refs[name]; ok {
+		return hash, nil
+	}
+	return "", fmt.Errorf("找不到引用 %s", name)
+}
+
+// packedRefs 读取 packed-refs
+//
+// 返回值:
+//   - map[string]string: 引用名到对象哈希的映射
+//   - map[string]string: 附注标签的引用名到其指向的对象哈希的映射
+//   - error: 文件存在但无法读取时返回错误
+func (r *gitRepo) packedRefs() (map[string]string, map[string]string, error) {
+	refs, peeled := map[string]string{}, map[string]string{}
+	data, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return refs, peeled, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var last string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#':
+		case line[0] == '^':
+			if last != "" {
+				peeled[last] = line[1:]
+			}
+		default:
+			if hash, name, ok := strings.Cut(line, " "); ok {
+				refs[name], last = hash, name
+			}
+		}
+	}
+	return refs, peeled, nil
+}
+
+// tags 返回所有标签指向的提交
+//
+// 返回值:
+//   - map[string]string: 标签名(不含 refs/tags/)到提交哈希的映射, 附注标签解析为其指向的提交, 不指向提交的标签被忽略
+//   - error: 错误信息
+func (r *gitRepo) tags() (map[string]string, error) {
+	refs, peeled, err := r.packedRefs()
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for name, hash := range refs {
+		if tag, ok := strings.CutPrefix(name, "refs/tags/"); ok {
+			if p, ok := peeled[name]; ok {
+				hash = p
+			}
+			tags[tag] = hash
+		}
+	}
+
+	// 松散引用优先于 packed-refs
+	root := filepath.Join(r.commonDir, "refs", "tags")
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		tags[filepath.ToSlash(rel)] = strings.TrimSpace(string(data))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取标签失败: %w", err)
+	}
+
+	for name, hash := range tags {
+		commit, err := r.peel(hash)
+		if err != nil {
+			delete(tags, name)
+			continue
+		}
+		tags[name] = commit
+	}
+	return tags, nil
+}
+
+// peel 将附注标签解析为其最终指向的对象, 返回的对象必须是提交
+func (r *gitRepo) peel(hash string) (string, error) {
+	for range 10 {
+		typ, data, err := r.readObject(hash)
+		if err != nil {
+			return "", err
+		}
+		switch typ {
+		case "commit":
+			return hash, nil
+		case "tag":
+			header, _, _ := strings.Cut(string(data), "\n")
+			target, ok := strings.CutPrefix(header, "object ")
+			if !ok {
+				return "", fmt.Errorf("标签对象 %s 格式无效", hash)
+			}
+			hash = target
+		default:
+			return "", fmt.Errorf("%s 指向 %s 对象", hash, typ)
+		}
+	}
+	return "", fmt.Errorf("标签 %s 嵌套过深", hash)
+}
+
+// ancestors 返回提交及其所有祖先提交
+//
+// 参数:
+//   - hash: 提交哈希
+//
+// 返回值:
+//   - map[string]bool: 提交哈希集合
+//   - error: 错误信息
+//
+// 注意:
+//   - 浅克隆中不存在的父提交被忽略
+func (r *gitRepo) ancestors(hash string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	stack := []string{hash}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[h] {
+			continue
+		}
+		c, err := r.commit(h)
+		if errors.Is(err, errGitObjectNotFound) && h != hash {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取提交 %s 失败: %w", h, err)
+		}
+		seen[h] = true
+		stack = append(stack, c.parents...)
+	}
+	return seen, nil
+}
+
+// commit 读取提交对象
+func (r *gitRepo) commit(hash string) (gitCommit, error) {
+	typ, data, err := r.readObject(hash)
+	if err != nil {
+		return gitCommit{}, err
+	}
+	if typ != "commit" {
+		return gitCommit{}, fmt.Errorf("%s 是 %s 对象, 不是提交", hash, typ)
+	}
+
+	var c gitCommit
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" { // 头部之后为提交信息
+			break
+		}
+		if parent, ok := strings.CutPrefix(line, "parent "); ok {
+			c.parents = append(c.parents, parent)
+		} else if committer, ok := strings.CutPrefix(line, "committer "); ok {
+			c.time = gitSignatureTime(committer)
+		}
+	}
+	return c, nil
+}
+
+// gitSignatureTime 解析 "名称 <邮箱> 时间戳 时区" 格式的签名中的时间
+//
+// 参数:
+//   - sig: 签名
+//
+// 返回值:
+//   - string: 2006-01-02 15:04:05 -0700 格式的时间, 与 git log --date=iso 相同; 无法解析时为 unknown
+func gitSignatureTime(sig string) string {
+	i := strings.LastIndexByte(sig, '>')
+	fields := strings.Fields(sig[i+1:])
+	if len(fields) != 2 || len(fields[1]) != 5 {
+		return types.UnknownGitValue
+	}
+	sec, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return types.UnknownGitValue
+	}
+	hours, err1 := strconv.Atoi(fields[1][1:3])
+	minutes, err2 := strconv.Atoi(fields[1][3:])
+	if err1 != nil || err2 != nil {
+		return types.UnknownGitValue
+	}
+	offset := hours*3600 + minutes*60
+	if fields[1][0] == '-' {
+		offset = -offset
+	}
+	return time.Unix(sec, 0).In(time.FixedZone("", offset)).Format("2006-01-02 15:04:05 -0700")
+}
+
+// readObject 读取对象, 依次查找松散对象和对象包
+//
+// 参数:
+//   - hash: 40位对象哈希
+//
+// 返回值:
+//   - string: 对象类型, 如 commit、tag
+//   - []byte: 对象内容
+//   - error: 对象不存在时返回 errGitObjectNotFound
+func (r *gitRepo) readObject(hash string) (string, []byte, error) {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != gitHashLen {
+		return "", nil, fmt.Errorf("无效的对象哈希 %q", hash)
+	}
+
+	f, err := os.Open(filepath.Join(r.commonDir, "objects", hash[:2], hash[2:]))
+	if err == nil {
+		defer f.Close()
+		data, err := inflate(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("读取对象 %s 失败: %w", hash, err)
+		}
+		header, body, ok := bytes.Cut(data, []byte{0})
+		if !ok {
+			return "", nil, fmt.Errorf("对象 %s 格式无效", hash)
+		}
+		typ, _, _ := strings.Cut(string(header), " ")
+		return typ, body, nil
+	}
+
+	for _, p := range r.packs {
+		if offset, ok := p.find(raw); ok {
+			return r.readPacked(p, offset)
+		}
+	}
+	return "", nil, fmt.Errorf("%s: %w", hash, errGitObjectNotFound)
+}
+
+// readPacked 读取对象包中的对象
+func (r *gitRepo) readPacked(p *gitPack, offset int64) (string, []byte, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	typ, data, err := r.unpack(f, offset, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("读取对象包 %s 失败: %w", filepath.Base(p.path), err)
+	}
+	return typ, data, nil
+}
+
+// unpack 解析对象包中指定偏移量处的对象, 增量对象与其基础对象合并
+//
+// 参数:
+//   - f: .pack文件
+//   - offset: 对象的偏移量
+//   - depth: 当前的增量链深度
+//
+// 返回值:
+//   - string: 对象类型
+//   - []byte: 对象内容
+//   - error: 错误信息
+func (r *gitRepo) unpack(f *os.File, offset int64, depth int) (string, []byte, error) {
+	if depth > 64 {
+		return "", nil, fmt.Errorf("增量链过长")
+	}
+	br := bufio.NewReader(io.NewSectionReader(f, offset, math.MaxInt64-offset))
+
+	// 头部: 类型和大小, 大小以变长编码表示, 解压后即可得到
+	c, err := br.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	typ := (c >> 4) & 7
+	for c&0x80 != 0 {
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var baseType string
+	var base []byte
+	switch typ {
+	case 1, 2, 3, 4:
+		data, err := inflate(br)
+		return gitPackTypes[typ], data, err
+	case 6: // 基础对象以相对偏移量表示
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = (rel+1)<<7 | int64(c&0x7f)
+		}
+		baseType, base, err = r.unpack(f, offset-rel, depth+1)
+	case 7: // 基础对象以哈希表示
+		var h [gitHashLen]byte
+		if _, err = io.ReadFull(br, h[:]); err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = r.readObject(hex.EncodeToString(h[:]))
+	default:
+		return "", nil, fmt.Errorf("未知的对象类型 %d", typ)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	delta, err := inflate(br)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := applyGitDelta(base, delta)
+	return baseType, data, err
+}
+
+// applyGitDelta 将增量数据应用到基础对象
+//
+// 参数:
+//   - base: 基础对象的内容
+//   - delta: 增量数据, 由基础对象大小、结果大小和复制或插入指令组成
+//
+// 返回值:
+//   - []byte: 结果对象的内容
+//   - error: 增量数据无效时返回错误
+func applyGitDelta(base, delta []byte) ([]byte, error) {
+	errInvalid := errors.New("增量数据无效")
+	pos := 0
+	readSize := func() (int, bool) {
+		size, shift := 0, 0
+		for pos < len(delta) {
+			c := delta[pos]
+			pos++
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+	baseSize, ok1 := readSize()
+	resultSize, ok2 := readSize()
+	if !ok1 || !ok2 || baseSize != len(base) {
+		return nil, errInvalid
+	}
+
+	out := make([]byte, 0, resultSize)
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		switch {
+		case op&0x80 != 0: // 从基础对象复制
+			var off, size int
+			for i := range 7 {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if pos >= len(delta) {
+					return nil, errInvalid
+				}
+				if i < 4 {
+					off |= int(delta[pos]) << (8 * i)
+				} else {
+					size |= int(delta[pos]) << (8 * (i - 4))
+				}
+				pos++
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if off+size > len(base) {
+				return nil, errInvalid
+			}
+			out = append(out, base[off:off+size]...)
+		case op != 0: // 插入增量数据中的字节
+			n := int(op)
+			if pos+n > len(delta) {
+				return nil, errInvalid
+			}
+			out = append(out, delta[pos:pos+n]...)
+			pos += n
+		default:
+			return nil, errInvalid
+		}
+	}
+	if len(out) != resultSize {
+		return nil, errInvalid
+	}
+	return out, nil
+}
+
+// inflate 解压zlib数据
+func inflate(r io.Reader) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// readGitPackIndex 读取第2版对象包索引(.idx)
+//
+// 参数:
+//   - path: .idx文件路径
+//
+// 返回值:
+//   - *gitPack: 对象包
+//   - error: 索引无法读取或格式不受支持时返回错误
+func readGitPackIndex(path string) (*gitPack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	const fanoutEnd = 8 + 256*4
+	if len(data) < fanoutEnd || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: 不支持的对象包索引格式", filepath.Base(path))
+	}
+
+	// 扇出表之后依次为哈希、CRC32、4字节偏移量和8字节大偏移量
+	n := int(binary.BigEndian.Uint32(data[fanoutEnd-4 : fanoutEnd]))
+	crcStart := fanoutEnd + n*gitHashLen
+	offsetStart := crcStart + n*4
+	largeStart := offsetStart + n*4
+	if len(data) < largeStart {
+		return nil, fmt.Errorf("%s: 对象包索引不完整", filepath.Base(path))
+	}
+
+	p := &gitPack{
+		path:    strings.TrimSuffix(path, ".idx") + ".pack",
+		hashes:  data[fanoutEnd:crcStart],
+		offsets: make([]uint64, n),
+	}
+	for i := range n {
+		off := binary.BigEndian.Uint32(data[offsetStart+i*4:])
+		if off&0x80000000 == 0 {
+			p.offsets[i] = uint64(off)
+			continue
+		}
+		j := largeStart + int(off&0x7fffffff)*8
+		if len(data) < j+8 {
+			return nil, fmt.Errorf("%s: 对象包索引不完整", filepath.Base(path))
+		}
+		p.offsets[i] = binary.BigEndian.Uint64(data[j:])
+	}
+	return p, nil
+}
+
+// find 查找对象在对象包中的偏移量
+func (p *gitPack) find(hash []byte) (int64, bool) {
+	n := len(p.offsets)
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(p.hashes[i*gitHashLen:(i+1)*gitHashLen], hash) >= 0
+	})
+	if i < n && bytes.Equal(p.hashes[i*gitHashLen:(i+1)*gitHashLen], hash) {
+		return int64(p.offsets[i]), true
+	}
+	return 0, false
+}
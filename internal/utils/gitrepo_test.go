@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// newGitRepoTestRepo 创建用于直接读取.git目录的Git仓库
+//
+// 返回值:
+//   - func(args ...string) string: 在仓库中执行git命令
+//
+// 注意:
+//   - main 分支依次为: c1 (v1.0.0, cli/v3.0.0), c2 (附注标签 v1.1.0, 指向该附注标签的 v1.2.0-rc.1), c3, c4
+//   - v5.0.0 位于未合并的 side 分支, v9.0.0 指向树对象, 均不应参与比较
+//   - 每个提交修改 data.txt 中的一行, 执行 git gc 后对象包中包含增量对象
+func newGitRepoTestRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	git := newTestGitRepo(t)
+
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %03d: gob 直接读取.git目录的测试数据", i)
+	}
+	commit := func(subject string) {
+		lines[len(subject)] = subject
+		if err := os.WriteFile("data.txt", []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", subject)
+	}
+	commit("c1")
+	git("tag", "v1.0.0")
+	git("tag", "cli/v3.0.0")
+	commit("c2 change")
+	git("tag", "-a", "v1.1.0", "-m", "Release v1.1.0")
+	git("tag", "-a", "v1.2.0-rc.1", "v1.1.0", "-m", "nested")
+	git("tag", "v9.0.0", "HEAD^{tree}")
+	git("checkout", "-q", "-b", "side")
+	commit("side branch")
+	git("tag", "v5.0.0")
+	git("checkout", "-q", "main")
+	commit("c3 another change")
+	commit("c4 yet another change")
+	return git
+}
+
+func TestGitRepoMetaData(t *testing.T) {
+	git := newGitRepoTestRepo(t)
+	head := git("rev-parse", "--short=7", "HEAD")
+
+	check := func(t *testing.T, dir string) {
+		t.Helper()
+		repo, err := openGitRepo(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			prefix, want string
+		}{
+			{"", "v1.2.0-rc.1-2-g" + head},
+			{"cli/", "cli/v3.0.0-3-g" + head},
+			{"web/", head},
+		}
+		for _, tt := range tests {
+			meta, err := repo.metaData(tt.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := types.GitMetaData{
+				GitVersion:    tt.want,
+				GitCommit:     head,
+				GitCommitTime: git("log", "-1", "--format=%cd", "--date=iso"),
+				GitTreeState:  types.UnknownGitValue,
+			}
+			if meta != want {
+				t.Errorf("前缀 %q: metaData() = %+v, 期望 %+v", tt.prefix, meta, want)
+			}
+		}
+	}
+
+	t.Run("松散对象", func(t *testing.T) { check(t, ".") })
+	if err := os.Mkdir("sub", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("子目录", func(t *testing.T) { check(t, "sub") })
+
+	git("gc", "-q", "--aggressive")
+	if _, err := os.Stat(filepath.Join(".git", "refs", "tags", "v1.0.0")); !os.IsNotExist(err) {
+		t.Fatal("git gc 后标签应位于 packed-refs")
+	}
+	t.Run("对象包和packed-refs", func(t *testing.T) { check(t, ".") })
+
+	git("tag", "-d", "v1.2.0-rc.1")
+	repo, err := openGitRepo(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta, err := repo.metaData(""); err != nil || meta.GitVersion != "v1.1.0-2-g"+head {
+		t.Errorf("删除 v1.2.0-rc.1 后期望 v1.1.0-2-g%s, got %+v, %v", head, meta, err)
+	}
+
+	// 松散引用优先于 packed-refs
+	git("tag", "-f", "v1.1.0", "HEAD")
+	if meta, err := repo.metaData(""); err != nil || meta.GitVersion != "v1.1.0" {
+		t.Errorf("当前提交是版本标签时期望 v1.1.0, got %+v, %v", meta, err)
+	}
+}
+
+func TestGitRepoWorktreeAndShallow(t *testing.T) {
+	git := newGitRepoTestRepo(t)
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	git("gc", "-q")
+
+	// 分离头指针
+	git("checkout", "-q", "--detach", "HEAD~1")
+	repo, err := openGitRepo(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta, err := repo.metaData(""); err != nil || meta.GitVersion != "v1.2.0-rc.1-1-g"+git("rev-parse", "--short=7", "HEAD") {
+		t.Errorf("分离头指针: got %+v, %v", meta, err)
+	}
+	git("checkout", "-q", "main")
+
+	// git worktree 创建的工作树中.git为文件, HEAD位于工作树的git目录
+	wt := filepath.Join(t.TempDir(), "wt")
+	git("worktree", "add", "-q", wt, "HEAD~2")
+	if repo, err = openGitRepo(wt); err != nil {
+		t.Fatal(err)
+	}
+	if meta, err := repo.metaData(""); err != nil || meta.GitVersion != "v1.2.0-rc.1" {
+		t.Errorf("工作树: got %+v, %v", meta, err)
+	}
+
+	// 浅克隆中边界提交的父提交不存在
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	git("clone", "-q", "--depth", "2", "file://"+filepath.ToSlash(root), shallow)
+	if repo, err = openGitRepo(shallow); err != nil {
+		t.Fatal(err)
+	}
+	head := git("rev-parse", "--short=7", "HEAD")
+	if meta, err := repo.metaData(""); err != nil || meta.GitVersion != head {
+		t.Errorf("浅克隆: 期望 %s, got %+v, %v", head, meta, err)
+	}
+}
+
+func TestOpenGitRepoErrors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未找到 git")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".git"), []byte("not a gitdir"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openGitRepo(dir); err == nil || !strings.Contains(err.Error(), "gitdir") {
+		t.Errorf(".git文件无效时期望返回错误, got %v", err)
+	}
+
+	sha256Dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "--object-format=sha256", sha256Dir).CombinedOutput(); err != nil {
+		t.Skipf("git 不支持 SHA-256 仓库: %s", out)
+	}
+	if _, err := openGitRepo(sha256Dir); err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Errorf("SHA-256 仓库期望返回错误, got %v", err)
+	}
+}
+
+func TestGitRepoReadObject(t *testing.T) {
+	git := newGitRepoTestRepo(t)
+	git("gc", "-q", "--aggressive")
+	indexes, _ := filepath.Glob(filepath.Join(".git", "objects", "pack", "*.idx"))
+	if len(indexes) != 1 {
+		t.Fatalf("git gc 后期望一个对象包, got %v", indexes)
+	}
+	if out := git("verify-pack", "-v", indexes[0]); !strings.Contains(out, "chain length = 1") {
+		t.Fatalf("对象包中应包含增量对象:\n%s", out)
+	}
+
+	repo, err := openGitRepo(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := git("cat-file", "--batch-all-objects", "--batch-check=%(objectname) %(objecttype)")
+	for line := range strings.Lines(objects) {
+		hash, typ, _ := strings.Cut(strings.TrimSpace(line), " ")
+		gotType, data, err := repo.readObject(hash)
+		if err != nil {
+			t.Errorf("读取对象 %s 失败: %v", hash, err)
+			continue
+		}
+		out, err := exec.Command("git", "cat-file", typ, hash).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotType != typ || !bytes.Equal(data, out) {
+			t.Errorf("对象 %s 的类型或内容与 git cat-file 不一致, got %s", hash, gotType)
+		}
+	}
+
+	if _, _, err := repo.readObject(strings.Repeat("0", 40)); err == nil || !strings.Contains(err.Error(), "对象不存在") {
+		t.Errorf("对象不存在时期望返回错误, got %v", err)
+	}
+	if _, _, err := repo.readObject("xyz"); err == nil || !strings.Contains(err.Error(), "无效的对象哈希") {
+		t.Errorf("哈希无效时期望返回错误, got %v", err)
+	}
+}
+
+func TestApplyGitDelta(t *testing.T) {
+	base := []byte("hello world")
+	tests := []struct {
+		name  string
+		delta []byte
+		want  string // 期望的结果, 为空时期望返回错误
+	}{
+		{"复制和插入", []byte{11, 9, 0x90, 6, 3, 'g', 'o', 'b'}, "hello gob"},
+		{"带偏移量复制", []byte{11, 5, 0x91, 6, 5}, "world"},
+		{"只插入", []byte{11, 2, 2, 'h', 'i'}, "hi"},
+		{"基础对象大小不符", []byte{10, 2, 2, 'h', 'i'}, ""},
+		{"结果大小不符", []byte{11, 3, 2, 'h', 'i'}, ""},
+		{"复制越界", []byte{11, 5, 0x91, 8, 5}, ""},
+		{"插入越界", []byte{11, 3, 3, 'h', 'i'}, ""},
+		{"复制指令不完整", []byte{11, 5, 0x91, 6}, ""},
+		{"保留指令", []byte{11, 0, 0}, ""},
+		{"大小不完整", []byte{0x8b}, ""},
+	}
+	for _, tt := range tests {
+		got, err := applyGitDelta(base, tt.delta)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: 期望返回错误, got %q", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: applyGitDelta() = %q, %v, 期望 %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestGitSignatureTime(t *testing.T) {
+	tests := map[string]string{
+		"gob <gob@example.com> 1714564800 +0000":         "2024-05-01 12:00:00 +0000",
+		"gob <gob@example.com> 1714564800 +0800":         "2024-05-01 20:00:00 +0800",
+		"gob <gob@example.com> 1714564800 -0530":         "2024-05-01 06:30:00 -0530",
+		"A <B> C <gob@example.com> 1714564800 +0000":     "2024-05-01 12:00:00 +0000",
+		"gob <gob@example.com> 1714564800":               types.UnknownGitValue,
+		"gob <gob@example.com> abc +0000":                types.UnknownGitValue,
+		"gob <gob@example.com> 1714564800 +08":           types.UnknownGitValue,
+		"gob <gob@example.com> 1714564800 +08x0 extra":   types.UnknownGitValue,
+		"gob <gob@example.com> 1714564800 +0x00":         types.UnknownGitValue,
+		"":                                               types.UnknownGitValue,
+		"gob <gob@example.com> 1714564800 +0000 garbage": types.UnknownGitValue,
+	}
+	for sig, want := range tests {
+		if got := gitSignatureTime(sig); got != want {
+			t.Errorf("gitSignatureTime(%q) = %q, 期望 %q", sig, got, want)
+		}
+	}
+}
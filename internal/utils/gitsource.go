@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/shellx"
+)
+
+// describeCountRegexp 匹配 git describe 在标签之后追加的提交数和哈希, 如 -3-g1a2b3c4
+var describeCountRegexp = regexp.MustCompile(`-(\d+)-g[0-9a-f]+$`)
+
+// GitSources 返回支持的Git元数据来源
+//
+// 返回值:
+//   - []string: 来源名称列表
+func GitSources() []string {
+	return []string{"env", "git", "repo", "file"}
+}
+
+// readGitSource 从指定来源读取Git元数据
+//
+// 参数:
+//   - timeout: git命令的超时时间
+//   - source: 来源名称, 见 GitSources
+//   - prefix: 标签前缀
+//
+// 返回值:
+//   - types.GitMetaData: Git元数据, 不包含应用名称和构建时间, Git版本可能包含标签前缀
+//   - types.GitSemver: 语义化版本信息, 不包含 IsSnapshot 和 Describe
+//   - error: 该来源不可用时返回错误
+func readGitSource(timeout time.Duration, source, prefix string) (types.GitMetaData, types.GitSemver, error) {
+	var meta types.GitMetaData
+	var err error
+	switch source {
+	case "git":
+		return readGitCommand(timeout, prefix)
+	case "repo":
+		var repo *gitRepo
+		if repo, err = openGitRepo("."); err == nil {
+			meta, err = repo.metaData(prefix)
+		}
+	case "file":
+		meta, err = readGitVersionFile()
+	case "env":
+		meta, err = readGitEnv()
+	default:
+		err = fmt.Errorf("未知的来源")
+	}
+	if err != nil {
+		return types.GitMetaData{}, types.GitSemver{}, err
+	}
+	return meta, semverFromDescribe(meta.GitVersion, prefix), nil
+}
+
+// readGitCommand 通过git命令读取Git元数据
+//
+// 参数:
+//   - timeout: 每个命令的超时时间
+//   - prefix: 标签前缀, 设置时 git describe 只匹配带该前缀的标签
+//
+// 返回值:
+//   - types.GitMetaData: Git元数据
+//   - types.GitSemver: 当前提交可达的最高语义化版本标签及其之后的提交数
+//   - error: 未安装git、当前目录不是Git仓库或命令执行失败时返回错误
+func readGitCommand(timeout time.Duration, prefix string) (types.GitMetaData, types.GitSemver, error) {
+	var meta types.GitMetaData
+
+	// 检查Git是否安装
+	if err := shellx.NewCmds([]string{"git", "--version"}).WithTimeout(timeout).Exec(); err != nil {
+		return meta, types.GitSemver{}, fmt.Errorf("未检测到Git, 请先安装Git并确保其在PATH中: %w", err)
+	}
+
+	// 检查当前目录是否为git仓库
+	if result, err := shellx.NewCmds(types.GitIsInsideWorkTreeCmd.Cmds).WithTimeout(timeout).ExecOutput(); err != nil {
+		if strings.Contains(string(result), "not a git repository") {
+			return meta, types.GitSemver{}, fmt.Errorf("当前目录不是Git仓库, 请先执行`git init`初始化仓库: %w", err)
+		}
+		return meta, types.GitSemver{}, fmt.Errorf("检查Git仓库状态失败: %w", err)
+	}
+
+	// 设置了标签前缀时只匹配带该前缀的标签
+	versionCmd := types.GitVersionCmd
+	if prefix != "" {
+		versionCmd.Cmds = append(slices.Clone(versionCmd.Cmds), "--match", prefix+"*")
+	}
+
+	// 定义命令和对应字段的映射
+	commands := []struct {
+		cmd   types.CommandGroup
+		field *string
+	}{
+		{versionCmd, &meta.GitVersion},
+		{types.GitCommitHashCmd, &meta.GitCommit},
+		{types.GitCommitTimeCmd, &meta.GitCommitTime},
+	}
+
+	// 处理常规git信息
+	for _, item := range commands {
+		cmdResult, runErr := shellx.NewCmds(item.cmd.Cmds).WithTimeout(timeout).WithShell(shellx.ShellNone).ExecOutput()
+		if runErr != nil {
+			return meta, types.GitSemver{}, fmt.Errorf("%s: \n\t%s \n%w", item.cmd.Name, string(cmdResult), runErr)
+		}
+		// 设置字段值，并去除首尾空格
+		*item.field = strings.TrimSpace(string(cmdResult))
+	}
+
+	// 特殊处理git树状态
+	result, err := shellx.NewCmds(types.GitTreeStatusCmd.Cmds).WithTimeout(timeout).ExecOutput()
+	if err != nil {
+		return meta, types.GitSemver{}, fmt.Errorf("%s: \n\t%s \n%w", types.GitTreeStatusCmd.Name, string(result), err)
+	}
+
+	// 根据git树状态设置GitTreeState字段
+	if strings.TrimSpace(string(result)) == "" {
+		meta.GitTreeState = "clean"
+	} else {
+		meta.GitTreeState = "dirty"
+	}
+
+	sv, err := readGitSemver(timeout, prefix)
+	if err != nil {
+		return meta, types.GitSemver{}, err
+	}
+	return meta, sv, nil
+}
+
+// readGitEnv 从 GOB_GIT_* 环境变量读取Git元数据
+//
+// 返回值:
+//   - types.GitMetaData: Git元数据, 未设置的字段为 unknown
+//   - error: 未设置 GOB_GIT_VERSION 时返回错误
+//
+// 注意:
+//   - 读取 GOB_GIT_VERSION、GOB_GIT_COMMIT、GOB_GIT_COMMIT_TIME 和 GOB_GIT_TREE_STATE
+func readGitEnv() (types.GitMetaData, error) {
+	values := make(map[string]string)
+	for _, key := range []string{"version", "commit", "commit_time", "tree_state"} {
+		values[key] = strings.TrimSpace(os.Getenv(types.GitEnvPrefix + strings.ToUpper(key)))
+	}
+	if values["version"] == "" {
+		return types.GitMetaData{}, fmt.Errorf("环境变量 %sVERSION 未设置", types.GitEnvPrefix)
+	}
+	return gitMetaDataFromValues(values), nil
+}
+
+// readGitVersionFile 从当前目录下的版本文件读取Git元数据
+//
+// 返回值:
+//   - types.GitMetaData: Git元数据, 未设置的字段为 unknown
+//   - error: 所有版本文件都不存在、内容为空或包含未替换的 $Format: 占位符时返回错误
+//
+// 注意:
+//   - 依次查找 types.GitVersionFiles, 使用第一个存在的文件
+//   - 文件中 key = value 形式的行设置 version、commit、commit_time 和 tree_state, 其他行中的第一行作为版本号, # 开头的行为注释
+func readGitVersionFile() (types.GitMetaData, error) {
+	for _, name := range types.GitVersionFiles {
+		data, err := os.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return types.GitMetaData{}, fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+
+		values := make(map[string]string)
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			} else if values["version"] == "" {
+				values["version"] = line
+			}
+		}
+		for key, value := range values {
+			// export-subst 只在 git archive 导出时替换
+			if strings.Contains(value, "$Format:") {
+				return types.GitMetaData{}, fmt.Errorf("%s 中的 %s 包含未替换的 $Format: 占位符", name, key)
+			}
+		}
+		if values["version"] == "" {
+			return types.GitMetaData{}, fmt.Errorf("%s 中没有版本号", name)
+		}
+		return gitMetaDataFromValues(values), nil
+	}
+	return types.GitMetaData{}, fmt.Errorf("版本文件 %s 不存在", strings.Join(types.GitVersionFiles, "、"))
+}
+
+// gitMetaDataFromValues 将 version、commit、commit_time 和 tree_state 转换为Git元数据, 缺少的值为 unknown
+func gitMetaDataFromValues(values map[string]string) types.GitMetaData {
+	value := func(key string) string {
+		if v := values[key]; v != "" {
+			return v
+		}
+		return types.UnknownGitValue
+	}
+	return types.GitMetaData{
+		GitVersion:    value("version"),
+		GitCommit:     value("commit"),
+		GitCommitTime: value("commit_time"),
+		GitTreeState:  value("tree_state"),
+	}
+}
+
+// semverFromDescribe 从 git describe 格式的版本号解析语义化版本信息
+//
+// 参数:
+//   - describe: 版本号, 如 v1.2.3、cli/v1.2.3-4-gabc1234-dirty
+//   - prefix: 标签前缀, 版本号中没有该前缀时视为已去除
+//
+// 返回值:
+//   - types.GitSemver: 标签、版本号和标签之后的提交数, 不是语义化版本时为空
+func semverFromDescribe(describe, prefix string) types.GitSemver {
+	base := strings.TrimSuffix(describe, "-dirty")
+	count := 0
+	if m := describeCountRegexp.FindStringSubmatch(base); m != nil {
+		base = strings.TrimSuffix(base, m[0])
+		count, _ = strconv.Atoi(m[1])
+	}
+	name := strings.TrimPrefix(base, prefix)
+	v, ok := ParseSemver(name)
+	if !ok {
+		return types.GitSemver{}
+	}
+	return types.GitSemver{Tag: prefix + name, Version: v, CommitsSinceTag: count}
+}
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+func TestReadGitEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want types.GitMetaData // 期望的Git元数据, 版本为空时期望返回错误
+	}{
+		{
+			name: "全部设置",
+			env:  map[string]string{"VERSION": "v1.2.3", "COMMIT": "abc1234", "COMMIT_TIME": "2024-05-01 12:00:00 +0000", "TREE_STATE": "clean"},
+			want: types.GitMetaData{GitVersion: "v1.2.3", GitCommit: "abc1234", GitCommitTime: "2024-05-01 12:00:00 +0000", GitTreeState: "clean"},
+		},
+		{
+			name: "只设置版本",
+			env:  map[string]string{"VERSION": " v1.2.3 \n"},
+			want: types.GitMetaData{GitVersion: "v1.2.3", GitCommit: "unknown", GitCommitTime: "unknown", GitTreeState: "unknown"},
+		},
+		{name: "未设置版本", env: map[string]string{"COMMIT": "abc1234"}},
+		{name: "版本为空白", env: map[string]string{"VERSION": "  "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"VERSION", "COMMIT", "COMMIT_TIME", "TREE_STATE"} {
+				t.Setenv(types.GitEnvPrefix+key, tt.env[key])
+			}
+			got, err := readGitEnv()
+			if tt.want.GitVersion == "" {
+				if err == nil || !strings.Contains(err.Error(), "GOB_GIT_VERSION") {
+					t.Errorf("期望提示 GOB_GIT_VERSION 未设置, got %v", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("readGitEnv() = %+v, %v, 期望 %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadGitVersionFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   types.GitMetaData // 期望的Git元数据
+		errMsg string            // 期望的错误信息, 不为空时期望返回错误
+	}{
+		{
+			name:  "只有版本号",
+			files: map[string]string{"VERSION": "v1.2.3\n"},
+			want:  types.GitMetaData{GitVersion: "v1.2.3", GitCommit: "unknown", GitCommitTime: "unknown", GitTreeState: "unknown"},
+		},
+		{
+			name:  "键值对和注释",
+			files: map[string]string{".gob-version": "# 由 git archive 生成\nversion = v1.2.3-4-gabc1234\nCommit = abc1234\ncommit_time = 2024-05-01 12:00:00 +0000\ntree_state = clean\n"},
+			want:  types.GitMetaData{GitVersion: "v1.2.3-4-gabc1234", GitCommit: "abc1234", GitCommitTime: "2024-05-01 12:00:00 +0000", GitTreeState: "clean"},
+		},
+		{
+			name:  "第一行非键值对的内容作为版本号",
+			files: map[string]string{"VERSION": "\n  v1.2.3  \nv9.9.9\ncommit=abc1234\n"},
+			want:  types.GitMetaData{GitVersion: "v1.2.3", GitCommit: "abc1234", GitCommitTime: "unknown", GitTreeState: "unknown"},
+		},
+		{
+			name:  "优先使用.gob-version",
+			files: map[string]string{".gob-version": "v2.0.0\n", "VERSION": "v1.0.0\n"},
+			want:  types.GitMetaData{GitVersion: "v2.0.0", GitCommit: "unknown", GitCommitTime: "unknown", GitTreeState: "unknown"},
+		},
+		{
+			name:   "存在的文件中没有版本号",
+			files:  map[string]string{".gob-version": "# 空\ncommit = abc1234\n", "VERSION": "v1.0.0\n"},
+			errMsg: ".gob-version 中没有版本号",
+		},
+		{
+			name:   "未替换的占位符",
+			files:  map[string]string{".gob-version": "version = v1.2.3\ncommit = $Format:%h$\n"},
+			errMsg: "$Format:",
+		},
+		{name: "文件不存在", errMsg: "版本文件 .gob-version、VERSION 不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(writeConfigFiles(t, tt.files))
+			got, err := readGitVersionFile()
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("期望错误包含 %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("readGitVersionFile() = %+v, %v, 期望 %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSemverFromDescribe(t *testing.T) {
+	tests := []struct {
+		describe, prefix string
+		want             types.GitSemver
+	}{
+		{"v1.2.3", "", types.GitSemver{Tag: "v1.2.3", Version: types.Semver{Major: 1, Minor: 2, Patch: 3}}},
+		{"v1.2.3-4-gabc1234-dirty", "", types.GitSemver{Tag: "v1.2.3", Version: types.Semver{Major: 1, Minor: 2, Patch: 3}, CommitsSinceTag: 4}},
+		{"v1.2.3-rc.1", "", types.GitSemver{Tag: "v1.2.3-rc.1", Version: types.Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"}}},
+		{"1.2.3-rc-12-g0123abc", "", types.GitSemver{Tag: "1.2.3-rc", Version: types.Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc"}, CommitsSinceTag: 12}},
+		{"cli/v2.0.0-1-gabc1234", "cli/", types.GitSemver{Tag: "cli/v2.0.0", Version: types.Semver{Major: 2}, CommitsSinceTag: 1}},
+		{"v2.0.0", "cli/", types.GitSemver{Tag: "cli/v2.0.0", Version: types.Semver{Major: 2}}},
+		{"abc1234", "", types.GitSemver{}},
+		{"abc1234-dirty", "", types.GitSemver{}},
+		{"nightly-3-gabc1234", "", types.GitSemver{}},
+		{"unknown", "", types.GitSemver{}},
+	}
+	for _, tt := range tests {
+		if got := semverFromDescribe(tt.describe, tt.prefix); got != tt.want {
+			t.Errorf("semverFromDescribe(%q, %q) = %+v, 期望 %+v", tt.describe, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestGetGitMetaDataSources(t *testing.T) {
+	git := newSemverTestRepo(t)
+	head := git("rev-parse", "--short=7", "HEAD")
+
+	tests := []struct {
+		name     string
+		sources  []string
+		prefix   string
+		env      string // GOB_GIT_VERSION
+		file     string // VERSION 文件内容, 为空时不创建
+		want     string // 期望的Git版本
+		semver   types.GitSemver
+		errMsgs  []string // 期望的错误信息, 不为空时期望返回错误
+		wantTime bool     // 期望提交时间不为 unknown
+	}{
+		{
+			name: "环境变量优先", sources: []string{"env", "git"}, env: "v3.0.0", file: "v1.0.0\n",
+			want:   "v3.0.0",
+			semver: types.GitSemver{Tag: "v3.0.0", Version: types.Semver{Major: 3}, Describe: "v3.0.0"},
+		},
+		{
+			name: "环境变量未设置时使用下一个来源", sources: []string{"env", "git"}, prefix: "cli/",
+			want:     "v2.0.0",
+			semver:   types.GitSemver{Tag: "cli/v2.0.0", Version: types.Semver{Major: 2}, Describe: "v2.0.0"},
+			wantTime: true,
+		},
+		{
+			name: "按配置顺序使用版本文件", sources: []string{"file", "env"}, env: "v3.0.0", file: "cli/v1.2.3-4-gabc1234\n", prefix: "cli/",
+			want:   "v1.2.3-4-gabc1234",
+			semver: types.GitSemver{Tag: "cli/v1.2.3", Version: types.Semver{Major: 1, Minor: 2, Patch: 3}, CommitsSinceTag: 4, IsSnapshot: true, Describe: "v1.2.3-4-gabc1234"},
+		},
+		{
+			name: "直接读取.git目录", sources: []string{"repo"},
+			want:     "v1.2.0-rc.1-1-g" + head,
+			semver:   types.GitSemver{Tag: "v1.2.0-rc.1", Version: types.Semver{Major: 1, Minor: 2, Prerelease: "rc.1"}, CommitsSinceTag: 1, IsSnapshot: true, Describe: "v1.2.0-rc.1-1-g" + head},
+			wantTime: true,
+		},
+		{
+			name: "版本号不是语义化版本", sources: []string{"env"}, env: "nightly",
+			want:   "nightly",
+			semver: types.GitSemver{IsSnapshot: true, Describe: "nightly"},
+		},
+		{
+			name: "所有来源均不可用", sources: []string{"env", "file", "bogus"},
+			errMsgs: []string{"所有来源均不可用", "env: 环境变量 GOB_GIT_VERSION 未设置", "file: 版本文件", "bogus: 未知的来源"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOB_GIT_VERSION", tt.env)
+			if tt.file != "" {
+				if err := os.WriteFile("VERSION", []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.Remove("VERSION") })
+			}
+
+			config := GetDefaultConfig()
+			config.Build.Output.Name = "myapp"
+			config.Build.Git.Sources = tt.sources
+			config.Build.Git.TagPrefix = tt.prefix
+			v := &verman.Info{}
+			err := GetGitMetaData(testGitTimeout, v, config)
+			if len(tt.errMsgs) > 0 {
+				for _, msg := range tt.errMsgs {
+					if err == nil || !strings.Contains(err.Error(), msg) {
+						t.Errorf("期望错误包含 %q, got %v", msg, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.GitVersion != tt.want || v.AppName != "myapp" || v.BuildTime == "" {
+				t.Errorf("Git版本 = %q, 应用名称 = %q, 构建时间 = %q, 期望Git版本 %q", v.GitVersion, v.AppName, v.BuildTime, tt.want)
+			}
+			if config.GitSemver != tt.semver {
+				t.Errorf("GitSemver = %+v, 期望 %+v", config.GitSemver, tt.semver)
+			}
+			if (v.GitCommitTime != types.UnknownGitValue) != tt.wantTime {
+				t.Errorf("提交时间 = %q", v.GitCommitTime)
+			}
+		})
+	}
+}
+
+func TestSetUnknownGitMetaData(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Build.Output.Name = "myapp"
+	config.GitSemver = types.GitSemver{Tag: "v1.0.0", Version: types.Semver{Major: 1}}
+	v := &verman.Info{GitVersion: "v1.0.0", GitCommit: "abc1234"}
+
+	SetUnknownGitMetaData(v, config)
+	if v.AppName != "myapp" || v.BuildTime == "" {
+		t.Errorf("应设置应用名称和构建时间, got %+v", v)
+	}
+	for name, got := range map[string]string{"GitVersion": v.GitVersion, "GitCommit": v.GitCommit, "GitCommitTime": v.GitCommitTime, "GitTreeState": v.GitTreeState} {
+		if got != types.UnknownGitValue {
+			t.Errorf("%s = %q, 期望 unknown", name, got)
+		}
+	}
+	if want := (types.GitSemver{IsSnapshot: true, Describe: types.UnknownGitValue}); config.GitSemver != want {
+		t.Errorf("GitSemver = %+v, 期望 %+v", config.GitSemver, want)
+	}
+
+	// 快照版本从 0.0.0 计算下一个版本号
+	if got := GitTemplateData(v, config); got.Version != "unknown" || got.NextPatch != "0.0.1" || !got.IsSnapshot {
+		t.Errorf("GitTemplateData() = %+v", got)
+	}
+}
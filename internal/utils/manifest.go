@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// NewArtifact 根据输出目录下的文件创建产物清单条目
+//
+// 参数:
+//   - dir: 输出目录
+//   - path: 产物文件路径
+//   - kind: 产物类型
+//
+// 返回值:
+//   - types.Artifact: 填充了名称、相对路径、大小和sha256的条目, 目标信息由调用方设置
+//   - error: 文件不在输出目录中或读取失败时返回错误
+func NewArtifact(dir, path string, kind types.ArtifactKind) (types.Artifact, error) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return types.Artifact{}, fmt.Errorf("构建产物 %s 不在输出目录 %s 中", path, dir)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return types.Artifact{}, fmt.Errorf("读取构建产物 %s 失败: %w", path, err)
+	}
+
+	sum, err := FileChecksum(path, "sha256")
+	if err != nil {
+		return types.Artifact{}, fmt.Errorf("计算 %s 的校验和失败: %w", path, err)
+	}
+
+	return types.Artifact{
+		Name:   filepath.Base(path),
+		Path:   filepath.ToSlash(rel),
+		Kind:   kind,
+		Size:   info.Size(),
+		SHA256: sum,
+	}, nil
+}
+
+// WriteArtifactManifest 将产物清单写入输出目录
+//
+// 参数:
+//   - dir: 输出目录
+//   - name: 产物清单文件名
+//   - manifest: 产物清单
+//
+// 返回值:
+//   - string: 产物清单文件路径
+//   - error: 序列化或写入失败时返回错误
+func WriteArtifactManifest(dir, name string, manifest *types.ArtifactManifest) (string, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化产物清单失败: %w", err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("写入产物清单 %s 失败: %w", path, err)
+	}
+	return path, nil
+}
+
+// ReadArtifactManifest 读取输出目录下的产物清单
+//
+// 参数:
+//   - dir: 输出目录
+//   - name: 产物清单文件名
+//
+// 返回值:
+//   - *types.ArtifactManifest: 产物清单
+//   - error: 文件不存在或格式错误时返回错误
+func ReadArtifactManifest(dir, name string) (*types.ArtifactManifest, error) {
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("产物清单 %s 不存在, 请先执行构建并启用 build.manifest", path)
+		}
+		return nil, fmt.Errorf("读取产物清单 %s 失败: %w", path, err)
+	}
+
+	var manifest types.ArtifactManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析产物清单 %s 失败: %w", path, err)
+	}
+	return &manifest, nil
+}
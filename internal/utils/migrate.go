@@ -0,0 +1,525 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// legacyKey 描述旧版本配置格式中的一个配置项及其在当前格式中的位置
+type legacyKey struct {
+	From    string                 // 旧配置项路径
+	To      string                 // 新配置项路径
+	Convert func(any) (any, error) // 值转换函数, 为nil时原样保留
+	Note    string                 // 迁移说明
+}
+
+// legacyKeys 版本1(未声明 version 的配置文件)中已更名或移动的配置项
+var legacyKeys = []legacyKey{
+	{From: "timeout", To: "build.compiler.timeout", Convert: secondsToDuration, Note: "单位由秒改为带单位的时间字符串"},
+	{From: "build.timeout", To: "build.compiler.timeout", Convert: secondsToDuration, Note: "单位由秒改为带单位的时间字符串"},
+	{From: "build.command.timeout", To: "build.compiler.timeout", Convert: secondsToDuration, Note: "单位由秒改为带单位的时间字符串"},
+	{From: "build.output.simple_name", To: "build.output.simple"},
+	{From: "build.build_command", To: "build.command.build"},
+	{From: "build.git_ldflags", To: "build.git.ldflags"},
+}
+
+// configMigration 单个配置文件的迁移结果
+type configMigration struct {
+	Moved    []string       // 已迁移的旧配置项路径
+	Values   map[string]any // 迁移后写入的新配置项及其值
+	Warnings []string       // 迁移提示
+}
+
+// migrateConfigDoc 将旧版本格式的配置文档原地升级为当前格式
+//
+// 参数:
+//   - doc: TOML解析得到的配置文档, 会被原地修改
+//
+// 返回值:
+//   - *configMigration: 迁移结果
+//   - error: 版本号无效或旧配置项的值无法转换时返回错误
+//
+// 注意:
+//   - 未声明 version 的配置文件视为版本1
+//   - 声明了当前版本的配置文件不做迁移, 旧配置项按未知配置项报告
+//   - 新旧配置项同时存在时以新配置项为准
+func migrateConfigDoc(doc map[string]any) (*configMigration, error) {
+	migration := &configMigration{Values: make(map[string]any)}
+
+	version, err := configVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version >= types.CurrentConfigVersion {
+		return migration, nil
+	}
+
+	for _, key := range legacyKeys {
+		value, ok := lookupConfigPath(doc, key.From)
+		if !ok {
+			continue
+		}
+		deleteConfigPath(doc, key.From)
+		migration.Moved = append(migration.Moved, key.From)
+
+		// 新配置项已存在时忽略旧配置项
+		if _, exists := lookupConfigPath(doc, key.To); exists {
+			migration.Warnings = append(migration.Warnings, fmt.Sprintf("已存在 %s, 忽略旧配置项 %s", key.To, key.From))
+			continue
+		}
+
+		if key.Convert != nil {
+			if value, err = key.Convert(value); err != nil {
+				return nil, fmt.Errorf("迁移旧配置项 %s 失败: %w", key.From, err)
+			}
+		}
+		if err := setConfigPath(doc, key.To, value); err != nil {
+			return nil, fmt.Errorf("迁移旧配置项 %s 失败: %w", key.From, err)
+		}
+		migration.Values[key.To] = value
+
+		warning := fmt.Sprintf("旧配置项 %s 已迁移为 %s", key.From, key.To)
+		if key.Note != "" {
+			warning += fmt.Sprintf(" (%s, 迁移后为 %q)", key.Note, value)
+		}
+		migration.Warnings = append(migration.Warnings, warning)
+	}
+
+	return migration, nil
+}
+
+// configVersion 读取配置文档中声明的格式版本
+//
+// 参数:
+//   - doc: 配置文档
+//
+// 返回值:
+//   - int64: 格式版本, 未声明时为1
+//   - error: 版本号不是正整数或高于当前支持的版本时返回错误
+func configVersion(doc map[string]any) (int64, error) {
+	raw, ok := doc["version"]
+	if !ok {
+		return 1, nil
+	}
+	version, ok := raw.(int64)
+	if !ok || version < 1 {
+		return 0, fmt.Errorf("version 必须是正整数, 当前为 %v", raw)
+	}
+	if version > types.CurrentConfigVersion {
+		return 0, fmt.Errorf("配置文件版本 %d 高于当前 gob 支持的版本 %d, 请升级 gob", version, types.CurrentConfigVersion)
+	}
+	return version, nil
+}
+
+// secondsToDuration 将旧版本以秒为单位的超时时间转换为带单位的时间字符串
+func secondsToDuration(value any) (any, error) {
+	switch v := value.(type) {
+	case int64:
+		return fmt.Sprintf("%ds", v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64) + "s", nil
+	case string:
+		if _, err := time.ParseDuration(v); err == nil {
+			return v, nil
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return v + "s", nil
+		}
+	}
+	return nil, fmt.Errorf("无法将 %v 转换为超时时间", value)
+}
+
+// lookupConfigPath 按以点分隔的路径查找配置文档中的值
+func lookupConfigPath(doc map[string]any, path string) (any, bool) {
+	keys := strings.Split(path, ".")
+	table := doc
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := table[key].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		table = next
+	}
+	value, ok := table[keys[len(keys)-1]]
+	return value, ok
+}
+
+// deleteConfigPath 按以点分隔的路径删除配置文档中的值
+func deleteConfigPath(doc map[string]any, path string) {
+	keys := strings.Split(path, ".")
+	table := doc
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := table[key].(map[string]any)
+		if !ok {
+			return
+		}
+		table = next
+	}
+	delete(table, keys[len(keys)-1])
+}
+
+// setConfigPath 按以点分隔的路径设置配置文档中的值, 自动创建中间的表
+func setConfigPath(doc map[string]any, path string, value any) error {
+	keys := strings.Split(path, ".")
+	table := doc
+	for i, key := range keys[:len(keys)-1] {
+		next, ok := table[key].(map[string]any)
+		if !ok {
+			if _, exists := table[key]; exists {
+				return fmt.Errorf("%s 不是表", strings.Join(keys[:i+1], "."))
+			}
+			next = make(map[string]any)
+			table[key] = next
+		}
+		table = next
+	}
+	table[keys[len(keys)-1]] = value
+	return nil
+}
+
+// MigrateConfigFile 将旧版本格式的配置文件原地升级为当前格式, 保留文件中的注释
+//
+// 参数:
+//   - filePath: 配置文件路径
+//
+// 返回值:
+//   - bool: 文件是否被修改
+//   - []string: 迁移提示
+//   - error: 读取、解析或写入失败时返回错误
+//
+// 注意:
+//   - 旧配置项所在的行被移除, 新配置项写入目标表的表头之后, 目标表不存在时追加到文件末尾
+//   - 写入前会重新解析改写后的内容, 与直接迁移的结果不一致时放弃写入
+func MigrateConfigFile(filePath string) (bool, []string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, nil, fmt.Errorf("读取配置文件 %s 失败: %w", filePath, err)
+	}
+
+	doc := make(map[string]any)
+	if err := toml.Unmarshal(content, &doc); err != nil {
+		return false, nil, fmt.Errorf("解析配置文件 %s 失败: %w", filePath, err)
+	}
+	version, err := configVersion(doc)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	if version >= types.CurrentConfigVersion {
+		return false, nil, nil
+	}
+
+	migration, err := migrateConfigDoc(doc)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	rewritten, err := rewriteLegacyKeys(string(content), migration)
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	// 校验改写结果与直接迁移的结果一致
+	doc["version"] = types.CurrentConfigVersion
+	check := make(map[string]any)
+	if err := toml.Unmarshal([]byte(rewritten), &check); err != nil {
+		return false, nil, fmt.Errorf("%s: 改写后的配置无法解析, 已放弃写入: %w", filePath, err)
+	}
+	if !reflect.DeepEqual(normalizeConfigDoc(doc), normalizeConfigDoc(check)) {
+		return false, nil, fmt.Errorf("%s: 改写后的配置与迁移结果不一致, 已放弃写入, 请手动迁移", filePath)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false, nil, fmt.Errorf("获取 %s 的文件信息失败: %w", filePath, err)
+	}
+	if err := os.WriteFile(filePath, []byte(rewritten), info.Mode().Perm()); err != nil {
+		return false, nil, fmt.Errorf("写入配置文件 %s 失败: %w", filePath, err)
+	}
+	return true, migration.Warnings, nil
+}
+
+// normalizeConfigDoc 统一配置文档中的数值类型, 便于比较
+func normalizeConfigDoc(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = normalizeConfigDoc(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalizeConfigDoc(item)
+		}
+		return out
+	case int:
+		return int64(v)
+	}
+	return value
+}
+
+// legacyEdit 对配置文件文本的一处改写
+type legacyEdit struct {
+	Start, End int    // 被移除的文本范围
+	Path       string // 配置项路径
+	Value      string // 原始值文本
+	Comment    string // 行尾注释
+}
+
+// rewriteLegacyKeys 在保留注释的前提下将旧配置项改写为新配置项
+//
+// 参数:
+//   - content: 配置文件内容
+//   - migration: 配置文档的迁移结果
+//
+// 返回值:
+//   - string: 改写后的内容
+//   - error: 无法定位旧配置项时返回错误
+func rewriteLegacyKeys(content string, migration *configMigration) (string, error) {
+	moved := make(map[string]bool, len(migration.Moved))
+	for _, path := range migration.Moved {
+		moved[path] = true
+	}
+
+	var (
+		edits        []legacyEdit
+		headers      = make(map[string]int) // 表路径到表头行末尾的偏移
+		table        string
+		firstContent = -1
+		versionEdit  *legacyEdit
+	)
+
+	for pos := 0; pos < len(content); {
+		lineEnd := strings.IndexByte(content[pos:], '\n')
+		next := len(content)
+		if lineEnd >= 0 {
+			next = pos + lineEnd + 1
+		}
+		line := strings.TrimSpace(content[pos:next])
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			pos = next
+			continue
+		case strings.HasPrefix(line, "["):
+			if firstContent < 0 {
+				firstContent = pos
+			}
+			table = parseTableHeader(line)
+			if !strings.HasPrefix(line, "[[") {
+				headers[table] = next
+			}
+			pos = next
+			continue
+		}
+
+		if firstContent < 0 {
+			firstContent = pos
+		}
+		eq := strings.IndexByte(content[pos:next], '=')
+		if eq < 0 {
+			pos = next
+			continue
+		}
+		key := strings.Trim(strings.TrimSpace(content[pos:pos+eq]), `"'`)
+		valueStart := pos + eq + 1
+		valueEnd, comment, end := scanTOMLValue(content, valueStart)
+		path := joinConfigPath(table, key)
+
+		edit := legacyEdit{Start: pos, End: end, Path: path, Value: strings.TrimSpace(content[valueStart:valueEnd]), Comment: comment}
+		if moved[path] {
+			edits = append(edits, edit)
+		} else if path == "version" {
+			versionEdit = &edit
+		}
+		pos = end
+	}
+
+	if len(edits) != len(migration.Moved) {
+		return "", errors.New("无法在文件中定位所有旧配置项, 请手动迁移")
+	}
+
+	// 按目标表收集需要插入的新配置项
+	inserts := make(map[string][]string)
+	var order []string
+	for _, edit := range edits {
+		key := legacyKeyFor(edit.Path)
+		value, ok := migration.Values[key.To]
+		if !ok {
+			// 新配置项已存在, 仅移除旧配置项
+			continue
+		}
+
+		text := edit.Value
+		if key.Convert != nil {
+			text = strconv.Quote(fmt.Sprint(value))
+		}
+		idx := strings.LastIndex(key.To, ".")
+		target, name := key.To[:idx], key.To[idx+1:]
+		line := fmt.Sprintf("%s = %s", name, text)
+		if edit.Comment != "" {
+			line += " " + edit.Comment
+		}
+		if _, ok := inserts[target]; !ok {
+			order = append(order, target)
+		}
+		inserts[target] = append(inserts[target], line+"\n")
+	}
+
+	// 按偏移生成改写后的内容
+	type insertion struct {
+		At   int
+		Text string
+	}
+	var insertions []insertion
+	versionLine := fmt.Sprintf("version = %d\n", types.CurrentConfigVersion)
+	if versionEdit != nil {
+		insertions = append(insertions, insertion{At: versionEdit.Start, Text: versionLine})
+		edits = append(edits, *versionEdit)
+	} else {
+		if firstContent < 0 {
+			firstContent = len(content)
+		}
+		// 首个配置项被移除且其后是空行时, 沿用该空行分隔
+		text := versionLine + "\n"
+		for _, edit := range edits {
+			if edit.Start == firstContent && (edit.End == len(content) || content[edit.End] == '\n') {
+				text = versionLine
+			}
+		}
+		insertions = append(insertions, insertion{At: firstContent, Text: text})
+	}
+
+	var appendix strings.Builder
+	for _, target := range order {
+		if at, ok := headers[target]; ok {
+			insertions = append(insertions, insertion{At: at, Text: strings.Join(inserts[target], "")})
+			continue
+		}
+		fmt.Fprintf(&appendix, "\n[%s]\n%s", target, strings.Join(inserts[target], ""))
+	}
+
+	removed := make(map[int]int, len(edits))
+	for _, edit := range edits {
+		removed[edit.Start] = edit.End
+	}
+
+	var sb strings.Builder
+	for pos := 0; pos <= len(content); {
+		for _, ins := range insertions {
+			if ins.At == pos {
+				sb.WriteString(ins.Text)
+			}
+		}
+		if pos == len(content) {
+			break
+		}
+		if end, ok := removed[pos]; ok {
+			pos = end
+			continue
+		}
+		sb.WriteByte(content[pos])
+		pos++
+	}
+
+	result := sb.String()
+	if appendix.Len() > 0 {
+		if !strings.HasSuffix(result, "\n") {
+			result += "\n"
+		}
+		result += appendix.String()
+	}
+	return result, nil
+}
+
+// legacyKeyFor 查找旧配置项路径对应的迁移规则
+func legacyKeyFor(path string) legacyKey {
+	for _, key := range legacyKeys {
+		if key.From == path {
+			return key
+		}
+	}
+	return legacyKey{}
+}
+
+// parseTableHeader 解析表头行, 返回以点分隔的表路径
+func parseTableHeader(line string) string {
+	line = strings.TrimPrefix(strings.TrimPrefix(line, "["), "[")
+	if idx := strings.IndexByte(line, ']'); idx >= 0 {
+		line = line[:idx]
+	}
+	parts := strings.Split(line, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// scanTOMLValue 从值的起始位置扫描到值的结尾, 支持跨行的数组和内联表
+//
+// 参数:
+//   - s: 配置文件内容
+//   - i: 值的起始偏移(等号之后)
+//
+// 返回值:
+//   - int: 值的结束偏移(不含行尾注释)
+//   - string: 行尾注释, 不存在时为空
+//   - int: 值所在的最后一行的下一行起始偏移
+func scanTOMLValue(s string, i int) (int, string, int) {
+	depth := 0
+	for i < len(s) {
+		switch c := s[i]; {
+		case strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], `'''`):
+			quote := s[i : i+3]
+			end := strings.Index(s[i+3:], quote)
+			if end < 0 {
+				return len(s), "", len(s)
+			}
+			i += 3 + end + 3
+			continue
+		case c == '"':
+			i++
+			for i < len(s) && s[i] != '"' && s[i] != '\n' {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+		case c == '\'':
+			i++
+			for i < len(s) && s[i] != '\'' && s[i] != '\n' {
+				i++
+			}
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == '#':
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			if depth == 0 {
+				next := min(i+end+1, len(s))
+				return i, strings.TrimSpace(s[i : i+end]), next
+			}
+			i += end
+			continue
+		case c == '\n':
+			if depth == 0 {
+				return i, "", i + 1
+			}
+		}
+		i++
+	}
+	return len(s), "", len(s)
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+func TestMigrateConfigDoc(t *testing.T) {
+	doc := map[string]any{
+		"timeout": int64(30),
+		"build": map[string]any{
+			"output":        map[string]any{"simple_name": true},
+			"build_command": []any{"go", "build"},
+			"git_ldflags":   "-X main.v={{GitVersion}}",
+		},
+	}
+	migration, err := migrateConfigDoc(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"build": map[string]any{
+			"output":   map[string]any{"simple": true},
+			"command":  map[string]any{"build": []any{"go", "build"}},
+			"git":      map[string]any{"ldflags": "-X main.v={{GitVersion}}"},
+			"compiler": map[string]any{"timeout": "30s"},
+		},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("迁移后的文档 = %v\n期望 %v", doc, want)
+	}
+	if got := migration.Moved; !reflect.DeepEqual(got, []string{"timeout", "build.output.simple_name", "build.build_command", "build.git_ldflags"}) {
+		t.Errorf("已迁移的配置项 = %v", got)
+	}
+	if len(migration.Warnings) != 4 || migration.Warnings[0] != `旧配置项 timeout 已迁移为 build.compiler.timeout (单位由秒改为带单位的时间字符串, 迁移后为 "30s")` {
+		t.Errorf("迁移提示 = %q", migration.Warnings)
+	}
+}
+
+func TestMigrateConfigDocKeepsNewKey(t *testing.T) {
+	doc := map[string]any{
+		"build": map[string]any{
+			"timeout":  int64(10),
+			"compiler": map[string]any{"timeout": "2m"},
+		},
+	}
+	migration, err := migrateConfigDoc(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := lookupConfigPath(doc, "build.compiler.timeout"); got != "2m" {
+		t.Errorf("新旧配置项同时存在时应以新配置项为准, got %v", got)
+	}
+	if _, ok := lookupConfigPath(doc, "build.timeout"); ok {
+		t.Error("旧配置项应被删除")
+	}
+	if len(migration.Warnings) != 1 || !strings.Contains(migration.Warnings[0], "忽略旧配置项 build.timeout") {
+		t.Errorf("迁移提示 = %q", migration.Warnings)
+	}
+}
+
+func TestMigrateConfigDocCurrentVersion(t *testing.T) {
+	doc := map[string]any{"version": int64(types.CurrentConfigVersion), "timeout": int64(30)}
+	migration, err := migrateConfigDoc(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migration.Moved) != 0 || doc["timeout"] != int64(30) {
+		t.Errorf("当前版本的配置不应迁移, got %v", doc)
+	}
+
+	for _, version := range []any{int64(0), "2", int64(types.CurrentConfigVersion + 1)} {
+		if _, err := migrateConfigDoc(map[string]any{"version": version}); err == nil {
+			t.Errorf("version = %v 时期望返回错误", version)
+		}
+	}
+	if _, err := migrateConfigDoc(map[string]any{"timeout": "abc"}); err == nil {
+		t.Error("旧超时时间无法转换时期望返回错误")
+	}
+}
+
+func TestSecondsToDuration(t *testing.T) {
+	tests := map[any]any{
+		int64(30):  "30s",
+		1.5:        "1.5s",
+		"45":       "45s",
+		"2m":       "2m",
+		"1h30m10s": "1h30m10s",
+	}
+	for input, want := range tests {
+		got, err := secondsToDuration(input)
+		if err != nil || got != want {
+			t.Errorf("secondsToDuration(%v) = %v, %v, 期望 %v", input, got, err, want)
+		}
+	}
+	for _, input := range []any{"soon", true, nil} {
+		if _, err := secondsToDuration(input); err == nil {
+			t.Errorf("secondsToDuration(%v) 期望返回错误", input)
+		}
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"gob.toml": `# 旧版本配置
+timeout = 30 # 编译超时
+
+[build]
+build_command = [
+  "go", "build", # 编译命令
+  "-o", "{{output}}",
+]
+
+[build.output]
+dir = "dist"
+simple_name = true
+`})
+	path := filepath.Join(dir, "gob.toml")
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, warnings, err := MigrateConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || len(warnings) != 3 {
+		t.Fatalf("期望文件被修改并给出3条提示, got %v %q", changed, warnings)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# 旧版本配置
+version = 2
+
+[build]
+
+[build.output]
+simple = true
+dir = "dist"
+
+[build.compiler]
+timeout = "30s" # 编译超时
+
+[build.command]
+build = [
+  "go", "build", # 编译命令
+  "-o", "{{output}}",
+]
+`
+	if string(content) != want {
+		t.Errorf("迁移后的文件内容:\n%s\n期望:\n%s", content, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("迁移后应保留文件权限, got %v %v", info.Mode().Perm(), err)
+	}
+
+	// 迁移后的文件可以按当前格式加载, 再次迁移不做修改
+	if errs := checkConfigKeys(path, content, nil); len(errs) > 0 {
+		t.Errorf("迁移后的文件不应包含未知配置项: %v", errs)
+	}
+	if changed, _, err := MigrateConfigFile(path); err != nil || changed {
+		t.Errorf("当前版本的配置文件不应再次修改, got %v %v", changed, err)
+	}
+}
+
+func TestLoadConfigMigratesLegacyKeys(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"gob.toml": "timeout = 90\n\n[build.output]\nsimple_name = true\n"})
+	config, err := LoadConfig(filepath.Join(dir, "gob.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Build.Compiler.Timeout != "90s" || config.Build.TimeoutDuration.Seconds() != 90 || !config.Build.Output.Simple {
+		t.Errorf("加载旧版本配置时应自动迁移: %q %v %v", config.Build.Compiler.Timeout, config.Build.TimeoutDuration, config.Build.Output.Simple)
+	}
+
+	// 原文件保持不变
+	content, err := os.ReadFile(filepath.Join(dir, "gob.toml"))
+	if err != nil || !strings.HasPrefix(string(content), "timeout = 90") {
+		t.Errorf("加载配置不应修改文件: %q", content)
+	}
+}
+
+func TestMigrateConfigFileVersionLine(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{
+			"插入到首个表头之前",
+			"# 注释\n[build.output]\nsimple_name = true\n",
+			"# 注释\nversion = 2\n\n[build.output]\nsimple = true\n",
+		},
+		{
+			"被移除的首个配置项后没有空行",
+			"timeout = 5\n[build.output]\ndir = \"dist\"\n",
+			"version = 2\n\n[build.output]\ndir = \"dist\"\n\n[build.compiler]\ntimeout = \"5s\"\n",
+		},
+		{
+			"替换已声明的旧版本号",
+			"version = 1 # 格式版本\ntimeout = 5\n",
+			"version = 2\n\n[build.compiler]\ntimeout = \"5s\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, map[string]string{"gob.toml": tt.content})
+			path := filepath.Join(dir, "gob.toml")
+			if _, _, err := MigrateConfigFile(path); err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("迁移后的文件内容 = %q, 期望 %q", content, tt.want)
+			}
+		})
+	}
+}
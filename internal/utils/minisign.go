@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// minisign 格式的ed25519签名, 签名文件由不可信注释、签名、可信注释和全局签名四行组成
+// 非预哈希("Ed")的签名与signify兼容, signify只读取前两行
+
+const (
+	minisignAlg          = "Ed"                  // 直接对文件内容签名
+	minisignAlgHashed    = "ED"                  // 对文件的BLAKE2b-512哈希签名
+	minisignKDFScrypt    = "Sc"                  // 私钥使用scrypt派生的密钥加密
+	minisignKDFNone      = "\x00\x00"            // 私钥未加密
+	minisignChecksumAlg  = "B2"                  // 私钥校验和使用BLAKE2b-256
+	minisignCommentLine  = "untrusted comment: " // 不可信注释的前缀
+	minisignTrustedLine  = "trusted comment: "   // 可信注释的前缀
+	minisignKeyNumLen    = 8                     // 密钥编号长度
+	minisignSecretKeyLen = 158                   // 私钥结构的长度
+	minisignKeyNumSKLen  = 104                   // 私钥中加密部分(密钥编号、私钥和校验和)的长度
+)
+
+// minisignSigner 使用minisign格式的私钥签名
+type minisignSigner struct {
+	keyNum  [minisignKeyNumLen]byte // 密钥编号
+	key     ed25519.PrivateKey      // 私钥
+	prehash bool                    // 是否对文件哈希签名
+}
+
+// minisignVerifier 使用minisign或signify格式的公钥校验签名
+type minisignVerifier struct {
+	keyNum [minisignKeyNumLen]byte // 密钥编号
+	key    ed25519.PublicKey       // 公钥
+}
+
+// parseMinisignSecretKey 解析minisign格式的私钥
+//
+// 参数:
+//   - data: 私钥文件内容
+//   - passphrase: 私钥口令, 私钥未加密时忽略
+//   - prehash: 是否对文件的BLAKE2b-512哈希签名
+//
+// 返回值:
+//   - *minisignSigner: 签名器
+//   - error: 格式无效、缺少口令或口令错误时返回错误
+func parseMinisignSecretKey(data []byte, passphrase string, prehash bool) (*minisignSigner, error) {
+	raw, err := decodeMinisignBlock(data)
+	if err != nil {
+		return nil, fmt.Errorf("无效的minisign私钥: %w", err)
+	}
+	if len(raw) != minisignSecretKeyLen || string(raw[:2]) != minisignAlg || string(raw[4:6]) != minisignChecksumAlg {
+		return nil, fmt.Errorf("无效的minisign私钥, 如为signify私钥请先通过minisign转换")
+	}
+
+	keyNumSK := raw[minisignSecretKeyLen-minisignKeyNumSKLen:]
+	switch string(raw[2:4]) {
+	case minisignKDFNone:
+	case minisignKDFScrypt:
+		if passphrase == "" {
+			return nil, errPassphraseRequired
+		}
+		salt := raw[6:38]
+		opsLimit := binary.LittleEndian.Uint64(raw[38:46])
+		memLimit := binary.LittleEndian.Uint64(raw[46:54])
+		n, r, p := scryptParams(opsLimit, memLimit)
+		stream, err := scryptKey([]byte(passphrase), salt, n, r, p, minisignKeyNumSKLen)
+		if err != nil {
+			return nil, fmt.Errorf("派生私钥的解密密钥失败: %w", err)
+		}
+		subtle.XORBytes(keyNumSK, keyNumSK, stream)
+	default:
+		return nil, fmt.Errorf("不支持的minisign私钥加密方式")
+	}
+
+	s := &minisignSigner{prehash: prehash}
+	copy(s.keyNum[:], keyNumSK[:minisignKeyNumLen])
+	s.key = ed25519.PrivateKey(bytes.Clone(keyNumSK[minisignKeyNumLen : minisignKeyNumLen+ed25519.PrivateKeySize]))
+
+	h := newBlake2b256()
+	h.Write(raw[:2])
+	h.Write(keyNumSK[:minisignKeyNumLen+ed25519.PrivateKeySize])
+	if subtle.ConstantTimeCompare(h.Sum(nil), keyNumSK[minisignKeyNumLen+ed25519.PrivateKeySize:]) != 1 {
+		return nil, errWrongPassphrase
+	}
+	return s, nil
+}
+
+// scryptParams 按libsodium的规则将minisign私钥中的opslimit和memlimit换算为scrypt参数
+func scryptParams(opsLimit, memLimit uint64) (n, r, p int) {
+	opsLimit = max(opsLimit, 32768)
+	r = 8
+	var maxN uint64
+	if opsLimit < memLimit/32 {
+		p = 1
+		maxN = opsLimit / (uint64(r) * 4)
+	} else {
+		maxN = memLimit / (uint64(r) * 128)
+	}
+	logN := 1
+	for ; logN < 63; logN++ {
+		if uint64(1)<<logN > maxN/2 {
+			break
+		}
+	}
+	if opsLimit >= memLimit/32 {
+		maxRP := min((opsLimit/4)>>logN, 0x3fffffff)
+		p = int(maxRP) / r
+	}
+	return 1 << logN, r, p
+}
+
+func (s *minisignSigner) KeyID() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(s.keyNum[:]))
+}
+
+func (s *minisignSigner) Extension() string {
+	return ".sig"
+}
+
+// Sign 生成minisign格式的签名, 可信注释包含签名时间和文件名
+func (s *minisignSigner) Sign(path string) ([]byte, error) {
+	alg := minisignAlg
+	trusted := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), filepath.Base(path))
+	if s.prehash {
+		alg = minisignAlgHashed
+		trusted += "\thashed"
+	}
+	message, err := minisignMessage(path, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := ed25519.Sign(s.key, message)
+	sigStruct := append(append([]byte(alg), s.keyNum[:]...), sig...)
+	globalSig := ed25519.Sign(s.key, append(bytes.Clone(sig), trusted...))
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%ssignature from minisign secret key\n", minisignCommentLine)
+	fmt.Fprintf(&b, "%s\n", base64.StdEncoding.EncodeToString(sigStruct))
+	fmt.Fprintf(&b, "%s%s\n", minisignTrustedLine, trusted)
+	fmt.Fprintf(&b, "%s\n", base64.StdEncoding.EncodeToString(globalSig))
+	return b.Bytes(), nil
+}
+
+// parseMinisignPublicKey 解析minisign或signify格式的公钥
+//
+// 参数:
+//   - data: 公钥文件内容, 也可以只包含base64编码的公钥
+//
+// 返回值:
+//   - *minisignVerifier: 校验器
+//   - error: 格式无效时返回错误
+func parseMinisignPublicKey(data []byte) (*minisignVerifier, error) {
+	raw, err := decodeMinisignBlock(data)
+	if err != nil || len(raw) != 2+minisignKeyNumLen+ed25519.PublicKeySize || string(raw[:2]) != minisignAlg {
+		return nil, fmt.Errorf("无效的minisign公钥")
+	}
+	v := &minisignVerifier{key: ed25519.PublicKey(raw[2+minisignKeyNumLen:])}
+	copy(v.keyNum[:], raw[2:])
+	return v, nil
+}
+
+func (v *minisignVerifier) KeyID() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(v.keyNum[:]))
+}
+
+func (v *minisignVerifier) Extension() string {
+	return ".sig"
+}
+
+// Verify 校验minisign或signify格式的签名, 签名包含可信注释时同时校验全局签名
+func (v *minisignVerifier) Verify(path string, sig []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], minisignCommentLine) {
+		return fmt.Errorf("无效的签名文件")
+	}
+	sigStruct, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sigStruct) != 2+minisignKeyNumLen+ed25519.SignatureSize {
+		return fmt.Errorf("无效的签名文件")
+	}
+	alg := string(sigStruct[:2])
+	if alg != minisignAlg && alg != minisignAlgHashed {
+		return fmt.Errorf("不支持的签名算法 %q", alg)
+	}
+	if !bytes.Equal(sigStruct[2:2+minisignKeyNumLen], v.keyNum[:]) {
+		return fmt.Errorf("签名使用的密钥 %016X 与公钥 %s 不一致", binary.LittleEndian.Uint64(sigStruct[2:]), v.KeyID())
+	}
+	signature := sigStruct[2+minisignKeyNumLen:]
+
+	message, err := minisignMessage(path, alg)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(v.key, message, signature) {
+		return fmt.Errorf("签名无效")
+	}
+
+	// signify的签名没有可信注释
+	if len(lines) < 4 || strings.TrimSpace(lines[2]) == "" {
+		return nil
+	}
+	trusted, ok := strings.CutPrefix(lines[2], minisignTrustedLine)
+	if !ok {
+		return fmt.Errorf("无效的可信注释")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(v.key, append(bytes.Clone(signature), trusted...), globalSig) {
+		return fmt.Errorf("可信注释的签名无效")
+	}
+	return nil
+}
+
+// minisignMessage 返回签名的消息, 预哈希签名为文件的BLAKE2b-512哈希, 否则为文件内容
+func minisignMessage(path, alg string) ([]byte, error) {
+	if alg != minisignAlgHashed {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		return data, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	h := newBlake2b512()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// decodeMinisignBlock 解码跳过不可信注释后的第一行base64内容
+func decodeMinisignBlock(data []byte) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, minisignCommentLine) {
+			continue
+		}
+		return base64.StdEncoding.DecodeString(line)
+	}
+	return nil, fmt.Errorf("缺少base64编码的内容")
+}
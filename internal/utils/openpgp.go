@@ -0,0 +1,850 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// OpenPGP(RFC 4880)分离签名的最小实现, 支持RSA和Ed25519(EdDSA)的v4密钥
+// 私钥可以未加密, 或使用AES和迭代加盐的S2K加密(gpg --export-secret-keys 的默认格式)
+
+const (
+	pgpTagSignature    = 2  // 签名包
+	pgpTagSecretKey    = 5  // 私钥包
+	pgpTagPublicKey    = 6  // 公钥包
+	pgpTagSecretSubkey = 7  // 私钥子密钥包
+	pgpTagPublicSubkey = 14 // 公钥子密钥包
+
+	pgpAlgoRSA         = 1  // RSA(加密和签名)
+	pgpAlgoRSASignOnly = 3  // RSA(仅签名)
+	pgpAlgoEdDSA       = 22 // EdDSA
+
+	pgpHashSHA256 = 8 // 签名使用的哈希算法
+
+	pgpSubpacketCreationTime = 2  // 签名创建时间
+	pgpSubpacketIssuer       = 16 // 签名者的密钥ID
+	pgpSubpacketKeyFlags     = 27 // 密钥用途
+	pgpSubpacketIssuerFP     = 33 // 签名者的指纹
+
+	pgpKeyFlagSign = 0x02 // 密钥可用于签名
+)
+
+// pgpEd25519OID Ed25519曲线的OID(1.3.6.1.4.1.11591.15.1)
+var pgpEd25519OID = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x0f, 0x01}
+
+// pgpHashes 签名校验支持的哈希算法
+var pgpHashes = map[byte]crypto.Hash{
+	8:  crypto.SHA256,
+	9:  crypto.SHA384,
+	10: crypto.SHA512,
+	11: crypto.SHA224,
+}
+
+// pgpPacket 表示一个OpenPGP数据包
+type pgpPacket struct {
+	tag  byte   // 包类型
+	body []byte // 包内容
+}
+
+// pgpPublicKey 表示一个v4公钥
+type pgpPublicKey struct {
+	algo        byte              // 公钥算法
+	fingerprint [20]byte          // 指纹
+	rsa         *rsa.PublicKey    // RSA公钥
+	ed25519     ed25519.PublicKey // Ed25519公钥
+	flags       int               // 自签名中声明的密钥用途, -1表示未声明
+	primary     bool              // 是否为主密钥
+}
+
+// pgpSigner 使用OpenPGP私钥签名
+type pgpSigner struct {
+	pub     *pgpPublicKey      // 签名密钥的公钥
+	rsa     *rsa.PrivateKey    // RSA私钥
+	ed25519 ed25519.PrivateKey // Ed25519私钥
+}
+
+// pgpVerifier 使用OpenPGP公钥校验签名
+type pgpVerifier struct {
+	keys []*pgpPublicKey // 主密钥和子密钥中支持的密钥
+}
+
+// parsePGPSecretKey 解析OpenPGP私钥并选择用于签名的密钥
+//
+// 参数:
+//   - data: ASCII armor或二进制格式的私钥
+//   - passphrase: 私钥口令, 私钥未加密时忽略
+//
+// 返回值:
+//   - *pgpSigner: 签名器
+//   - error: 格式无效、没有可用于签名的密钥、缺少口令或口令错误时返回错误
+//
+// 注意:
+//   - 优先使用主密钥, 主密钥声明了不可签名或私钥不可用时使用第一个可签名的子密钥
+func parsePGPSecretKey(data []byte, passphrase string) (*pgpSigner, error) {
+	packets, err := readPGPData(data, "PGP PRIVATE KEY BLOCK")
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		pub    *pgpPublicKey
+		secret []byte // 公钥之后的私钥部分
+	}
+	var candidates []*candidate
+	var current *candidate
+	for _, p := range packets {
+		switch p.tag {
+		case pgpTagSecretKey, pgpTagSecretSubkey:
+			current = nil
+			pub, n, err := parsePGPPublicKey(p.body)
+			if err != nil {
+				// 跳过不支持的密钥, 如Curve25519加密子密钥
+				continue
+			}
+			pub.primary = p.tag == pgpTagSecretKey
+			current = &candidate{pub: pub, secret: p.body[n:]}
+			candidates = append(candidates, current)
+		case pgpTagSignature:
+			if current != nil {
+				if flags, ok := pgpSignatureKeyFlags(p.body); ok {
+					current.pub.flags = flags
+				}
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		canSign := c.pub.flags&pgpKeyFlagSign != 0 || (c.pub.flags < 0 && c.pub.primary)
+		if !canSign || pgpSecretUnavailable(c.secret) {
+			continue
+		}
+		return decryptPGPSecretKey(c.pub, c.secret, passphrase)
+	}
+	return nil, fmt.Errorf("OpenPGP私钥中没有可用于签名的RSA或Ed25519密钥")
+}
+
+// pgpSecretUnavailable 判断私钥部分是否为gpg导出的占位符(如主密钥离线保存时的 --export-secret-subkeys)
+func pgpSecretUnavailable(secret []byte) bool {
+	return len(secret) >= 5 && (secret[0] == 254 || secret[0] == 255) && secret[2] == 101
+}
+
+// decryptPGPSecretKey 解密私钥部分
+//
+// 参数:
+//   - pub: 对应的公钥
+//   - secret: 私钥包中公钥之后的内容
+//   - passphrase: 私钥口令
+//
+// 返回值:
+//   - *pgpSigner: 签名器
+//   - error: 加密方式不支持、缺少口令或口令错误时返回错误
+func decryptPGPSecretKey(pub *pgpPublicKey, secret []byte, passphrase string) (*pgpSigner, error) {
+	if len(secret) == 0 {
+		return nil, errInvalidPGPKey
+	}
+	usage := secret[0]
+	secret = secret[1:]
+
+	var plain []byte
+	switch usage {
+	case 0:
+		if len(secret) < 2 || pgpChecksum(secret[:len(secret)-2]) != binary.BigEndian.Uint16(secret[len(secret)-2:]) {
+			return nil, errInvalidPGPKey
+		}
+		plain = secret[:len(secret)-2]
+	case 254, 255:
+		if passphrase == "" {
+			return nil, errPassphraseRequired
+		}
+		if len(secret) < 2 {
+			return nil, errInvalidPGPKey
+		}
+		keyLen, ok := map[byte]int{7: 16, 8: 24, 9: 32}[secret[0]]
+		if !ok {
+			return nil, fmt.Errorf("不支持的私钥加密算法 %d, 仅支持AES", secret[0])
+		}
+		key, n, err := pgpS2K(secret[1:], passphrase, keyLen)
+		if err != nil {
+			return nil, err
+		}
+		rest := secret[1+n:]
+		if len(rest) < aes.BlockSize {
+			return nil, errInvalidPGPKey
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		plain = make([]byte, len(rest)-aes.BlockSize)
+		pgpCFBDecrypt(block.Encrypt, rest[:aes.BlockSize], plain, rest[aes.BlockSize:])
+
+		if usage == 254 {
+			if len(plain) < sha1.Size {
+				return nil, errWrongPassphrase
+			}
+			sum := sha1.Sum(plain[:len(plain)-sha1.Size])
+			if subtle.ConstantTimeCompare(sum[:], plain[len(plain)-sha1.Size:]) != 1 {
+				return nil, errWrongPassphrase
+			}
+			plain = plain[:len(plain)-sha1.Size]
+		} else {
+			if len(plain) < 2 || pgpChecksum(plain[:len(plain)-2]) != binary.BigEndian.Uint16(plain[len(plain)-2:]) {
+				return nil, errWrongPassphrase
+			}
+			plain = plain[:len(plain)-2]
+		}
+	default:
+		return nil, fmt.Errorf("不支持的私钥保护方式 %d", usage)
+	}
+
+	s := &pgpSigner{pub: pub}
+	switch pub.algo {
+	case pgpAlgoRSA, pgpAlgoRSASignOnly:
+		var mpis [4]*big.Int
+		for i := range mpis {
+			v, n, err := readMPI(plain)
+			if err != nil {
+				return nil, err
+			}
+			mpis[i], plain = new(big.Int).SetBytes(v), plain[n:]
+		}
+		s.rsa = &rsa.PrivateKey{PublicKey: *pub.rsa, D: mpis[0], Primes: []*big.Int{mpis[1], mpis[2]}}
+		if err := s.rsa.Validate(); err != nil {
+			return nil, fmt.Errorf("无效的RSA私钥: %w", err)
+		}
+		s.rsa.Precompute()
+	case pgpAlgoEdDSA:
+		v, _, err := readMPI(plain)
+		if err != nil || len(v) > ed25519.SeedSize {
+			return nil, errInvalidPGPKey
+		}
+		seed := make([]byte, ed25519.SeedSize)
+		copy(seed[ed25519.SeedSize-len(v):], v)
+		s.ed25519 = ed25519.NewKeyFromSeed(seed)
+		if !bytes.Equal(s.ed25519.Public().(ed25519.PublicKey), pub.ed25519) {
+			return nil, errInvalidPGPKey
+		}
+	}
+	return s, nil
+}
+
+func (s *pgpSigner) KeyID() string {
+	return strings.ToUpper(hex.EncodeToString(s.pub.fingerprint[:]))
+}
+
+func (s *pgpSigner) Extension() string {
+	return ".asc"
+}
+
+// Sign 生成ASCII armor格式的二进制文档分离签名
+func (s *pgpSigner) Sign(path string) ([]byte, error) {
+	var hashed bytes.Buffer
+	hashed.Write([]byte{4, 0x00, s.pub.algo, pgpHashSHA256})
+	var subpackets bytes.Buffer
+	subpackets.Write([]byte{5, pgpSubpacketCreationTime})
+	_ = binary.Write(&subpackets, binary.BigEndian, uint32(time.Now().Unix()))
+	subpackets.Write([]byte{22, pgpSubpacketIssuerFP, 4})
+	subpackets.Write(s.pub.fingerprint[:])
+	_ = binary.Write(&hashed, binary.BigEndian, uint16(subpackets.Len()))
+	hashed.Write(subpackets.Bytes())
+
+	digest, err := pgpDigest(path, sha256.New(), hashed.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	body.Write(hashed.Bytes())
+	body.Write([]byte{0, 10, 9, pgpSubpacketIssuer})
+	body.Write(s.pub.fingerprint[12:])
+	body.Write(digest[:2])
+	switch {
+	case s.rsa != nil:
+		sig, err := rsa.SignPKCS1v15(nil, s.rsa, crypto.SHA256, digest)
+		if err != nil {
+			return nil, fmt.Errorf("签名失败: %w", err)
+		}
+		writeMPI(&body, sig)
+	default:
+		sig := ed25519.Sign(s.ed25519, digest)
+		writeMPI(&body, sig[:32])
+		writeMPI(&body, sig[32:])
+	}
+
+	var packet bytes.Buffer
+	packet.WriteByte(0xc0 | pgpTagSignature)
+	writePGPLength(&packet, body.Len())
+	packet.Write(body.Bytes())
+	return encodePGPArmor("PGP SIGNATURE", packet.Bytes()), nil
+}
+
+// parsePGPPublicKeys 解析OpenPGP公钥
+//
+// 参数:
+//   - data: ASCII armor或二进制格式的公钥
+//
+// 返回值:
+//   - *pgpVerifier: 校验器
+//   - error: 格式无效或没有支持的密钥时返回错误
+func parsePGPPublicKeys(data []byte) (*pgpVerifier, error) {
+	packets, err := readPGPData(data, "PGP PUBLIC KEY BLOCK")
+	if err != nil {
+		return nil, err
+	}
+	v := &pgpVerifier{}
+	for _, p := range packets {
+		if p.tag != pgpTagPublicKey && p.tag != pgpTagPublicSubkey {
+			continue
+		}
+		if pub, _, err := parsePGPPublicKey(p.body); err == nil {
+			pub.primary = p.tag == pgpTagPublicKey
+			v.keys = append(v.keys, pub)
+		}
+	}
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("OpenPGP公钥中没有支持的RSA或Ed25519密钥")
+	}
+	return v, nil
+}
+
+func (v *pgpVerifier) KeyID() string {
+	for _, k := range v.keys {
+		if k.primary {
+			return strings.ToUpper(hex.EncodeToString(k.fingerprint[:]))
+		}
+	}
+	return strings.ToUpper(hex.EncodeToString(v.keys[0].fingerprint[:]))
+}
+
+func (v *pgpVerifier) Extension() string {
+	return ".asc"
+}
+
+// Verify 校验二进制文档的分离签名, 签名者可以是主密钥或任一子密钥
+func (v *pgpVerifier) Verify(path string, sig []byte) error {
+	packets, err := readPGPData(sig, "PGP SIGNATURE")
+	if err != nil {
+		return err
+	}
+	var body []byte
+	for _, p := range packets {
+		if p.tag == pgpTagSignature {
+			body = p.body
+			break
+		}
+	}
+	if len(body) < 6 || body[0] != 4 {
+		return fmt.Errorf("无效的签名文件, 仅支持v4签名")
+	}
+	if body[1] != 0x00 {
+		return fmt.Errorf("不支持的签名类型 0x%02x, 仅支持二进制文档签名", body[1])
+	}
+	algo := body[2]
+	h, ok := pgpHashes[body[3]]
+	if !ok {
+		return fmt.Errorf("不支持的签名哈希算法 %d", body[3])
+	}
+
+	hashedLen := int(binary.BigEndian.Uint16(body[4:]))
+	if len(body) < 6+hashedLen+2 {
+		return errInvalidPGPSignature
+	}
+	hashedEnd := 6 + hashedLen
+	unhashedLen := int(binary.BigEndian.Uint16(body[hashedEnd:]))
+	if len(body) < hashedEnd+2+unhashedLen+2 {
+		return errInvalidPGPSignature
+	}
+	unhashed := body[hashedEnd+2 : hashedEnd+2+unhashedLen]
+	left16 := body[hashedEnd+2+unhashedLen:][:2]
+	sigMPIs := body[hashedEnd+2+unhashedLen+2:]
+
+	// 签名者的指纹或密钥ID, 未包含时尝试所有密钥
+	var issuer []byte
+	for _, area := range [][]byte{body[6:hashedEnd], unhashed} {
+		for typ, data := range pgpSubpackets(area) {
+			switch {
+			case typ == pgpSubpacketIssuerFP && len(data) == 21 && data[0] == 4:
+				issuer = data[1:]
+			case typ == pgpSubpacketIssuer && len(data) == 8 && issuer == nil:
+				issuer = data
+			}
+		}
+	}
+
+	digest, err := pgpDigest(path, h.New(), body[:hashedEnd])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(digest[:2], left16) {
+		return fmt.Errorf("签名无效")
+	}
+
+	matched := false
+	for _, k := range v.keys {
+		if k.algo != algo && !(isPGPRSA(k.algo) && isPGPRSA(algo)) {
+			continue
+		}
+		if issuer != nil && !bytes.HasSuffix(k.fingerprint[:], issuer) {
+			continue
+		}
+		matched = true
+		if verifyPGPSignature(k, h, digest, sigMPIs) {
+			return nil
+		}
+	}
+	if !matched {
+		return fmt.Errorf("签名者 %X 不在公钥中", issuer)
+	}
+	return fmt.Errorf("签名无效")
+}
+
+// verifyPGPSignature 使用公钥校验签名值
+func verifyPGPSignature(k *pgpPublicKey, h crypto.Hash, digest, sigMPIs []byte) bool {
+	switch k.algo {
+	case pgpAlgoEdDSA:
+		r, n, err := readMPI(sigMPIs)
+		if err != nil || len(r) > 32 {
+			return false
+		}
+		s, _, err := readMPI(sigMPIs[n:])
+		if err != nil || len(s) > 32 {
+			return false
+		}
+		sig := make([]byte, ed25519.SignatureSize)
+		copy(sig[32-len(r):], r)
+		copy(sig[64-len(s):], s)
+		return ed25519.Verify(k.ed25519, digest, sig)
+	default:
+		s, _, err := readMPI(sigMPIs)
+		if err != nil || len(s) > k.rsa.Size() {
+			return false
+		}
+		sig := make([]byte, k.rsa.Size())
+		copy(sig[len(sig)-len(s):], s)
+		return rsa.VerifyPKCS1v15(k.rsa, h, digest, sig) == nil
+	}
+}
+
+// isPGPRSA 判断公钥算法是否为RSA
+func isPGPRSA(algo byte) bool {
+	return algo == pgpAlgoRSA || algo == pgpAlgoRSASignOnly
+}
+
+// parsePGPPublicKey 解析公钥包或私钥包开头的v4公钥
+//
+// 参数:
+//   - body: 包内容
+//
+// 返回值:
+//   - *pgpPublicKey: 公钥
+//   - int: 公钥部分的长度
+//   - error: 版本或算法不支持时返回错误
+func parsePGPPublicKey(body []byte) (*pgpPublicKey, int, error) {
+	if len(body) < 6 || body[0] != 4 {
+		return nil, 0, fmt.Errorf("仅支持v4密钥")
+	}
+	pub := &pgpPublicKey{algo: body[5], flags: -1}
+	n := 6
+	switch pub.algo {
+	case pgpAlgoRSA, pgpAlgoRSASignOnly:
+		modulus, m, err := readMPI(body[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		n += m
+		exponent, m, err := readMPI(body[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		n += m
+		e := new(big.Int).SetBytes(exponent)
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, 0, errInvalidPGPKey
+		}
+		pub.rsa = &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(e.Int64())}
+	case pgpAlgoEdDSA:
+		if len(body) < n+1 || len(body) < n+1+int(body[n]) || !bytes.Equal(body[n+1:n+1+int(body[n])], pgpEd25519OID) {
+			return nil, 0, fmt.Errorf("仅支持Ed25519曲线")
+		}
+		n += 1 + int(body[n])
+		point, m, err := readMPI(body[n:])
+		if err != nil || len(point) != 33 || point[0] != 0x40 {
+			return nil, 0, errInvalidPGPKey
+		}
+		n += m
+		pub.ed25519 = ed25519.PublicKey(bytes.Clone(point[1:]))
+	default:
+		return nil, 0, fmt.Errorf("不支持的公钥算法 %d", pub.algo)
+	}
+
+	h := sha1.New()
+	h.Write([]byte{0x99, byte(n >> 8), byte(n)})
+	h.Write(body[:n])
+	copy(pub.fingerprint[:], h.Sum(nil))
+	return pub, n, nil
+}
+
+// pgpSignatureKeyFlags 读取v4签名包中声明的密钥用途
+func pgpSignatureKeyFlags(body []byte) (int, bool) {
+	if len(body) < 6 || body[0] != 4 {
+		return 0, false
+	}
+	hashedLen := int(binary.BigEndian.Uint16(body[4:]))
+	if len(body) < 6+hashedLen {
+		return 0, false
+	}
+	for typ, data := range pgpSubpackets(body[6 : 6+hashedLen]) {
+		if typ == pgpSubpacketKeyFlags && len(data) > 0 {
+			return int(data[0]), true
+		}
+	}
+	return 0, false
+}
+
+// pgpSubpackets 遍历签名子包, 返回去掉关键位的类型和内容, 格式错误时停止
+func pgpSubpackets(area []byte) func(yield func(byte, []byte) bool) {
+	return func(yield func(byte, []byte) bool) {
+		for len(area) > 0 {
+			var length, n int
+			switch first := int(area[0]); {
+			case first < 192:
+				length, n = first, 1
+			case first < 255:
+				if len(area) < 2 {
+					return
+				}
+				length, n = (first-192)<<8+int(area[1])+192, 2
+			default:
+				if len(area) < 5 {
+					return
+				}
+				length, n = int(binary.BigEndian.Uint32(area[1:])), 5
+			}
+			if length < 1 || len(area) < n+length {
+				return
+			}
+			if !yield(area[n]&0x7f, area[n+1:n+length]) {
+				return
+			}
+			area = area[n+length:]
+		}
+	}
+}
+
+// pgpDigest 计算文件和签名元数据的哈希
+func pgpDigest(path string, h hash.Hash, hashed []byte) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	h.Write(hashed)
+	h.Write([]byte{4, 0xff})
+	_ = binary.Write(h, binary.BigEndian, uint32(len(hashed)))
+	return h.Sum(nil), nil
+}
+
+// pgpS2K 根据S2K说明符从口令派生密钥
+//
+// 参数:
+//   - spec: 以S2K说明符开头的数据
+//   - passphrase: 口令
+//   - keyLen: 密钥长度
+//
+// 返回值:
+//   - []byte: 派生的密钥
+//   - int: S2K说明符的长度
+//   - error: S2K类型或哈希算法不支持时返回错误
+func pgpS2K(spec []byte, passphrase string, keyLen int) ([]byte, int, error) {
+	if len(spec) < 2 {
+		return nil, 0, errInvalidPGPKey
+	}
+	var newHash func() hash.Hash
+	switch spec[1] {
+	case 2:
+		newHash = sha1.New
+	case 8:
+		newHash = sha256.New
+	case 10:
+		newHash = sha512.New
+	default:
+		return nil, 0, fmt.Errorf("不支持的S2K哈希算法 %d", spec[1])
+	}
+
+	var salt []byte
+	n, count := 2, 0
+	switch spec[0] {
+	case 0:
+	case 1, 3:
+		if len(spec) < 10 {
+			return nil, 0, errInvalidPGPKey
+		}
+		salt, n = spec[2:10], 10
+		if spec[0] == 3 {
+			if len(spec) < 11 {
+				return nil, 0, errInvalidPGPKey
+			}
+			c := int(spec[10])
+			count, n = (16+c&15)<<(c>>4+6), 11
+		}
+	default:
+		return nil, 0, fmt.Errorf("不支持的S2K类型 %d", spec[0])
+	}
+
+	input := append(bytes.Clone(salt), passphrase...)
+	count = max(count, len(input))
+	key := make([]byte, 0, keyLen)
+	for i := 0; len(key) < keyLen; i++ {
+		h := newHash()
+		h.Write(make([]byte, i))
+		for written := 0; written < count; written += len(input) {
+			h.Write(input[:min(len(input), count-written)])
+		}
+		key = h.Sum(key)
+	}
+	return key[:keyLen], n, nil
+}
+
+// pgpCFBDecrypt 使用CFB模式解密私钥
+func pgpCFBDecrypt(encrypt func(dst, src []byte), iv, dst, src []byte) {
+	feedback := bytes.Clone(iv)
+	stream := make([]byte, len(iv))
+	for len(src) > 0 {
+		encrypt(stream, feedback)
+		n := subtle.XORBytes(dst, src, stream)
+		copy(feedback, src[:n])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// pgpChecksum 返回私钥的16位校验和
+func pgpChecksum(data []byte) uint16 {
+	var sum uint16
+	for _, b := range data {
+		sum += uint16(b)
+	}
+	return sum
+}
+
+// readMPI 读取多精度整数, 返回去掉长度前缀的内容和消耗的字节数
+func readMPI(data []byte) ([]byte, int, error) {
+	if len(data) < 2 {
+		return nil, 0, errInvalidPGPKey
+	}
+	n := (int(binary.BigEndian.Uint16(data)) + 7) / 8
+	if len(data) < 2+n {
+		return nil, 0, errInvalidPGPKey
+	}
+	return data[2 : 2+n], 2 + n, nil
+}
+
+// writeMPI 写入多精度整数, 去掉开头的零字节
+func writeMPI(b *bytes.Buffer, v []byte) {
+	v = bytes.TrimLeft(v, "\x00")
+	bitLen := 0
+	if len(v) > 0 {
+		bitLen = (len(v)-1)*8 + new(big.Int).SetBytes(v[:1]).BitLen()
+	}
+	_ = binary.Write(b, binary.BigEndian, uint16(bitLen))
+	b.Write(v)
+}
+
+// writePGPLength 写入新格式的包长度
+func writePGPLength(b *bytes.Buffer, n int) {
+	switch {
+	case n < 192:
+		b.WriteByte(byte(n))
+	case n < 8384:
+		n -= 192
+		b.Write([]byte{byte(n>>8) + 192, byte(n)})
+	default:
+		b.WriteByte(255)
+		_ = binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
+
+// readPGPData 读取ASCII armor或二进制格式的数据包
+func readPGPData(data []byte, blockType string) ([]pgpPacket, error) {
+	if bytes.Contains(data, []byte("-----BEGIN ")) {
+		decoded, err := decodePGPArmor(data, blockType)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+	return readPGPPackets(data)
+}
+
+// readPGPPackets 拆分数据包, 支持新旧两种包头格式
+func readPGPPackets(data []byte) ([]pgpPacket, error) {
+	var packets []pgpPacket
+	for len(data) > 0 {
+		first := data[0]
+		if first&0x80 == 0 {
+			return nil, errInvalidPGPData
+		}
+		data = data[1:]
+
+		var tag byte
+		var body []byte
+		if first&0x40 != 0 {
+			tag = first & 0x3f
+			// 新格式的包可能分成多个部分长度的分段
+			for {
+				if len(data) == 0 {
+					return nil, errInvalidPGPData
+				}
+				var length, n int
+				partial := false
+				switch l := int(data[0]); {
+				case l < 192:
+					length, n = l, 1
+				case l < 224:
+					if len(data) < 2 {
+						return nil, errInvalidPGPData
+					}
+					length, n = (l-192)<<8+int(data[1])+192, 2
+				case l < 255:
+					length, n, partial = 1<<(l&0x1f), 1, true
+				default:
+					if len(data) < 5 {
+						return nil, errInvalidPGPData
+					}
+					length, n = int(binary.BigEndian.Uint32(data[1:])), 5
+				}
+				if length < 0 || len(data) < n+length {
+					return nil, errInvalidPGPData
+				}
+				body = append(body, data[n:n+length]...)
+				data = data[n+length:]
+				if !partial {
+					break
+				}
+			}
+		} else {
+			tag = (first >> 2) & 0x0f
+			var length, n int
+			switch first & 3 {
+			case 0:
+				if len(data) < 1 {
+					return nil, errInvalidPGPData
+				}
+				length, n = int(data[0]), 1
+			case 1:
+				if len(data) < 2 {
+					return nil, errInvalidPGPData
+				}
+				length, n = int(binary.BigEndian.Uint16(data)), 2
+			case 2:
+				if len(data) < 4 {
+					return nil, errInvalidPGPData
+				}
+				length, n = int(binary.BigEndian.Uint32(data)), 4
+			default:
+				length = len(data)
+			}
+			if length < 0 || len(data) < n+length {
+				return nil, errInvalidPGPData
+			}
+			body = data[n : n+length]
+			data = data[n+length:]
+		}
+		packets = append(packets, pgpPacket{tag: tag, body: body})
+	}
+	return packets, nil
+}
+
+// decodePGPArmor 解码ASCII armor, 存在CRC24校验和时进行校验
+func decodePGPArmor(data []byte, blockType string) ([]byte, error) {
+	begin, end := "-----BEGIN "+blockType+"-----", "-----END "+blockType+"-----"
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	inBlock, inBody := false, false
+	var b64, crc strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case !inBlock:
+			inBlock = line == begin
+		case line == end:
+			decoded, err := base64.StdEncoding.DecodeString(b64.String())
+			if err != nil {
+				return nil, errInvalidPGPData
+			}
+			if crc.Len() > 0 {
+				want, err := base64.StdEncoding.DecodeString(crc.String())
+				sum := pgpCRC24(decoded)
+				if err != nil || len(want) != 3 || !bytes.Equal(want, []byte{byte(sum >> 16), byte(sum >> 8), byte(sum)}) {
+					return nil, fmt.Errorf("ASCII armor的校验和不一致")
+				}
+			}
+			return decoded, nil
+		case !inBody:
+			// 头部以空行结束, 没有空行时第一行不含冒号的内容即为正文
+			if line == "" {
+				inBody = true
+			} else if !strings.Contains(line, ":") {
+				inBody = true
+				b64.WriteString(line)
+			}
+		case strings.HasPrefix(line, "=") && len(line) == 5:
+			crc.WriteString(line[1:])
+		default:
+			b64.WriteString(line)
+		}
+	}
+	return nil, fmt.Errorf("未找到 %s", begin)
+}
+
+// encodePGPArmor 编码为ASCII armor, 每行64个字符并附加CRC24校验和
+func encodePGPArmor(blockType string, data []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "-----BEGIN %s-----\n\n", blockType)
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 64 {
+		b.WriteString(encoded[:64])
+		b.WriteByte('\n')
+		encoded = encoded[64:]
+	}
+	b.WriteString(encoded)
+	b.WriteByte('\n')
+	sum := pgpCRC24(data)
+	fmt.Fprintf(&b, "=%s\n", base64.StdEncoding.EncodeToString([]byte{byte(sum >> 16), byte(sum >> 8), byte(sum)}))
+	fmt.Fprintf(&b, "-----END %s-----\n", blockType)
+	return b.Bytes()
+}
+
+// pgpCRC24 计算ASCII armor使用的CRC24校验和
+func pgpCRC24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, c := range data {
+		crc ^= uint32(c) << 16
+		for range 8 {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
@@ -0,0 +1,530 @@
+package utils
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// packageArchs 各安装包格式使用的架构名称, 键为GOARCH
+var packageArchs = map[string]map[string]string{
+	"deb": {
+		"amd64": "amd64", "386": "i386", "arm64": "arm64", "arm": "armhf",
+		"ppc64le": "ppc64el", "s390x": "s390x", "riscv64": "riscv64", "loong64": "loong64",
+		"mips": "mips", "mipsle": "mipsel", "mips64": "mips64", "mips64le": "mips64el",
+	},
+	"rpm": {
+		"amd64": "x86_64", "386": "i686", "arm64": "aarch64", "arm": "armv7hl",
+		"ppc64": "ppc64", "ppc64le": "ppc64le", "s390x": "s390x", "riscv64": "riscv64", "loong64": "loongarch64",
+		"mips": "mips", "mipsle": "mipsel", "mips64": "mips64", "mips64le": "mips64el",
+	},
+	"apk": {
+		"amd64": "x86_64", "386": "x86", "arm64": "aarch64", "arm": "armv7",
+		"ppc64le": "ppc64le", "s390x": "s390x", "riscv64": "riscv64", "loong64": "loongarch64",
+	},
+}
+
+var (
+	// packageNameRegexp 匹配各安装包格式均可接受的包名
+	packageNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9+._-]*$`)
+
+	// packageReleaseRegexp 匹配各安装包格式均可接受的修订号
+	packageReleaseRegexp = regexp.MustCompile(`^[0-9A-Za-z.+~]+$`)
+
+	// describeRegexp 匹配 git describe 在标签之后追加的提交数和提交哈希
+	describeRegexp = regexp.MustCompile(`^(.+)-(\d+)-g([0-9a-f]+)$`)
+
+	// packageVersionRegexp 匹配可转换为安装包版本的版本号, 可带预发布标识
+	packageVersionRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)*)(?:-([0-9A-Za-z.-]+))?$`)
+
+	// apkPreReleaseRegexp 匹配apk支持的预发布标识
+	apkPreReleaseRegexp = regexp.MustCompile(`^(alpha|beta|pre|rc)[.-]?(\d*)$`)
+
+	// dependencyRegexp 匹配包关系, 如 git、libc6 >= 2.17、libc6 (>= 2.17)
+	dependencyRegexp = regexp.MustCompile(`^([^\s<>=()!]+)\s*(?:\(?\s*(<<|>>|<=|>=|=|<|>)\s*([^\s()]+)\s*\)?)?$`)
+)
+
+// packageFile 表示安装包中的一个文件
+type packageFile struct {
+	src    string      // 源文件路径
+	dst    string      // 安装路径, 以/开头
+	mode   fs.FileMode // 文件权限
+	size   int64       // 文件大小
+	config bool        // 是否为配置文件
+}
+
+// dependency 表示一条包关系
+type dependency struct {
+	name    string // 包名
+	op      string // 版本比较符: <、<=、=、>=、>, 无版本约束时为空
+	version string // 版本号
+}
+
+// linuxPackage 表示解析后的安装包内容, 由各格式的写入函数使用
+type linuxPackage struct {
+	cfg        types.LinuxPackageConfig
+	name       string            // 包名
+	version    string            // 按当前格式转换后的版本号
+	release    string            // 打包修订号
+	arch       string            // 当前格式的架构名称
+	files      []packageFile     // 按安装路径排序的文件
+	scripts    map[string]string // 维护脚本内容, 键为 pre_install、post_install、pre_remove、post_remove
+	mtime      time.Time         // 文件修改时间
+	depends    []dependency      // 依赖
+	recommends []dependency      // 推荐
+	conflicts  []dependency      // 冲突
+	provides   []dependency      // 提供
+	replaces   []dependency      // 替代
+}
+
+// PackageFormats 返回支持的Linux安装包格式
+//
+// 返回值:
+//   - []string: 排序后的安装包格式列表
+func PackageFormats() []string {
+	return slices.Sorted(maps.Keys(packageArchs))
+}
+
+// BuildLinuxPackages 为单个linux目标生成配置的所有安装包
+//
+// 参数:
+//   - cfg: Linux安装包配置
+//   - spec: 目标的包名、版本、架构和可执行文件
+//
+// 返回值:
+//   - []string: 生成的安装包路径, 顺序与 formats 一致
+//   - error: 架构不受支持、版本无法转换或写入失败时返回错误
+func BuildLinuxPackages(cfg types.LinuxPackageConfig, spec types.PackageSpec) ([]string, error) {
+	if !packageNameRegexp.MatchString(spec.Name) {
+		return nil, fmt.Errorf("包名 %q 无效, 只能包含小写字母、数字和 + . _ -, 且以字母或数字开头", spec.Name)
+	}
+
+	files, err := collectPackageFiles(cfg, spec)
+	if err != nil {
+		return nil, err
+	}
+	scripts, err := readPackageScripts(cfg.Scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []string
+	for _, format := range cfg.Formats {
+		arch, ok := packageArchs[format][spec.Arch]
+		if !ok {
+			return nil, fmt.Errorf("%s 格式不支持架构 %s", format, spec.Arch)
+		}
+		version, err := linuxPackageVersion(spec.Version, format)
+		if err != nil {
+			return nil, err
+		}
+
+		pkg := &linuxPackage{
+			cfg:     cfg,
+			name:    spec.Name,
+			version: version,
+			release: cfg.Release,
+			arch:    arch,
+			files:   files,
+			scripts: scripts,
+			mtime:   spec.MTime.Truncate(time.Second),
+		}
+		if err := pkg.setRelations(format); err != nil {
+			return nil, err
+		}
+
+		var file string
+		switch format {
+		case "deb":
+			file, err = writeDeb(pkg, spec.OutDir)
+		case "rpm":
+			file, err = writeRPM(pkg, spec.OutDir)
+		case "apk":
+			file, err = writeAPK(pkg, spec.OutDir)
+		default:
+			err = fmt.Errorf("不支持的安装包格式 %q", format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("生成 %s 安装包失败: %w", format, err)
+		}
+		packages = append(packages, file)
+	}
+	return packages, nil
+}
+
+// setRelations 解析包关系, 按格式覆盖的字段优先
+//
+// 参数:
+//   - format: 安装包格式
+//
+// 返回值:
+//   - error: 包关系格式无效时返回错误
+func (p *linuxPackage) setRelations(format string) error {
+	override := p.cfg.Overrides[format]
+	pick := func(base, override []string) []string {
+		if override != nil {
+			return override
+		}
+		return base
+	}
+
+	relations := []struct {
+		values []string
+		dst    *[]dependency
+	}{
+		{pick(p.cfg.Depends, override.Depends), &p.depends},
+		{pick(p.cfg.Recommends, override.Recommends), &p.recommends},
+		{pick(p.cfg.Conflicts, override.Conflicts), &p.conflicts},
+		{pick(p.cfg.Provides, override.Provides), &p.provides},
+		{pick(p.cfg.Replaces, override.Replaces), &p.replaces},
+	}
+	for _, r := range relations {
+		deps := make([]dependency, 0, len(r.values))
+		for _, value := range r.values {
+			dep, err := parseDependency(value)
+			if err != nil {
+				return err
+			}
+			deps = append(deps, dep)
+		}
+		*r.dst = deps
+	}
+	return nil
+}
+
+// summary 返回描述的第一行, 未设置描述时使用包名
+func (p *linuxPackage) summary() string {
+	line, _, _ := strings.Cut(strings.TrimSpace(p.cfg.Description), "\n")
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return p.name
+}
+
+// installedSize 返回所有文件的总大小(字节)
+func (p *linuxPackage) installedSize() int64 {
+	var size int64
+	for _, f := range p.files {
+		size += f.size
+	}
+	return size
+}
+
+// parseDependency 解析包关系
+//
+// 参数:
+//   - value: 包关系, 如 git、libc6 >= 2.17、libc6 (>= 2.17)
+//
+// 返回值:
+//   - dependency: 解析结果, deb风格的 << 和 >> 转换为 < 和 >
+//   - error: 格式无效时返回错误
+func parseDependency(value string) (dependency, error) {
+	m := dependencyRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil || (m[2] == "") != (m[3] == "") {
+		return dependency{}, fmt.Errorf("包关系 %q 格式无效, 应为 '包名' 或 '包名 >= 版本'", value)
+	}
+	op := strings.NewReplacer("<<", "<", ">>", ">").Replace(m[2])
+	return dependency{name: m[1], op: op, version: m[3]}, nil
+}
+
+// linuxPackageVersion 将Git版本转换为安装包版本
+//
+// 参数:
+//   - raw: 原始版本号, 如 v1.2.0、v1.2.0-rc.1、v1.2.0-3-gabc1234-dirty
+//   - format: 安装包格式
+//
+// 返回值:
+//   - string: 安装包版本, 不含修订号
+//   - error: 无法转换时返回错误
+//
+// 注意:
+//   - deb和rpm的预发布标识转换为 ~rc.1, 标签之后的提交转换为 +git3.abc1234
+//   - apk仅支持 alpha、beta、pre、rc 预发布标识, 标签之后的提交转换为 _git3
+func linuxPackageVersion(raw, format string) (string, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	v = strings.TrimSuffix(v, "-dirty")
+
+	var commits, hash string
+	if m := describeRegexp.FindStringSubmatch(v); m != nil {
+		v, commits, hash = m[1], m[2], m[3]
+	}
+
+	m := packageVersionRegexp.FindStringSubmatch(v)
+	if m == nil {
+		return "", fmt.Errorf("无法从版本 %q 推导安装包版本, 请创建语义化版本标签(如 v1.2.0)或设置 package.linux.version", raw)
+	}
+	version, pre := m[1], m[2]
+
+	if format == "apk" {
+		if pre != "" {
+			pm := apkPreReleaseRegexp.FindStringSubmatch(strings.ToLower(pre))
+			if pm == nil {
+				return "", fmt.Errorf("apk 版本不支持预发布标识 %q, 仅支持 alpha、beta、pre、rc", pre)
+			}
+			version += "_" + pm[1] + pm[2]
+		}
+		if commits != "" {
+			version += "_git" + commits
+		}
+		return version, nil
+	}
+
+	if pre != "" {
+		version += "~" + strings.ReplaceAll(pre, "-", ".")
+	}
+	if commits != "" {
+		version += "+git" + commits + "." + hash
+	}
+	return version, nil
+}
+
+// ParseFileMode 解析八进制文件权限
+//
+// 参数:
+//   - mode: 八进制权限, 如 0644、755
+//
+// 返回值:
+//   - fs.FileMode: 文件权限
+//   - error: 格式无效时返回错误
+func ParseFileMode(mode string) (fs.FileMode, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0o7777 {
+		return 0, fmt.Errorf("文件权限 %q 无效, 应为八进制数, 如 '0644'", mode)
+	}
+	return fs.FileMode(perm), nil
+}
+
+// collectPackageFiles 收集安装包中的所有文件
+//
+// 参数:
+//   - cfg: Linux安装包配置
+//   - spec: 目标的可执行文件信息
+//
+// 返回值:
+//   - []packageFile: 按安装路径排序的文件, 包含可执行文件
+//   - error: 文件不存在或安装路径重复时返回错误
+func collectPackageFiles(cfg types.LinuxPackageConfig, spec types.PackageSpec) ([]packageFile, error) {
+	files := make(map[string]packageFile)
+	add := func(f packageFile) error {
+		if _, ok := files[f.dst]; ok {
+			return fmt.Errorf("安装路径 %s 重复", f.dst)
+		}
+		files[f.dst] = f
+		return nil
+	}
+
+	info, err := os.Stat(spec.Binary)
+	if err != nil {
+		return nil, fmt.Errorf("读取可执行文件失败: %w", err)
+	}
+	binDir := cfg.BinDir
+	if binDir == "" {
+		binDir = types.DefaultPackageBinDir
+	}
+	if err := add(packageFile{src: spec.Binary, dst: path.Join(binDir, spec.BinName), mode: 0o755, size: info.Size()}); err != nil {
+		return nil, err
+	}
+
+	for _, mapping := range cfg.Files {
+		matches, err := filepath.Glob(mapping.Src)
+		if err != nil {
+			return nil, fmt.Errorf("文件映射 %q 的通配符无效: %w", mapping.Src, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("文件映射 %q 没有匹配的文件", mapping.Src)
+		}
+
+		var mode fs.FileMode
+		if mapping.Mode != "" {
+			if mode, err = ParseFileMode(mapping.Mode); err != nil {
+				return nil, err
+			}
+		}
+
+		// 以/结尾、通配符或匹配多个文件时, dst 为存放匹配项的目录
+		asDir := strings.HasSuffix(mapping.Dst, "/") || len(matches) > 1 || mapping.Src != matches[0]
+		for _, match := range matches {
+			dst := path.Clean(mapping.Dst)
+			if asDir {
+				dst = path.Join(dst, filepath.Base(match))
+			}
+
+			err := filepath.WalkDir(match, func(src string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				if !info.Mode().IsRegular() {
+					return nil
+				}
+				rel, err := filepath.Rel(match, src)
+				if err != nil {
+					return err
+				}
+
+				f := packageFile{src: src, dst: path.Join(dst, filepath.ToSlash(rel)), mode: info.Mode().Perm(), size: info.Size(), config: mapping.Config}
+				if mode != 0 {
+					f.mode = mode
+				}
+				return add(f)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("收集文件 %s 失败: %w", match, err)
+			}
+		}
+	}
+
+	result := slices.Collect(maps.Values(files))
+	slices.SortFunc(result, func(a, b packageFile) int { return strings.Compare(a.dst, b.dst) })
+	return result, nil
+}
+
+// readPackageScripts 读取维护脚本
+//
+// 参数:
+//   - scripts: 维护脚本配置
+//
+// 返回值:
+//   - map[string]string: 脚本内容, 未配置的脚本不包含在内
+//   - error: 读取失败时返回错误
+func readPackageScripts(scripts types.PackageScripts) (map[string]string, error) {
+	result := make(map[string]string)
+	for name, file := range map[string]string{
+		"pre_install":  scripts.PreInstall,
+		"post_install": scripts.PostInstall,
+		"pre_remove":   scripts.PreRemove,
+		"post_remove":  scripts.PostRemove,
+	} {
+		if file == "" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取维护脚本 %s 失败: %w", file, err)
+		}
+		result[name] = string(content)
+	}
+	return result, nil
+}
+
+// packageDirs 返回文件所在的所有上级目录, 不包含根目录
+//
+// 参数:
+//   - files: 安装包中的文件
+//
+// 返回值:
+//   - []string: 排序后的目录, 以/开头
+func packageDirs(files []packageFile) []string {
+	seen := make(map[string]bool)
+	for _, f := range files {
+		for dir := path.Dir(f.dst); dir != "/" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// writeTarDir 向tar写入目录条目
+func writeTarDir(tw *tar.Writer, format tar.Format, name string, mtime time.Time) error {
+	return tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0o755,
+		ModTime:  mtime,
+		Uname:    "root",
+		Gname:    "root",
+		Format:   format,
+	})
+}
+
+// writeTarBytes 向tar写入内存中的文件
+func writeTarBytes(tw *tar.Writer, format tar.Format, name string, mode int64, mtime time.Time, content []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     mode,
+		Size:     int64(len(content)),
+		ModTime:  mtime,
+		Uname:    "root",
+		Gname:    "root",
+		Format:   format,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+// writeTarFile 向tar写入安装包中的文件
+//
+// 参数:
+//   - tw: tar写入器
+//   - format: tar格式, dpkg不支持PAX, deb使用GNU格式
+//   - name: 在tar中的路径
+//   - f: 安装包中的文件
+//   - mtime: 修改时间
+//   - pax: 附加的PAX记录, 仅PAX格式可用, 可为nil
+//
+// 返回值:
+//   - error: 读取或写入失败时返回错误
+func writeTarFile(tw *tar.Writer, format tar.Format, name string, f packageFile, mtime time.Time, pax map[string]string) error {
+	header := &tar.Header{
+		Typeflag:   tar.TypeReg,
+		Name:       name,
+		Mode:       int64(f.mode),
+		Size:       f.size,
+		ModTime:    mtime,
+		Uname:      "root",
+		Gname:      "root",
+		PAXRecords: pax,
+		Format:     format,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	return copyFile(tw, f.src)
+}
+
+// writePackageFile 将内存中的安装包写入输出目录, 失败时删除未完成的文件
+//
+// 参数:
+//   - dir: 输出目录
+//   - name: 文件名
+//   - write: 写入安装包内容的函数
+//
+// 返回值:
+//   - string: 安装包路径
+//   - error: 写入失败时返回错误
+func writePackageFile(dir, name string, write func(w io.Writer) error) (string, error) {
+	dst := filepath.Join(dir, name)
+	file, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("创建安装包 %s 失败: %w", dst, err)
+	}
+	if err := write(file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(dst)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(dst)
+		return "", fmt.Errorf("写入安装包 %s 失败: %w", dst, err)
+	}
+	return dst, nil
+}
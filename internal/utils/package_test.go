@@ -0,0 +1,460 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// newTestPackage 创建安装包测试用的可执行文件、配置文件和维护脚本
+func newTestPackage(t *testing.T, formats ...string) (types.LinuxPackageConfig, types.PackageSpec) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"myapp":       "#!/bin/sh\necho myapp\n",
+		"myapp.conf":  "key = value\n",
+		"postinst.sh": "#!/bin/sh\necho installed\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := GetDefaultConfig().Package.Linux
+	cfg.Enabled = true
+	cfg.Formats = formats
+	cfg.Maintainer = "Dev <dev@example.com>"
+	cfg.Homepage = "https://example.com/myapp"
+	cfg.License = "MIT"
+	cfg.Description = "My test app\nLonger description."
+	cfg.Depends = []string{"git", "libc6 >= 2.17"}
+	cfg.Conflicts = []string{"oldapp"}
+	cfg.Files = []types.PackageFile{{Src: filepath.Join(dir, "myapp.conf"), Dst: "/etc/myapp/myapp.conf", Mode: "0640", Config: true}}
+	cfg.Scripts.PostInstall = filepath.Join(dir, "postinst.sh")
+
+	spec := types.PackageSpec{
+		Name:    "myapp",
+		Version: "v1.2.0-rc.1",
+		Arch:    "amd64",
+		Binary:  filepath.Join(dir, "myapp"),
+		BinName: "myapp",
+		OutDir:  dir,
+		MTime:   time.Unix(1700000000, 0),
+	}
+	return cfg, spec
+}
+
+// readArMembers 解析ar归档, 返回按顺序排列的成员名称和内容
+func readArMembers(t *testing.T, data []byte) ([]string, map[string][]byte) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("!<arch>\n")) {
+		t.Fatal("缺少ar魔数")
+	}
+	data = data[8:]
+	var names []string
+	members := make(map[string][]byte)
+	for len(data) > 0 {
+		if len(data) < 60 || string(data[58:60]) != "`\n" {
+			t.Fatalf("ar成员头无效: %q", data[:min(len(data), 60)])
+		}
+		name := strings.TrimSpace(string(data[:16]))
+		size, err := strconv.Atoi(strings.TrimSpace(string(data[48:58])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+		members[name] = data[60 : 60+size]
+		data = data[60+size+size%2:]
+	}
+	return names, members
+}
+
+func TestBuildDeb(t *testing.T) {
+	cfg, spec := newTestPackage(t, "deb")
+	packages, err := BuildLinuxPackages(cfg, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(spec.OutDir, "myapp_1.2.0~rc.1-1_amd64.deb"); len(packages) != 1 || packages[0] != want {
+		t.Fatalf("安装包路径 = %v, 期望 %s", packages, want)
+	}
+	data, err := os.ReadFile(packages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, members := readArMembers(t, data)
+	if !reflect.DeepEqual(names, []string{"debian-binary", "control.tar.gz", "data.tar.gz"}) {
+		t.Fatalf("ar成员 = %v", names)
+	}
+	if string(members["debian-binary"]) != "2.0\n" {
+		t.Errorf("debian-binary = %q", members["debian-binary"])
+	}
+
+	control := readTarGz(t, members["control.tar.gz"])
+	wantControl := "Package: myapp\n" +
+		"Version: 1.2.0~rc.1-1\n" +
+		"Section: utils\n" +
+		"Priority: optional\n" +
+		"Architecture: amd64\n" +
+		"Maintainer: Dev <dev@example.com>\n" +
+		"Installed-Size: 1\n" +
+		"Depends: git, libc6 (>= 2.17)\n" +
+		"Conflicts: oldapp\n" +
+		"Homepage: https://example.com/myapp\n" +
+		"Description: My test app\n" +
+		" Longer description.\n"
+	if got := string(control["./control"]); got != wantControl {
+		t.Errorf("control:\n%s\n期望:\n%s", got, wantControl)
+	}
+	if got := string(control["./conffiles"]); got != "/etc/myapp/myapp.conf\n" {
+		t.Errorf("conffiles = %q", got)
+	}
+	if got := string(control["./postinst"]); got != "#!/bin/sh\necho installed\n" {
+		t.Errorf("postinst = %q", got)
+	}
+	md5sums := string(control["./md5sums"])
+	if !strings.Contains(md5sums, "  etc/myapp/myapp.conf\n") || !strings.Contains(md5sums, "  usr/bin/myapp\n") {
+		t.Errorf("md5sums = %q", md5sums)
+	}
+
+	files := readTarGz(t, members["data.tar.gz"])
+	if string(files["./usr/bin/myapp"]) != "#!/bin/sh\necho myapp\n" || string(files["./etc/myapp/myapp.conf"]) != "key = value\n" {
+		t.Errorf("data.tar.gz 中的文件不正确: %v", files)
+	}
+	if _, ok := files["./etc/myapp/"]; !ok {
+		t.Errorf("data.tar.gz 应包含上级目录, got %v", files)
+	}
+}
+
+func TestBuildDebDpkgDeb(t *testing.T) {
+	dpkgDeb, err := exec.LookPath("dpkg-deb")
+	if err != nil {
+		t.Skip("未安装 dpkg-deb")
+	}
+	cfg, spec := newTestPackage(t, "deb")
+	packages, err := BuildLinuxPackages(cfg, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 使用 dpkg-deb 读取控制信息
+	out, err := exec.Command(dpkgDeb, "-I", packages[0]).CombinedOutput()
+	if err != nil {
+		t.Fatalf("dpkg-deb -I 失败: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"Package: myapp",
+		"Version: 1.2.0~rc.1-1",
+		"Architecture: amd64",
+		"Depends: git, libc6 (>= 2.17)",
+		"conffiles",
+		"postinst",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("dpkg-deb -I 输出应包含 %q:\n%s", want, out)
+		}
+	}
+
+	// 使用 dpkg-deb 列出数据文件
+	out, err = exec.Command(dpkgDeb, "-c", packages[0]).CombinedOutput()
+	if err != nil {
+		t.Fatalf("dpkg-deb -c 失败: %v\n%s", err, out)
+	}
+	for _, want := range []string{"./usr/bin/myapp", "./etc/myapp/myapp.conf", "-rw-r-----"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("dpkg-deb -c 输出应包含 %q:\n%s", want, out)
+		}
+	}
+}
+
+// rpmTags 表示解析后的rpm头, 键为标签
+type rpmTags map[int32][]byte
+
+// parseRPMHeader 解析rpm头, 返回标签数据、每个标签的元素个数和头的总长度
+func parseRPMHeader(t *testing.T, data []byte) (rpmTags, map[int32]int32, int) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte{0x8e, 0xad, 0xe8, 0x01}) {
+		t.Fatal("rpm头魔数无效")
+	}
+	count := int(binary.BigEndian.Uint32(data[8:]))
+	size := int(binary.BigEndian.Uint32(data[12:]))
+	store := data[16+count*16 : 16+count*16+size]
+
+	type index struct{ tag, typ, offset, count int32 }
+	entries := make([]index, count)
+	for i := range entries {
+		b := data[16+i*16:]
+		entries[i] = index{
+			int32(binary.BigEndian.Uint32(b)), int32(binary.BigEndian.Uint32(b[4:])),
+			int32(binary.BigEndian.Uint32(b[8:])), int32(binary.BigEndian.Uint32(b[12:])),
+		}
+	}
+
+	tags, counts := make(rpmTags), make(map[int32]int32)
+	for _, e := range entries {
+		end := int32(len(store))
+		for _, other := range entries {
+			if other.offset > e.offset && other.offset < end {
+				end = other.offset
+			}
+		}
+		tags[e.tag] = store[e.offset:end]
+		counts[e.tag] = e.count
+	}
+	return tags, counts, 16 + count*16 + size
+}
+
+// str 返回字符串标签的值
+func (r rpmTags) str(tag int32) string {
+	s, _, _ := strings.Cut(string(r[tag]), "\x00")
+	return s
+}
+
+// strs 返回字符串数组标签的值
+func (r rpmTags) strs(tag int32, count int32) []string {
+	return strings.SplitN(string(r[tag]), "\x00", int(count)+1)[:count]
+}
+
+func TestBuildRPM(t *testing.T) {
+	cfg, spec := newTestPackage(t, "rpm")
+	packages, err := BuildLinuxPackages(cfg, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(spec.OutDir, "myapp-1.2.0~rc.1-1.x86_64.rpm"); len(packages) != 1 || packages[0] != want {
+		t.Fatalf("安装包路径 = %v, 期望 %s", packages, want)
+	}
+	data, err := os.ReadFile(packages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lead
+	if !bytes.HasPrefix(data, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0}) {
+		t.Fatal("lead魔数无效")
+	}
+	if name, _, _ := strings.Cut(string(data[10:76]), "\x00"); name != "myapp-1.2.0~rc.1-1" {
+		t.Errorf("lead中的名称 = %q", name)
+	}
+
+	// 签名头按8字节对齐, 之后为主头和负载
+	sig, _, sigLen := parseRPMHeader(t, data[96:])
+	offset := 96 + sigLen + (8-sigLen%8)%8
+	tags, counts, headerLen := parseRPMHeader(t, data[offset:])
+	header := data[offset : offset+headerLen]
+	payload := data[offset+headerLen:]
+
+	headerSum := sha256.Sum256(header)
+	if got := sig.str(rpmSigTagSHA256); got != hex.EncodeToString(headerSum[:]) {
+		t.Errorf("签名头中的sha256 = %s, 期望主头的摘要 %x", got, headerSum)
+	}
+	if got := binary.BigEndian.Uint32(sig[rpmSigTagSize]); int(got) != len(header)+len(payload) {
+		t.Errorf("签名头中的大小 = %d, 期望 %d", got, len(header)+len(payload))
+	}
+	payloadSum := sha256.Sum256(payload)
+	if got := tags.str(rpmTagPayloadDigest); got != hex.EncodeToString(payloadSum[:]) {
+		t.Errorf("负载摘要 = %s, 期望 %x", got, payloadSum)
+	}
+
+	for tag, want := range map[int32]string{
+		rpmTagName:              "myapp",
+		rpmTagVersion:           "1.2.0~rc.1",
+		rpmTagRelease:           "1",
+		rpmTagArch:              "x86_64",
+		rpmTagOS:                "linux",
+		rpmTagLicense:           "MIT",
+		rpmTagURL:               "https://example.com/myapp",
+		rpmTagPackager:          "Dev <dev@example.com>",
+		rpmTagSummary:           "My test app",
+		rpmTagPayloadCompressor: "gzip",
+		rpmTagPostIn:            "#!/bin/sh\necho installed\n",
+		rpmTagPostInProg:        "/bin/sh",
+	} {
+		if got := tags.str(tag); got != want {
+			t.Errorf("标签 %d = %q, 期望 %q", tag, got, want)
+		}
+	}
+
+	if got := tags.strs(rpmTagBaseNames, counts[rpmTagBaseNames]); !reflect.DeepEqual(got, []string{"myapp.conf", "myapp"}) {
+		t.Errorf("文件名 = %v", got)
+	}
+	if got := tags.strs(rpmTagDirNames, counts[rpmTagDirNames]); !reflect.DeepEqual(got, []string{"/etc/myapp/", "/usr/bin/"}) {
+		t.Errorf("目录 = %v", got)
+	}
+	if flags := tags[rpmTagFileFlags]; binary.BigEndian.Uint32(flags) != rpmFileConfig|rpmFileNoReplace || binary.BigEndian.Uint32(flags[4:]) != 0 {
+		t.Errorf("配置文件标志不正确: %v", flags)
+	}
+	if modes := tags[rpmTagFileModes]; binary.BigEndian.Uint16(modes) != 0o100640 || binary.BigEndian.Uint16(modes[2:]) != 0o100755 {
+		t.Errorf("文件权限不正确: %o %o", binary.BigEndian.Uint16(modes), binary.BigEndian.Uint16(modes[2:]))
+	}
+	confSum := sha256.Sum256([]byte("key = value\n"))
+	if got := tags.strs(rpmTagFileDigests, counts[rpmTagFileDigests]); got[0] != hex.EncodeToString(confSum[:]) {
+		t.Errorf("文件摘要 = %v", got)
+	}
+	requires := tags.strs(rpmTagRequireName, counts[rpmTagRequireName])
+	if requires[0] != "git" || requires[1] != "libc6" {
+		t.Errorf("依赖 = %v", requires)
+	}
+	if got := tags.strs(rpmTagConflictName, counts[rpmTagConflictName]); !reflect.DeepEqual(got, []string{"oldapp"}) {
+		t.Errorf("冲突 = %v", got)
+	}
+
+	// 负载为gzip压缩的cpio, 文件名以 ./ 开头
+	gr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpio, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"070701", "./etc/myapp/myapp.conf", "./usr/bin/myapp", "key = value\n", "TRAILER!!!"} {
+		if !bytes.Contains(cpio, []byte(want)) {
+			t.Errorf("cpio负载中缺少 %q", want)
+		}
+	}
+}
+
+func TestBuildAPK(t *testing.T) {
+	cfg, spec := newTestPackage(t, "apk")
+	spec.Version = "v1.2.0-3-gabc1234"
+	packages, err := BuildLinuxPackages(cfg, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(spec.OutDir, "myapp-1.2.0_git3-r1.x86_64.apk"); len(packages) != 1 || packages[0] != want {
+		t.Fatalf("安装包路径 = %v, 期望 %s", packages, want)
+	}
+	data, err := os.ReadFile(packages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 控制段和数据段为两个独立的gzip流
+	r := bytes.NewReader(data)
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr.Multistream(false)
+	control := readTarFiles(t, gr)
+	dataSegment := data[len(data)-r.Len():]
+
+	dataSum := sha256.Sum256(dataSegment)
+	wantInfo := "# Generated by gob\n" +
+		"pkgname = myapp\n" +
+		"pkgver = 1.2.0_git3-r1\n" +
+		"pkgdesc = My test app\n" +
+		"url = https://example.com/myapp\n" +
+		"builddate = 1700000000\n" +
+		"packager = Dev <dev@example.com>\n" +
+		fmt.Sprintf("size = %d\n", len("#!/bin/sh\necho myapp\n")+len("key = value\n")) +
+		"arch = x86_64\n" +
+		"origin = myapp\n" +
+		"maintainer = Dev <dev@example.com>\n" +
+		"license = MIT\n" +
+		"depend = git\n" +
+		"depend = libc6>=2.17\n" +
+		"depend = !oldapp\n" +
+		"datahash = " + hex.EncodeToString(dataSum[:]) + "\n"
+	if got := string(control[".PKGINFO"]); got != wantInfo {
+		t.Errorf(".PKGINFO:\n%s\n期望:\n%s", got, wantInfo)
+	}
+	if got := string(control[".post-install"]); got != "#!/bin/sh\necho installed\n" {
+		t.Errorf(".post-install = %q", got)
+	}
+
+	files := readTarGz(t, dataSegment)
+	if string(files["usr/bin/myapp"]) != "#!/bin/sh\necho myapp\n" || string(files["etc/myapp/myapp.conf"]) != "key = value\n" {
+		t.Errorf("数据段中的文件不正确: %v", files)
+	}
+}
+
+func TestBuildLinuxPackagesErrors(t *testing.T) {
+	cfg, spec := newTestPackage(t, "apk")
+	spec.Arch = "mips"
+	if _, err := BuildLinuxPackages(cfg, spec); err == nil || !strings.Contains(err.Error(), "apk 格式不支持架构 mips") {
+		t.Errorf("期望架构不受支持的错误, got %v", err)
+	}
+
+	cfg, spec = newTestPackage(t, "deb")
+	spec.Name = "My App"
+	if _, err := BuildLinuxPackages(cfg, spec); err == nil {
+		t.Error("包名无效时期望返回错误")
+	}
+
+	cfg, spec = newTestPackage(t, "deb")
+	cfg.Files = append(cfg.Files, types.PackageFile{Src: spec.Binary, Dst: "/usr/bin/myapp"})
+	if _, err := BuildLinuxPackages(cfg, spec); err == nil || !strings.Contains(err.Error(), "安装路径 /usr/bin/myapp 重复") {
+		t.Errorf("期望安装路径重复的错误, got %v", err)
+	}
+}
+
+func TestLinuxPackageVersion(t *testing.T) {
+	tests := []struct {
+		raw, format, want string
+	}{
+		{"v1.2.0", "deb", "1.2.0"},
+		{"1.2.0", "rpm", "1.2.0"},
+		{"v1.2.0-dirty", "deb", "1.2.0"},
+		{"v1.2.0-rc.1", "deb", "1.2.0~rc.1"},
+		{"v1.2.0-beta-2", "rpm", "1.2.0~beta.2"},
+		{"v1.2.0-3-gabc1234", "deb", "1.2.0+git3.abc1234"},
+		{"v1.2.0-rc.1-3-gabc1234-dirty", "rpm", "1.2.0~rc.1+git3.abc1234"},
+		{"v1.2.0", "apk", "1.2.0"},
+		{"v1.2.0-rc.1", "apk", "1.2.0_rc1"},
+		{"v1.2.0-beta", "apk", "1.2.0_beta"},
+		{"v1.2.0-3-gabc1234", "apk", "1.2.0_git3"},
+	}
+	for _, tt := range tests {
+		got, err := linuxPackageVersion(tt.raw, tt.format)
+		if err != nil || got != tt.want {
+			t.Errorf("linuxPackageVersion(%q, %q) = %q, %v, 期望 %q", tt.raw, tt.format, got, err, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ raw, format string }{{"abc1234", "deb"}, {"unknown", "rpm"}, {"v1.2.0-snapshot", "apk"}} {
+		if _, err := linuxPackageVersion(tt.raw, tt.format); err == nil {
+			t.Errorf("linuxPackageVersion(%q, %q) 期望返回错误", tt.raw, tt.format)
+		}
+	}
+}
+
+func TestParseDependency(t *testing.T) {
+	tests := map[string]dependency{
+		"git":              {name: "git"},
+		"libc6 >= 2.17":    {name: "libc6", op: ">=", version: "2.17"},
+		"libc6 (>= 2.17)":  {name: "libc6", op: ">=", version: "2.17"},
+		"foo (<< 2)":       {name: "foo", op: "<", version: "2"},
+		"foo>>1.0":         {name: "foo", op: ">", version: "1.0"},
+		" bar = 1.0-1 ":    {name: "bar", op: "=", version: "1.0-1"},
+		"python3-foo<=3.0": {name: "python3-foo", op: "<=", version: "3.0"},
+	}
+	for in, want := range tests {
+		got, err := parseDependency(in)
+		if err != nil || got != want {
+			t.Errorf("parseDependency(%q) = %+v, %v, 期望 %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "foo bar", ">= 1.0"} {
+		if _, err := parseDependency(in); err == nil {
+			t.Errorf("parseDependency(%q) 期望返回错误", in)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// wingetManifestVersion 生成的winget清单格式版本
+const wingetManifestVersion = "1.6.0"
+
+// ErrNoReleaseAssets 没有适用于清单格式的产物
+var ErrNoReleaseAssets = errors.New("没有适用的产物")
+
+// publishManifestWriters 各清单格式的生成函数
+var publishManifestWriters = map[string]func(spec *types.ReleaseSpec, cfg types.PublishManifestsConfig, dir string) ([]string, error){
+	"homebrew": writeHomebrewFormula,
+	"scoop":    writeScoopManifest,
+	"winget":   writeWingetManifests,
+}
+
+// homebrewTargets Homebrew支持的目标及其在formula中的判断条件
+var homebrewTargets = []struct{ os, arch, block, cond string }{
+	{"darwin", "amd64", "on_macos", "Hardware::CPU.intel?"},
+	{"darwin", "arm64", "on_macos", "Hardware::CPU.arm?"},
+	{"linux", "amd64", "on_linux", "Hardware::CPU.intel? && Hardware::CPU.is_64_bit?"},
+	{"linux", "arm64", "on_linux", "Hardware::CPU.arm? && Hardware::CPU.is_64_bit?"},
+	{"linux", "arm", "on_linux", "Hardware::CPU.arm? && !Hardware::CPU.is_64_bit?"},
+}
+
+// scoopArchs GOARCH到Scoop架构名称的映射
+var scoopArchs = map[string]string{
+	"amd64": "64bit",
+	"386":   "32bit",
+	"arm64": "arm64",
+}
+
+// wingetArchs GOARCH到winget架构名称的映射
+var wingetArchs = map[string]string{
+	"amd64": "x64",
+	"386":   "x86",
+	"arm64": "arm64",
+	"arm":   "arm",
+}
+
+// wingetIdentifierRegexp 匹配winget包标识符, 如 Publisher.Package
+var wingetIdentifierRegexp = regexp.MustCompile(`^[^.\s\\/:*?"<>|\x01-\x1f]{1,32}(\.[^.\s\\/:*?"<>|\x01-\x1f]{1,32}){1,7}$`)
+
+// PublishManifestFormats 返回支持的包管理器清单格式
+//
+// 返回值:
+//   - []string: 排序后的清单格式列表
+func PublishManifestFormats() []string {
+	return slices.Sorted(maps.Keys(publishManifestWriters))
+}
+
+// WritePublishManifest 生成指定格式的包管理器清单
+//
+// 参数:
+//   - format: 清单格式, 如 homebrew
+//   - spec: 软件信息及下载产物
+//   - cfg: 包管理器清单配置
+//   - dir: 清单输出目录
+//
+// 返回值:
+//   - []string: 生成的清单文件路径
+//   - error: 没有适用的产物时返回 ErrNoReleaseAssets, 写入失败时返回错误
+func WritePublishManifest(format string, spec *types.ReleaseSpec, cfg types.PublishManifestsConfig, dir string) ([]string, error) {
+	write, ok := publishManifestWriters[format]
+	if !ok {
+		return nil, fmt.Errorf("不支持的清单格式 %q, 可用的格式: %s", format, strings.Join(PublishManifestFormats(), "、"))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建清单目录 %s 失败: %w", dir, err)
+	}
+	return write(spec, cfg, dir)
+}
+
+// findAsset 查找指定平台和架构的第一个产物
+func findAsset(spec *types.ReleaseSpec, goos, goarch string) (types.ReleaseAsset, bool) {
+	for _, asset := range spec.Assets {
+		if asset.OS == goos && asset.Arch == goarch {
+			return asset, true
+		}
+	}
+	return types.ReleaseAsset{}, false
+}
+
+// summaryLine 返回描述的第一行, 描述为空时返回软件名称
+func summaryLine(spec *types.ReleaseSpec) string {
+	summary, _, _ := strings.Cut(strings.TrimSpace(spec.Description), "\n")
+	if summary = strings.TrimSpace(summary); summary == "" {
+		return spec.Name
+	}
+	return summary
+}
+
+// writeManifestFile 写入清单文件
+func writeManifestFile(dir, name, content string) (string, error) {
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("写入清单文件 %s 失败: %w", p, err)
+	}
+	return p, nil
+}
+
+// writeHomebrewFormula 生成Homebrew formula
+//
+// 参数:
+//   - spec: 软件信息及下载产物
+//   - cfg: 包管理器清单配置
+//   - dir: 清单输出目录
+//
+// 返回值:
+//   - []string: formula文件路径, 文件名为 软件名称.rb
+//   - error: 没有darwin或linux产物时返回 ErrNoReleaseAssets
+//
+// 注意:
+//   - Homebrew解压时会自动进入唯一的顶层目录, 因此只按文件名安装可执行文件
+func writeHomebrewFormula(spec *types.ReleaseSpec, _ types.PublishManifestsConfig, dir string) ([]string, error) {
+	blocks := map[string][]string{}
+	var order []string
+	for _, target := range homebrewTargets {
+		asset, ok := findAsset(spec, target.os, target.arch)
+		if !ok {
+			continue
+		}
+		if _, ok := blocks[target.block]; !ok {
+			order = append(order, target.block)
+		}
+		blocks[target.block] = append(blocks[target.block], fmt.Sprintf(
+			"    if %s\n      url %s\n      sha256 %s\n\n      def install\n        bin.install %s => %s\n      end\n    end\n",
+			target.cond, rubyString(asset.URL), rubyString(asset.SHA256), rubyString(path.Base(asset.Binary)), rubyString(spec.Name)))
+	}
+	if len(order) == 0 {
+		return nil, ErrNoReleaseAssets
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Generated by gob. DO NOT EDIT.\n")
+	fmt.Fprintf(&sb, "class %s < Formula\n", homebrewClassName(spec.Name))
+	fmt.Fprintf(&sb, "  desc %s\n", rubyString(summaryLine(spec)))
+	if spec.Homepage != "" {
+		fmt.Fprintf(&sb, "  homepage %s\n", rubyString(spec.Homepage))
+	}
+	fmt.Fprintf(&sb, "  version %s\n", rubyString(spec.Version))
+	if spec.License != "" {
+		fmt.Fprintf(&sb, "  license %s\n", rubyString(spec.License))
+	}
+	for _, block := range order {
+		fmt.Fprintf(&sb, "\n  %s do\n%s  end\n", block, strings.Join(blocks[block], ""))
+	}
+	fmt.Fprintf(&sb, "\n  test do\n    assert_path_exists bin/%s\n  end\nend\n", rubyString(spec.Name))
+
+	p, err := writeManifestFile(dir, strings.ToLower(spec.Name)+".rb", sb.String())
+	if err != nil {
+		return nil, err
+	}
+	return []string{p}, nil
+}
+
+// homebrewClassName 将软件名称转换为formula的类名, 如 my-app 转换为 MyApp
+func homebrewClassName(name string) string {
+	name = strings.ReplaceAll(name, "@", "AT")
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// rubyString 将字符串格式化为Ruby双引号字符串, 并转义插值语法
+func rubyString(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), "#{", `\#{`)
+}
+
+// scoopManifest Scoop清单
+type scoopManifest struct {
+	Version      string               `json:"version"`
+	Description  string               `json:"description"`
+	Homepage     string               `json:"homepage,omitempty"`
+	License      string               `json:"license,omitempty"`
+	Architecture map[string]scoopArch `json:"architecture"`
+}
+
+// scoopArch Scoop清单中单个架构的下载信息
+type scoopArch struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+	Bin  any    `json:"bin"`
+}
+
+// writeScoopManifest 生成Scoop清单
+//
+// 参数:
+//   - spec: 软件信息及下载产物
+//   - cfg: 包管理器清单配置
+//   - dir: 清单输出目录
+//
+// 返回值:
+//   - []string: 清单文件路径, 文件名为 软件名称.json
+//   - error: 没有windows产物时返回 ErrNoReleaseAssets
+func writeScoopManifest(spec *types.ReleaseSpec, _ types.PublishManifestsConfig, dir string) ([]string, error) {
+	manifest := scoopManifest{
+		Version:      spec.Version,
+		Description:  summaryLine(spec),
+		Homepage:     spec.Homepage,
+		License:      spec.License,
+		Architecture: map[string]scoopArch{},
+	}
+	for _, goarch := range slices.Sorted(maps.Keys(scoopArchs)) {
+		asset, ok := findAsset(spec, "windows", goarch)
+		if !ok {
+			continue
+		}
+		// 可执行文件名与软件名称不同时通过别名暴露命令
+		binary := strings.ReplaceAll(asset.Binary, "/", `\`)
+		var bin any = binary
+		if strings.TrimSuffix(path.Base(asset.Binary), ".exe") != spec.Name {
+			bin = [][]string{{binary, spec.Name}}
+		}
+		manifest.Architecture[scoopArchs[goarch]] = scoopArch{URL: asset.URL, Hash: asset.SHA256, Bin: bin}
+	}
+	if len(manifest.Architecture) == 0 {
+		return nil, ErrNoReleaseAssets
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化Scoop清单失败: %w", err)
+	}
+	p, err := writeManifestFile(dir, strings.ToLower(spec.Name)+".json", string(data)+"\n")
+	if err != nil {
+		return nil, err
+	}
+	return []string{p}, nil
+}
+
+// WingetPackageIdentifier 返回winget包标识符
+//
+// 参数:
+//   - cfg: winget清单配置
+//   - name: 软件名称
+//
+// 返回值:
+//   - string: 配置的包标识符, 未配置时为去掉空白的 发布者.软件名称
+//   - error: 包标识符格式无效时返回错误
+func WingetPackageIdentifier(cfg types.WingetConfig, name string) (string, error) {
+	id := cfg.PackageIdentifier
+	if id == "" {
+		id = strings.Join(strings.Fields(cfg.Publisher), "") + "." + strings.Join(strings.Fields(name), "")
+	}
+	if !wingetIdentifierRegexp.MatchString(id) {
+		return "", fmt.Errorf("winget包标识符格式无效 %q, 应为 发布者.软件名称 的形式", id)
+	}
+	return id, nil
+}
+
+// writeWingetManifests 生成winget多文件清单
+//
+// 参数:
+//   - spec: 软件信息及下载产物
+//   - cfg: 包管理器清单配置
+//   - dir: 清单输出目录
+//
+// 返回值:
+//   - []string: 版本、安装程序和默认语言区域三个清单文件的路径
+//   - error: 没有windows的zip或可执行文件产物时返回 ErrNoReleaseAssets
+//
+// 注意:
+//   - zip产物以便携式程序的形式安装, 其他归档格式不受winget支持, 会被忽略
+func writeWingetManifests(spec *types.ReleaseSpec, cfg types.PublishManifestsConfig, dir string) ([]string, error) {
+	id, err := WingetPackageIdentifier(cfg.Winget, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var installers strings.Builder
+	for _, goarch := range slices.Sorted(maps.Keys(wingetArchs)) {
+		asset, ok := findAsset(spec, "windows", goarch)
+		if !ok || (asset.Format != "" && asset.Format != "zip") {
+			continue
+		}
+		fmt.Fprintf(&installers, "  - Architecture: %s\n", wingetArchs[goarch])
+		if asset.Format == "zip" {
+			installers.WriteString("    InstallerType: zip\n    NestedInstallerType: portable\n    NestedInstallerFiles:\n")
+			fmt.Fprintf(&installers, "      - RelativeFilePath: %s\n        PortableCommandAlias: %s\n",
+				yamlString(strings.ReplaceAll(asset.Binary, "/", `\`)), yamlString(spec.Name))
+		} else {
+			fmt.Fprintf(&installers, "    InstallerType: portable\n    Commands:\n      - %s\n", yamlString(spec.Name))
+		}
+		fmt.Fprintf(&installers, "    InstallerUrl: %s\n    InstallerSha256: %s\n", yamlString(asset.URL), strings.ToUpper(asset.SHA256))
+	}
+	if installers.Len() == 0 {
+		return nil, ErrNoReleaseAssets
+	}
+
+	header := func(manifestType string) string {
+		return fmt.Sprintf("# Generated by gob. DO NOT EDIT.\n# yaml-language-server: $schema=https://aka.ms/winget-manifest.%s.%s.schema.json\n\nPackageIdentifier: %s\nPackageVersion: %s\n",
+			manifestType, wingetManifestVersion, yamlString(id), yamlString(spec.Version))
+	}
+	footer := func(manifestType string) string {
+		return fmt.Sprintf("ManifestType: %s\nManifestVersion: %s\n", manifestType, wingetManifestVersion)
+	}
+
+	locale := cfg.Winget.Locale
+	files := []struct{ name, content string }{
+		{id + ".yaml", header("version") +
+			fmt.Sprintf("DefaultLocale: %s\n", yamlString(locale)) + footer("version")},
+		{id + ".installer.yaml", header("installer") +
+			"Installers:\n" + installers.String() + footer("installer")},
+		{id + ".locale." + locale + ".yaml", header("defaultLocale") +
+			wingetLocale(spec, cfg.Winget.Publisher, locale) + footer("defaultLocale")},
+	}
+
+	var paths []string
+	for _, f := range files {
+		p, err := writeManifestFile(dir, f.name, f.content)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// wingetLocale 生成默认语言区域清单的字段
+func wingetLocale(spec *types.ReleaseSpec, publisher, locale string) string {
+	var sb strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", name, yamlString(value))
+		}
+	}
+
+	field("PackageLocale", locale)
+	field("Publisher", publisher)
+	field("PackageName", spec.Name)
+	field("PackageUrl", spec.Homepage)
+	field("License", spec.License)
+	field("ShortDescription", summaryLine(spec))
+	if description := strings.TrimSpace(spec.Description); strings.Contains(description, "\n") {
+		field("Description", description)
+	}
+	return sb.String()
+}
+
+// yamlString 将字符串格式化为YAML双引号字符串
+func yamlString(s string) string {
+	return strconv.Quote(s)
+}
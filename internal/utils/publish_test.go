@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// newReleaseSpec 创建包含各平台产物的软件信息
+func newReleaseSpec() *types.ReleaseSpec {
+	sum := strings.Repeat("ab", 32)
+	asset := func(goos, goarch, format, binary string) types.ReleaseAsset {
+		return types.ReleaseAsset{
+			OS:     goos,
+			Arch:   goarch,
+			URL:    "https://example.com/v1.2.0/my-app_" + goos + "_" + goarch,
+			SHA256: sum,
+			Format: format,
+			Binary: binary,
+		}
+	}
+	return &types.ReleaseSpec{
+		Name:        "my-app",
+		Version:     "1.2.0",
+		Description: "A small tool\nwith a longer description",
+		Homepage:    "https://example.com",
+		License:     "MIT",
+		Assets: []types.ReleaseAsset{
+			asset("linux", "amd64", "tar.gz", "my-app_linux_amd64/my-app"),
+			asset("darwin", "arm64", "tar.gz", "my-app_darwin_arm64/my-app"),
+			asset("windows", "amd64", "zip", "my-app_windows_amd64/my-app.exe"),
+			asset("windows", "arm64", "zip", "bin/tool.exe"),
+			asset("windows", "386", "tar.gz", "my-app.exe"),
+			asset("freebsd", "amd64", "tar.gz", "my-app"),
+		},
+	}
+}
+
+// newPublishManifestsConfig 创建启用winget清单的包管理器清单配置
+func newPublishManifestsConfig() types.PublishManifestsConfig {
+	cfg := GetDefaultConfig().Publish.Manifests
+	cfg.Winget.Publisher = "Example Corp"
+	return cfg
+}
+
+// readManifestFile 读取生成的清单文件
+func readManifestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteHomebrewFormula(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := WritePublishManifest("homebrew", newReleaseSpec(), newPublishManifestsConfig(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "my-app.rb") {
+		t.Fatalf("formula路径 = %v", paths)
+	}
+
+	sum := strings.Repeat("ab", 32)
+	want := `# Generated by gob. DO NOT EDIT.
+class MyApp < Formula
+  desc "A small tool"
+  homepage "https://example.com"
+  version "1.2.0"
+  license "MIT"
+
+  on_macos do
+    if Hardware::CPU.arm?
+      url "https://example.com/v1.2.0/my-app_darwin_arm64"
+      sha256 "` + sum + `"
+
+      def install
+        bin.install "my-app" => "my-app"
+      end
+    end
+  end
+
+  on_linux do
+    if Hardware::CPU.intel? && Hardware::CPU.is_64_bit?
+      url "https://example.com/v1.2.0/my-app_linux_amd64"
+      sha256 "` + sum + `"
+
+      def install
+        bin.install "my-app" => "my-app"
+      end
+    end
+  end
+
+  test do
+    assert_path_exists bin/"my-app"
+  end
+end
+`
+	if got := readManifestFile(t, paths[0]); got != want {
+		t.Errorf("formula内容:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestWriteScoopManifest(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := WritePublishManifest("scoop", newReleaseSpec(), newPublishManifestsConfig(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "my-app.json") {
+		t.Fatalf("清单路径 = %v", paths)
+	}
+
+	var manifest map[string]any
+	if err := json.Unmarshal([]byte(readManifestFile(t, paths[0])), &manifest); err != nil {
+		t.Fatalf("清单应为有效的JSON: %v", err)
+	}
+	sum := strings.Repeat("ab", 32)
+	want := map[string]any{
+		"version":     "1.2.0",
+		"description": "A small tool",
+		"homepage":    "https://example.com",
+		"license":     "MIT",
+		"architecture": map[string]any{
+			// 可执行文件名与软件名称一致时直接引用, 否则通过别名暴露命令
+			"64bit": map[string]any{"url": "https://example.com/v1.2.0/my-app_windows_amd64", "hash": sum, "bin": `my-app_windows_amd64\my-app.exe`},
+			"32bit": map[string]any{"url": "https://example.com/v1.2.0/my-app_windows_386", "hash": sum, "bin": "my-app.exe"},
+			"arm64": map[string]any{"url": "https://example.com/v1.2.0/my-app_windows_arm64", "hash": sum, "bin": []any{[]any{`bin\tool.exe`, "my-app"}}},
+		},
+	}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("Scoop清单:\n%v\n期望:\n%v", manifest, want)
+	}
+}
+
+func TestWriteWingetManifests(t *testing.T) {
+	dir := t.TempDir()
+	spec := newReleaseSpec()
+	// 可执行文件产物以便携式程序安装
+	spec.Assets = append(spec.Assets, types.ReleaseAsset{OS: "windows", Arch: "arm", URL: "https://example.com/my-app.exe", SHA256: "cd"})
+	paths, err := WritePublishManifest("winget", spec, newPublishManifestsConfig(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := func(manifestType string) string {
+		return "# Generated by gob. DO NOT EDIT.\n" +
+			"# yaml-language-server: $schema=https://aka.ms/winget-manifest." + manifestType + ".1.6.0.schema.json\n\n" +
+			"PackageIdentifier: \"ExampleCorp.my-app\"\nPackageVersion: \"1.2.0\"\n"
+	}
+	sum := strings.Repeat("AB", 32)
+	want := map[string]string{
+		"ExampleCorp.my-app.yaml": header("version") +
+			"DefaultLocale: \"en-US\"\nManifestType: version\nManifestVersion: 1.6.0\n",
+		// 按GOARCH排序, 386 的 tar.gz 产物不受winget支持, 被忽略
+		"ExampleCorp.my-app.installer.yaml": header("installer") + `Installers:
+  - Architecture: x64
+    InstallerType: zip
+    NestedInstallerType: portable
+    NestedInstallerFiles:
+      - RelativeFilePath: "my-app_windows_amd64\\my-app.exe"
+        PortableCommandAlias: "my-app"
+    InstallerUrl: "https://example.com/v1.2.0/my-app_windows_amd64"
+    InstallerSha256: ` + sum + `
+  - Architecture: arm
+    InstallerType: portable
+    Commands:
+      - "my-app"
+    InstallerUrl: "https://example.com/my-app.exe"
+    InstallerSha256: CD
+  - Architecture: arm64
+    InstallerType: zip
+    NestedInstallerType: portable
+    NestedInstallerFiles:
+      - RelativeFilePath: "bin\\tool.exe"
+        PortableCommandAlias: "my-app"
+    InstallerUrl: "https://example.com/v1.2.0/my-app_windows_arm64"
+    InstallerSha256: ` + sum + `
+ManifestType: installer
+ManifestVersion: 1.6.0
+`,
+		"ExampleCorp.my-app.locale.en-US.yaml": header("defaultLocale") + `PackageLocale: "en-US"
+Publisher: "Example Corp"
+PackageName: "my-app"
+PackageUrl: "https://example.com"
+License: "MIT"
+ShortDescription: "A small tool"
+Description: "A small tool\nwith a longer description"
+ManifestType: defaultLocale
+ManifestVersion: 1.6.0
+`,
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("期望生成 %d 个清单文件, got %v", len(want), paths)
+	}
+	for _, p := range paths {
+		name := filepath.Base(p)
+		if got := readManifestFile(t, p); got != want[name] {
+			t.Errorf("%s 内容:\n%s\n期望:\n%s", name, got, want[name])
+		}
+	}
+}
+
+func TestWritePublishManifestNoAssets(t *testing.T) {
+	spec := newReleaseSpec()
+	spec.Assets = []types.ReleaseAsset{{OS: "freebsd", Arch: "amd64", URL: "https://example.com/a", SHA256: "ab"}}
+	for _, format := range PublishManifestFormats() {
+		if _, err := WritePublishManifest(format, spec, newPublishManifestsConfig(), t.TempDir()); !errors.Is(err, ErrNoReleaseAssets) {
+			t.Errorf("%s: 没有适用的产物时期望返回 ErrNoReleaseAssets, got %v", format, err)
+		}
+	}
+
+	// winget只支持zip和可执行文件产物
+	spec.Assets = []types.ReleaseAsset{{OS: "windows", Arch: "amd64", Format: "tar.gz", URL: "https://example.com/a", SHA256: "ab"}}
+	if _, err := WritePublishManifest("winget", spec, newPublishManifestsConfig(), t.TempDir()); !errors.Is(err, ErrNoReleaseAssets) {
+		t.Errorf("只有tar.gz产物时期望返回 ErrNoReleaseAssets, got %v", err)
+	}
+
+	if _, err := WritePublishManifest("chocolatey", spec, newPublishManifestsConfig(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "homebrew、scoop、winget") {
+		t.Errorf("不支持的格式期望列出可用的格式, got %v", err)
+	}
+}
+
+func TestHomebrewClassName(t *testing.T) {
+	tests := map[string]string{
+		"gob":         "Gob",
+		"my-app":      "MyApp",
+		"my_app.cli":  "MyAppCli",
+		"node@18":     "NodeAT18",
+		"k9s":         "K9s",
+		"--weird--x-": "WeirdX",
+	}
+	for name, want := range tests {
+		if got := homebrewClassName(name); got != want {
+			t.Errorf("homebrewClassName(%q) = %q, 期望 %q", name, got, want)
+		}
+	}
+}
+
+func TestRubyString(t *testing.T) {
+	tests := map[string]string{
+		"plain":          `"plain"`,
+		`say "hi"`:       `"say \"hi\""`,
+		"#{system 'ls'}": `"\#{system 'ls'}"`,
+		"a#b":            `"a#b"`,
+	}
+	for s, want := range tests {
+		if got := rubyString(s); got != want {
+			t.Errorf("rubyString(%q) = %s, 期望 %s", s, got, want)
+		}
+	}
+}
+
+func TestWingetPackageIdentifier(t *testing.T) {
+	tests := []struct {
+		cfg     types.WingetConfig
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{types.WingetConfig{Publisher: "Example Corp"}, "my app", "ExampleCorp.myapp", false},
+		{types.WingetConfig{Publisher: "Example", PackageIdentifier: "Example.Tools.Gob"}, "gob", "Example.Tools.Gob", false},
+		{types.WingetConfig{}, "gob", "", true},
+		{types.WingetConfig{PackageIdentifier: "NoDot"}, "gob", "", true},
+		{types.WingetConfig{PackageIdentifier: "Bad.Name/Slash"}, "gob", "", true},
+		{types.WingetConfig{PackageIdentifier: strings.Repeat("a", 33) + ".b"}, "gob", "", true},
+	}
+	for _, tt := range tests {
+		got, err := WingetPackageIdentifier(tt.cfg, tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("WingetPackageIdentifier(%+v, %q) = %q, %v, 期望 %q", tt.cfg, tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// releaseProvider 表示托管平台的默认API地址和访问令牌的环境变量
+type releaseProvider struct {
+	url      string // 默认API地址, 为空时必须在配置中指定
+	tokenEnv string // 默认的访问令牌环境变量
+}
+
+// releaseProviders 支持的托管平台
+var releaseProviders = map[string]releaseProvider{
+	"github": {"https://api.github.com", "GITHUB_TOKEN"},
+	"gitea":  {"", "GITEA_TOKEN"},
+	"gitee":  {"https://gitee.com/api/v5", "GITEE_TOKEN"},
+}
+
+// ReleaseProviders 返回支持的托管平台
+//
+// 返回值:
+//   - []string: 按名称排序的托管平台
+func ReleaseProviders() []string {
+	providers := make([]string, 0, len(releaseProviders))
+	for name := range releaseProviders {
+		providers = append(providers, name)
+	}
+	slices.Sort(providers)
+	return providers
+}
+
+// ReleaseTokenEnv 返回存放访问令牌的环境变量名
+//
+// 参数:
+//   - cfg: 发布配置
+//
+// 返回值:
+//   - string: 配置的环境变量名, 未配置时为托管平台的默认值
+func ReleaseTokenEnv(cfg types.ReleaseConfig) string {
+	if cfg.TokenEnv != "" {
+		return cfg.TokenEnv
+	}
+	return releaseProviders[cfg.Provider].tokenEnv
+}
+
+// ReleaseAPIURL 返回托管平台的API地址
+//
+// 参数:
+//   - cfg: 发布配置
+//
+// 返回值:
+//   - string: 去掉末尾斜杠的API地址, 未配置时为托管平台的默认值
+func ReleaseAPIURL(cfg types.ReleaseConfig) string {
+	if cfg.URL != "" {
+		return strings.TrimRight(cfg.URL, "/")
+	}
+	return releaseProviders[cfg.Provider].url
+}
+
+// ReleaseClient 通过托管平台的REST API创建发布并上传附件
+type ReleaseClient struct {
+	provider string        // 托管平台
+	baseURL  string        // API地址
+	owner    string        // 仓库所有者
+	repo     string        // 仓库名称
+	token    string        // 访问令牌, 不会出现在输出和错误信息中
+	retries  int           // 重试次数
+	backoff  time.Duration // 首次重试的等待时间, 之后每次翻倍
+	client   *http.Client  // HTTP客户端
+}
+
+// NewReleaseClient 创建托管平台的发布客户端
+//
+// 参数:
+//   - cfg: 发布配置
+//   - token: 访问令牌
+//
+// 返回值:
+//   - *ReleaseClient: 发布客户端
+//   - error: 托管平台不支持或缺少API地址时返回错误
+func NewReleaseClient(cfg types.ReleaseConfig, token string) (*ReleaseClient, error) {
+	if _, ok := releaseProviders[cfg.Provider]; !ok {
+		return nil, fmt.Errorf("不支持的托管平台 %q, 可用的平台: %s", cfg.Provider, strings.Join(ReleaseProviders(), "、"))
+	}
+	baseURL := ReleaseAPIURL(cfg)
+	if baseURL == "" {
+		return nil, fmt.Errorf("托管平台 %s 需要通过 publish.release.url 指定API地址", cfg.Provider)
+	}
+
+	return &ReleaseClient{
+		provider: cfg.Provider,
+		baseURL:  baseURL,
+		owner:    cfg.Owner,
+		repo:     cfg.Repo,
+		token:    token,
+		retries:  max(cfg.Retries, 0),
+		backoff:  time.Second,
+		client:   &http.Client{},
+	}, nil
+}
+
+// EnsureRelease 查找标签对应的发布, 存在时更新, 不存在时创建
+//
+// 参数:
+//   - ctx: 上下文
+//   - req: 发布信息
+//
+// 返回值:
+//   - *types.HostedRelease: 创建或更新后的发布
+//   - bool: 是否新建了发布
+//   - error: 错误信息
+func (c *ReleaseClient) EnsureRelease(ctx context.Context, req types.ReleaseRequest) (*types.HostedRelease, bool, error) {
+	existing, err := c.findRelease(ctx, req.Tag)
+	if err != nil {
+		return nil, false, fmt.Errorf("查询标签 %s 的发布失败: %w", req.Tag, err)
+	}
+
+	payload := map[string]any{
+		"tag_name":   req.Tag,
+		"name":       req.Name,
+		"body":       req.Notes,
+		"prerelease": req.Prerelease,
+	}
+	if c.provider != "gitee" {
+		payload["draft"] = req.Draft
+	} else if req.Notes == "" {
+		payload["body"] = req.Name // gitee要求发布说明不能为空
+	}
+
+	var rel types.HostedRelease
+	if existing != nil {
+		if err := c.doJSON(ctx, http.MethodPatch, c.repoURL("releases", strconv.FormatInt(existing.ID, 10)), payload, &rel); err != nil {
+			return nil, false, fmt.Errorf("更新发布 %s 失败: %w", req.Tag, err)
+		}
+		return &rel, false, nil
+	}
+
+	if req.Commitish != "" {
+		payload["target_commitish"] = req.Commitish
+	}
+	if err := c.doJSON(ctx, http.MethodPost, c.repoURL("releases"), payload, &rel); err != nil {
+		return nil, false, fmt.Errorf("创建发布 %s 失败: %w", req.Tag, err)
+	}
+	return &rel, true, nil
+}
+
+// UploadAsset 将文件上传为发布的附件, 已存在同名附件时先删除
+//
+// 参数:
+//   - ctx: 上下文
+//   - rel: 发布
+//   - path: 文件路径
+//
+// 返回值:
+//   - bool: 是否替换了同名附件
+//   - error: 错误信息
+//
+// 注意:
+//   - 上传失败需要重试时先重新查询附件并删除同名附件, 避免服务端已部分接收的上传留下重复或残缺的附件
+func (c *ReleaseClient) UploadAsset(ctx context.Context, rel *types.HostedRelease, path string) (bool, error) {
+	name := filepath.Base(path)
+	id := strconv.FormatInt(rel.ID, 10)
+	var body bodyFunc
+	var target string
+	switch c.provider {
+	case "github":
+		uploadURL, _, _ := strings.Cut(rel.UploadURL, "{")
+		if uploadURL == "" {
+			return false, fmt.Errorf("发布 %s 缺少附件上传地址", rel.TagName)
+		}
+		target = uploadURL + "?name=" + url.QueryEscape(name)
+		body = rawFileBody(path)
+	case "gitea":
+		target = c.repoURL("releases", id, "assets") + "?name=" + url.QueryEscape(name)
+		body = multipartFileBody(path, "attachment")
+	default:
+		target = c.repoURL("releases", id, "attach_files")
+		body = multipartFileBody(path, "file")
+	}
+
+	replaced := false
+	attempt := 0
+	err := retryRequest(ctx, c.retries, c.backoff, func() (bool, error) {
+		// 首次上传使用发布中的附件列表, 重试时重新查询
+		assets := rel.Assets
+		if c.provider == "gitee" || attempt > 0 {
+			var err error
+			if assets, err = c.listAssets(ctx, rel); err != nil {
+				return false, fmt.Errorf("查询发布附件失败: %w", err)
+			}
+		}
+		for _, asset := range assets {
+			if asset.Name != name {
+				continue
+			}
+			if err := c.do(ctx, http.MethodDelete, c.assetURL(rel, asset.ID), nil, nil); err != nil {
+				return false, fmt.Errorf("删除已存在的附件 %s 失败: %w", name, err)
+			}
+			replaced = replaced || attempt == 0
+		}
+		attempt++
+		return c.send(ctx, http.MethodPost, target, body, nil)
+	})
+	if err != nil {
+		return replaced, fmt.Errorf("上传附件 %s 失败: %w", name, err)
+	}
+	return replaced, nil
+}
+
+// findRelease 查找标签对应的发布
+//
+// 参数:
+//   - ctx: 上下文
+//   - tag: 标签
+//
+// 返回值:
+//   - *types.HostedRelease: 找到的发布, 不存在时为nil
+//   - error: 错误信息
+//
+// 注意:
+//   - github和gitea按标签查询时不返回草稿, 因此未找到时再在最近的发布中查找
+func (c *ReleaseClient) findRelease(ctx context.Context, tag string) (*types.HostedRelease, error) {
+	var rel types.HostedRelease
+	err := c.doJSON(ctx, http.MethodGet, c.repoURL("releases", "tags", tag), nil, &rel)
+	var statusErr *httpStatusError
+	switch {
+	case err == nil && rel.ID != 0:
+		return &rel, nil
+	case err != nil && (!errors.As(err, &statusErr) || statusErr.status != http.StatusNotFound):
+		return nil, err
+	case c.provider == "gitee":
+		return nil, nil
+	}
+
+	var releases []types.HostedRelease
+	if err := c.doJSON(ctx, http.MethodGet, c.repoURL("releases")+"?per_page=100&limit=50", nil, &releases); err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		if releases[i].TagName == tag {
+			return &releases[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// listAssets 查询发布中的附件
+func (c *ReleaseClient) listAssets(ctx context.Context, rel *types.HostedRelease) ([]types.HostedAsset, error) {
+	if c.provider != "gitee" {
+		var latest types.HostedRelease
+		if err := c.doJSON(ctx, http.MethodGet, c.repoURL("releases", strconv.FormatInt(rel.ID, 10)), nil, &latest); err != nil {
+			return nil, err
+		}
+		return latest.Assets, nil
+	}
+	var assets []types.HostedAsset
+	if err := c.doJSON(ctx, http.MethodGet, c.repoURL("releases", strconv.FormatInt(rel.ID, 10), "attach_files"), nil, &assets); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+// assetURL 返回删除附件使用的地址
+func (c *ReleaseClient) assetURL(rel *types.HostedRelease, assetID int64) string {
+	id := strconv.FormatInt(assetID, 10)
+	switch c.provider {
+	case "github":
+		return c.repoURL("releases", "assets", id)
+	case "gitea":
+		return c.repoURL("releases", strconv.FormatInt(rel.ID, 10), "assets", id)
+	default:
+		return c.repoURL("releases", strconv.FormatInt(rel.ID, 10), "attach_files", id)
+	}
+}
+
+// repoURL 返回仓库下的API地址, 每一段路径都会被转义
+func (c *ReleaseClient) repoURL(elems ...string) string {
+	var b strings.Builder
+	b.WriteString(c.baseURL)
+	b.WriteString("/repos/")
+	b.WriteString(url.PathEscape(c.owner))
+	b.WriteString("/")
+	b.WriteString(url.PathEscape(c.repo))
+	for _, elem := range elems {
+		b.WriteString("/")
+		b.WriteString(url.PathEscape(elem))
+	}
+	return b.String()
+}
+
+// doJSON 发送JSON请求并解析JSON响应
+//
+// 参数:
+//   - ctx: 上下文
+//   - method: 请求方法
+//   - target: 请求地址
+//   - payload: 请求体, 为nil时不发送请求体
+//   - out: 响应的解析目标
+//
+// 返回值:
+//   - error: 错误信息
+func (c *ReleaseClient) doJSON(ctx context.Context, method, target string, payload, out any) error {
+	var body bodyFunc
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		body = func() (io.Reader, string, int64, error) {
+			return bytes.NewReader(data), "application/json", int64(len(data)), nil
+		}
+	}
+	return c.do(ctx, method, target, body, out)
+}
+
+// do 发送请求, 网络错误、429和5xx响应按指数退避重试
+//
+// 参数:
+//   - ctx: 上下文
+//   - method: 请求方法
+//   - target: 请求地址
+//   - body: 请求体, 为nil时不发送请求体
+//   - out: 响应的解析目标, 为nil时忽略响应内容
+//
+// 返回值:
+//   - error: 重试用尽或遇到不可重试的响应时返回错误
+func (c *ReleaseClient) do(ctx context.Context, method, target string, body bodyFunc, out any) error {
+	return retryRequest(ctx, c.retries, c.backoff, func() (bool, error) {
+		return c.send(ctx, method, target, body, out)
+	})
+}
+
+// send 发送一次请求, 不重试
+//
+// 参数:
+//   - ctx: 上下文
+//   - method: 请求方法
+//   - target: 请求地址
+//   - body: 请求体, 为nil时不发送请求体
+//   - out: 响应的解析目标, 为nil时忽略响应内容
+//
+// 返回值:
+//   - bool: 失败时是否可以重试
+//   - error: 错误信息, 不包含访问令牌
+func (c *ReleaseClient) send(ctx context.Context, method, target string, body bodyFunc, out any) (bool, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false, fmt.Errorf("无效的请求地址: %w", err)
+	}
+	display := redactURL(u)
+	if c.provider == "gitee" && c.token != "" {
+		q := u.Query()
+		q.Set("access_token", c.token)
+		u.RawQuery = q.Encode()
+	}
+	return sendRequest(ctx, c.client, method, u.String(), display, body, c.authorize, decodeJSON(out))
+}
+
+// authorize 设置托管平台要求的请求头和访问令牌
+func (c *ReleaseClient) authorize(req *http.Request) error {
+	switch c.provider {
+	case "github":
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+	case "gitea":
+		req.Header.Set("Accept", "application/json")
+		if c.token != "" {
+			req.Header.Set("Authorization", "token "+c.token)
+		}
+	default:
+		// gitee的访问令牌通过查询参数传递
+		req.Header.Set("Accept", "application/json")
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// fakeReleaseServer 模拟托管平台的发布API, 记录收到的请求
+type fakeReleaseServer struct {
+	mu       sync.Mutex
+	requests []string                                            // 收到的请求, 格式为 "方法 路径"
+	bodies   map[string][]byte                                   // 每个请求的请求体, 键为 "方法 路径"
+	queries  []string                                            // 每个请求的查询参数
+	handle   func(w http.ResponseWriter, r *http.Request, n int) // 处理请求, n 为同一请求出现的次数
+	counts   map[string]int
+}
+
+// newFakeReleaseServer 启动模拟服务并创建指向它的发布客户端
+func newFakeReleaseServer(t *testing.T, provider, token string, handle func(w http.ResponseWriter, r *http.Request, n int)) (*fakeReleaseServer, *ReleaseClient) {
+	t.Helper()
+	f := &fakeReleaseServer{handle: handle, bodies: make(map[string][]byte), counts: make(map[string]int)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		key := r.Method + " " + r.URL.Path
+		f.mu.Lock()
+		f.requests = append(f.requests, key)
+		f.queries = append(f.queries, r.URL.RawQuery)
+		f.bodies[key] = body
+		f.counts[key]++
+		n := f.counts[key]
+		f.mu.Unlock()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		f.handle(w, r, n)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewReleaseClient(types.ReleaseConfig{Provider: provider, URL: srv.URL, Owner: "owner", Repo: "repo", Retries: 3}, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.backoff = time.Millisecond
+	return f, client
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// payload 返回请求的JSON请求体
+func (f *fakeReleaseServer) payload(t *testing.T, key string) map[string]any {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var m map[string]any
+	if err := json.Unmarshal(f.bodies[key], &m); err != nil {
+		t.Fatalf("解析 %s 的请求体失败: %v", key, err)
+	}
+	return m
+}
+
+// writeAsset 创建待上传的文件
+func writeAsset(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "myapp_linux_amd64.tar.gz")
+	if err := os.WriteFile(path, []byte("archive-content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnsureReleaseCreate(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     []string
+	}{
+		{"github", []string{"GET /repos/owner/repo/releases/tags/v1.0.0", "GET /repos/owner/repo/releases", "POST /repos/owner/repo/releases"}},
+		{"gitea", []string{"GET /repos/owner/repo/releases/tags/v1.0.0", "GET /repos/owner/repo/releases", "POST /repos/owner/repo/releases"}},
+		{"gitee", []string{"GET /repos/owner/repo/releases/tags/v1.0.0", "POST /repos/owner/repo/releases"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			f, client := newFakeReleaseServer(t, tt.provider, "token", func(w http.ResponseWriter, r *http.Request, n int) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/tags/v1.0.0"):
+					writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+				case r.Method == http.MethodGet:
+					writeJSON(w, http.StatusOK, []types.HostedRelease{{ID: 2, TagName: "v0.9.0"}})
+				default:
+					writeJSON(w, http.StatusCreated, types.HostedRelease{ID: 1, TagName: "v1.0.0"})
+				}
+			})
+
+			rel, created, err := client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0", Name: "myapp v1.0.0", Commitish: "abc1234"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !created || rel.ID != 1 {
+				t.Errorf("期望新建发布, got created=%v rel=%+v", created, rel)
+			}
+			if !reflect.DeepEqual(f.requests, tt.want) {
+				t.Errorf("请求顺序:\n got %v\nwant %v", f.requests, tt.want)
+			}
+
+			payload := f.payload(t, "POST /repos/owner/repo/releases")
+			if payload["tag_name"] != "v1.0.0" || payload["name"] != "myapp v1.0.0" || payload["target_commitish"] != "abc1234" {
+				t.Errorf("创建发布的请求体不正确: %v", payload)
+			}
+			if _, ok := payload["draft"]; ok == (tt.provider == "gitee") {
+				t.Errorf("只有gitee不发送draft, got %v", payload)
+			}
+			if tt.provider == "gitee" && payload["body"] != "myapp v1.0.0" {
+				t.Errorf("gitee发布说明为空时应使用标题, got %v", payload["body"])
+			}
+		})
+	}
+}
+
+func TestEnsureReleaseUpdate(t *testing.T) {
+	for _, provider := range []string{"github", "gitea", "gitee"} {
+		t.Run(provider, func(t *testing.T) {
+			f, client := newFakeReleaseServer(t, provider, "token", func(w http.ResponseWriter, r *http.Request, n int) {
+				if r.Method == http.MethodGet {
+					writeJSON(w, http.StatusOK, types.HostedRelease{ID: 7, TagName: "v1.0.0"})
+					return
+				}
+				writeJSON(w, http.StatusOK, types.HostedRelease{ID: 7, TagName: "v1.0.0", Name: "new"})
+			})
+
+			rel, created, err := client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0", Name: "new", Notes: "notes", Commitish: "abc1234"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created || rel.ID != 7 || rel.Name != "new" {
+				t.Errorf("期望更新已存在的发布, got created=%v rel=%+v", created, rel)
+			}
+			want := []string{"GET /repos/owner/repo/releases/tags/v1.0.0", "PATCH /repos/owner/repo/releases/7"}
+			if !reflect.DeepEqual(f.requests, want) {
+				t.Errorf("请求顺序:\n got %v\nwant %v", f.requests, want)
+			}
+			payload := f.payload(t, "PATCH /repos/owner/repo/releases/7")
+			if payload["body"] != "notes" {
+				t.Errorf("更新发布的请求体不正确: %v", payload)
+			}
+			if _, ok := payload["target_commitish"]; ok {
+				t.Errorf("更新发布时不应发送target_commitish: %v", payload)
+			}
+		})
+	}
+}
+
+func TestEnsureReleaseDraftFallback(t *testing.T) {
+	for _, provider := range []string{"github", "gitea"} {
+		t.Run(provider, func(t *testing.T) {
+			f, client := newFakeReleaseServer(t, provider, "token", func(w http.ResponseWriter, r *http.Request, n int) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/tags/v1.0.0"):
+					writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+				case r.Method == http.MethodGet:
+					writeJSON(w, http.StatusOK, []types.HostedRelease{{ID: 2, TagName: "v0.9.0"}, {ID: 3, TagName: "v1.0.0", Draft: true}})
+				default:
+					writeJSON(w, http.StatusOK, types.HostedRelease{ID: 3, TagName: "v1.0.0"})
+				}
+			})
+
+			_, created, err := client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0", Draft: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"GET /repos/owner/repo/releases/tags/v1.0.0", "GET /repos/owner/repo/releases", "PATCH /repos/owner/repo/releases/3"}
+			if created || !reflect.DeepEqual(f.requests, want) {
+				t.Errorf("草稿发布应被找到并更新, created=%v 请求:\n got %v\nwant %v", created, f.requests, want)
+			}
+			if f.payload(t, "PATCH /repos/owner/repo/releases/3")["draft"] != true {
+				t.Error("更新草稿时应发送draft")
+			}
+		})
+	}
+}
+
+func TestUploadAssetReplace(t *testing.T) {
+	tests := []struct {
+		provider string
+		field    string // multipart表单字段, 为空时为原始文件内容
+		want     []string
+	}{
+		{"github", "", []string{"DELETE /repos/owner/repo/releases/assets/5", "POST /uploads/repos/owner/repo/releases/1/assets"}},
+		{"gitea", "attachment", []string{"DELETE /repos/owner/repo/releases/1/assets/5", "POST /repos/owner/repo/releases/1/assets"}},
+		{"gitee", "file", []string{"GET /repos/owner/repo/releases/1/attach_files", "DELETE /repos/owner/repo/releases/1/attach_files/5", "POST /repos/owner/repo/releases/1/attach_files"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			path := writeAsset(t)
+			assets := []types.HostedAsset{{ID: 4, Name: "other.zip"}, {ID: 5, Name: filepath.Base(path)}}
+			f, client := newFakeReleaseServer(t, tt.provider, "token", func(w http.ResponseWriter, r *http.Request, n int) {
+				switch r.Method {
+				case http.MethodGet:
+					writeJSON(w, http.StatusOK, assets)
+				case http.MethodDelete:
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					if tt.field != "" {
+						file, header, err := r.FormFile(tt.field)
+						if err != nil {
+							t.Errorf("读取表单字段 %s 失败: %v", tt.field, err)
+							w.WriteHeader(http.StatusBadRequest)
+							return
+						}
+						content, _ := io.ReadAll(file)
+						if header.Filename != filepath.Base(path) || string(content) != "archive-content" {
+							t.Errorf("上传的文件不正确: %s %q", header.Filename, content)
+						}
+					}
+					writeJSON(w, http.StatusCreated, types.HostedAsset{ID: 6, Name: filepath.Base(path)})
+				}
+			})
+			rel := &types.HostedRelease{ID: 1, TagName: "v1.0.0", Assets: assets}
+			if tt.provider == "github" {
+				rel.UploadURL = client.baseURL + "/uploads/repos/owner/repo/releases/1/assets{?name,label}"
+			}
+
+			replaced, err := client.UploadAsset(context.Background(), rel, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !replaced {
+				t.Error("期望替换同名附件")
+			}
+			if !reflect.DeepEqual(f.requests, tt.want) {
+				t.Errorf("请求顺序:\n got %v\nwant %v", f.requests, tt.want)
+			}
+			if tt.field == "" {
+				if body := f.bodies[tt.want[len(tt.want)-1]]; string(body) != "archive-content" {
+					t.Errorf("github应直接上传文件内容, got %q", body)
+				}
+			}
+			if tt.provider != "gitee" && !strings.Contains(f.queries[len(f.queries)-1], "name=myapp_linux_amd64.tar.gz") {
+				t.Errorf("上传地址应包含文件名, got %q", f.queries[len(f.queries)-1])
+			}
+		})
+	}
+}
+
+func TestUploadAssetRetryRemovesPartialAsset(t *testing.T) {
+	path := writeAsset(t)
+	f, client := newFakeReleaseServer(t, "gitea", "token", func(w http.ResponseWriter, r *http.Request, n int) {
+		switch r.Method {
+		case http.MethodGet:
+			// 第一次上传虽然返回502, 服务端已经保存了附件
+			writeJSON(w, http.StatusOK, types.HostedRelease{ID: 1, Assets: []types.HostedAsset{{ID: 9, Name: filepath.Base(path)}}})
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			if n == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			writeJSON(w, http.StatusCreated, types.HostedAsset{ID: 10})
+		}
+	})
+
+	replaced, err := client.UploadAsset(context.Background(), &types.HostedRelease{ID: 1}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced {
+		t.Error("重试时删除的是本次上传残留的附件, 不应报告为替换")
+	}
+	want := []string{
+		"POST /repos/owner/repo/releases/1/assets",
+		"GET /repos/owner/repo/releases/1",
+		"DELETE /repos/owner/repo/releases/1/assets/9",
+		"POST /repos/owner/repo/releases/1/assets",
+	}
+	if !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("请求顺序:\n got %v\nwant %v", f.requests, want)
+	}
+}
+
+func TestReleaseClientRetry(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		f, client := newFakeReleaseServer(t, "github", "token", func(w http.ResponseWriter, r *http.Request, n int) {
+			if n < 3 {
+				w.WriteHeader(status)
+				return
+			}
+			writeJSON(w, http.StatusOK, types.HostedRelease{ID: 1, TagName: "v1.0.0"})
+		})
+		if _, _, err := client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0"}); err != nil {
+			t.Fatalf("%d 响应应被重试: %v", status, err)
+		}
+		if got := f.counts["GET /repos/owner/repo/releases/tags/v1.0.0"]; got != 3 {
+			t.Errorf("%d 响应的请求次数 = %d, 期望 3", status, got)
+		}
+	}
+
+	// 重试用尽
+	f, client := newFakeReleaseServer(t, "github", "token", func(w http.ResponseWriter, r *http.Request, n int) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	client.retries = 1
+	_, _, err := client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "重试 1 次后仍然失败") || len(f.requests) != 2 {
+		t.Errorf("期望重试1次后失败, got %v, 请求 %v", err, f.requests)
+	}
+
+	// 4xx 不重试
+	f, client = newFakeReleaseServer(t, "github", "token", func(w http.ResponseWriter, r *http.Request, n int) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+	})
+	_, _, err = client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "401") || len(f.requests) != 1 {
+		t.Errorf("4xx响应不应重试, got %v, 请求 %v", err, f.requests)
+	}
+}
+
+func TestReleaseClientAuthorization(t *testing.T) {
+	tests := []struct {
+		provider, header string
+	}{
+		{"github", "Bearer secret-token"},
+		{"gitea", "token secret-token"},
+		{"gitee", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			var header, query string
+			_, client := newFakeReleaseServer(t, tt.provider, "secret-token", func(w http.ResponseWriter, r *http.Request, n int) {
+				header, query = r.Header.Get("Authorization"), r.URL.Query().Get("access_token")
+				writeJSON(w, http.StatusForbidden, map[string]string{"message": "forbidden"})
+			})
+
+			_, _, err := client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0"})
+			if err == nil {
+				t.Fatal("期望返回错误")
+			}
+			if header != tt.header {
+				t.Errorf("Authorization = %q, 期望 %q", header, tt.header)
+			}
+			if wantQuery := map[bool]string{true: "secret-token"}[tt.provider == "gitee"]; query != wantQuery {
+				t.Errorf("access_token = %q, 期望 %q", query, wantQuery)
+			}
+			if strings.Contains(err.Error(), "secret-token") || !strings.Contains(err.Error(), "/repos/owner/repo/releases/tags/v1.0.0") {
+				t.Errorf("错误信息应包含请求地址且不包含访问令牌: %v", err)
+			}
+		})
+	}
+}
+
+func TestGiteeTokenRedactedFromNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // 连接被拒绝
+	client, err := NewReleaseClient(types.ReleaseConfig{Provider: "gitee", URL: srv.URL, Owner: "owner", Repo: "repo"}, "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = client.EnsureRelease(context.Background(), types.ReleaseRequest{Tag: "v1.0.0"})
+	if err == nil {
+		t.Fatal("期望返回网络错误")
+	}
+	if strings.Contains(err.Error(), "secret-token") || strings.Contains(err.Error(), "access_token") {
+		t.Errorf("网络错误中不应包含访问令牌: %v", err)
+	}
+}
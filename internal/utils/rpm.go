@@ -0,0 +1,512 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// rpm 标签的数据类型
+const (
+	rpmTypeInt16       = 3
+	rpmTypeInt32       = 4
+	rpmTypeString      = 6
+	rpmTypeBin         = 7
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// rpm 签名头中的标签
+const (
+	rpmTagSignatures     = 62
+	rpmSigTagSHA1        = 269
+	rpmSigTagSHA256      = 273
+	rpmSigTagSize        = 1000
+	rpmSigTagMD5         = 1004
+	rpmSigTagPayloadSize = 1007
+)
+
+// rpm 主头中的标签
+const (
+	rpmTagImmutable         = 63
+	rpmTagI18NTable         = 100
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagSummary           = 1004
+	rpmTagDescription       = 1005
+	rpmTagBuildTime         = 1006
+	rpmTagBuildHost         = 1007
+	rpmTagSize              = 1009
+	rpmTagVendor            = 1011
+	rpmTagLicense           = 1014
+	rpmTagPackager          = 1015
+	rpmTagGroup             = 1016
+	rpmTagURL               = 1020
+	rpmTagOS                = 1021
+	rpmTagArch              = 1022
+	rpmTagPreIn             = 1023
+	rpmTagPostIn            = 1024
+	rpmTagPreUn             = 1025
+	rpmTagPostUn            = 1026
+	rpmTagFileSizes         = 1028
+	rpmTagFileModes         = 1030
+	rpmTagFileRdevs         = 1033
+	rpmTagFileMtimes        = 1034
+	rpmTagFileDigests       = 1035
+	rpmTagFileLinkTos       = 1036
+	rpmTagFileFlags         = 1037
+	rpmTagFileUserName      = 1039
+	rpmTagFileGroupName     = 1040
+	rpmTagSourceRPM         = 1044
+	rpmTagProvideName       = 1047
+	rpmTagRequireFlags      = 1048
+	rpmTagRequireName       = 1049
+	rpmTagRequireVersion    = 1050
+	rpmTagConflictFlags     = 1053
+	rpmTagConflictName      = 1054
+	rpmTagConflictVersion   = 1055
+	rpmTagPreInProg         = 1085
+	rpmTagPostInProg        = 1086
+	rpmTagPreUnProg         = 1087
+	rpmTagPostUnProg        = 1088
+	rpmTagObsoleteName      = 1090
+	rpmTagFileDevices       = 1095
+	rpmTagFileInodes        = 1096
+	rpmTagFileLangs         = 1097
+	rpmTagProvideFlags      = 1112
+	rpmTagProvideVersion    = 1113
+	rpmTagObsoleteFlags     = 1114
+	rpmTagObsoleteVersion   = 1115
+	rpmTagDirIndexes        = 1116
+	rpmTagBaseNames         = 1117
+	rpmTagDirNames          = 1118
+	rpmTagPayloadFormat     = 1124
+	rpmTagPayloadCompressor = 1125
+	rpmTagPayloadFlags      = 1126
+	rpmTagFileDigestAlgo    = 5011
+	rpmTagRecommendName     = 5046
+	rpmTagRecommendVersion  = 5047
+	rpmTagRecommendFlags    = 5048
+	rpmTagPayloadDigest     = 5092
+	rpmTagPayloadDigestAlgo = 5093
+)
+
+// rpm 包关系的比较标志
+const (
+	rpmSenseLess    = 1 << 1
+	rpmSenseGreater = 1 << 2
+	rpmSenseEqual   = 1 << 3
+	rpmSenseRPMLib  = 1 << 24
+)
+
+const (
+	rpmFileConfig    = 1 << 0 // %config
+	rpmFileNoReplace = 1 << 4 // %config(noreplace)
+	rpmDigestSHA256  = 8      // PGPHASHALGO_SHA256
+)
+
+// rpmScripts rpm维护脚本对应的标签
+var rpmScripts = []struct {
+	key       string
+	tag, prog int32
+}{
+	{"pre_install", rpmTagPreIn, rpmTagPreInProg},
+	{"post_install", rpmTagPostIn, rpmTagPostInProg},
+	{"pre_remove", rpmTagPreUn, rpmTagPreUnProg},
+	{"post_remove", rpmTagPostUn, rpmTagPostUnProg},
+}
+
+// rpmEntry 表示rpm头中的一个标签
+type rpmEntry struct {
+	tag   int32  // 标签
+	typ   int32  // 数据类型
+	count int32  // 元素个数, 二进制数据为字节数
+	data  []byte // 大端序编码的数据
+}
+
+// rpmHeader 表示rpm的签名头或主头
+type rpmHeader struct {
+	entries []rpmEntry
+}
+
+func (h *rpmHeader) add(tag, typ int32, count int, data []byte) {
+	h.entries = append(h.entries, rpmEntry{tag: tag, typ: typ, count: int32(count), data: data})
+}
+
+func (h *rpmHeader) addString(tag int32, value string) {
+	h.add(tag, rpmTypeString, 1, append([]byte(value), 0))
+}
+
+func (h *rpmHeader) addI18N(tag int32, value string) {
+	h.add(tag, rpmTypeI18NString, 1, append([]byte(value), 0))
+}
+
+func (h *rpmHeader) addStrings(tag int32, values []string) {
+	var data []byte
+	for _, v := range values {
+		data = append(append(data, v...), 0)
+	}
+	h.add(tag, rpmTypeStringArray, len(values), data)
+}
+
+func (h *rpmHeader) addInt32(tag int32, values ...int32) {
+	data := make([]byte, 0, len(values)*4)
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint32(data, uint32(v))
+	}
+	h.add(tag, rpmTypeInt32, len(values), data)
+}
+
+func (h *rpmHeader) addInt16(tag int32, values ...uint16) {
+	data := make([]byte, 0, len(values)*2)
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint16(data, v)
+	}
+	h.add(tag, rpmTypeInt16, len(values), data)
+}
+
+func (h *rpmHeader) addBin(tag int32, data []byte) {
+	h.add(tag, rpmTypeBin, len(data), data)
+}
+
+// addRelations 添加一组包关系的名称、标志和版本标签
+func (h *rpmHeader) addRelations(nameTag, flagsTag, versionTag int32, deps []dependency) {
+	if len(deps) == 0 {
+		return
+	}
+	names := make([]string, len(deps))
+	flags := make([]int32, len(deps))
+	versions := make([]string, len(deps))
+	for i, dep := range deps {
+		names[i], versions[i] = dep.name, dep.version
+		for _, c := range dep.op {
+			switch c {
+			case '<':
+				flags[i] |= rpmSenseLess
+			case '>':
+				flags[i] |= rpmSenseGreater
+			case '=':
+				flags[i] |= rpmSenseEqual
+			}
+		}
+		// rpmlib() 依赖表示rpm自身需要支持的特性
+		if strings.HasPrefix(dep.name, "rpmlib(") {
+			flags[i] |= rpmSenseRPMLib
+		}
+	}
+	h.addStrings(nameTag, names)
+	h.addInt32(flagsTag, flags...)
+	h.addStrings(versionTag, versions)
+}
+
+// marshal 编码rpm头
+//
+// 参数:
+//   - region: 区域标签, 签名头为62, 主头为63
+//
+// 返回值:
+//   - []byte: 编码后的头, 包含魔数、索引和数据区
+//
+// 注意:
+//   - 所有标签都位于同一个区域内, 区域尾部记录在数据区末尾
+func (h *rpmHeader) marshal(region int32) []byte {
+	entries := slices.Clone(h.entries)
+	slices.SortStableFunc(entries, func(a, b rpmEntry) int { return int(a.tag - b.tag) })
+
+	var store bytes.Buffer
+	offsets := make([]int32, len(entries))
+	for i, e := range entries {
+		align := map[int32]int{rpmTypeInt16: 2, rpmTypeInt32: 4}[e.typ]
+		for align > 0 && store.Len()%align != 0 {
+			store.WriteByte(0)
+		}
+		offsets[i] = int32(store.Len())
+		store.Write(e.data)
+	}
+
+	// 区域尾部: 偏移量为区域内索引条目总大小的负数
+	count := int32(len(entries) + 1)
+	trailerOffset := int32(store.Len())
+	for _, v := range []int32{region, rpmTypeBin, -count * 16, 16} {
+		_ = binary.Write(&store, binary.BigEndian, v)
+	}
+
+	var out bytes.Buffer
+	out.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+	for _, v := range []int32{count, int32(store.Len()), region, rpmTypeBin, trailerOffset, 16} {
+		_ = binary.Write(&out, binary.BigEndian, v)
+	}
+	for i, e := range entries {
+		for _, v := range []int32{e.tag, e.typ, offsets[i], e.count} {
+			_ = binary.Write(&out, binary.BigEndian, v)
+		}
+	}
+	out.Write(store.Bytes())
+	return out.Bytes()
+}
+
+// writeRPM 生成rpm安装包
+//
+// 参数:
+//   - p: 安装包内容
+//   - dir: 输出目录
+//
+// 返回值:
+//   - string: 安装包路径, 文件名为 包名-版本-修订号.架构.rpm
+//   - error: 写入失败时返回错误
+//
+// 注意:
+//   - rpm依次包含 lead、签名头、主头和gzip压缩的cpio负载
+//   - 只打包文件, 不声明系统目录的所有权
+func writeRPM(p *linuxPackage, dir string) (string, error) {
+	payload, payloadSize, err := rpmPayload(p)
+	if err != nil {
+		return "", err
+	}
+	header, err := rpmMainHeader(p, payload)
+	if err != nil {
+		return "", err
+	}
+
+	// 签名头记录主头和负载的摘要
+	sha1Sum := sha1.Sum(header)
+	sha256Sum := sha256.Sum256(header)
+	md5Hash := md5.New()
+	md5Hash.Write(header)
+	md5Hash.Write(payload)
+
+	var sig rpmHeader
+	sig.addString(rpmSigTagSHA1, hex.EncodeToString(sha1Sum[:]))
+	sig.addString(rpmSigTagSHA256, hex.EncodeToString(sha256Sum[:]))
+	sig.addInt32(rpmSigTagSize, int32(len(header)+len(payload)))
+	sig.addBin(rpmSigTagMD5, md5Hash.Sum(nil))
+	sig.addInt32(rpmSigTagPayloadSize, int32(payloadSize))
+	signature := sig.marshal(rpmTagSignatures)
+
+	nvr := fmt.Sprintf("%s-%s-%s", p.name, p.version, p.release)
+	name := fmt.Sprintf("%s.%s.rpm", nvr, p.arch)
+	return writePackageFile(dir, name, func(w io.Writer) error {
+		// lead: 魔数、版本3.0、二进制包、包名、操作系统linux、签名类型5
+		lead := make([]byte, 96)
+		copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+		copy(lead[10:76], nvr)
+		binary.BigEndian.PutUint16(lead[76:], 1)
+		binary.BigEndian.PutUint16(lead[78:], 5)
+
+		// 签名头按8字节对齐
+		padding := make([]byte, (8-len(signature)%8)%8)
+		for _, part := range [][]byte{lead, signature, padding, header, payload} {
+			if _, err := w.Write(part); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// rpmMainHeader 生成rpm主头
+//
+// 参数:
+//   - p: 安装包内容
+//   - payload: 压缩后的负载
+//
+// 返回值:
+//   - []byte: 编码后的主头
+//   - error: 读取文件失败时返回错误
+func rpmMainHeader(p *linuxPackage, payload []byte) ([]byte, error) {
+	var h rpmHeader
+	h.addStrings(rpmTagI18NTable, []string{"C"})
+	h.addString(rpmTagName, p.name)
+	h.addString(rpmTagVersion, p.version)
+	h.addString(rpmTagRelease, p.release)
+	h.addI18N(rpmTagSummary, p.summary())
+	description := p.cfg.Description
+	if description == "" {
+		description = p.summary()
+	}
+	h.addI18N(rpmTagDescription, description)
+	h.addInt32(rpmTagBuildTime, int32(p.mtime.Unix()))
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	h.addString(rpmTagBuildHost, host)
+	h.addInt32(rpmTagSize, int32(p.installedSize()))
+	h.addI18N(rpmTagGroup, "Unspecified")
+	h.addString(rpmTagOS, "linux")
+	h.addString(rpmTagArch, p.arch)
+	h.addString(rpmTagSourceRPM, fmt.Sprintf("%s-%s-%s.src.rpm", p.name, p.version, p.release))
+	for tag, value := range map[int32]string{
+		rpmTagVendor:   p.cfg.Vendor,
+		rpmTagLicense:  p.cfg.License,
+		rpmTagPackager: p.cfg.Maintainer,
+		rpmTagURL:      p.cfg.Homepage,
+	} {
+		if value != "" {
+			h.addString(tag, value)
+		}
+	}
+
+	for _, script := range rpmScripts {
+		if content, ok := p.scripts[script.key]; ok {
+			h.addString(script.tag, content)
+			h.addString(script.prog, "/bin/sh")
+		}
+	}
+
+	// 文件列表, 路径拆分为目录和文件名
+	n := len(p.files)
+	sizes, mtimes, flags := make([]int32, n), make([]int32, n), make([]int32, n)
+	devices, inodes, dirIndexes := make([]int32, n), make([]int32, n), make([]int32, n)
+	modes, rdevs := make([]uint16, n), make([]uint16, n)
+	digests, linkTos, langs := make([]string, n), make([]string, n), make([]string, n)
+	users, groups, baseNames := make([]string, n), make([]string, n), make([]string, n)
+	var dirNames []string
+	for i, f := range p.files {
+		digest, err := fileDigest(f.src, sha256.New())
+		if err != nil {
+			return nil, err
+		}
+		dir := path.Dir(f.dst) + "/"
+		idx := slices.Index(dirNames, dir)
+		if idx < 0 {
+			idx = len(dirNames)
+			dirNames = append(dirNames, dir)
+		}
+
+		sizes[i] = int32(f.size)
+		mtimes[i] = int32(p.mtime.Unix())
+		devices[i] = 1
+		inodes[i] = int32(i + 1)
+		dirIndexes[i] = int32(idx)
+		modes[i] = uint16(0o100000 | f.mode.Perm())
+		digests[i] = digest
+		users[i], groups[i] = "root", "root"
+		baseNames[i] = path.Base(f.dst)
+		if f.config {
+			flags[i] = rpmFileConfig | rpmFileNoReplace
+		}
+	}
+	h.addInt32(rpmTagFileSizes, sizes...)
+	h.addInt16(rpmTagFileModes, modes...)
+	h.addInt16(rpmTagFileRdevs, rdevs...)
+	h.addInt32(rpmTagFileMtimes, mtimes...)
+	h.addStrings(rpmTagFileDigests, digests)
+	h.addStrings(rpmTagFileLinkTos, linkTos)
+	h.addInt32(rpmTagFileFlags, flags...)
+	h.addStrings(rpmTagFileUserName, users)
+	h.addStrings(rpmTagFileGroupName, groups)
+	h.addInt32(rpmTagFileDevices, devices...)
+	h.addInt32(rpmTagFileInodes, inodes...)
+	h.addStrings(rpmTagFileLangs, langs)
+	h.addInt32(rpmTagDirIndexes, dirIndexes...)
+	h.addStrings(rpmTagBaseNames, baseNames)
+	h.addStrings(rpmTagDirNames, dirNames)
+	h.addInt32(rpmTagFileDigestAlgo, rpmDigestSHA256)
+
+	// 包关系, 包自身提供 包名 = 版本-修订号, 依赖中声明负载使用的rpm特性
+	provides := append([]dependency{{name: p.name, op: "=", version: p.version + "-" + p.release}}, p.provides...)
+	requires := append(slices.Clone(p.depends),
+		dependency{name: "rpmlib(CompressedFileNames)", op: "<=", version: "3.0.4-1"},
+		dependency{name: "rpmlib(FileDigests)", op: "<=", version: "4.6.0-1"},
+		dependency{name: "rpmlib(PayloadFilesHavePrefix)", op: "<=", version: "4.0-1"},
+	)
+	h.addRelations(rpmTagProvideName, rpmTagProvideFlags, rpmTagProvideVersion, provides)
+	h.addRelations(rpmTagRequireName, rpmTagRequireFlags, rpmTagRequireVersion, requires)
+	h.addRelations(rpmTagConflictName, rpmTagConflictFlags, rpmTagConflictVersion, p.conflicts)
+	h.addRelations(rpmTagObsoleteName, rpmTagObsoleteFlags, rpmTagObsoleteVersion, p.replaces)
+	h.addRelations(rpmTagRecommendName, rpmTagRecommendFlags, rpmTagRecommendVersion, p.recommends)
+
+	payloadSum := sha256.Sum256(payload)
+	h.addString(rpmTagPayloadFormat, "cpio")
+	h.addString(rpmTagPayloadCompressor, "gzip")
+	h.addString(rpmTagPayloadFlags, "9")
+	h.addStrings(rpmTagPayloadDigest, []string{hex.EncodeToString(payloadSum[:])})
+	h.addInt32(rpmTagPayloadDigestAlgo, rpmDigestSHA256)
+
+	return h.marshal(rpmTagImmutable), nil
+}
+
+// rpmPayload 生成gzip压缩的cpio(newc)负载
+//
+// 参数:
+//   - p: 安装包内容
+//
+// 返回值:
+//   - []byte: 压缩后的负载
+//   - int64: 压缩前的大小
+//   - error: 读取文件失败时返回错误
+func rpmPayload(p *linuxPackage) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	gw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, 0, err
+	}
+	cw := &countingWriter{w: gw}
+
+	for i, f := range p.files {
+		if err := writeCpioHeader(cw, i+1, 0o100000|uint32(f.mode.Perm()), p.mtime.Unix(), f.size, "."+f.dst); err != nil {
+			return nil, 0, err
+		}
+		if err := copyFile(cw, f.src); err != nil {
+			return nil, 0, fmt.Errorf("打包 %s 失败: %w", f.src, err)
+		}
+		if err := writeCpioPadding(cw); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := writeCpioHeader(cw, 0, 0, 0, 0, "TRAILER!!!"); err != nil {
+		return nil, 0, err
+	}
+	if err := writeCpioPadding(cw); err != nil {
+		return nil, 0, err
+	}
+
+	if err := gw.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), cw.n, nil
+}
+
+// writeCpioHeader 写入cpio(newc)条目头和文件名
+func writeCpioHeader(w *countingWriter, ino int, mode uint32, mtime, size int64, name string) error {
+	nlink := 1
+	header := fmt.Sprintf("070701%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X",
+		ino, mode, 0, 0, nlink, mtime, size, 0, 0, 0, 0, len(name)+1, 0)
+	if _, err := io.WriteString(w, header+name+"\x00"); err != nil {
+		return err
+	}
+	return writeCpioPadding(w)
+}
+
+// writeCpioPadding 将cpio数据补齐到4字节
+func writeCpioPadding(w *countingWriter) error {
+	if pad := (4 - w.n%4) % 4; pad > 0 {
+		_, err := w.Write(make([]byte, pad))
+		return err
+	}
+	return nil
+}
+
+// countingWriter 记录已写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
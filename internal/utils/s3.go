@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// emptySHA256 空请求体的sha256, 用于没有请求体的签名请求
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Client 通过SigV4签名的请求将文件上传到S3兼容的对象存储
+type S3Client struct {
+	cfg          types.S3UploadConfig // 对象存储配置
+	accessKey    string               // 访问密钥ID
+	secretKey    string               // 访问密钥, 不会出现在输出和错误信息中
+	sessionToken string               // 临时会话令牌, 可为空
+	partSize     int64                // 分片大小(字节)
+	backoff      time.Duration        // 首次重试的等待时间, 之后每次翻倍
+	client       *http.Client         // HTTP客户端
+}
+
+// NewS3Client 创建对象存储客户端
+//
+// 参数:
+//   - cfg: 对象存储配置
+//
+// 返回值:
+//   - *S3Client: 对象存储客户端
+//   - error: 访问密钥的环境变量未设置时返回错误
+func NewS3Client(cfg types.S3UploadConfig) (*S3Client, error) {
+	accessKey, err := requireEnv(cfg.AccessKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := requireEnv(cfg.SecretKeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	var sessionToken string
+	if cfg.SessionTokenEnv != "" {
+		sessionToken = os.Getenv(cfg.SessionTokenEnv)
+	}
+
+	return &S3Client{
+		cfg:          cfg,
+		accessKey:    accessKey,
+		secretKey:    secretKey,
+		sessionToken: sessionToken,
+		partSize:     int64(max(cfg.PartSize, types.MinS3PartSize)) << 20,
+		backoff:      time.Second,
+		client:       &http.Client{},
+	}, nil
+}
+
+// S3ObjectURL 返回对象的访问地址
+//
+// 参数:
+//   - cfg: 对象存储配置
+//   - key: 对象路径
+//
+// 返回值:
+//   - *url.URL: 对象地址, 路径按SigV4的规则转义
+//   - error: 服务地址无效时返回错误
+func S3ObjectURL(cfg types.S3UploadConfig, key string) (*url.URL, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("无效的对象存储地址 %q", cfg.Endpoint)
+	}
+
+	u := &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host}
+	base := strings.TrimSuffix(endpoint.Path, "/")
+	if cfg.PathStyle {
+		u.Path = base + "/" + cfg.Bucket + "/" + key
+	} else {
+		u.Host = cfg.Bucket + "." + endpoint.Host
+		u.Path = base + "/" + key
+	}
+	u.RawPath = s3Escape(u.Path, true)
+	return u, nil
+}
+
+// Upload 将文件上传为对象, 大于分片大小的文件使用分片上传
+//
+// 参数:
+//   - ctx: 上下文
+//   - key: 对象路径
+//   - path: 文件路径
+//
+// 返回值:
+//   - error: 错误信息
+func (c *S3Client) Upload(ctx context.Context, key, path string) error {
+	u, err := S3ObjectURL(c.cfg, key)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("读取文件 %s 失败: %w", path, err)
+	}
+
+	if info.Size() <= c.partSize {
+		hash, err := fileSectionSHA256(path, 0, info.Size())
+		if err != nil {
+			return err
+		}
+		return c.do(ctx, http.MethodPut, u, nil, fileSectionBody(path, 0, info.Size()), hash, nil)
+	}
+	return c.uploadMultipart(ctx, u, path, info.Size())
+}
+
+// uploadMultipart 分片上传文件, 失败时取消本次分片上传
+//
+// 参数:
+//   - ctx: 上下文
+//   - u: 对象地址
+//   - path: 文件路径
+//   - size: 文件大小
+//
+// 返回值:
+//   - error: 错误信息
+func (c *S3Client) uploadMultipart(ctx context.Context, u *url.URL, path string, size int64) error {
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := c.do(ctx, http.MethodPost, u, url.Values{"uploads": {""}}, nil, emptySHA256, decodeXML(&initiated)); err != nil {
+		return fmt.Errorf("创建分片上传失败: %w", err)
+	}
+	if initiated.UploadID == "" {
+		return fmt.Errorf("创建分片上传失败: 响应中没有 UploadId")
+	}
+
+	err := c.uploadParts(ctx, u, path, size, initiated.UploadID)
+	if err != nil {
+		// 取消分片上传以释放已上传的分片, 中断时同样尝试取消
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		_ = c.do(abortCtx, http.MethodDelete, u, url.Values{"uploadId": {initiated.UploadID}}, nil, emptySHA256, nil)
+	}
+	return err
+}
+
+// uploadParts 上传所有分片并完成分片上传
+func (c *S3Client) uploadParts(ctx context.Context, u *url.URL, path string, size int64, uploadID string) error {
+	type part struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var complete struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}
+
+	for offset, number := int64(0), 1; offset < size; offset, number = offset+c.partSize, number+1 {
+		length := min(c.partSize, size-offset)
+		hash, err := fileSectionSHA256(path, offset, length)
+		if err != nil {
+			return err
+		}
+
+		var etag string
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		err = c.do(ctx, http.MethodPut, u, query, fileSectionBody(path, offset, length), hash, func(resp *http.Response) error {
+			etag = resp.Header.Get("ETag")
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("上传第 %d 个分片失败: %w", number, err)
+		}
+		if etag == "" {
+			return fmt.Errorf("上传第 %d 个分片失败: 响应中没有 ETag", number)
+		}
+		complete.Parts = append(complete.Parts, part{PartNumber: number, ETag: etag})
+	}
+
+	data, err := xml.Marshal(complete)
+	if err != nil {
+		return fmt.Errorf("序列化分片列表失败: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	body := func() (io.Reader, string, int64, error) {
+		return bytes.NewReader(data), "application/xml", int64(len(data)), nil
+	}
+	// 完成分片上传的请求可能在返回200后才在响应内容中报告错误
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := c.do(ctx, http.MethodPost, u, url.Values{"uploadId": {uploadID}}, body, hex.EncodeToString(sum[:]), decodeXML(&result)); err != nil {
+		return fmt.Errorf("完成分片上传失败: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("完成分片上传失败: %s: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// do 发送签名的请求, 网络错误、429和5xx响应按指数退避重试
+//
+// 参数:
+//   - ctx: 上下文
+//   - method: 请求方法
+//   - u: 对象地址
+//   - query: 查询参数, 可为nil
+//   - body: 请求体, 为nil时不发送请求体
+//   - payloadHash: 请求体的sha256
+//   - handle: 处理成功的响应, 为nil时忽略响应内容
+//
+// 返回值:
+//   - error: 错误信息
+func (c *S3Client) do(ctx context.Context, method string, u *url.URL, query url.Values, body bodyFunc, payloadHash string, handle func(*http.Response) error) error {
+	target := *u
+	target.RawQuery = s3CanonicalQuery(query)
+	display := redactURL(u)
+
+	return retryRequest(ctx, c.cfg.Retries, c.backoff, func() (bool, error) {
+		return sendRequest(ctx, c.client, method, target.String(), display, body, func(req *http.Request) error {
+			c.sign(req, payloadHash, time.Now())
+			return nil
+		}, handle)
+	})
+}
+
+// sign 使用AWS Signature Version 4为请求签名
+//
+// 参数:
+//   - req: 请求, 除 Authorization 和 User-Agent 外的所有请求头以及Host都会参与签名
+//   - payloadHash: 请求体的sha256
+//   - t: 签名时间
+func (c *S3Client) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	scope := t.Format("20060102") + "/" + c.cfg.Region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	// 规范请求头: 名称小写并排序, 值去掉首尾空白并合并连续空白
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" || name == "user-agent" {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical.WriteString(path + "\n")
+	canonical.WriteString(req.URL.RawQuery + "\n")
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonical.WriteString("\n" + signedHeaders + "\n" + payloadHash)
+
+	canonicalHash := sha256.Sum256([]byte(canonical.String()))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), t.Format("20060102"))
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 计算HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalQuery 按SigV4的规则编码并排序查询参数
+func s3CanonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(key, false)+"="+s3Escape(v, false))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape 按SigV4的规则转义字符串, 只保留字母、数字和 -._~
+//
+// 参数:
+//   - s: 要转义的字符串
+//   - keepSlash: 是否保留/, 用于转义路径
+//
+// 返回值:
+//   - string: 转义后的字符串
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// decodeXML 返回将XML响应解析到out的处理函数
+func decodeXML(out any) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		return xml.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// fileSectionBody 返回以文件的一段内容作为请求体的函数
+func fileSectionBody(path string, offset, length int64) bodyFunc {
+	return func() (io.Reader, string, int64, error) {
+		f, _, err := openRegularFile(path)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		reader := struct {
+			io.Reader
+			io.Closer
+		}{io.NewSectionReader(f, offset, length), f}
+		return reader, "application/octet-stream", length, nil
+	}
+}
+
+// fileSectionSHA256 计算文件中一段内容的sha256
+func fileSectionSHA256(path string, offset, length int64) (string, error) {
+	f, _, err := openRegularFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, offset, length)); err != nil {
+		return "", fmt.Errorf("读取文件 %s 失败: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
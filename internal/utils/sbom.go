@@ -0,0 +1,460 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// sbomFormats 支持的SBOM格式及其文件扩展名
+var sbomFormats = map[string]string{
+	"spdx":      ".sbom.spdx.json",
+	"cyclonedx": ".sbom.cdx.json",
+}
+
+// SBOMFormats 返回支持的SBOM格式
+//
+// 返回值:
+//   - []string: SBOM格式
+func SBOMFormats() []string {
+	return []string{"spdx", "cyclonedx"}
+}
+
+// goModule 表示SBOM中的一个Go模块
+type goModule struct {
+	Path    string // 模块路径
+	Version string // 版本, 替换为本地目录的模块为空
+}
+
+// moduleGraph 表示可执行文件依赖的模块
+type moduleGraph struct {
+	GoVersion string     // 编译使用的Go版本
+	Main      goModule   // 主模块
+	Deps      []goModule // 编译进可执行文件的依赖模块
+}
+
+// dependencies 返回主模块的依赖, 包括Go标准库
+func (g *moduleGraph) dependencies() []goModule {
+	if g.GoVersion == "" {
+		return g.Deps
+	}
+	// Go标准库的版本不带go前缀, 与常见SBOM工具的purl一致
+	stdlib := goModule{Path: "stdlib", Version: strings.TrimPrefix(g.GoVersion, "go")}
+	return append([]goModule{stdlib}, g.Deps...)
+}
+
+// WriteSBOMs 为可执行文件生成SBOM
+//
+// 参数:
+//   - spec: SBOM信息
+//   - formats: SBOM格式
+//
+// 返回值:
+//   - []string: 生成的SBOM文件路径, 与可执行文件位于同一目录, 文件名为去掉.exe后缀的可执行文件名加上格式的扩展名
+//   - bool: 是否从 go.mod 读取了模块信息, 可执行文件中没有构建信息时为true
+//   - error: 错误信息
+func WriteSBOMs(spec types.SBOMSpec, formats []string) ([]string, bool, error) {
+	graph, fallback, err := readModuleGraph(spec.Binary)
+	if err != nil {
+		return nil, false, err
+	}
+	if spec.Version != "" {
+		graph.Main.Version = spec.Version
+	}
+	sum, err := FileChecksum(spec.Binary, "sha256")
+	if err != nil {
+		return nil, false, err
+	}
+
+	base := strings.TrimSuffix(spec.Binary, ".exe")
+	var paths []string
+	for _, format := range formats {
+		ext, ok := sbomFormats[format]
+		if !ok {
+			return nil, false, fmt.Errorf("不支持的SBOM格式 %q", format)
+		}
+		var doc any
+		switch format {
+		case "spdx":
+			doc = newSPDXDocument(spec, graph, sum)
+		case "cyclonedx":
+			doc = newCycloneDXDocument(spec, graph, sum)
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, false, fmt.Errorf("序列化SBOM失败: %w", err)
+		}
+		path := base + ext
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return nil, false, fmt.Errorf("写入SBOM失败: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, fallback, nil
+}
+
+// readModuleGraph 读取可执行文件中的模块信息
+//
+// 参数:
+//   - binary: 可执行文件路径
+//
+// 返回值:
+//   - *moduleGraph: 模块信息
+//   - bool: 可执行文件中没有构建信息, 回退到当前目录的 go.mod 和 vendor/modules.txt 时为true
+//   - error: 两种方式都无法读取时返回错误
+//
+// 注意:
+//   - 以文件形式编译(如 go build main.go)时构建信息中没有主模块, 主模块的路径取自 go.mod
+func readModuleGraph(binary string) (*moduleGraph, bool, error) {
+	info, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		graph, modErr := readGoModGraph(".")
+		if modErr != nil {
+			return nil, false, fmt.Errorf("读取 %s 的构建信息失败: %v; 读取go.mod失败: %w", binary, err, modErr)
+		}
+		return graph, true, nil
+	}
+
+	graph := &moduleGraph{
+		GoVersion: info.GoVersion,
+		Main:      goModule{Path: info.Main.Path, Version: info.Main.Version},
+	}
+	if graph.Main.Version == "(devel)" {
+		graph.Main.Version = ""
+	}
+	if graph.Main.Path == "" {
+		mod, err := readGoModGraph(".")
+		if err != nil {
+			return nil, false, fmt.Errorf("%s 的构建信息中没有主模块, 读取go.mod失败: %w", binary, err)
+		}
+		graph.Main.Path = mod.Main.Path
+	}
+	for _, dep := range info.Deps {
+		m := goModule{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			m = replacedModule(dep.Path, dep.Replace.Path, dep.Replace.Version)
+		}
+		graph.Deps = append(graph.Deps, m)
+	}
+	return graph, false, nil
+}
+
+// readGoModGraph 从 go.mod 和 vendor/modules.txt 读取模块信息
+//
+// 参数:
+//   - dir: 模块根目录
+//
+// 返回值:
+//   - *moduleGraph: 模块信息, 存在 vendor/modules.txt 时依赖取自该文件, 否则取自 go.mod 的 require
+//   - error: go.mod 不存在或缺少 module 指令时返回错误
+func readGoModGraph(dir string) (*moduleGraph, error) {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	graph := &moduleGraph{}
+	var requires []goModule
+	inRequire := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire && len(fields) >= 2:
+			requires = append(requires, goModule{Path: fields[0], Version: fields[1]})
+		case fields[0] == "module" && len(fields) >= 2:
+			graph.Main.Path = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) >= 2:
+			graph.GoVersion = "go" + fields[1]
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) >= 3:
+			requires = append(requires, goModule{Path: fields[1], Version: fields[2]})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if graph.Main.Path == "" {
+		return nil, fmt.Errorf("go.mod 缺少 module 指令")
+	}
+
+	vendored, err := readVendorModules(filepath.Join(dir, "vendor", "modules.txt"))
+	if err != nil {
+		graph.Deps = requires
+	} else {
+		graph.Deps = vendored
+	}
+	return graph, nil
+}
+
+// readVendorModules 读取 vendor/modules.txt 中的模块, 替换的模块使用替换后的路径和版本
+func readVendorModules(path string) ([]goModule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var modules []goModule
+	for line := range strings.Lines(string(data)) {
+		// 模块行的格式为 "# path version" 或 "# path [version] => replacement [version]"
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "# ")
+		if !ok {
+			continue
+		}
+		orig, repl, replaced := strings.Cut(rest, "=>")
+		fields := strings.Fields(orig)
+		if len(fields) == 0 {
+			continue
+		}
+		m := goModule{Path: fields[0]}
+		if len(fields) > 1 {
+			m.Version = fields[1]
+		}
+		if replaced {
+			if fields := strings.Fields(repl); len(fields) > 0 {
+				m = replacedModule(m.Path, fields[0], strings.Join(fields[1:], ""))
+			}
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// replacedModule 返回被 replace 指令替换的模块
+//
+// 参数:
+//   - path: 原模块路径
+//   - replPath: 替换后的模块路径或本地目录
+//   - replVersion: 替换后的版本, 替换为本地目录时为空
+//
+// 返回值:
+//   - goModule: 替换为其他模块时为替换后的模块, 替换为本地目录时沿用原模块路径且不带版本
+func replacedModule(path, replPath, replVersion string) goModule {
+	if replVersion == "" || replVersion == "(devel)" || isLocalModulePath(replPath) {
+		return goModule{Path: path}
+	}
+	return goModule{Path: replPath, Version: replVersion}
+}
+
+// isLocalModulePath 判断 replace 指令的目标是否为本地目录
+func isLocalModulePath(path string) bool {
+	return strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || path == "." || path == ".." || filepath.IsAbs(path) ||
+		strings.HasPrefix(path, `.\`) || strings.HasPrefix(path, `..\`)
+}
+
+// goPURL 返回Go模块的Package URL
+func goPURL(m goModule) string {
+	purl := "pkg:golang/" + m.Path
+	if m.Version != "" {
+		purl += "@" + strings.ReplaceAll(url.PathEscape(m.Version), "+", "%2B")
+	}
+	return purl
+}
+
+// newUUID 生成随机的UUID(版本4)
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// sbomToolName 返回写入SBOM的生成工具名称
+func sbomToolName(version string) string {
+	if version == "" {
+		return "gob"
+	}
+	return "gob-" + version
+}
+
+// spdxDocument SPDX 2.3 JSON文档
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+// spdxCreationInfo SPDX文档的生成信息
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// spdxPackage SPDX文档中的软件包
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	PackageFileName       string            `json:"packageFileName,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+// spdxChecksum SPDX软件包的校验和
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// spdxExternalRef SPDX软件包的外部引用
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// spdxRelationship SPDX元素之间的关系
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// newSPDXDocument 生成SPDX文档, 主模块描述可执行文件, 依赖模块和Go标准库为其依赖
+func newSPDXDocument(spec types.SBOMSpec, graph *moduleGraph, sha256 string) *spdxDocument {
+	name := filepath.Base(spec.Binary)
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + url.PathEscape(name) + "-" + newUUID(),
+		CreationInfo: spdxCreationInfo{
+			Created:  spec.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + sbomToolName(spec.ToolVersion)},
+		},
+	}
+
+	purlRef := func(m goModule) []spdxExternalRef {
+		return []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: goPURL(m)}}
+	}
+	mainID := "SPDXRef-Package-main"
+	doc.Packages = append(doc.Packages, spdxPackage{
+		SPDXID:                mainID,
+		Name:                  graph.Main.Path,
+		VersionInfo:           graph.Main.Version,
+		PackageFileName:       name,
+		DownloadLocation:      "NOASSERTION",
+		Checksums:             []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: sha256}},
+		ExternalRefs:          purlRef(graph.Main),
+		PrimaryPackagePurpose: "APPLICATION",
+	})
+	doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: doc.SPDXID, RelationshipType: "DESCRIBES", RelatedSPDXElement: mainID})
+
+	deps := graph.dependencies()
+	for i, dep := range deps {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:                id,
+			Name:                  dep.Path,
+			VersionInfo:           dep.Version,
+			DownloadLocation:      "NOASSERTION",
+			ExternalRefs:          purlRef(dep),
+			PrimaryPackagePurpose: "LIBRARY",
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: mainID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id})
+	}
+	return doc
+}
+
+// cdxDocument CycloneDX 1.5 JSON文档
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+// cdxMetadata CycloneDX文档的元数据
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+// cdxTools 生成CycloneDX文档的工具
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+// cdxComponent CycloneDX文档中的组件
+type cdxComponent struct {
+	Type    string    `json:"type"`
+	BOMRef  string    `json:"bom-ref,omitempty"`
+	Name    string    `json:"name"`
+	Version string    `json:"version,omitempty"`
+	PURL    string    `json:"purl,omitempty"`
+	Hashes  []cdxHash `json:"hashes,omitempty"`
+}
+
+// cdxHash CycloneDX组件的哈希
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// cdxDependency CycloneDX组件之间的依赖关系
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// newCycloneDXDocument 生成CycloneDX文档, 主模块作为元数据中的组件, 依赖模块和Go标准库作为组件
+func newCycloneDXDocument(spec types.SBOMSpec, graph *moduleGraph, sha256 string) *cdxDocument {
+	mainRef := goPURL(graph.Main)
+	doc := &cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: spec.Created.UTC().Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "gob", Version: spec.ToolVersion}}},
+			Component: cdxComponent{
+				Type:    "application",
+				BOMRef:  mainRef,
+				Name:    graph.Main.Path,
+				Version: graph.Main.Version,
+				PURL:    mainRef,
+				Hashes:  []cdxHash{{Alg: "SHA-256", Content: sha256}},
+			},
+		},
+		Components: []cdxComponent{},
+	}
+
+	deps := graph.dependencies()
+	refs := []string{}
+	for _, dep := range deps {
+		ref := goPURL(dep)
+		doc.Components = append(doc.Components, cdxComponent{Type: "library", BOMRef: ref, Name: dep.Path, Version: dep.Version, PURL: ref})
+		refs = append(refs, ref)
+	}
+	doc.Dependencies = []cdxDependency{{Ref: mainRef, DependsOn: refs}}
+	return doc
+}
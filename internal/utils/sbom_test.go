@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// copyTestExecutable 将当前测试程序复制到临时目录, 作为带有构建信息的Go可执行文件
+func copyTestExecutable(t *testing.T) string {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("无法获取测试程序路径: %v", err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "myapp")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteSBOMs(t *testing.T) {
+	binary := copyTestExecutable(t)
+	spec := types.SBOMSpec{Binary: binary, Version: "v1.2.0", ToolVersion: "v0.9.0", Created: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	paths, fallback, err := WriteSBOMs(spec, SBOMFormats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallback {
+		t.Error("Go可执行文件中有构建信息, 不应回退到 go.mod")
+	}
+	want := []string{binary + ".sbom.spdx.json", binary + ".sbom.cdx.json"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("SBOM文件 = %v, 期望 %v", paths, want)
+	}
+	sum, err := FileChecksum(binary, "sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goVersion := strings.TrimPrefix(runtime.Version(), "go")
+
+	var spdx spdxDocument
+	readSBOM(t, paths[0], &spdx)
+	main := spdx.Packages[0]
+	if spdx.SPDXVersion != "SPDX-2.3" || spdx.CreationInfo.Created != "2025-01-02T03:04:05Z" || !reflect.DeepEqual(spdx.CreationInfo.Creators, []string{"Tool: gob-v0.9.0"}) {
+		t.Errorf("SPDX文档信息不正确: %+v", spdx)
+	}
+	if !strings.HasPrefix(spdx.DocumentNamespace, "https://spdx.org/spdxdocs/myapp-") {
+		t.Errorf("documentNamespace = %s", spdx.DocumentNamespace)
+	}
+	if main.Name != "gitee.com/MM-Q/gob" || main.VersionInfo != "v1.2.0" || main.PackageFileName != "myapp" || main.Checksums[0].ChecksumValue != sum || main.ExternalRefs[0].ReferenceLocator != "pkg:golang/gitee.com/MM-Q/gob@v1.2.0" {
+		t.Errorf("主模块 = %+v", main)
+	}
+	spdxDeps := map[string]string{}
+	for _, p := range spdx.Packages[1:] {
+		spdxDeps[p.Name] = p.VersionInfo
+	}
+	if spdxDeps["stdlib"] != goVersion || spdxDeps["gitee.com/MM-Q/verman"] == "" || spdxDeps["github.com/pelletier/go-toml/v2"] == "" {
+		t.Errorf("依赖应包含Go标准库和编译进可执行文件的模块, got %v", spdxDeps)
+	}
+	// 文档描述主模块, 主模块依赖其余每个软件包
+	if len(spdx.Relationships) != len(spdx.Packages) || spdx.Relationships[0] != (spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Package-main"}) {
+		t.Errorf("关系 = %+v", spdx.Relationships)
+	}
+	for i, r := range spdx.Relationships[1:] {
+		if r.SPDXElementID != "SPDXRef-Package-main" || r.RelationshipType != "DEPENDS_ON" || r.RelatedSPDXElement != spdx.Packages[i+1].SPDXID {
+			t.Errorf("第 %d 个依赖关系 = %+v", i+1, r)
+		}
+	}
+
+	var cdx cdxDocument
+	readSBOM(t, paths[1], &cdx)
+	if cdx.BOMFormat != "CycloneDX" || cdx.SpecVersion != "1.5" || !regexp.MustCompile(`^urn:uuid:[0-9a-f-]{36}$`).MatchString(cdx.SerialNumber) {
+		t.Errorf("CycloneDX文档信息不正确: %+v", cdx)
+	}
+	component := cdx.Metadata.Component
+	if component.PURL != "pkg:golang/gitee.com/MM-Q/gob@v1.2.0" || component.Hashes[0].Content != sum || cdx.Metadata.Tools.Components[0].Version != "v0.9.0" {
+		t.Errorf("主组件 = %+v", cdx.Metadata)
+	}
+	if len(cdx.Components) != len(spdx.Packages)-1 || cdx.Components[0].PURL != "pkg:golang/stdlib@"+goVersion {
+		t.Errorf("组件 = %+v", cdx.Components)
+	}
+	if len(cdx.Dependencies) != 1 || cdx.Dependencies[0].Ref != component.BOMRef || len(cdx.Dependencies[0].DependsOn) != len(cdx.Components) {
+		t.Errorf("依赖关系 = %+v", cdx.Dependencies)
+	}
+
+	if _, _, err := WriteSBOMs(spec, []string{"swid"}); err == nil || !strings.Contains(err.Error(), "不支持的SBOM格式") {
+		t.Errorf("不支持的格式期望返回错误, got %v", err)
+	}
+}
+
+// readSBOM 读取并解析SBOM文件
+func readSBOM(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("解析 %s 失败: %v", path, err)
+	}
+}
+
+func TestWriteSBOMsFallback(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	files := map[string]string{
+		"go.mod":             "module example.com/hello\n\ngo 1.22\n\nrequire example.com/dep v1.0.0\n",
+		"vendor/modules.txt": "# example.com/dep v1.0.0\n## explicit\nexample.com/dep\n",
+		"hello.exe":          "not a go binary",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 没有构建信息时依赖取自 vendor/modules.txt, Windows可执行文件去掉.exe后缀
+	paths, fallback, err := WriteSBOMs(types.SBOMSpec{Binary: filepath.Join(dir, "hello.exe")}, []string{"cyclonedx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fallback || !reflect.DeepEqual(paths, []string{filepath.Join(dir, "hello.sbom.cdx.json")}) {
+		t.Fatalf("期望回退到 go.mod 并生成 hello.sbom.cdx.json, got %v, %v", paths, fallback)
+	}
+	var cdx cdxDocument
+	readSBOM(t, paths[0], &cdx)
+	var purls []string
+	for _, c := range cdx.Components {
+		purls = append(purls, c.PURL)
+	}
+	if cdx.Metadata.Component.PURL != "pkg:golang/example.com/hello" || !slices.Equal(purls, []string{"pkg:golang/stdlib@1.22", "pkg:golang/example.com/dep@v1.0.0"}) {
+		t.Errorf("主组件 = %s, 组件 = %v", cdx.Metadata.Component.PURL, purls)
+	}
+	if cdx.Metadata.Tools.Components[0].Name != "gob" {
+		t.Errorf("生成工具 = %+v", cdx.Metadata.Tools)
+	}
+
+	if err := os.Remove("go.mod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := WriteSBOMs(types.SBOMSpec{Binary: filepath.Join(dir, "hello.exe")}, []string{"spdx"}); err == nil || !strings.Contains(err.Error(), "读取go.mod失败") {
+		t.Errorf("没有构建信息和 go.mod 时期望返回错误, got %v", err)
+	}
+}
+
+func TestReadGoModGraph(t *testing.T) {
+	goMod := `// 模块注释
+module "example.com/hello" // 行尾注释
+
+go 1.22.1
+
+require example.com/single v0.1.0
+
+require (
+	example.com/a v1.0.0
+	// example.com/commented v9.9.9
+	example.com/b v2.0.0+incompatible // indirect
+)
+
+replace example.com/a => ../a
+`
+	tests := []struct {
+		name    string
+		modules string // vendor/modules.txt 的内容, 为空时不创建
+		want    []goModule
+	}{
+		{"取自go.mod", "", []goModule{{"example.com/single", "v0.1.0"}, {"example.com/a", "v1.0.0"}, {"example.com/b", "v2.0.0+incompatible"}}},
+		{"取自vendor", "# example.com/a v1.0.0 => ../a\n## explicit\nexample.com/a\n" +
+			"# example.com/b v2.0.0+incompatible => example.com/fork/b v2.1.0\nexample.com/b\n" +
+			"# example.com/c => example.com/fork/c v0.3.0\n" +
+			"# example.com/single v0.1.0\n",
+			[]goModule{{"example.com/a", ""}, {"example.com/fork/b", "v2.1.0"}, {"example.com/fork/c", "v0.3.0"}, {"example.com/single", "v0.1.0"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"go.mod": goMod}
+			if tt.modules != "" {
+				files["vendor/modules.txt"] = tt.modules
+			}
+			dir := writeConfigFiles(t, files)
+			graph, err := readGoModGraph(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if graph.Main.Path != "example.com/hello" || graph.GoVersion != "go1.22.1" {
+				t.Errorf("主模块 = %+v, Go版本 = %s", graph.Main, graph.GoVersion)
+			}
+			if !reflect.DeepEqual(graph.Deps, tt.want) {
+				t.Errorf("依赖 = %v\n期望 %v", graph.Deps, tt.want)
+			}
+		})
+	}
+
+	dir := writeConfigFiles(t, map[string]string{"go.mod": "go 1.22\n"})
+	if _, err := readGoModGraph(dir); err == nil || !strings.Contains(err.Error(), "缺少 module 指令") {
+		t.Errorf("缺少 module 指令时期望返回错误, got %v", err)
+	}
+}
+
+func TestReplacedModule(t *testing.T) {
+	tests := []struct {
+		path, replPath, replVersion string
+		want                        goModule
+	}{
+		{"example.com/a", "example.com/fork", "v1.1.0", goModule{"example.com/fork", "v1.1.0"}},
+		{"example.com/a", "../a", "", goModule{"example.com/a", ""}},
+		{"example.com/a", "./a", "v1.0.0", goModule{"example.com/a", ""}},
+		{"example.com/a", `..\a`, "", goModule{"example.com/a", ""}},
+		{"example.com/a", "example.com/fork", "(devel)", goModule{"example.com/a", ""}},
+	}
+	for _, tt := range tests {
+		if got := replacedModule(tt.path, tt.replPath, tt.replVersion); got != tt.want {
+			t.Errorf("replacedModule(%q, %q, %q) = %v, 期望 %v", tt.path, tt.replPath, tt.replVersion, got, tt.want)
+		}
+	}
+}
+
+func TestGoPURL(t *testing.T) {
+	tests := []struct {
+		m    goModule
+		want string
+	}{
+		{goModule{"github.com/pelletier/go-toml/v2", "v2.2.4"}, "pkg:golang/github.com/pelletier/go-toml/v2@v2.2.4"},
+		{goModule{"example.com/b", "v2.0.0+incompatible"}, "pkg:golang/example.com/b@v2.0.0%2Bincompatible"},
+		{goModule{"example.com/local", ""}, "pkg:golang/example.com/local"},
+		{goModule{"stdlib", "1.22.1"}, "pkg:golang/stdlib@1.22.1"},
+	}
+	for _, tt := range tests {
+		if got := goPURL(tt.m); got != tt.want {
+			t.Errorf("goPURL(%v) = %s, 期望 %s", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := map[string]bool{}
+	for range 100 {
+		id := newUUID()
+		if !pattern.MatchString(id) || seen[id] {
+			t.Fatalf("newUUID() = %s, 应为不重复的版本4 UUID", id)
+		}
+		seen[id] = true
+	}
+}
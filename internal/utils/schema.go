@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// schemaOverrides 按配置路径补充反射无法得到的约束, 如枚举和格式
+var schemaOverrides = map[string]map[string]any{
+	"version": {
+		"minimum": 1,
+		"maximum": types.CurrentConfigVersion,
+	},
+	"build.compiler.timeout": {
+		"pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+	},
+	"build.output.archive.format": {
+		"enum": ArchiveFormats(),
+	},
+	"build.output.archive.format_overrides": {
+		"additionalProperties": map[string]any{"type": "string", "enum": ArchiveFormats()},
+		"propertyNames":        map[string]any{"enum": types.KnownPlatforms},
+	},
+	"build.git.sources": {
+		"items":       map[string]any{"type": "string", "enum": GitSources()},
+		"uniqueItems": true,
+		"minItems":    1,
+	},
+	"build.git.mode": {
+		"enum": GitModes(),
+	},
+	"build.checksum.algorithm": {
+		"enum": ChecksumAlgorithms(),
+	},
+	"build.sbom.formats": {
+		"items":       map[string]any{"type": "string", "enum": SBOMFormats()},
+		"uniqueItems": true,
+	},
+	"package.linux.formats": {
+		"items":       map[string]any{"type": "string", "enum": PackageFormats()},
+		"uniqueItems": true,
+	},
+	"package.linux.overrides": {
+		"propertyNames": map[string]any{"enum": PackageFormats()},
+	},
+	"package.linux.files.dst": {
+		"pattern": "^/",
+	},
+	"package.linux.files.mode": {
+		"pattern": "^[0-7]{3,4}$",
+	},
+	"container.format": {
+		"enum": ContainerFormats(),
+	},
+	"container.bin_dir": {
+		"pattern": "^/",
+	},
+	"sign.method": {
+		"enum": SignMethods(),
+	},
+	"publish.manifests.formats": {
+		"items":       map[string]any{"type": "string", "enum": PublishManifestFormats()},
+		"uniqueItems": true,
+	},
+	"publish.release.provider": {
+		"enum": ReleaseProviders(),
+	},
+	"publish.release.retries": {
+		"minimum": 0,
+	},
+	"publish.s3.part_size": {
+		"minimum": types.MinS3PartSize,
+	},
+	"publish.s3.retries": {
+		"minimum": 0,
+	},
+	"publish.http.url": {
+		"pattern": "^https?://",
+	},
+	"publish.http.retries": {
+		"minimum": 0,
+	},
+	"build.target.platforms": {
+		"items": map[string]any{"type": "string", "enum": types.KnownPlatforms},
+	},
+	"build.target.architectures": {
+		"items": map[string]any{"type": "string", "enum": types.KnownArchs},
+	},
+	"build.target.targets": {
+		"items": map[string]any{"type": "string", "pattern": `^!?[^/\s]+/[^/\s]+$`},
+	},
+	"build.target.matrix.goos": {
+		"enum": types.KnownPlatforms,
+	},
+	"build.target.matrix.goarch": {
+		"enum": types.KnownArchs,
+	},
+	"vars": {
+		"additionalProperties": map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": []string{"string", "number", "boolean"}, "description": "静态值"},
+				map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": map[string]any{
+						"value":   map[string]any{"type": "string", "description": "静态值"},
+						"env":     map[string]any{"type": "string", "description": "从该环境变量取值"},
+						"cmd":     map[string]any{"type": "string", "description": "在启动时执行该命令, 使用去除首尾空白的标准输出作为值"},
+						"default": map[string]any{"type": "string", "description": "env或cmd的结果为空时使用的默认值"},
+					},
+				},
+			},
+		},
+	},
+}
+
+// GenerateSchema 根据GobConfig结构体生成gob.toml的JSON Schema
+//
+// 返回值:
+//   - []byte: 格式化后的JSON Schema
+//   - error: 序列化失败时返回错误
+//
+// 注意:
+//   - 字段说明取自结构体的 comment 标签, 默认值取自 GetDefaultConfig
+func GenerateSchema() ([]byte, error) {
+	schema := structSchema(reflect.TypeFor[types.GobConfig](), reflect.ValueOf(GetDefaultConfig()).Elem(), "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "gob.toml"
+	schema["description"] = "gob 构建工具配置文件"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化JSON Schema失败: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteSchemaFile 将JSON Schema写入指定目录下的 gob.schema.json
+//
+// 参数:
+//   - dir: 目标目录
+//
+// 返回值:
+//   - error: 生成或写入失败时返回错误
+func WriteSchemaFile(dir string) error {
+	data, err := GenerateSchema()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, types.SchemaFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// structSchema 生成结构体对应的对象Schema
+//
+// 参数:
+//   - t: 结构体类型
+//   - v: 结构体的默认值, 无效值表示没有默认值
+//   - prefix: 当前表的配置路径
+//
+// 返回值:
+//   - map[string]any: 对象Schema, 不允许未知的配置项
+func structSchema(t reflect.Type, v reflect.Value, prefix string) map[string]any {
+	properties := make(map[string]any)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		path := joinConfigPath(prefix, name)
+
+		prop := typeSchema(field.Type, fv, path)
+		if comment := field.Tag.Get("comment"); comment != "" {
+			prop["description"] = comment
+		}
+		maps.Copy(prop, schemaOverrides[path])
+		properties[name] = prop
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema 生成单个字段类型对应的Schema
+//
+// 参数:
+//   - t: 字段类型
+//   - v: 字段的默认值, 无效值表示没有默认值
+//   - path: 字段的配置路径
+//
+// 返回值:
+//   - map[string]any: 字段Schema
+func typeSchema(t reflect.Type, v reflect.Value, path string) map[string]any {
+	var schema map[string]any
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t, v, path)
+	case reflect.String:
+		schema = map[string]any{"type": "string"}
+	case reflect.Bool:
+		schema = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema = map[string]any{"type": "integer"}
+	case reflect.Slice:
+		// 结构体数组对应TOML的表数组, 元素没有默认值
+		schema = map[string]any{"type": "array", "items": typeSchema(t.Elem(), reflect.Value{}, path)}
+	case reflect.Map:
+		schema = map[string]any{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = typeSchema(t.Elem(), reflect.Value{}, path)
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+
+	// 标量和数组附带默认值, 表数组的默认值使用Go字段名, 不写入Schema
+	if v.IsValid() && !(v.Kind() == reflect.Slice && (v.IsNil() || t.Elem().Kind() == reflect.Struct)) {
+		schema["default"] = v.Interface()
+	}
+	return schema
+}
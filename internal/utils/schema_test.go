@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// loadTestSchema 生成并解析JSON Schema
+func loadTestSchema(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := GenerateSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("JSON Schema 不是有效的JSON: %v", err)
+	}
+	return schema
+}
+
+// schemaProperty 按配置路径查找Schema中的属性, 表数组的路径穿过 items
+func schemaProperty(schema map[string]any, path string) map[string]any {
+	node := schema
+	for name := range strings.SplitSeq(path, ".") {
+		if items, ok := node["items"].(map[string]any); ok {
+			node = items
+		}
+		props, _ := node["properties"].(map[string]any)
+		next, ok := props[name].(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// walkSchema 遍历Schema中的所有属性
+func walkSchema(node map[string]any, path string, fn func(path string, prop map[string]any)) {
+	if items, ok := node["items"].(map[string]any); ok {
+		walkSchema(items, path, fn)
+	}
+	props, _ := node["properties"].(map[string]any)
+	for name, value := range props {
+		prop := value.(map[string]any)
+		childPath := joinConfigPath(path, name)
+		fn(childPath, prop)
+		walkSchema(prop, childPath, fn)
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema := loadTestSchema(t)
+	if schema["$schema"] != "http://json-schema.org/draft-07/schema#" || schema["additionalProperties"] != false {
+		t.Errorf("根对象应声明draft-07并禁止未知配置项: %v %v", schema["$schema"], schema["additionalProperties"])
+	}
+
+	tests := []struct {
+		path, key string
+		want      any
+	}{
+		{"version", "default", float64(types.CurrentConfigVersion)},
+		{"version", "maximum", float64(types.CurrentConfigVersion)},
+		{"build.output.dir", "type", "string"},
+		{"build.output.dir", "default", types.DefaultOutputDir},
+		{"build.output", "additionalProperties", false},
+		{"build.target.jobs", "type", "integer"},
+		{"build.target.fail_fast", "type", "boolean"},
+		{"build.target.matrix", "type", "array"},
+		{"build.target.matrix.goos", "type", "string"},
+		{"build.checksum.algorithm", "enum", []any{"blake2b", "sha256", "sha512"}},
+		{"sign.method", "enum", []any{"minisign", "openpgp"}},
+		{"env", "additionalProperties", map[string]any{"type": "string"}},
+	}
+	for _, tt := range tests {
+		prop := schemaProperty(schema, tt.path)
+		if prop == nil {
+			t.Errorf("Schema 中缺少 %s", tt.path)
+			continue
+		}
+		if !reflect.DeepEqual(prop[tt.key], tt.want) {
+			t.Errorf("%s.%s = %#v, 期望 %#v", tt.path, tt.key, prop[tt.key], tt.want)
+		}
+	}
+
+	// 字段说明取自 comment 标签
+	field, _ := reflect.TypeFor[types.TargetConfig]().FieldByName("Jobs")
+	if got := schemaProperty(schema, "build.target.jobs")["description"]; got != field.Tag.Get("comment") {
+		t.Errorf("字段说明应取自 comment 标签, got %v", got)
+	}
+
+	// 表数组和空数组不写入默认值
+	if _, ok := schemaProperty(schema, "build.target.matrix")["default"]; ok {
+		t.Error("表数组不应包含默认值")
+	}
+}
+
+func TestSchemaOverridesMatchConfig(t *testing.T) {
+	schema := loadTestSchema(t)
+	for path := range schemaOverrides {
+		if schemaProperty(schema, path) == nil {
+			t.Errorf("schemaOverrides 中的 %s 不是有效的配置路径", path)
+		}
+	}
+
+	// 默认值必须满足枚举和范围约束
+	walkSchema(schema, "", func(path string, prop map[string]any) {
+		def, ok := prop["default"]
+		if !ok {
+			return
+		}
+		if enum, ok := prop["enum"].([]any); ok && !slices.Contains(enum, def) {
+			t.Errorf("%s 的默认值 %v 不在枚举 %v 中", path, def, enum)
+		}
+		if items, ok := prop["items"].(map[string]any); ok {
+			enum, _ := items["enum"].([]any)
+			for _, v := range def.([]any) {
+				if enum != nil && !slices.Contains(enum, v) {
+					t.Errorf("%s 的默认值 %v 不在枚举 %v 中", path, v, enum)
+				}
+			}
+		}
+		if minimum, ok := prop["minimum"].(float64); ok && def.(float64) < minimum {
+			t.Errorf("%s 的默认值 %v 小于最小值 %v", path, def, minimum)
+		}
+	})
+}
+
+func TestWriteSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteSchemaFile(dir); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(filepath.Join(dir, types.SchemaFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := GenerateSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != string(generated) {
+		t.Error("写入的文件与 GenerateSchema 的结果不一致")
+	}
+
+	if err := WriteSchemaFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("目录不存在时期望返回错误")
+	}
+}
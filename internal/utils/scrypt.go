@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// scrypt 的实现(RFC 7914), minisign使用它从口令派生私钥的加密密钥
+// 标准库未提供scrypt, 为避免引入额外依赖在此实现
+
+// scryptKey 从口令派生密钥
+//
+// 参数:
+//   - password: 口令
+//   - salt: 盐
+//   - n: CPU/内存开销参数, 必须是大于1的2的幂
+//   - r: 分组大小参数
+//   - p: 并行参数
+//   - keyLen: 派生密钥的长度
+//
+// 返回值:
+//   - []byte: 派生的密钥
+//   - error: 参数无效时返回错误
+//
+// 注意:
+//   - 需要 128*r*n 字节的内存, minisign的默认参数约为1GiB
+func scryptKey(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	if n <= 1 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("scrypt参数N必须是大于1的2的幂")
+	}
+	if r <= 0 || p <= 0 || uint64(r)*uint64(p) >= 1<<30 || r > (1<<31-1)/128/p || n > (1<<31-1)/128/r {
+		return nil, fmt.Errorf("scrypt参数过大")
+	}
+
+	b, err := pbkdf2.Key(sha256.New, string(password), salt, 1, p*128*r)
+	if err != nil {
+		return nil, err
+	}
+
+	x := make([]uint32, 32*r)
+	y := make([]uint32, 32*r)
+	v := make([]uint32, 32*r*n)
+	for i := range p {
+		scryptSMix(b[i*128*r:(i+1)*128*r], r, n, v, x, y)
+	}
+	return pbkdf2.Key(sha256.New, string(password), b, 1, keyLen)
+}
+
+// scryptSMix 对一个128*r字节的分组执行ROMix
+func scryptSMix(b []byte, r, n int, v, x, y []uint32) {
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	for i := range n {
+		copy(v[i*32*r:], x)
+		scryptBlockMix(x, y, r)
+	}
+	for range n {
+		j := int(x[(2*r-1)*16]) & (n - 1)
+		for k := range x {
+			x[k] ^= v[j*32*r+k]
+		}
+		scryptBlockMix(x, y, r)
+	}
+	for i, w := range x {
+		binary.LittleEndian.PutUint32(b[i*4:], w)
+	}
+}
+
+// scryptBlockMix 执行BlockMix, 结果写回x, y作为临时空间
+func scryptBlockMix(x, y []uint32, r int) {
+	var t [16]uint32
+	copy(t[:], x[(2*r-1)*16:])
+	for i := range 2 * r {
+		for k := range t {
+			t[k] ^= x[i*16+k]
+		}
+		salsa208(&t)
+		// 偶数分组放在前半部分, 奇数分组放在后半部分
+		copy(y[(i/2+(i%2)*r)*16:], t[:])
+	}
+	copy(x, y)
+}
+
+// salsa208 执行Salsa20/8核心函数
+func salsa208(b *[16]uint32) {
+	x := *b
+	for range 4 {
+		x[4] ^= bits.RotateLeft32(x[0]+x[12], 7)
+		x[8] ^= bits.RotateLeft32(x[4]+x[0], 9)
+		x[12] ^= bits.RotateLeft32(x[8]+x[4], 13)
+		x[0] ^= bits.RotateLeft32(x[12]+x[8], 18)
+
+		x[9] ^= bits.RotateLeft32(x[5]+x[1], 7)
+		x[13] ^= bits.RotateLeft32(x[9]+x[5], 9)
+		x[1] ^= bits.RotateLeft32(x[13]+x[9], 13)
+		x[5] ^= bits.RotateLeft32(x[1]+x[13], 18)
+
+		x[14] ^= bits.RotateLeft32(x[10]+x[6], 7)
+		x[2] ^= bits.RotateLeft32(x[14]+x[10], 9)
+		x[6] ^= bits.RotateLeft32(x[2]+x[14], 13)
+		x[10] ^= bits.RotateLeft32(x[6]+x[2], 18)
+
+		x[3] ^= bits.RotateLeft32(x[15]+x[11], 7)
+		x[7] ^= bits.RotateLeft32(x[3]+x[15], 9)
+		x[11] ^= bits.RotateLeft32(x[7]+x[3], 13)
+		x[15] ^= bits.RotateLeft32(x[11]+x[7], 18)
+
+		x[1] ^= bits.RotateLeft32(x[0]+x[3], 7)
+		x[2] ^= bits.RotateLeft32(x[1]+x[0], 9)
+		x[3] ^= bits.RotateLeft32(x[2]+x[1], 13)
+		x[0] ^= bits.RotateLeft32(x[3]+x[2], 18)
+
+		x[6] ^= bits.RotateLeft32(x[5]+x[4], 7)
+		x[7] ^= bits.RotateLeft32(x[6]+x[5], 9)
+		x[4] ^= bits.RotateLeft32(x[7]+x[6], 13)
+		x[5] ^= bits.RotateLeft32(x[4]+x[7], 18)
+
+		x[11] ^= bits.RotateLeft32(x[10]+x[9], 7)
+		x[8] ^= bits.RotateLeft32(x[11]+x[10], 9)
+		x[9] ^= bits.RotateLeft32(x[8]+x[11], 13)
+		x[10] ^= bits.RotateLeft32(x[9]+x[8], 18)
+
+		x[12] ^= bits.RotateLeft32(x[15]+x[14], 7)
+		x[13] ^= bits.RotateLeft32(x[12]+x[15], 9)
+		x[14] ^= bits.RotateLeft32(x[13]+x[12], 13)
+		x[15] ^= bits.RotateLeft32(x[14]+x[13], 18)
+	}
+	for i := range b {
+		b[i] += x[i]
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestScryptKey(t *testing.T) {
+	// RFC 7914 第12节的测试向量, 省略需要1GiB内存的 N=1048576
+	tests := []struct {
+		password, salt string
+		n, r, p        int
+		want           string
+	}{
+		{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+		{"pleaseletmein", "SodiumChloride", 16384, 8, 1, "7023bdcb3afd7348461c06cd81fd38ebfda8fbba904f8e3ea9b543f6545da1f2d5432955613f0fcf62d49705242a9af9e61e85dc0d651e40dfcf017b45575887"},
+	}
+	for _, tt := range tests {
+		got, err := scryptKey([]byte(tt.password), []byte(tt.salt), tt.n, tt.r, tt.p, 64)
+		if err != nil {
+			t.Fatalf("scryptKey(%q, %q, %d, %d, %d) 返回错误: %v", tt.password, tt.salt, tt.n, tt.r, tt.p, err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("scryptKey(%q, %q, %d, %d, %d) = %x, 期望 %s", tt.password, tt.salt, tt.n, tt.r, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestScryptKeyInvalidParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		n, r, p int
+	}{
+		{"N为1", 1, 8, 1},
+		{"N不是2的幂", 1000, 8, 1},
+		{"r为0", 16, 0, 1},
+		{"p为0", 16, 8, 0},
+		{"r*p过大", 16, 1 << 15, 1 << 15},
+	}
+	for _, tt := range tests {
+		if _, err := scryptKey([]byte("pw"), []byte("salt"), tt.n, tt.r, tt.p, 32); err == nil {
+			t.Errorf("%s: 期望返回错误", tt.name)
+		}
+	}
+}
+
+func TestScryptParams(t *testing.T) {
+	tests := []struct {
+		opsLimit, memLimit uint64
+		n, r, p            int
+	}{
+		// minisign 的默认参数(libsodium 的 sensitive 级别)
+		{33554432, 1073741824, 1 << 20, 8, 1},
+		// libsodium 的 interactive 级别
+		{524288, 16777216, 1 << 14, 8, 1},
+		// opslimit 低于下限时按 32768 计算
+		{1, 16777216, 1 << 10, 8, 1},
+	}
+	for _, tt := range tests {
+		n, r, p := scryptParams(tt.opsLimit, tt.memLimit)
+		if n != tt.n || r != tt.r || p != tt.p {
+			t.Errorf("scryptParams(%d, %d) = %d, %d, %d, 期望 %d, %d, %d", tt.opsLimit, tt.memLimit, n, r, p, tt.n, tt.r, tt.p)
+		}
+	}
+}
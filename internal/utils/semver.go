@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// ParseSemver 解析语义化版本号
+//
+// 参数:
+//   - s: 版本号, 可带前缀v, 如 v1.2.3-rc.1+build.5
+//
+// 返回值:
+//   - types.Semver: 解析后的版本号
+//   - bool: 是否为有效的语义化版本号
+func ParseSemver(s string) (types.Semver, bool) {
+	var v types.Semver
+	var hasBuild, hasPre bool
+	s = strings.TrimPrefix(s, "v")
+	s, v.Build, hasBuild = strings.Cut(s, "+")
+	s, v.Prerelease, hasPre = strings.Cut(s, "-")
+	if (hasBuild && v.Build == "") || (hasPre && v.Prerelease == "") {
+		return types.Semver{}, false
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return types.Semver{}, false
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, ok := parseSemverNumber(p)
+		if !ok {
+			return types.Semver{}, false
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	if !validSemverIdents(v.Prerelease, true) || !validSemverIdents(v.Build, false) {
+		return types.Semver{}, false
+	}
+	return v, true
+}
+
+// parseSemverNumber 解析版本号中的数字, 不允许前导零
+func parseSemverNumber(s string) (int, bool) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// validSemverIdents 检查预发布标识或构建元数据, 为空时有效
+//
+// 参数:
+//   - s: 以点分隔的标识
+//   - numeric: 是否检查纯数字标识的前导零(仅预发布标识要求)
+//
+// 返回值:
+//   - bool: 每个标识都非空且只包含字母、数字和连字符时返回true
+func validSemverIdents(s string, numeric bool) bool {
+	if s == "" {
+		return true
+	}
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if _, err := strconv.Atoi(id); numeric && err == nil && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareSemver 按语义化版本的优先级比较两个版本号, 忽略构建元数据
+//
+// 参数:
+//   - a: 版本号
+//   - b: 版本号
+//
+// 返回值:
+//   - int: a小于b时为-1, 相等时为0, 大于时为1
+func CompareSemver(a, b types.Semver) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// 预发布版本低于对应的正式版本
+	switch {
+	case a.Prerelease == b.Prerelease:
+		return 0
+	case a.Prerelease == "":
+		return 1
+	case b.Prerelease == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a.Prerelease, "."), strings.Split(b.Prerelease, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil: // 纯数字标识低于非数字标识
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+// sign 返回整数的符号
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// NextSemver 计算下一个版本号
+//
+// 参数:
+//   - v: 当前版本号
+//   - part: 递增的部分, major、minor 或 patch
+//
+// 返回值:
+//   - types.Semver: 下一个版本号, 不包含预发布标识和构建元数据
+//   - error: part 无效时返回错误
+//
+// 注意:
+//   - 预发布版本的下一个版本为对应的正式版本, 如 1.3.0-rc.1 的下一个次版本为 1.3.0
+func NextSemver(v types.Semver, part string) (types.Semver, error) {
+	pre := v.Prerelease != ""
+	next := types.Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch part {
+	case "major":
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			next = types.Semver{Major: v.Major + 1}
+		}
+	case "minor":
+		if !pre || v.Patch != 0 {
+			next = types.Semver{Major: v.Major, Minor: v.Minor + 1}
+		}
+	case "patch":
+		if !pre {
+			next.Patch++
+		}
+	default:
+		return types.Semver{}, fmt.Errorf("无效的版本递增部分 %q, 可用: major、minor、patch", part)
+	}
+	return next, nil
+}
+
+// BumpParts 返回 gob release bump 支持的版本递增部分
+//
+// 返回值:
+//   - []string: 版本递增部分
+func BumpParts() []string {
+	return []string{"major", "minor", "patch", "prerelease"}
+}
+
+// BumpSemver 按递增部分和预发布标识计算新版本号
+//
+// 参数:
+//   - v: 当前版本号
+//   - part: 递增的部分, major、minor、patch 或 prerelease
+//   - preid: 预发布标识的名称, 如 rc; major、minor、patch 为空时生成正式版本
+//
+// 返回值:
+//   - types.Semver: 新版本号
+//   - error: part 或 preid 无效时返回错误
+//
+// 注意:
+//   - 指定 preid 时 major、minor、patch 从正式版本递增后追加 preid.1, 如 1.3.0-rc.2 的 minor 为 1.4.0-rc.1
+//   - prerelease 递增同名预发布标识的序号, 如 1.3.0-rc.1 变为 1.3.0-rc.2; 标识不同时从1开始, 正式版本先递增修订号
+func BumpSemver(v types.Semver, part, preid string) (types.Semver, error) {
+	if preid != "" && !validSemverIdents(preid, true) {
+		return types.Semver{}, fmt.Errorf("无效的预发布标识 %q", preid)
+	}
+
+	if part != "prerelease" {
+		if preid == "" {
+			return NextSemver(v, part)
+		}
+		base := types.Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+		next, err := NextSemver(base, part)
+		if err != nil {
+			return types.Semver{}, err
+		}
+		next.Prerelease = preid + ".1"
+		return next, nil
+	}
+
+	if preid == "" {
+		return types.Semver{}, fmt.Errorf("prerelease 需要指定预发布标识")
+	}
+	next := types.Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: preid + ".1"}
+	if v.Prerelease == "" {
+		next.Patch++
+		return next, nil
+	}
+	if rest, ok := strings.CutPrefix(v.Prerelease, preid+"."); ok {
+		if n, err := strconv.Atoi(rest); err == nil {
+			next.Prerelease = fmt.Sprintf("%s.%d", preid, n+1)
+		}
+	}
+	return next, nil
+}
+
+// readGitSemver 读取当前提交可达的最高语义化版本标签及其之后的提交数
+//
+// 参数:
+//   - timeout: 命令的超时时间
+//   - prefix: 标签前缀, 只有以该前缀开头的标签参与比较
+//
+// 返回值:
+//   - types.GitSemver: 版本信息, 不包含 IsSnapshot 和 Describe
+//   - error: git命令执行失败时返回错误
+func readGitSemver(timeout time.Duration, prefix string) (types.GitSemver, error) {
+	tags, err := gitOutput(timeout, types.GitMergedTagsCmd)
+	if err != nil {
+		return types.GitSemver{}, err
+	}
+
+	var info types.GitSemver
+	for _, tag := range strings.Fields(tags) {
+		name, ok := strings.CutPrefix(tag, prefix)
+		if !ok {
+			continue
+		}
+		v, ok := ParseSemver(name)
+		if !ok {
+			continue
+		}
+		if info.Tag == "" || CompareSemver(v, info.Version) > 0 {
+			info.Tag, info.Version = tag, v
+		}
+	}
+
+	// 没有标签时统计全部提交数
+	count, err := gitOutput(timeout, types.GitCommitCountCmd, gitRange(info.Tag))
+	if err != nil {
+		return types.GitSemver{}, err
+	}
+	if info.CommitsSinceTag, err = strconv.Atoi(count); err != nil {
+		return types.GitSemver{}, fmt.Errorf("%s: 无法解析输出 %q", types.GitCommitCountCmd.Name, count)
+	}
+	return info, nil
+}
+
+// gitRange 返回从标签到当前提交的版本范围, 标签为空时返回 HEAD
+func gitRange(tag string) string {
+	if tag == "" {
+		return "HEAD"
+	}
+	return "refs/tags/" + tag + "..HEAD"
+}
+
+// GitTemplateData 将Git元数据转换为模板中的 {{.Git.*}}
+//
+// 参数:
+//   - v: verman对象
+//   - config: 配置对象, 提供语义化版本信息
+//
+// 返回值:
+//   - types.TemplateGit: 模板中的Git元数据
+func GitTemplateData(v *verman.Info, config *types.GobConfig) types.TemplateGit {
+	sv := config.GitSemver
+	next := func(part string) string {
+		n, _ := NextSemver(sv.Version, part)
+		return n.String()
+	}
+	return types.TemplateGit{
+		AppName:         v.AppName,
+		Version:         v.GitVersion,
+		Commit:          v.GitCommit,
+		CommitTime:      v.GitCommitTime,
+		BuildTime:       v.BuildTime,
+		TreeState:       v.GitTreeState,
+		Tag:             sv.Tag,
+		Semver:          sv.Version.String(),
+		Major:           sv.Version.Major,
+		Minor:           sv.Version.Minor,
+		Patch:           sv.Version.Patch,
+		Prerelease:      sv.Version.Prerelease,
+		CommitsSinceTag: sv.CommitsSinceTag,
+		IsSnapshot:      sv.IsSnapshot,
+		NextMajor:       next("major"),
+		NextMinor:       next("minor"),
+		NextPatch:       next("patch"),
+	}
+}
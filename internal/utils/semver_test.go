@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// testGitTimeout 测试中执行git命令的超时时间
+const testGitTimeout = 10 * time.Second
+
+// newTestGitRepo 在临时目录中初始化Git仓库并切换到该目录
+//
+// 返回值:
+//   - func(args ...string) string: 在仓库中执行git命令并返回去除首尾空白的输出, 失败时终止测试
+func newTestGitRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未找到 git")
+	}
+	t.Chdir(t.TempDir())
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "gob")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "gob@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s 失败: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	return git
+}
+
+// newSemverTestRepo 创建带版本标签的Git仓库
+//
+// 注意:
+//   - main 分支依次为: v1.0.0, v1.2.0-rc.1 和 nightly, cli/v2.0.0
+//   - v9.0.0 位于未合并的 side 分支, 不应参与比较
+func newSemverTestRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	git := newTestGitRepo(t)
+	if err := os.WriteFile("main.go", []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	git("tag", "v1.0.0")
+	git("checkout", "-q", "-b", "side")
+	git("commit", "-q", "--allow-empty", "-m", "side")
+	git("tag", "v9.0.0")
+	git("checkout", "-q", "main")
+	git("commit", "-q", "--allow-empty", "-m", "feat: rc")
+	git("tag", "v1.2.0-rc.1")
+	git("tag", "nightly")
+	git("commit", "-q", "--allow-empty", "-m", "feat: cli")
+	git("tag", "cli/v2.0.0")
+	return git
+}
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		in   string
+		want types.Semver
+		ok   bool
+	}{
+		{"v1.2.3", types.Semver{Major: 1, Minor: 2, Patch: 3}, true},
+		{"1.2.3-rc.1+build.5", types.Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Build: "build.5"}, true},
+		{"0.0.0", types.Semver{}, true},
+		{"10.20.30-alpha-1.0+001", types.Semver{Major: 10, Minor: 20, Patch: 30, Prerelease: "alpha-1.0", Build: "001"}, true},
+		{"01.2.3", types.Semver{}, false},
+		{"1.2", types.Semver{}, false},
+		{"1.2.3.4", types.Semver{}, false},
+		{"1.2.x", types.Semver{}, false},
+		{"1.2.3-", types.Semver{}, false},
+		{"1.2.3+", types.Semver{}, false},
+		{"1.2.3-01", types.Semver{}, false},
+		{"1.2.3-a..b", types.Semver{}, false},
+		{"1.2.3-rc_1", types.Semver{}, false},
+		{"nightly", types.Semver{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSemver(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseSemver(%q) = %+v, %v, 期望 %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	// semver.org 中的优先级示例, 依次递增
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "1.10.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseSemver(ordered[i])
+			b, _ := ParseSemver(ordered[j])
+			want := sign(i - j)
+			if got := CompareSemver(a, b); got != want {
+				t.Errorf("CompareSemver(%s, %s) = %d, 期望 %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	a, _ := ParseSemver("1.2.3+build.1")
+	b, _ := ParseSemver("1.2.3+build.2")
+	if got := CompareSemver(a, b); got != 0 {
+		t.Errorf("比较时应忽略构建元数据, got %d", got)
+	}
+}
+
+func TestNextSemver(t *testing.T) {
+	tests := []struct {
+		in, part, want string
+	}{
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2.3+build", "patch", "1.2.4"},
+		{"0.0.0", "patch", "0.0.1"},
+		{"1.3.0-rc.1", "major", "2.0.0"},
+		{"1.3.0-rc.1", "minor", "1.3.0"},
+		{"1.3.0-rc.1", "patch", "1.3.0"},
+		{"2.0.0-rc.1", "major", "2.0.0"},
+		{"2.0.0-rc.1", "minor", "2.0.0"},
+		{"1.2.3-rc.1", "minor", "1.3.0"},
+		{"1.2.3-rc.1", "patch", "1.2.3"},
+	}
+	for _, tt := range tests {
+		v, _ := ParseSemver(tt.in)
+		got, err := NextSemver(v, tt.part)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("NextSemver(%s, %s) = %s, %v, 期望 %s", tt.in, tt.part, got, err, tt.want)
+		}
+	}
+
+	if _, err := NextSemver(types.Semver{}, "build"); err == nil || !strings.Contains(err.Error(), "无效的版本递增部分") {
+		t.Errorf("无效的递增部分期望返回错误, got %v", err)
+	}
+}
+
+func TestSemverString(t *testing.T) {
+	tests := map[string]types.Semver{
+		"0.0.0":               {},
+		"1.2.3":               {Major: 1, Minor: 2, Patch: 3},
+		"1.2.3-rc.1":          {Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"},
+		"1.2.3+build.5":       {Major: 1, Minor: 2, Patch: 3, Build: "build.5"},
+		"1.2.3-rc.1+build.5":  {Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Build: "build.5"},
+		"10.0.0-alpha-1.beta": {Major: 10, Prerelease: "alpha-1.beta"},
+	}
+	for want, v := range tests {
+		if got := v.String(); got != want {
+			t.Errorf("%+v.String() = %q, 期望 %q", v, got, want)
+		}
+	}
+}
+
+func TestReadGitSemver(t *testing.T) {
+	newSemverTestRepo(t)
+
+	tests := []struct {
+		prefix  string
+		tag     string
+		version string
+		commits int
+	}{
+		{"", "v1.2.0-rc.1", "1.2.0-rc.1", 1},
+		{"cli/", "cli/v2.0.0", "2.0.0", 0},
+		{"web/", "", "0.0.0", 3},
+	}
+	for _, tt := range tests {
+		info, err := readGitSemver(testGitTimeout, tt.prefix)
+		if err != nil {
+			t.Fatalf("前缀 %q: %v", tt.prefix, err)
+		}
+		if info.Tag != tt.tag || info.Version.String() != tt.version || info.CommitsSinceTag != tt.commits {
+			t.Errorf("前缀 %q: got %+v, 期望标签 %q 版本 %s 提交数 %d", tt.prefix, info, tt.tag, tt.version, tt.commits)
+		}
+	}
+}
+
+func TestGetGitMetaDataSemver(t *testing.T) {
+	newSemverTestRepo(t)
+
+	tests := []struct {
+		name     string
+		prefix   string
+		snapshot string
+		dirty    bool
+		want     string // 期望的Git版本
+		wantSnap bool
+	}{
+		{"去除标签前缀", "cli/", "v{{.Git.NextPatch}}-dev.{{.Git.CommitsSinceTag}}", false, "v2.0.0", false},
+		{"标签之后有提交", "", "v{{.Git.NextPatch}}-dev.{{.Git.CommitsSinceTag}}", false, "v1.2.0-dev.1", true},
+		{"未配置快照模板", "", "", false, "cli/v2.0.0", true},
+		{"工作区有修改", "cli/", "v{{.Git.NextPatch}}-dev.{{.Git.CommitsSinceTag}}", true, "v2.0.1-dev.0", true},
+		{"工作区有修改时不使用快照模板", "cli/", "", true, "v2.0.0-dirty", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "package main\n"
+			if tt.dirty {
+				content += "\nfunc main() {}\n"
+			}
+			if err := os.WriteFile("main.go", []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config := GetDefaultConfig()
+			config.Build.Output.Name = "myapp"
+			config.Build.Git.Sources = []string{"git"}
+			config.Build.Git.TagPrefix = tt.prefix
+			config.Build.Git.Snapshot = tt.snapshot
+			v := &verman.Info{}
+			if err := GetGitMetaData(testGitTimeout, v, config); err != nil {
+				t.Fatal(err)
+			}
+			if v.GitVersion != tt.want {
+				t.Errorf("GitVersion = %q, 期望 %q", v.GitVersion, tt.want)
+			}
+			if config.GitSemver.IsSnapshot != tt.wantSnap {
+				t.Errorf("IsSnapshot = %v, 期望 %v", config.GitSemver.IsSnapshot, tt.wantSnap)
+			}
+			if tt.prefix != "" && strings.HasPrefix(config.GitSemver.Describe, tt.prefix) {
+				t.Errorf("Describe 应去除标签前缀, got %q", config.GitSemver.Describe)
+			}
+		})
+	}
+
+	config := GetDefaultConfig()
+	config.Build.Git.Sources = []string{"git"}
+	config.Build.Git.Snapshot = "{{if false}}v0{{end}}"
+	if err := GetGitMetaData(testGitTimeout, &verman.Info{}, config); err == nil || !strings.Contains(err.Error(), "渲染结果为空") {
+		t.Errorf("快照版本号为空时期望返回错误, got %v", err)
+	}
+}
+
+func TestGitTemplateData(t *testing.T) {
+	v := &verman.Info{AppName: "myapp", GitVersion: "v1.3.0-rc.1-2-gabc1234", GitCommit: "abc1234", GitTreeState: "clean"}
+	config := GetDefaultConfig()
+	config.GitSemver = types.GitSemver{
+		Tag:             "v1.3.0-rc.1",
+		Version:         types.Semver{Major: 1, Minor: 3, Prerelease: "rc.1"},
+		CommitsSinceTag: 2,
+		IsSnapshot:      true,
+	}
+
+	got := GitTemplateData(v, config)
+	want := types.TemplateGit{
+		AppName:         "myapp",
+		Version:         "v1.3.0-rc.1-2-gabc1234",
+		Commit:          "abc1234",
+		TreeState:       "clean",
+		Tag:             "v1.3.0-rc.1",
+		Semver:          "1.3.0-rc.1",
+		Major:           1,
+		Minor:           3,
+		Prerelease:      "rc.1",
+		CommitsSinceTag: 2,
+		IsSnapshot:      true,
+		NextMajor:       "2.0.0",
+		NextMinor:       "1.3.0",
+		NextPatch:       "1.3.0",
+	}
+	if got != want {
+		t.Errorf("GitTemplateData() = %+v, 期望 %+v", got, want)
+	}
+
+	// 没有标签时从 0.0.0 计算
+	got = GitTemplateData(&verman.Info{}, GetDefaultConfig())
+	if got.Semver != "0.0.0" || got.NextMajor != "1.0.0" || got.NextMinor != "0.1.0" || got.NextPatch != "0.0.1" {
+		t.Errorf("没有标签时 got %+v", got)
+	}
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+var (
+	// errPassphraseRequired 私钥已加密但未提供口令
+	errPassphraseRequired = errors.New("私钥已加密, 需要提供口令")
+	// errWrongPassphrase 解密后的私钥校验失败
+	errWrongPassphrase = errors.New("私钥口令错误或私钥已损坏")
+	// errInvalidPGPKey OpenPGP密钥格式无效
+	errInvalidPGPKey = errors.New("无效的OpenPGP密钥")
+	// errInvalidPGPSignature OpenPGP签名格式无效
+	errInvalidPGPSignature = errors.New("无效的OpenPGP签名")
+	// errInvalidPGPData OpenPGP数据包格式无效
+	errInvalidPGPData = errors.New("无效的OpenPGP数据")
+)
+
+// Signer 为文件生成分离签名
+type Signer interface {
+	// KeyID 返回签名密钥的标识, 用于输出
+	KeyID() string
+	// Extension 返回签名文件的扩展名
+	Extension() string
+	// Sign 返回文件的签名内容
+	Sign(path string) ([]byte, error)
+}
+
+// Verifier 校验文件的分离签名
+type Verifier interface {
+	// KeyID 返回公钥的标识, 用于输出
+	KeyID() string
+	// Extension 返回签名文件的扩展名
+	Extension() string
+	// Verify 校验文件与签名内容是否匹配
+	Verify(path string, sig []byte) error
+}
+
+// SignMethods 返回支持的签名方式
+//
+// 返回值:
+//   - []string: 签名方式
+func SignMethods() []string {
+	return []string{"minisign", "openpgp"}
+}
+
+// NewSigner 读取私钥并创建签名器
+//
+// 参数:
+//   - cfg: 签名配置
+//
+// 返回值:
+//   - Signer: 签名器
+//   - error: 私钥无法读取、格式无效、缺少口令或口令错误时返回错误
+//
+// 注意:
+//   - 口令只从环境变量读取, 不会出现在输出和错误信息中
+func NewSigner(cfg types.SignConfig) (Signer, error) {
+	var data []byte
+	if cfg.KeyEnv != "" {
+		value, err := requireEnv(cfg.KeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(value)
+	} else {
+		var err error
+		if data, err = os.ReadFile(cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("读取私钥文件失败: %w", err)
+		}
+	}
+
+	var passphrase string
+	if cfg.PassphraseEnv != "" {
+		passphrase = os.Getenv(cfg.PassphraseEnv)
+	}
+
+	var signer Signer
+	var err error
+	switch cfg.Method {
+	case "minisign":
+		signer, err = parseMinisignSecretKey(data, passphrase, cfg.Prehash)
+	case "openpgp":
+		signer, err = parsePGPSecretKey(data, passphrase)
+	default:
+		return nil, fmt.Errorf("不支持的签名方式 %q", cfg.Method)
+	}
+	if errors.Is(err, errPassphraseRequired) {
+		if cfg.PassphraseEnv == "" {
+			return nil, fmt.Errorf("%w, 请通过 passphrase_env 指定存放口令的环境变量", err)
+		}
+		return nil, fmt.Errorf("%w, 请设置环境变量 %s", err, cfg.PassphraseEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// NewVerifier 根据公钥的格式创建校验器
+//
+// 参数:
+//   - path: 公钥文件路径, minisign/signify公钥或OpenPGP公钥
+//
+// 返回值:
+//   - Verifier: 校验器
+//   - error: 公钥无法读取或格式无效时返回错误
+func NewVerifier(path string) (Verifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取公钥文件失败: %w", err)
+	}
+	if bytes.Contains(data, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) {
+		return parsePGPPublicKeys(data)
+	}
+	return parseMinisignPublicKey(data)
+}
+
+// SignFile 为文件生成签名文件
+//
+// 参数:
+//   - signer: 签名器
+//   - path: 被签名的文件路径
+//
+// 返回值:
+//   - string: 签名文件路径, 为被签名文件路径加上签名的扩展名
+//   - error: 错误信息
+func SignFile(signer Signer, path string) (string, error) {
+	sig, err := signer.Sign(path)
+	if err != nil {
+		return "", fmt.Errorf("为 %s 签名失败: %w", path, err)
+	}
+	sigPath := path + signer.Extension()
+	if err := os.WriteFile(sigPath, sig, 0o644); err != nil {
+		return "", fmt.Errorf("写入签名文件失败: %w", err)
+	}
+	return sigPath, nil
+}
+
+// VerifyFileSignature 校验文件旁的签名文件
+//
+// 参数:
+//   - verifier: 校验器
+//   - path: 被签名的文件路径
+//
+// 返回值:
+//   - error: 签名文件不存在或签名无效时返回错误
+func VerifyFileSignature(verifier Verifier, path string) error {
+	sigPath := path + verifier.Extension()
+	sig, err := os.ReadFile(sigPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("缺少签名文件 %s", filepath.Base(sigPath))
+	}
+	if err != nil {
+		return fmt.Errorf("读取签名文件失败: %w", err)
+	}
+	if err := verifier.Verify(path, sig); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(sigPath), err)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// 测试密钥位于 testdata/sign, 口令均为 test-passphrase
+//   - minisign.key: scrypt加密的minisign私钥, 密钥ID为 8877665544332211
+//   - openpgp-*.key: gpg 生成的 ed25519 和 rsa2048 签名密钥
+//   - hello.txt.sig / hello.txt.asc / hello-rsa.txt.asc: 由 openssl 和 gpg 生成的 hello.txt 的参考签名
+const (
+	testSignDir        = "testdata/sign"
+	testSignPassphrase = "test-passphrase"
+	testMinisignKeyID  = "8877665544332211"
+	testPGPEd25519ID   = "132886524E1EBBA34F56CAECB4A11A02AD5ED6B6"
+	testPGPRSAID       = "F091FBDEE7436ECD577328EBB0DB9E497BBC053D"
+)
+
+func testSignFile(name string) string {
+	return filepath.Join(testSignDir, name)
+}
+
+// copyTestSignFile 将 hello.txt 复制到临时目录, 返回副本路径
+func copyTestSignFile(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(testSignFile("hello.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		key     string
+		pub     string
+		prehash bool
+		keyID   string
+		ext     string
+	}{
+		{"minisign", "minisign", "minisign.key", "minisign.pub", false, testMinisignKeyID, ".sig"},
+		{"minisign预哈希", "minisign", "minisign.key", "minisign.pub", true, testMinisignKeyID, ".sig"},
+		{"openpgp ed25519", "openpgp", "openpgp-ed25519.key", "openpgp-ed25519.pub", false, testPGPEd25519ID, ".asc"},
+		{"openpgp rsa", "openpgp", "openpgp-rsa.key", "openpgp-rsa.pub", false, testPGPRSAID, ".asc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOB_TEST_PASSPHRASE", testSignPassphrase)
+			signer, err := NewSigner(types.SignConfig{
+				Method:        tt.method,
+				KeyFile:       testSignFile(tt.key),
+				PassphraseEnv: "GOB_TEST_PASSPHRASE",
+				Prehash:       tt.prehash,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signer.KeyID() != tt.keyID || signer.Extension() != tt.ext {
+				t.Errorf("签名器密钥ID或扩展名不正确: %s %s", signer.KeyID(), signer.Extension())
+			}
+
+			path := copyTestSignFile(t)
+			sigPath, err := SignFile(signer, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sigPath != path+tt.ext {
+				t.Errorf("签名文件路径不正确: %s", sigPath)
+			}
+
+			verifier, err := NewVerifier(testSignFile(tt.pub))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verifier.KeyID() != tt.keyID {
+				t.Errorf("校验器密钥ID不正确: got %s, 期望 %s", verifier.KeyID(), tt.keyID)
+			}
+			if err := VerifyFileSignature(verifier, path); err != nil {
+				t.Fatalf("校验自己生成的签名失败: %v", err)
+			}
+
+			if err := os.WriteFile(path, []byte("hello from gob!\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyFileSignature(verifier, path); err == nil {
+				t.Error("文件被篡改后期望校验失败")
+			}
+		})
+	}
+}
+
+func TestVerifyReferenceSignatures(t *testing.T) {
+	path := testSignFile("hello.txt")
+
+	// openssl 生成的signify格式签名, 不含可信注释
+	verifier, err := NewVerifier(testSignFile("minisign.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFileSignature(verifier, path); err != nil {
+		t.Errorf("校验minisign参考签名失败: %v", err)
+	}
+
+	// gpg 生成的 ed25519/SHA512 签名
+	if verifier, err = NewVerifier(testSignFile("openpgp-ed25519.pub")); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFileSignature(verifier, path); err != nil {
+		t.Errorf("校验gpg ed25519参考签名失败: %v", err)
+	}
+
+	// gpg 生成的 rsa/SHA256 签名
+	if verifier, err = NewVerifier(testSignFile("openpgp-rsa.pub")); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := os.ReadFile(testSignFile("hello-rsa.txt.asc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(path, sig); err != nil {
+		t.Errorf("校验gpg rsa参考签名失败: %v", err)
+	}
+
+	// 使用其他密钥的签名
+	ed25519Sig, err := os.ReadFile(testSignFile("hello.txt.asc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(path, ed25519Sig); err == nil {
+		t.Error("使用其他OpenPGP密钥的签名期望校验失败")
+	}
+}
+
+func TestVerifyWrongMinisignKey(t *testing.T) {
+	path := copyTestSignFile(t)
+	t.Setenv("GOB_TEST_PASSPHRASE", testSignPassphrase)
+	signer, err := NewSigner(types.SignConfig{Method: "minisign", KeyFile: testSignFile("minisign.key"), PassphraseEnv: "GOB_TEST_PASSPHRASE", Prehash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignFile(signer, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 密钥ID相同但公钥不同
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := append([]byte{'E', 'd', 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}, pub...)
+	pubPath := filepath.Join(t.TempDir(), "other.pub")
+	content := "untrusted comment: other key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+	if err := os.WriteFile(pubPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewVerifier(pubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFileSignature(verifier, path); err == nil || !strings.Contains(err.Error(), "签名无效") {
+		t.Errorf("使用其他公钥校验期望返回签名无效, got %v", err)
+	}
+
+	// 密钥ID不同
+	raw[2] = 0x99
+	content = "untrusted comment: other key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+	if err := os.WriteFile(pubPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if verifier, err = NewVerifier(pubPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFileSignature(verifier, path); err == nil || !strings.Contains(err.Error(), testMinisignKeyID) {
+		t.Errorf("密钥ID不一致时期望在错误中给出签名的密钥ID, got %v", err)
+	}
+}
+
+func TestVerifyTamperedTrustedComment(t *testing.T) {
+	path := copyTestSignFile(t)
+	t.Setenv("GOB_TEST_PASSPHRASE", testSignPassphrase)
+	signer, err := NewSigner(types.SignConfig{Method: "minisign", KeyFile: testSignFile("minisign.key"), PassphraseEnv: "GOB_TEST_PASSPHRASE", Prehash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.Sign(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(sig), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		t.Fatalf("minisign签名应包含可信注释:\n%s", sig)
+	}
+	lines[2] += " tampered"
+
+	verifier, err := NewVerifier(testSignFile("minisign.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(path, []byte(strings.Join(lines, "\n"))); err == nil {
+		t.Error("可信注释被修改后期望校验失败")
+	}
+}
+
+func TestNewSignerPassphrase(t *testing.T) {
+	for _, tt := range []struct{ method, key string }{
+		{"minisign", "minisign.key"},
+		{"openpgp", "openpgp-ed25519.key"},
+		{"openpgp", "openpgp-rsa.key"},
+	} {
+		cfg := types.SignConfig{Method: tt.method, KeyFile: testSignFile(tt.key)}
+		_, err := NewSigner(cfg)
+		if !errors.Is(err, errPassphraseRequired) || !strings.Contains(err.Error(), "passphrase_env") {
+			t.Errorf("%s: 未配置口令环境变量时期望提示 passphrase_env, got %v", tt.key, err)
+		}
+
+		cfg.PassphraseEnv = "GOB_TEST_PASSPHRASE"
+		t.Setenv("GOB_TEST_PASSPHRASE", "")
+		_, err = NewSigner(cfg)
+		if !errors.Is(err, errPassphraseRequired) || !strings.Contains(err.Error(), "GOB_TEST_PASSPHRASE") {
+			t.Errorf("%s: 口令环境变量为空时期望提示设置该变量, got %v", tt.key, err)
+		}
+
+		t.Setenv("GOB_TEST_PASSPHRASE", "wrong-passphrase")
+		if _, err := NewSigner(cfg); !errors.Is(err, errWrongPassphrase) {
+			t.Errorf("%s: 口令错误时期望返回 errWrongPassphrase, got %v", tt.key, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "wrong-passphrase") {
+			t.Errorf("%s: 错误信息不应包含口令", tt.key)
+		}
+	}
+}
+
+func TestNewSignerKeyEnv(t *testing.T) {
+	data, err := os.ReadFile(testSignFile("minisign.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOB_TEST_KEY", string(data))
+	t.Setenv("GOB_TEST_PASSPHRASE", testSignPassphrase)
+	signer, err := NewSigner(types.SignConfig{Method: "minisign", KeyFile: "missing.key", KeyEnv: "GOB_TEST_KEY", PassphraseEnv: "GOB_TEST_PASSPHRASE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.KeyID() != testMinisignKeyID {
+		t.Errorf("密钥ID不正确: %s", signer.KeyID())
+	}
+
+	if _, err := NewSigner(types.SignConfig{Method: "ssh", KeyEnv: "GOB_TEST_KEY"}); err == nil {
+		t.Error("不支持的签名方式期望返回错误")
+	}
+	if _, err := NewVerifier(testSignFile("hello.txt")); err == nil {
+		t.Error("无效的公钥期望返回错误")
+	}
+}
+
+func TestVerifyFileSignatureMissing(t *testing.T) {
+	verifier, err := NewVerifier(testSignFile("minisign.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyFileSignature(verifier, copyTestSignFile(t))
+	if err == nil || !strings.Contains(err.Error(), "hello.txt.sig") {
+		t.Errorf("缺少签名文件时期望给出签名文件名, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/shellx"
+)
+
+// LoadDistTargets 查询当前Go工具链支持的目标平台列表
+//
+// 参数:
+//   - timeout: 命令超时时间
+//
+// 返回值:
+//   - map[string]types.DistTarget: 以 "平台/架构" 为键的目标平台
+//   - error: 执行 go tool dist list 或解析输出失败时返回错误
+func LoadDistTargets(timeout time.Duration) (map[string]types.DistTarget, error) {
+	output, err := shellx.NewCmds(types.GoDistListCmd.Cmds).WithTimeout(timeout).ExecStdout()
+	if err != nil {
+		return nil, fmt.Errorf("%s失败: %w", types.GoDistListCmd.Name, err)
+	}
+
+	var list []types.DistTarget
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("解析 %s 输出失败: %w", strings.Join(types.GoDistListCmd.Cmds, " "), err)
+	}
+
+	dist := make(map[string]types.DistTarget, len(list))
+	for _, t := range list {
+		dist[t.GOOS+"/"+t.GOARCH] = t
+	}
+	return dist, nil
+}
+
+// ResolveTargets 解析配置中的所有构建目标
+//
+// 参数:
+//   - config: 配置结构体
+//   - dist: 工具链支持的目标平台, 为nil时跳过工具链校验
+//
+// 返回值:
+//   - []types.MatrixEntry: 按顺序排列的构建目标列表
+//   - []string: 因工具链不支持而跳过的目标及原因
+//   - error: 目标模式无效或矩阵条目不受支持时返回错误
+//
+// 注意:
+//   - 配置了targets中的包含模式时, 以模式匹配结果代替平台×架构组合
+//   - 以 ! 开头的排除模式作用于平台×架构组合和包含模式的匹配结果
+//   - 矩阵条目与平台×架构组合相同时, 覆盖该组合(仅覆盖第一个条目)
+//   - 其余矩阵条目追加到列表末尾, 同一平台和架构可以出现多次(如不同的GOARM)
+//   - 自动生成的目标不受支持时跳过, 显式声明的矩阵条目不受支持时返回错误
+func ResolveTargets(config *types.GobConfig, dist map[string]types.DistTarget) ([]types.MatrixEntry, []string, error) {
+	includes, excludes, err := parseTargetPatterns(config.Build.Target.Targets)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var targets []types.MatrixEntry
+	var skipped []string
+	index := make(map[string]int) // 平台/架构 -> 平台×架构组合在列表中的位置
+
+	// 平台×架构组合, 配置了包含模式时使用模式匹配的结果
+	for _, pair := range basePairs(config, includes, dist) {
+		key := pair[0] + "/" + pair[1]
+		if _, ok := index[key]; ok || matchAny(excludes, key) {
+			continue
+		}
+
+		entry := types.MatrixEntry{GOOS: pair[0], GOARCH: pair[1]}
+		if reason := unsupportedReason(config, entry, dist); reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", key, reason))
+			continue
+		}
+
+		index[key] = len(targets)
+		targets = append(targets, entry)
+	}
+
+	// 矩阵条目
+	overridden := make(map[string]bool)
+	for i, entry := range config.Build.Target.Matrix {
+		if entry.GOOS == "" || entry.GOARCH == "" {
+			return nil, nil, fmt.Errorf("[[build.target.matrix]] 第 %d 个条目必须同时指定 goos 和 goarch", i+1)
+		}
+		if reason := unsupportedReason(config, entry, dist); reason != "" {
+			return nil, nil, fmt.Errorf("[[build.target.matrix]] 第 %d 个条目 %s 不可构建: %s", i+1, TargetName(entry), reason)
+		}
+
+		key := entry.GOOS + "/" + entry.GOARCH
+		if idx, ok := index[key]; ok && !overridden[key] {
+			targets[idx] = entry
+			overridden[key] = true
+			continue
+		}
+		targets = append(targets, entry)
+	}
+
+	return targets, skipped, nil
+}
+
+// TargetName 返回构建目标的显示名称
+//
+// 参数:
+//   - entry: 构建目标
+//
+// 返回值:
+//   - string: "平台/架构", 指定了输出文件名时为 "平台/架构 (输出文件名)"
+func TargetName(entry types.MatrixEntry) string {
+	if entry.Output != "" {
+		return fmt.Sprintf("%s/%s (%s)", entry.GOOS, entry.GOARCH, entry.Output)
+	}
+	return fmt.Sprintf("%s/%s", entry.GOOS, entry.GOARCH)
+}
+
+// parseTargetPatterns 解析并校验目标平台模式
+//
+// 参数:
+//   - patterns: 目标平台模式列表, 如 linux/*、*/arm64、!windows/386
+//
+// 返回值:
+//   - []string: 包含模式
+//   - []string: 排除模式(已去掉前缀 !)
+//   - error: 模式格式无效时返回错误
+func parseTargetPatterns(patterns []string) ([]string, []string, error) {
+	var includes, excludes []string
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		goos, goarch, ok := strings.Cut(pattern, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			return nil, nil, fmt.Errorf("目标平台模式 %q 无效, 格式应为 平台/架构, 如 linux/*", raw)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("目标平台模式 %q 无效: %w", raw, err)
+		}
+
+		if exclude {
+			excludes = append(excludes, pattern)
+		} else {
+			includes = append(includes, pattern)
+		}
+	}
+	return includes, excludes, nil
+}
+
+// basePairs 生成自动构建的平台和架构组合
+//
+// 参数:
+//   - config: 配置结构体
+//   - includes: 包含模式
+//   - dist: 工具链支持的目标平台, 为nil时包含模式匹配默认的平台和架构
+//
+// 返回值:
+//   - [][2]string: 按顺序排列的平台和架构组合
+func basePairs(config *types.GobConfig, includes []string, dist map[string]types.DistTarget) [][2]string {
+	var pairs [][2]string
+
+	// 未配置包含模式时使用平台×架构组合
+	if len(includes) == 0 {
+		for _, platform := range config.Build.Target.Platforms {
+			for _, arch := range config.Build.Target.Architectures {
+				pairs = append(pairs, [2]string{platform, arch})
+			}
+		}
+		return pairs
+	}
+
+	// 可供匹配的候选组合, 工具链不可用时退回默认的平台和架构
+	var candidates []string
+	if dist != nil {
+		candidates = slices.Sorted(maps.Keys(dist))
+	} else {
+		for _, platform := range types.DefaultPlatforms {
+			for _, arch := range types.DefaultArchs {
+				candidates = append(candidates, platform+"/"+arch)
+			}
+		}
+	}
+
+	// 按模式顺序展开, 不含通配符的模式原样保留以便给出明确的不支持提示
+	for _, pattern := range includes {
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			goos, goarch, _ := strings.Cut(pattern, "/")
+			pairs = append(pairs, [2]string{goos, goarch})
+			continue
+		}
+		for _, key := range candidates {
+			if ok, _ := path.Match(pattern, key); ok {
+				goos, goarch, _ := strings.Cut(key, "/")
+				pairs = append(pairs, [2]string{goos, goarch})
+			}
+		}
+	}
+	return pairs
+}
+
+// matchAny 检查目标是否匹配任意一个模式
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedReason 检查目标能否使用当前工具链和CGO设置构建
+//
+// 参数:
+//   - config: 配置结构体
+//   - entry: 构建目标
+//   - dist: 工具链支持的目标平台, 为nil时跳过检查
+//
+// 返回值:
+//   - string: 不可构建的原因, 可以构建时返回空字符串
+func unsupportedReason(config *types.GobConfig, entry types.MatrixEntry, dist map[string]types.DistTarget) string {
+	if dist == nil {
+		return ""
+	}
+
+	target, ok := dist[entry.GOOS+"/"+entry.GOARCH]
+	if !ok {
+		return "当前Go工具链不支持该平台和架构组合"
+	}
+
+	// 目标专属的CGO_ENABLED优先于全局的enable_cgo
+	cgo := config.Build.Compiler.EnableCgo
+	if v, ok := entry.Env["CGO_ENABLED"]; ok {
+		cgo = v == "1"
+	}
+
+	if cgo && !target.CgoSupported {
+		return "该目标不支持CGO"
+	}
+	if !cgo && requiresExternalLinking(entry.GOOS, entry.GOARCH) {
+		return "该目标需要CGO外部链接, 请启用CGO并配置交叉编译器"
+	}
+	return ""
+}
+
+// requiresExternalLinking 检查目标是否必须通过CGO外部链接
+//
+// 注意:
+//   - ios的所有架构以及android除arm64以外的架构都不支持Go内部链接
+func requiresExternalLinking(goos, goarch string) bool {
+	return goos == "ios" || (goos == "android" && goarch != "arm64")
+}
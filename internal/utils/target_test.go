@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// targetNames 返回构建目标的显示名称列表
+func targetNames(targets []types.MatrixEntry) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, TargetName(target))
+	}
+	return names
+}
+
+func TestResolveTargetsMatrix(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Build.Target.Platforms = []string{"linux", "windows"}
+	config.Build.Target.Architectures = []string{"amd64", "arm64"}
+	config.Build.Target.Matrix = []types.MatrixEntry{
+		{GOOS: "linux", GOARCH: "arm64", Env: map[string]string{"CC": "aarch64-linux-gnu-gcc"}, Tags: []string{"netgo"}},
+		{GOOS: "linux", GOARCH: "arm64", Ldflags: "-X main.variant=musl", Output: "myapp-musl"},
+		{GOOS: "linux", GOARCH: "arm", Env: map[string]string{"GOARM": "7"}, Output: "myapp-armv7"},
+	}
+
+	targets, skipped, err := ResolveTargets(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("未提供工具链目标时不应跳过任何目标, got %v", skipped)
+	}
+
+	// 第一个相同组合的矩阵条目覆盖原位置, 其余条目追加到末尾
+	want := []string{"linux/amd64", "linux/arm64", "windows/amd64", "windows/arm64", "linux/arm64 (myapp-musl)", "linux/arm (myapp-armv7)"}
+	if got := targetNames(targets); !reflect.DeepEqual(got, want) {
+		t.Fatalf("构建目标 = %v, 期望 %v", got, want)
+	}
+	if !reflect.DeepEqual(targets[1], config.Build.Target.Matrix[0]) {
+		t.Errorf("矩阵条目应覆盖相同的平台×架构组合, got %+v", targets[1])
+	}
+	if targets[4].Ldflags != "-X main.variant=musl" || targets[5].Env["GOARM"] != "7" {
+		t.Errorf("追加的矩阵条目应保留覆盖项: %+v %+v", targets[4], targets[5])
+	}
+}
+
+func TestResolveTargetsMatrixOnly(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Build.Target.Platforms = nil
+	config.Build.Target.Matrix = []types.MatrixEntry{{GOOS: "linux", GOARCH: "riscv64"}}
+
+	targets, _, err := ResolveTargets(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := targetNames(targets); !reflect.DeepEqual(got, []string{"linux/riscv64"}) {
+		t.Errorf("未配置平台时只构建矩阵条目, got %v", got)
+	}
+}
+
+func TestResolveTargetsMatrixErrors(t *testing.T) {
+	for _, entry := range []types.MatrixEntry{{GOOS: "linux"}, {GOARCH: "amd64"}} {
+		config := GetDefaultConfig()
+		config.Build.Target.Matrix = []types.MatrixEntry{{GOOS: "linux", GOARCH: "amd64"}, entry}
+		_, _, err := ResolveTargets(config, nil)
+		if err == nil || !strings.Contains(err.Error(), "第 2 个条目") {
+			t.Errorf("矩阵条目 %+v 缺少平台或架构时期望返回错误, got %v", entry, err)
+		}
+	}
+}
+
+// testDist 模拟 go tool dist list -json 的部分输出
+var testDist = map[string]types.DistTarget{
+	"android/amd64": {GOOS: "android", GOARCH: "amd64", CgoSupported: true},
+	"android/arm64": {GOOS: "android", GOARCH: "arm64", CgoSupported: true},
+	"darwin/amd64":  {GOOS: "darwin", GOARCH: "amd64", CgoSupported: true, FirstClass: true},
+	"darwin/arm64":  {GOOS: "darwin", GOARCH: "arm64", CgoSupported: true, FirstClass: true},
+	"ios/arm64":     {GOOS: "ios", GOARCH: "arm64", CgoSupported: true},
+	"js/wasm":       {GOOS: "js", GOARCH: "wasm"},
+	"linux/386":     {GOOS: "linux", GOARCH: "386", CgoSupported: true, FirstClass: true},
+	"linux/amd64":   {GOOS: "linux", GOARCH: "amd64", CgoSupported: true, FirstClass: true},
+	"linux/arm64":   {GOOS: "linux", GOARCH: "arm64", CgoSupported: true, FirstClass: true},
+	"windows/386":   {GOOS: "windows", GOARCH: "386", CgoSupported: true, FirstClass: true},
+	"windows/amd64": {GOOS: "windows", GOARCH: "amd64", CgoSupported: true, FirstClass: true},
+}
+
+func TestResolveTargetsPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		dist     map[string]types.DistTarget
+		want     []string
+		skipped  int
+	}{
+		{"通配符按工具链目标展开", []string{"linux/*"}, testDist, []string{"linux/386", "linux/amd64", "linux/arm64"}, 0},
+		{"排除模式", []string{"*/amd64", "!android/*", "!darwin/amd64"}, testDist, []string{"linux/amd64", "windows/amd64"}, 0},
+		{"模式按顺序展开并去重", []string{"windows/386", "*/386"}, testDist, []string{"windows/386", "linux/386"}, 0},
+		{"不含通配符的模式原样保留", []string{"plan9/amd64"}, testDist, nil, 1},
+		{"工具链不可用时匹配默认平台", []string{"*/arm64"}, nil, []string{"darwin/arm64", "linux/arm64", "windows/arm64"}, 0},
+		{"只有排除模式时作用于平台×架构组合", []string{"!windows/*"}, testDist, []string{"darwin/amd64", "darwin/arm64", "linux/amd64", "linux/arm64"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetDefaultConfig()
+			config.Build.Target.Platforms = []string{"darwin", "linux", "windows"}
+			config.Build.Target.Architectures = []string{"amd64", "arm64"}
+			config.Build.Target.Targets = tt.patterns
+
+			targets, skipped, err := ResolveTargets(config, tt.dist)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := targetNames(targets); !slices.Equal(got, tt.want) {
+				t.Errorf("构建目标 = %v, 期望 %v", got, tt.want)
+			}
+			if len(skipped) != tt.skipped {
+				t.Errorf("跳过的目标 = %v, 期望 %d 个", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestResolveTargetsSkipsUnsupported(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Build.Target.Platforms = []string{"darwin", "windows", "ios", "android"}
+	config.Build.Target.Architectures = []string{"arm64"}
+
+	targets, skipped, err := ResolveTargets(config, testDist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := targetNames(targets); !reflect.DeepEqual(got, []string{"darwin/arm64", "android/arm64"}) {
+		t.Errorf("构建目标 = %v", got)
+	}
+	want := []string{
+		"windows/arm64 (当前Go工具链不支持该平台和架构组合)",
+		"ios/arm64 (该目标需要CGO外部链接, 请启用CGO并配置交叉编译器)",
+	}
+	if !reflect.DeepEqual(skipped, want) {
+		t.Errorf("跳过的目标 = %v, 期望 %v", skipped, want)
+	}
+
+	// 启用CGO后 ios 可以构建, 不支持CGO的目标被跳过
+	config.Build.Compiler.EnableCgo = true
+	config.Build.Target.Platforms = []string{"ios", "js"}
+	config.Build.Target.Architectures = []string{"arm64", "wasm"}
+	if targets, skipped, err = ResolveTargets(config, testDist); err != nil {
+		t.Fatal(err)
+	}
+	if got := targetNames(targets); !reflect.DeepEqual(got, []string{"ios/arm64"}) {
+		t.Errorf("启用CGO后构建目标 = %v", got)
+	}
+	if len(skipped) != 3 || skipped[2] != "js/wasm (该目标不支持CGO)" {
+		t.Errorf("启用CGO后跳过的目标 = %v", skipped)
+	}
+}
+
+func TestResolveTargetsMatrixUnsupported(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Build.Target.Platforms = nil
+	config.Build.Target.Matrix = []types.MatrixEntry{{GOOS: "windows", GOARCH: "arm64"}}
+	if _, _, err := ResolveTargets(config, testDist); err == nil || !strings.Contains(err.Error(), "不可构建") {
+		t.Errorf("显式声明的矩阵条目不受支持时期望返回错误, got %v", err)
+	}
+
+	// 目标专属的CGO_ENABLED优先于全局设置
+	config.Build.Target.Matrix = []types.MatrixEntry{{GOOS: "js", GOARCH: "wasm", Env: map[string]string{"CGO_ENABLED": "1"}}}
+	if _, _, err := ResolveTargets(config, testDist); err == nil || !strings.Contains(err.Error(), "不支持CGO") {
+		t.Errorf("目标启用CGO但不支持时期望返回错误, got %v", err)
+	}
+	config.Build.Compiler.EnableCgo = true
+	config.Build.Target.Matrix[0].Env["CGO_ENABLED"] = "0"
+	if _, _, err := ResolveTargets(config, testDist); err != nil {
+		t.Errorf("目标关闭CGO后应可以构建: %v", err)
+	}
+}
+
+func TestParseTargetPatternsErrors(t *testing.T) {
+	for _, pattern := range []string{"linux", "/amd64", "linux/", "!linux", "linux/amd64/v3", "linux/[", ""} {
+		if _, _, err := parseTargetPatterns([]string{pattern}); err == nil {
+			t.Errorf("目标平台模式 %q 无效, 期望返回错误", pattern)
+		}
+	}
+	includes, excludes, err := parseTargetPatterns([]string{" linux/* ", "!linux/386"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(includes, []string{"linux/*"}) || !reflect.DeepEqual(excludes, []string{"linux/386"}) {
+		t.Errorf("包含模式 %v, 排除模式 %v", includes, excludes)
+	}
+}
+
+func TestLoadDistTargets(t *testing.T) {
+	dist, err := LoadDistTargets(time.Minute)
+	if err != nil {
+		t.Skipf("当前环境无法执行 go tool dist list: %v", err)
+	}
+	target, ok := dist[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok || target.GOOS != runtime.GOOS || target.GOARCH != runtime.GOARCH {
+		t.Errorf("工具链目标中应包含当前平台, got %+v", target)
+	}
+	// darwin 是工具链支持的目标, 不应再被硬编码跳过
+	if _, ok := dist["darwin/arm64"]; !ok {
+		t.Error("工具链目标中应包含 darwin/arm64")
+	}
+}
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// legacyVendorPlaceholder 旧版本的条件vendor占位符, 未启用vendor时需要输出-mod=readonly
+const legacyVendorPlaceholder = "{{if UseVendor}}-mod=vendor{{end}}"
+
+var (
+	// describeSuffixRegexp 匹配 git describe 输出中的 -提交数-g哈希 后缀
+	describeSuffixRegexp = regexp.MustCompile(`-\d+-g[0-9a-f]+$`)
+
+	// semverRegexp 匹配语义化版本号(不含前缀v)
+	semverRegexp = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+// NewTemplateData 根据构建上下文创建模板数据
+//
+// 参数:
+//   - bc: 构建上下文
+//   - envs: 构建使用的环境变量列表, 后出现的同名变量优先
+//
+// 返回值:
+//   - *types.TemplateData: 模板数据, Ldflags和Output需要在渲染后由调用方填充
+func NewTemplateData(bc *types.BuildContext, envs []string) *types.TemplateData {
+	data := &types.TemplateData{
+		Target: types.TemplateTarget{
+			OS:   bc.SysPlatform,
+			Arch: bc.SysArch,
+			Tags: bc.Tags,
+		},
+		Git:      types.TemplateGit{AppName: bc.Config.Build.Output.Name},
+		Env:      make(map[string]string, len(envs)),
+		Vars:     make(map[string]string, len(bc.Config.VarValues)),
+		Config:   bc.Config,
+		MainFile: MainFileArg(bc.Config),
+	}
+
+	for _, env := range envs {
+		if k, v, ok := strings.Cut(env, "="); ok {
+			data.Env[k] = v
+		}
+	}
+
+	maps.Copy(data.Vars, bc.Config.VarValues)
+
+	// 仅在启用Git信息注入时填充Git元数据
+	if bc.Config.Build.Git.Inject && bc.VerMan != nil {
+		data.Git = GitTemplateData(bc.VerMan, bc.Config)
+	}
+
+	return data
+}
+
+// RenderTemplate 使用text/template渲染字符串
+//
+// 参数:
+//   - text: 模板字符串
+//   - data: 模板数据
+//
+// 返回值:
+//   - string: 渲染后的字符串
+//   - error: 模板解析或执行失败时返回错误
+//
+// 注意:
+//   - 不包含 {{ 的字符串原样返回
+//   - 兼容旧版本的占位符, 如 {{ldflags}}、{{output}}、{{mainFile}}、{{tags}}、{{GitVersion}} 等, 以函数形式提供
+func RenderTemplate(text string, data *types.TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	// 旧版本的条件vendor占位符在未启用vendor时输出-mod=readonly
+	text = strings.ReplaceAll(text, legacyVendorPlaceholder, "{{if UseVendor}}-mod=vendor{{else}}-mod=readonly{{end}}")
+
+	tmpl, err := template.New("gob").Funcs(templateFuncs(data)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("解析模板 %q 失败: %w", text, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("渲染模板 %q 失败: %w", text, err)
+	}
+	return sb.String(), nil
+}
+
+// RenderTemplates 使用text/template渲染字符串列表
+//
+// 参数:
+//   - texts: 模板字符串列表
+//   - data: 模板数据
+//
+// 返回值:
+//   - []string: 渲染后的字符串列表, 渲染结果为空的元素会被移除
+//   - error: 任一模板渲染失败时返回错误
+func RenderTemplates(texts []string, data *types.TemplateData) ([]string, error) {
+	rendered := make([]string, 0, len(texts))
+	for _, text := range texts {
+		s, err := RenderTemplate(text, data)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		rendered = append(rendered, s)
+	}
+	return rendered, nil
+}
+
+// templateFuncs 返回模板可用的函数
+//
+// 参数:
+//   - data: 模板数据, 兼容旧版本占位符的函数从中取值
+//
+// 返回值:
+//   - template.FuncMap: 模板函数映射
+func templateFuncs(data *types.TemplateData) template.FuncMap {
+	return template.FuncMap{
+		// 字符串处理
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"default":    defaultValue,
+		"now":        now,
+		"semver":     semver,
+		"env":        func(key string) string { return data.Env[key] },
+
+		// 兼容旧版本的占位符
+		"ldflags":       func() string { return fmt.Sprintf("\"%s\"", data.Ldflags) },
+		"output":        func() string { return data.Output },
+		"mainFile":      func() string { return data.MainFile },
+		"UseVendor":     func() bool { return data.Config.Build.Source.UseVendor },
+		"tags":          func() string { return tagsArg(data.Target.Tags) },
+		"AppName":       func() string { return data.Git.AppName },
+		"GitVersion":    func() string { return data.Git.Version },
+		"GitCommit":     func() string { return data.Git.Commit },
+		"GitCommitTime": func() string { return data.Git.CommitTime },
+		"BuildTime":     func() string { return data.Git.BuildTime },
+		"GitTreeState":  func() string { return data.Git.TreeState },
+	}
+}
+
+// tagsArg 生成构建标签参数
+//
+// 参数:
+//   - tags: 构建标签列表
+//
+// 返回值:
+//   - string: -tags=a,b 形式的参数, 无标签时返回空字符串
+func tagsArg(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "-tags=" + strings.Join(tags, ",")
+}
+
+// defaultValue 值为空时返回默认值, 用法: {{default "dev" .Env.CHANNEL}}
+func defaultValue(def any, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case []string:
+		if len(v) == 0 {
+			return def
+		}
+	case bool:
+		if !v {
+			return def
+		}
+	}
+	return value
+}
+
+// now 返回当前时间, 可指定Go时间格式, 默认使用RFC3339, 用法: {{now "20060102"}}
+func now(layout ...string) string {
+	if len(layout) > 0 && layout[0] != "" {
+		return time.Now().Format(layout[0])
+	}
+	return time.Now().Format(time.RFC3339)
+}
+
+// semver 从 git describe 的输出中提取语义化版本号
+//
+// 参数:
+//   - version: 版本字符串, 如 v1.2.0-5-gabc123-dirty
+//
+// 返回值:
+//   - string: 去掉前缀v和 git describe 后缀的版本号, 如 1.2.0; 无法解析时原样返回
+func semver(version string) string {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	v = strings.TrimSuffix(v, "-dirty")
+	v = describeSuffixRegexp.ReplaceAllString(v, "")
+	if !semverRegexp.MatchString(v) {
+		return version
+	}
+	return v
+}
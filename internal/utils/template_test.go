@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/verman"
+)
+
+// newTestTemplateData 创建用于模板测试的数据
+func newTestTemplateData() *types.TemplateData {
+	config := GetDefaultConfig()
+	config.Build.Output.Name = "myapp"
+	return &types.TemplateData{
+		Target:   types.TemplateTarget{OS: "linux", Arch: "arm64", Tags: []string{"netgo", "osusergo"}},
+		Git:      types.TemplateGit{AppName: "myapp", Version: "v1.2.3-4-gabc1234-dirty", Commit: "abc1234", CommitTime: "2024-01-01", BuildTime: "2024-01-02", TreeState: "dirty"},
+		Env:      map[string]string{"CHANNEL": "beta", "EMPTY": ""},
+		Vars:     map[string]string{"region": "cn"},
+		Config:   config,
+		MainFile: "main.go",
+		Ldflags:  "-s -w",
+		Output:   "output/myapp",
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"不含模板原样返回", "echo }} {.Target.OS}", "echo }} {.Target.OS}"},
+		{"纯文本", "go build", "go build"},
+		{"目标", "{{.Target.OS}}/{{.Target.Arch}}", "linux/arm64"},
+		{"Git", "{{.Git.Version}} {{.Git.Commit}}", "v1.2.3-4-gabc1234-dirty abc1234"},
+		{"环境变量", "{{.Env.CHANNEL}}", "beta"},
+		{"自定义变量", "{{.Vars.region}}", "cn"},
+		{"配置", "{{.Config.Build.Output.Name}}", "myapp"},
+		{"入口和输出", "{{.MainFile}} {{.Output}} {{.Ldflags}}", "main.go output/myapp -s -w"},
+		{"upper", `{{upper .Target.OS}}`, "LINUX"},
+		{"lower", `{{lower "ABC"}}`, "abc"},
+		{"trim", `{{trim "  a  "}}`, "a"},
+		{"trimPrefix", `{{trimPrefix "v" "v1.0.0"}}`, "1.0.0"},
+		{"trimSuffix", `{{trimSuffix ".exe" "app.exe"}}`, "app"},
+		{"replace", `{{replace "/" "-" "a/b/c"}}`, "a-b-c"},
+		{"contains", `{{if contains "arm" .Target.Arch}}arm{{end}}`, "arm"},
+		{"hasPrefix", `{{if hasPrefix "li" .Target.OS}}yes{{end}}`, "yes"},
+		{"hasSuffix", `{{if hasSuffix "64" .Target.Arch}}yes{{end}}`, "yes"},
+		{"join", `{{join "," .Target.Tags}}`, "netgo,osusergo"},
+		{"split", `{{index (split "." "a.b.c") 1}}`, "b"},
+		{"default 空字符串", `{{default "stable" .Env.EMPTY}}`, "stable"},
+		{"default 缺失的键", `{{default "stable" .Env.MISSING}}`, "stable"},
+		{"default 非空", `{{default "stable" .Env.CHANNEL}}`, "beta"},
+		{"env", `{{env "CHANNEL"}}`, "beta"},
+		{"env 不存在", `{{env "MISSING"}}`, ""},
+		{"semver", `{{semver .Git.Version}}`, "1.2.3"},
+		{"兼容 ldflags", "{{ldflags}}", `"-s -w"`},
+		{"兼容 output", "{{output}}", "output/myapp"},
+		{"兼容 mainFile", "{{mainFile}}", "main.go"},
+		{"兼容 tags", "{{tags}}", "-tags=netgo,osusergo"},
+		{"兼容 vendor 占位符", "{{if UseVendor}}-mod=vendor{{end}}", "-mod=readonly"},
+		{"兼容 Git 占位符", "{{AppName}} {{GitVersion}} {{GitCommit}} {{GitCommitTime}} {{BuildTime}} {{GitTreeState}}", "myapp v1.2.3-4-gabc1234-dirty abc1234 2024-01-01 2024-01-02 dirty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.text, newTestTemplateData())
+			if err != nil {
+				t.Fatalf("RenderTemplate(%q) 返回错误: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, 期望 %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateVendor(t *testing.T) {
+	data := newTestTemplateData()
+	data.Config.Build.Source.UseVendor = true
+	data.Target.Tags = nil
+
+	got, err := RenderTemplate("go build {{tags}} {{if UseVendor}}-mod=vendor{{end}}", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "go build  -mod=vendor"; got != want {
+		t.Errorf("got %q, 期望 %q", got, want)
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	for _, text := range []string{"{{.Target.OS", "{{nope}}", "{{.Nope}}"} {
+		if _, err := RenderTemplate(text, newTestTemplateData()); err == nil {
+			t.Errorf("RenderTemplate(%q) 期望返回错误", text)
+		}
+	}
+}
+
+func TestRenderTemplates(t *testing.T) {
+	got, err := RenderTemplates([]string{"a {{.Target.OS}}", "{{if false}}x{{end}}", "  ", "b"}, newTestTemplateData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "a linux|b" {
+		t.Errorf("渲染结果为空的元素应被移除, got %q", got)
+	}
+
+	if _, err := RenderTemplates([]string{"ok", "{{bad"}, newTestTemplateData()); err == nil {
+		t.Error("任一模板无效时期望返回错误")
+	}
+}
+
+func TestTemplateNow(t *testing.T) {
+	got, err := RenderTemplate(`{{now "20060102"}}`, newTestTemplateData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^\d{8}$`).MatchString(got) {
+		t.Errorf(`{{now "20060102"}} = %q`, got)
+	}
+
+	got, err = RenderTemplate(`{{now}}`, newTestTemplateData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse(time.RFC3339, got); err != nil {
+		t.Errorf("{{now}} 默认应为RFC3339格式, got %q", got)
+	}
+}
+
+func TestSemverFunc(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.0":                 "1.2.0",
+		"1.2.0":                  "1.2.0",
+		"v1.2.0-dirty":           "1.2.0",
+		"v1.2.0-5-gabc123":       "1.2.0",
+		"v1.2.0-5-gabc123-dirty": "1.2.0",
+		"v1.3.0-rc.1":            "1.3.0-rc.1",
+		"v1.3.0-rc.1-2-gdeadbee": "1.3.0-rc.1",
+		"v1.0.0+build.5":         "1.0.0+build.5",
+		"abc1234":                "abc1234",
+		"unknown":                "unknown",
+		"release-2024":           "release-2024",
+	}
+	for in, want := range tests {
+		if got := semver(in); got != want {
+			t.Errorf("semver(%q) = %q, 期望 %q", in, got, want)
+		}
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  any
+	}{
+		{nil, "def"},
+		{"", "def"},
+		{"x", "x"},
+		{[]string{}, "def"},
+		{false, "def"},
+		{true, true},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		got := defaultValue("def", tt.value)
+		if s, ok := tt.want.([]string); ok {
+			if len(got.([]string)) != len(s) {
+				t.Errorf("defaultValue(%v) = %v, 期望 %v", tt.value, got, tt.want)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("defaultValue(%v) = %v, 期望 %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNewTemplateData(t *testing.T) {
+	config := GetDefaultConfig()
+	config.Build.Output.Name = "myapp"
+	config.VarValues = map[string]string{"region": "cn"}
+	v := &verman.Info{AppName: "myapp", GitVersion: "v1.0.0", GitCommit: "abc1234"}
+	bc := &types.BuildContext{VerMan: v, SysPlatform: "windows", SysArch: "amd64", Config: config, Tags: []string{"a"}}
+
+	data := NewTemplateData(bc, []string{"A=1", "B=x=y", "A=2", "INVALID"})
+	if data.Env["A"] != "2" || data.Env["B"] != "x=y" {
+		t.Errorf("后出现的同名变量应优先且值可包含=, got %v", data.Env)
+	}
+	if _, ok := data.Env["INVALID"]; ok {
+		t.Error("不含=的环境变量应被忽略")
+	}
+	if data.Target.OS != "windows" || data.Target.Arch != "amd64" || data.Vars["region"] != "cn" || data.MainFile != config.Build.Source.MainFile {
+		t.Errorf("模板数据不正确: %+v", data)
+	}
+	if data.Git.AppName != "myapp" || data.Git.Version != "" {
+		t.Errorf("未启用Git信息注入时只填充应用名称, got %+v", data.Git)
+	}
+
+	config.Build.Git.Inject = true
+	data = NewTemplateData(bc, nil)
+	if data.Git.Version != "v1.0.0" || data.Git.Commit != "abc1234" {
+		t.Errorf("启用Git信息注入时应填充Git元数据, got %+v", data.Git)
+	}
+}
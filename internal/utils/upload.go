@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// maxRetryDelay 重试的最长等待时间
+const maxRetryDelay = 30 * time.Second
+
+// bodyFunc 创建请求体, 返回内容、内容类型和长度
+// 每次尝试请求时调用一次, 返回的内容实现 io.Closer 时在请求结束后关闭
+type bodyFunc func() (io.Reader, string, int64, error)
+
+// httpStatusError 表示服务端返回的错误响应
+type httpStatusError struct {
+	method  string // 请求方法
+	url     string // 去掉查询参数的请求地址
+	status  int    // 状态码
+	message string // 响应内容
+}
+
+func (e *httpStatusError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("%s %s 返回 %d", e.method, e.url, e.status)
+	}
+	return fmt.Sprintf("%s %s 返回 %d: %s", e.method, e.url, e.status, e.message)
+}
+
+// HTTPUploader 通过HTTP PUT上传文件
+type HTTPUploader struct {
+	username string            // 基本认证的用户名
+	password string            // 基本认证的密码
+	token    string            // Bearer令牌, 设置后不使用基本认证
+	headers  map[string]string // 附加的请求头
+	retries  int               // 重试次数
+	backoff  time.Duration     // 首次重试的等待时间, 之后每次翻倍
+	client   *http.Client      // HTTP客户端
+}
+
+// NewHTTPUploader 创建HTTP PUT上传器
+//
+// 参数:
+//   - cfg: HTTP上传配置
+//
+// 返回值:
+//   - *HTTPUploader: 上传器
+//   - error: 配置的认证环境变量未设置时返回错误
+func NewHTTPUploader(cfg types.HTTPUploadConfig) (*HTTPUploader, error) {
+	u := &HTTPUploader{
+		headers: cfg.Headers,
+		retries: max(cfg.Retries, 0),
+		backoff: time.Second,
+		client:  &http.Client{},
+	}
+
+	var err error
+	if cfg.TokenEnv != "" {
+		if u.token, err = requireEnv(cfg.TokenEnv); err != nil {
+			return nil, err
+		}
+	} else if cfg.UsernameEnv != "" {
+		if u.username, err = requireEnv(cfg.UsernameEnv); err != nil {
+			return nil, err
+		}
+		if u.password, err = requireEnv(cfg.PasswordEnv); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// Upload 将文件上传到指定地址
+//
+// 参数:
+//   - ctx: 上下文
+//   - target: 上传地址
+//   - path: 文件路径
+//
+// 返回值:
+//   - error: 重试用尽或遇到不可重试的响应时返回错误
+func (u *HTTPUploader) Upload(ctx context.Context, target, path string) error {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("无效的上传地址 %q", target)
+	}
+	display := redactURL(parsed)
+
+	return retryRequest(ctx, u.retries, u.backoff, func() (bool, error) {
+		return sendRequest(ctx, u.client, http.MethodPut, target, display, rawFileBody(path), u.authorize, nil)
+	})
+}
+
+// authorize 设置附加请求头和认证信息
+func (u *HTTPUploader) authorize(req *http.Request) error {
+	for _, name := range slices.Sorted(maps.Keys(u.headers)) {
+		req.Header.Set(name, u.headers[name])
+	}
+	switch {
+	case u.token != "":
+		req.Header.Set("Authorization", "Bearer "+u.token)
+	case u.username != "":
+		req.SetBasicAuth(u.username, u.password)
+	}
+	return nil
+}
+
+// requireEnv 读取必须设置的环境变量
+func requireEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("环境变量 %s 未设置", name)
+	}
+	return value, nil
+}
+
+// retryRequest 执行请求, 失败且可以重试时按指数退避重试
+//
+// 参数:
+//   - ctx: 上下文
+//   - retries: 重试次数
+//   - backoff: 首次重试的等待时间, 之后每次翻倍, 最长为30秒
+//   - send: 发送一次请求, 返回失败时是否可以重试
+//
+// 返回值:
+//   - error: 重试用尽或遇到不可重试的错误时返回错误
+func retryRequest(ctx context.Context, retries int, backoff time.Duration, send func() (bool, error)) error {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(min(backoff<<(attempt-1), maxRetryDelay)):
+			}
+		}
+
+		retry, err := send()
+		if err == nil {
+			return nil
+		}
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("重试 %d 次后仍然失败: %w", retries, lastErr)
+}
+
+// sendRequest 发送一次请求
+//
+// 参数:
+//   - ctx: 上下文
+//   - client: HTTP客户端
+//   - method: 请求方法
+//   - target: 请求地址
+//   - display: 用于错误信息的地址, 不应包含凭据
+//   - body: 请求体, 为nil时不发送请求体
+//   - prepare: 发送前设置请求头或签名, 为nil时不处理
+//   - handle: 处理成功的响应, 为nil时忽略响应内容
+//
+// 返回值:
+//   - bool: 失败时是否可以重试, 网络错误、429和5xx响应可以重试
+//   - error: 错误信息
+func sendRequest(ctx context.Context, client *http.Client, method, target, display string, body bodyFunc, prepare func(*http.Request) error, handle func(*http.Response) error) (bool, error) {
+	var reader io.Reader
+	var contentType string
+	var length int64
+	if body != nil {
+		var err error
+		if reader, contentType, length, err = body(); err != nil {
+			return false, err
+		}
+		if closer, ok := reader.(io.Closer); ok {
+			defer func() { _ = closer.Close() }()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return false, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = length
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", "gob")
+	if prepare != nil {
+		if err := prepare(req); err != nil {
+			return false, err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// url.Error 中包含完整的请求地址, 只保留底层错误
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return true, fmt.Errorf("%s %s: %w", method, display, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		statusErr := &httpStatusError{method: method, url: display, status: resp.StatusCode, message: strings.TrimSpace(string(data))}
+		return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, statusErr
+	}
+	if handle == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	if err := handle(resp); err != nil {
+		return false, fmt.Errorf("%s %s: 解析响应失败: %w", method, display, err)
+	}
+	return false, nil
+}
+
+// decodeJSON 返回将JSON响应解析到out的处理函数
+//
+// 参数:
+//   - out: 解析目标, 为nil时返回nil
+//
+// 返回值:
+//   - func: 响应处理函数, 空响应不视为错误
+func decodeJSON(out any) func(*http.Response) error {
+	if out == nil {
+		return nil
+	}
+	return func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+}
+
+// redactURL 返回去掉用户信息和查询参数的地址, 用于输出和错误信息
+func redactURL(u *url.URL) string {
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawPath: u.RawPath}).String()
+}
+
+// rawFileBody 返回以文件内容作为请求体的函数
+func rawFileBody(path string) bodyFunc {
+	return func() (io.Reader, string, int64, error) {
+		f, info, err := openRegularFile(path)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		return f, "application/octet-stream", info.Size(), nil
+	}
+}
+
+// multipartFileBody 返回以multipart表单上传文件的请求体函数
+//
+// 参数:
+//   - path: 文件路径
+//   - field: 表单字段名
+//
+// 返回值:
+//   - bodyFunc: 每次调用重新打开文件, 以流的方式发送表单头、文件内容和结束边界
+func multipartFileBody(path, field string) bodyFunc {
+	return func() (io.Reader, string, int64, error) {
+		f, info, err := openRegularFile(path)
+		if err != nil {
+			return nil, "", 0, err
+		}
+
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		if _, err := mw.CreateFormFile(field, filepath.Base(path)); err != nil {
+			_ = f.Close()
+			return nil, "", 0, err
+		}
+		head := bytes.Clone(buf.Bytes())
+		buf.Reset()
+		if err := mw.Close(); err != nil {
+			_ = f.Close()
+			return nil, "", 0, err
+		}
+		tail := buf.Bytes()
+
+		reader := struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), f, bytes.NewReader(tail)), f}
+		return reader, mw.FormDataContentType(), int64(len(head)) + info.Size() + int64(len(tail)), nil
+	}
+}
+
+// openRegularFile 打开普通文件并返回其信息
+func openRegularFile(path string) (*os.File, os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开文件 %s 失败: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("%s 不是普通文件", path)
+	}
+	return f, info, nil
+}
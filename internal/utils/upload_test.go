@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+)
+
+// uploadRequest 模拟服务收到的上传请求
+type uploadRequest struct {
+	path          string
+	authorization string
+	contentType   string
+	contentLength int64
+	header        http.Header
+	body          string
+}
+
+// newUploadServer 启动模拟上传服务, statuses 依次为每次请求的响应状态码, 用尽后返回201
+func newUploadServer(t *testing.T, statuses ...int) (*httptest.Server, func() []uploadRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []uploadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		n := len(requests)
+		requests = append(requests, uploadRequest{
+			path:          r.URL.Path,
+			authorization: r.Header.Get("Authorization"),
+			contentType:   r.Header.Get("Content-Type"),
+			contentLength: r.ContentLength,
+			header:        r.Header,
+			body:          string(body),
+		})
+		mu.Unlock()
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if n < len(statuses) {
+			http.Error(w, http.StatusText(statuses[n]), statuses[n])
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []uploadRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+// newTestUploader 创建重试等待时间为1毫秒的上传器
+func newTestUploader(t *testing.T, cfg types.HTTPUploadConfig) *HTTPUploader {
+	t.Helper()
+	u, err := NewHTTPUploader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.backoff = time.Millisecond
+	return u
+}
+
+func TestHTTPUploaderAuth(t *testing.T) {
+	t.Setenv("GOB_TEST_TOKEN", "secret-token")
+	t.Setenv("GOB_TEST_USER", "alice")
+	t.Setenv("GOB_TEST_PASSWORD", "p@ss")
+
+	tests := []struct {
+		name string
+		cfg  types.HTTPUploadConfig
+		want string
+	}{
+		{"无认证", types.HTTPUploadConfig{}, ""},
+		{"Bearer令牌", types.HTTPUploadConfig{TokenEnv: "GOB_TEST_TOKEN", UsernameEnv: "GOB_TEST_USER"}, "Bearer secret-token"},
+		{"基本认证", types.HTTPUploadConfig{UsernameEnv: "GOB_TEST_USER", PasswordEnv: "GOB_TEST_PASSWORD"}, "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:p@ss"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newUploadServer(t)
+			tt.cfg.Headers = map[string]string{"X-Checksum-Sha256": "abc"}
+			path := writeUploadFile(t, "artifact content")
+
+			if err := newTestUploader(t, tt.cfg).Upload(context.Background(), srv.URL+"/repo/v1.0.0/app.tar.gz", path); err != nil {
+				t.Fatal(err)
+			}
+			got := requests()
+			if len(got) != 1 {
+				t.Fatalf("期望发送1个请求, got %d", len(got))
+			}
+			r := got[0]
+			if r.path != "/repo/v1.0.0/app.tar.gz" || r.body != "artifact content" || r.contentLength != int64(len("artifact content")) || r.contentType != "application/octet-stream" {
+				t.Errorf("上传请求不正确: %+v", r)
+			}
+			if r.authorization != tt.want || r.header.Get("X-Checksum-Sha256") != "abc" || r.header.Get("User-Agent") != "gob" {
+				t.Errorf("请求头不正确: Authorization = %q, %v", r.authorization, r.header)
+			}
+		})
+	}
+}
+
+func TestNewHTTPUploaderMissingEnv(t *testing.T) {
+	t.Setenv("GOB_TEST_USER", "alice")
+	t.Setenv("GOB_TEST_PASSWORD", "")
+	for _, cfg := range []types.HTTPUploadConfig{
+		{TokenEnv: "GOB_TEST_MISSING_TOKEN"},
+		{UsernameEnv: "GOB_TEST_USER", PasswordEnv: "GOB_TEST_PASSWORD"},
+	} {
+		if _, err := NewHTTPUploader(cfg); err == nil || !strings.Contains(err.Error(), "未设置") {
+			t.Errorf("%+v: 环境变量未设置时期望返回错误, got %v", cfg, err)
+		}
+	}
+}
+
+func TestHTTPUploaderRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		requests int
+		want     string // 期望的错误信息, 为空时期望上传成功
+	}{
+		{"5xx后重试成功", []int{500, 503}, 3, 3, ""},
+		{"429后重试成功", []int{429}, 1, 2, ""},
+		{"重试用尽", []int{502, 502, 502}, 2, 3, "重试 2 次后仍然失败"},
+		{"4xx不重试", []int{403}, 3, 1, "返回 403: Forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newUploadServer(t, tt.statuses...)
+			path := writeUploadFile(t, "content")
+			err := newTestUploader(t, types.HTTPUploadConfig{Retries: tt.retries}).Upload(context.Background(), srv.URL+"/app.tar.gz", path)
+			if tt.want == "" && err != nil {
+				t.Errorf("期望上传成功, got %v", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("期望错误包含 %q, got %v", tt.want, err)
+			}
+			got := requests()
+			if len(got) != tt.requests {
+				t.Errorf("期望发送 %d 个请求, got %d", tt.requests, len(got))
+			}
+			// 每次重试重新发送完整的请求体
+			for _, r := range got {
+				if r.body != "content" {
+					t.Errorf("请求体 = %q", r.body)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPUploaderErrors(t *testing.T) {
+	srv, _ := newUploadServer(t, 401)
+	u := newTestUploader(t, types.HTTPUploadConfig{})
+	path := writeUploadFile(t, "content")
+
+	// 错误信息中不包含地址中的凭据和查询参数
+	target := strings.Replace(srv.URL, "http://", "http://user:secret@", 1) + "/app.tar.gz?token=secret"
+	err := u.Upload(context.Background(), target, path)
+	if err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), "/app.tar.gz 返回 401") {
+		t.Errorf("错误信息应隐藏凭据, got %v", err)
+	}
+
+	for _, target := range []string{"ftp://example.com/a", "/relative/path", "http://"} {
+		if err := u.Upload(context.Background(), target, path); err == nil || !strings.Contains(err.Error(), "无效的上传地址") {
+			t.Errorf("Upload(%q) 期望返回地址无效的错误, got %v", target, err)
+		}
+	}
+	if err := u.Upload(context.Background(), srv.URL+"/dir", t.TempDir()); err == nil || !strings.Contains(err.Error(), "不是普通文件") {
+		t.Errorf("上传目录时期望返回错误, got %v", err)
+	}
+}
+
+func TestRetryRequest(t *testing.T) {
+	errTemporary := errors.New("temporary")
+
+	attempts := 0
+	err := retryRequest(context.Background(), 2, time.Millisecond, func() (bool, error) {
+		attempts++
+		return true, errTemporary
+	})
+	if !errors.Is(err, errTemporary) || attempts != 3 {
+		t.Errorf("期望尝试3次并保留最后的错误, got %d 次, %v", attempts, err)
+	}
+
+	// 不可重试的错误直接返回
+	attempts = 0
+	errFatal := errors.New("fatal")
+	if err := retryRequest(context.Background(), 2, time.Millisecond, func() (bool, error) {
+		attempts++
+		return false, errFatal
+	}); err != errFatal || attempts != 1 {
+		t.Errorf("不可重试的错误应直接返回, got %d 次, %v", attempts, err)
+	}
+
+	// 等待重试时上下文取消
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts = 0
+	err = retryRequest(ctx, 3, time.Hour, func() (bool, error) {
+		attempts++
+		cancel()
+		return true, errTemporary
+	})
+	if err != errTemporary || attempts != 1 {
+		t.Errorf("上下文取消后不应继续重试, got %d 次, %v", attempts, err)
+	}
+}
@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -131,57 +133,113 @@ func CheckBaseEnv(config *types.GobConfig) error {
 //
 // 返回值：
 //   - error: 错误信息，如果获取成功则返回nil
+//
+// 注意:
+//   - 按 build.git.sources 的顺序使用第一个可用的来源, 所有来源都不可用时返回包含每个来源失败原因的错误
+//   - 语义化版本信息写入 c.GitSemver, 设置了标签前缀时Git版本中不包含该前缀
+//   - 当前提交是快照且配置了 build.git.snapshot 时, Git版本为该模板的渲染结果
+//   - 构建时间设置为当前时间, 格式为 RFC3339
 func GetGitMetaData(timeout time.Duration, v *verman.Info, c *types.GobConfig) error {
-	// 检查Git是否安装
-	if err := shellx.NewCmds([]string{"git", "--version"}).WithTimeout(timeout).Exec(); err != nil {
-		return fmt.Errorf("未检测到Git, 请先安装Git并确保其在PATH中: %w", err)
-	}
+	prefix := c.Build.Git.TagPrefix
 
-	// 检查当前目录是否为git仓库
-	if result, err := shellx.NewCmds(types.GitIsInsideWorkTreeCmd.Cmds).WithTimeout(timeout).ExecOutput(); err != nil {
-		if strings.Contains(string(result), "not a git repository") {
-			return fmt.Errorf("当前目录不是Git仓库, 请先执行`git init`初始化仓库: %w", err)
+	var meta types.GitMetaData
+	var sv types.GitSemver
+	var problems []error
+	found := false
+	for _, source := range c.Build.Git.Sources {
+		m, s, err := readGitSource(timeout, source, prefix)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", source, err))
+			continue
 		}
-		return fmt.Errorf("检查Git仓库状态失败: %w", err)
+		meta, sv, found = m, s, true
+		break
 	}
-
-	// 定义命令和对应字段的映射
-	commands := []struct {
-		cmd   types.CommandGroup
-		field *string
-	}{
-		{types.GitVersionCmd, &v.GitVersion},
-		{types.GitCommitHashCmd, &v.GitCommit},
-		{types.GitCommitTimeCmd, &v.GitCommitTime},
+	if !found {
+		return fmt.Errorf("所有来源均不可用 (build.git.sources):\n%w", errors.Join(problems...))
 	}
 
-	// 处理常规git信息
-	for _, item := range commands {
-		cmdResult, runErr := shellx.NewCmds(item.cmd.Cmds).WithTimeout(timeout).ExecOutput()
-		if runErr != nil {
-			return fmt.Errorf("%s: \n\t%s \n%w", item.cmd.Name, string(cmdResult), runErr)
+	v.AppName = c.Build.Output.Name
+	v.BuildTime = time.Now().Format(time.RFC3339)
+	v.GitVersion = strings.TrimPrefix(meta.GitVersion, prefix)
+	v.GitCommit = meta.GitCommit
+	v.GitCommitTime = meta.GitCommitTime
+	v.GitTreeState = meta.GitTreeState
+
+	sv.IsSnapshot = sv.Tag == "" || sv.CommitsSinceTag > 0 || v.GitTreeState == "dirty"
+	sv.Describe = v.GitVersion
+	c.GitSemver = sv
+
+	// 快照版本使用配置的版本号模板
+	if sv.IsSnapshot && c.Build.Git.Snapshot != "" {
+		data := &types.TemplateData{Git: GitTemplateData(v, c), Vars: c.VarValues, Config: c}
+		version, err := RenderTemplate(c.Build.Git.Snapshot, data)
+		if err != nil {
+			return fmt.Errorf("渲染快照版本号失败: %w", err)
+		}
+		if version = strings.TrimSpace(version); version == "" {
+			return fmt.Errorf("快照版本号模板 %q 的渲染结果为空", c.Build.Git.Snapshot)
 		}
-		// 设置字段值，并去除首尾空格
-		*item.field = strings.TrimSpace(string(cmdResult))
+		v.GitVersion = version
 	}
 
-	// 特殊处理git树状态
-	result, err := shellx.NewCmds(types.GitTreeStatusCmd.Cmds).WithTimeout(timeout).ExecOutput()
+	return nil
+}
+
+// SetUnknownGitMetaData 将Git元数据设置为 unknown
+//
+// 参数:
+//   - v: verman.Info 结构体指针
+//   - c: 配置对象, 语义化版本信息重置为快照版本
+//
+// 注意:
+//   - 用于 build.git.inject 为 true 但无法获取Git元数据时继续构建
+func SetUnknownGitMetaData(v *verman.Info, c *types.GobConfig) {
+	v.AppName = c.Build.Output.Name
+	v.BuildTime = time.Now().Format(time.RFC3339)
+	v.GitVersion = types.UnknownGitValue
+	v.GitCommit = types.UnknownGitValue
+	v.GitCommitTime = types.UnknownGitValue
+	v.GitTreeState = types.UnknownGitValue
+	c.GitSemver = types.GitSemver{IsSnapshot: true, Describe: types.UnknownGitValue}
+}
+
+// GetGitHeadCommit 获取当前提交的完整哈希值
+//
+// 参数:
+//   - timeout: 命令的超时时间
+//
+// 返回值:
+//   - string: 40位的提交哈希值
+//   - error: 错误信息
+func GetGitHeadCommit(timeout time.Duration) (string, error) {
+	result, err := shellx.NewCmds(types.GitFullCommitHashCmd.Cmds).WithTimeout(timeout).ExecOutput()
 	if err != nil {
-		return fmt.Errorf("%s: \n\t%s \n%w", types.GitTreeStatusCmd.Name, string(result), err)
+		return "", fmt.Errorf("%s: \n\t%s \n%w", types.GitFullCommitHashCmd.Name, string(result), err)
 	}
+	return strings.TrimSpace(string(result)), nil
+}
 
-	// 根据git树状态设置GitTreeState字段
-	if strings.TrimSpace(string(result)) == "" {
-		v.GitTreeState = "clean"
-	} else {
-		v.GitTreeState = "dirty"
+// gitOutput 执行git命令并返回去除首尾空白的输出
+//
+// 参数:
+//   - timeout: 命令的超时时间
+//   - group: git命令
+//   - args: 追加到命令之后的参数
+//
+// 返回值:
+//   - string: 命令的输出
+//   - error: 命令执行失败时返回包含输出的错误
+//
+// 注意:
+//   - 不经过shell直接执行, 参数中的空格、换行和通配符原样传给git
+func gitOutput(timeout time.Duration, group types.CommandGroup, args ...string) (string, error) {
+	cmds := append(slices.Clone(group.Cmds), args...)
+	result, err := shellx.NewCmds(cmds).WithTimeout(timeout).WithShell(shellx.ShellNone).ExecOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: \n\t%s \n%w", group.Name, string(result), err)
 	}
-
-	// 设置appName字段
-	v.AppName = c.Build.Output.Name
-
-	return nil
+	return strings.TrimSpace(string(result)), nil
 }
 
 // GetDefaultInstallPath 返回默认安装路径（多级回退策略）
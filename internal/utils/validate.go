@@ -0,0 +1,643 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// configProblems 收集配置校验发现的问题, 所有问题一次性返回
+type configProblems []error
+
+// add 按格式添加一个问题
+func (p *configProblems) add(format string, args ...any) {
+	*p = append(*p, fmt.Errorf(format, args...))
+}
+
+// err 返回由errors.Join合并的所有问题, 没有问题时返回nil
+func (p configProblems) err() error {
+	return errors.Join(p...)
+}
+
+// checkConfigKeys 严格解析单个配置文件, 检查未知的配置项和类型错误
+//
+// 参数:
+//   - filePath: 配置文件路径, 用于错误信息
+//   - content: 配置文件内容
+//   - migrated: 已迁移的旧配置项路径, 不作为未知配置项报告
+//
+// 返回值:
+//   - []error: 发现的问题, 每个问题包含文件、行号和列号
+func checkConfigKeys(filePath string, content []byte, migrated []string) []error {
+	decoder := toml.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var probe types.GobConfig
+	err := decoder.Decode(&probe)
+	if err == nil {
+		return nil
+	}
+
+	// 未知的配置项
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		problems := make([]error, 0, len(strictErr.Errors))
+		for _, e := range strictErr.Errors {
+			row, col := e.Position()
+			key := strings.Join(e.Key(), ".")
+			if slices.Contains(migrated, key) {
+				continue
+			}
+			msg := fmt.Sprintf("%s:%d:%d: 未知的配置项 %s", filePath, row, col, key)
+			if suggestion := suggestConfigKey(e.Key()); suggestion != "" {
+				msg += fmt.Sprintf(", 是否应为 %s?", suggestion)
+			}
+			problems = append(problems, errors.New(msg))
+		}
+		if len(problems) == 0 {
+			return nil
+		}
+		return problems
+	}
+
+	// 类型错误
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, col := decodeErr.Position()
+		return []error{fmt.Errorf("%s:%d:%d: 类型错误: %v", filePath, row, col, decodeErr.Error())}
+	}
+
+	return []error{fmt.Errorf("%s: %w", filePath, err)}
+}
+
+// ValidateConfig 校验配置的语义
+//
+// 参数:
+//   - config: 已加载的配置
+//
+// 返回值:
+//   - error: 存在问题时返回由errors.Join合并的所有问题, 否则返回nil
+//
+// 注意:
+//   - 所有问题一次性返回, 不会在第一个问题处停止
+func ValidateConfig(config *types.GobConfig) error {
+	var problems configProblems
+
+	// 超时时间
+	if timeout, err := time.ParseDuration(config.Build.Compiler.Timeout); err != nil {
+		problems.add("build.compiler.timeout 格式无效 %q, 应为带单位的时间, 如 60s、5m", config.Build.Compiler.Timeout)
+	} else if timeout < 0 {
+		problems.add("build.compiler.timeout 不能为负数")
+	}
+
+	// 输出配置
+	if strings.TrimSpace(config.Build.Output.Dir) == "" {
+		problems.add("build.output.dir 不能为空")
+	}
+	if strings.TrimSpace(config.Build.Output.Name) == "" {
+		problems.add("build.output.name 不能为空")
+	}
+
+	// 入口文件
+	if strings.TrimSpace(config.Build.Source.MainFile) == "" {
+		problems.add("build.source.main_file 不能为空")
+	} else if _, err := os.Stat(config.Build.Source.MainFile); err != nil {
+		problems.add("build.source.main_file 指定的入口文件 %s 不存在", config.Build.Source.MainFile)
+	}
+
+	// 目标平台, 使用目标平台模式或构建矩阵时可以不配置平台和架构
+	target := config.Build.Target
+	if len(target.Targets) == 0 && len(target.Matrix) == 0 {
+		if len(target.Platforms) == 0 {
+			problems.add("build.target.platforms 不能为空")
+		}
+		if len(target.Architectures) == 0 {
+			problems.add("build.target.architectures 不能为空")
+		}
+	}
+	if _, _, err := parseTargetPatterns(target.Targets); err != nil {
+		problems.add("build.target.targets: %v", err)
+	}
+	for i, entry := range target.Matrix {
+		if entry.GOOS == "" || entry.GOARCH == "" {
+			problems.add("[[build.target.matrix]] 第 %d 个条目必须同时指定 goos 和 goarch", i+1)
+		}
+	}
+	if target.Jobs < 0 {
+		problems.add("build.target.jobs 不能为负数")
+	}
+
+	// 编译命令
+	if len(config.Build.Command.Build) == 0 {
+		problems.add("build.command.build 不能为空")
+	}
+
+	// 互斥的选项
+	if target.Batch && config.Install.Install {
+		problems.add("不能同时启用批量构建(build.target.batch)和安装(install.install)")
+	}
+	if config.Install.Install && config.Build.Output.Zip {
+		problems.add("不能同时启用安装(install.install)和zip打包(build.output.zip)")
+	}
+
+	// 归档配置
+	if archive := config.Build.Output.Archive; archive.Enabled {
+		formats := map[string]string{"build.output.archive.format": archive.Format}
+		for _, goos := range slices.Sorted(maps.Keys(archive.FormatOverrides)) {
+			key := "build.output.archive.format_overrides." + goos
+			if !slices.Contains(types.KnownPlatforms, goos) {
+				problems.add("%s: 未知的目标平台 %s", key, goos)
+			}
+			formats[key] = archive.FormatOverrides[goos]
+		}
+		for _, key := range slices.Sorted(maps.Keys(formats)) {
+			if _, err := ArchiveExt(formats[key]); err != nil {
+				problems.add("%s: %v", key, err)
+			} else if formats[key] == "gz" && (len(archive.Files) > 0 || archive.WrapInDirectory) {
+				problems.add("%s: gz 格式只能压缩单个文件, 不能与 files 或 wrap_in_directory 一起使用", key)
+			}
+		}
+		if config.Build.Output.Zip {
+			problems.add("不能同时启用zip打包(build.output.zip)和归档(build.output.archive.enabled), 可改为设置 format = 'zip'")
+		}
+		if config.Install.Install {
+			problems.add("不能同时启用安装(install.install)和归档(build.output.archive.enabled)")
+		}
+	}
+
+	// Git元数据来源
+	sources := config.Build.Git.Sources
+	if len(sources) == 0 {
+		problems.add("build.git.sources 不能为空, 可用的来源: %s", strings.Join(GitSources(), "、"))
+	}
+	for i, source := range sources {
+		if !slices.Contains(GitSources(), source) {
+			problems.add("build.git.sources: 未知的来源 %q, 可用的来源: %s", source, strings.Join(GitSources(), "、"))
+		} else if slices.Index(sources, source) != i {
+			problems.add("build.git.sources: 来源 %q 重复", source)
+		}
+	}
+	if !slices.Contains(GitModes(), config.Build.Git.Mode) {
+		problems.add("build.git.mode: 不支持的注入方式 %q, 可用的方式: %s", config.Build.Git.Mode, strings.Join(GitModes(), "、"))
+	}
+
+	// 校验和配置
+	if checksum := config.Build.Checksum; checksum.Enabled {
+		if !slices.Contains(ChecksumAlgorithms(), checksum.Algorithm) {
+			problems.add("build.checksum.algorithm: 不支持的校验算法 %q, 可用的算法: %s", checksum.Algorithm, strings.Join(ChecksumAlgorithms(), "、"))
+		}
+		if name := strings.TrimSpace(checksum.File); name == "" || name != filepath.Base(name) {
+			problems.add("build.checksum.file 必须是输出目录下的文件名, 当前为 %q", checksum.File)
+		}
+	}
+
+	// 产物清单配置
+	if manifest := config.Build.Manifest; manifest.Enabled {
+		if name := strings.TrimSpace(manifest.File); name == "" || name != filepath.Base(name) {
+			problems.add("build.manifest.file 必须是输出目录下的文件名, 当前为 %q", manifest.File)
+		} else if config.Build.Checksum.Enabled && name == strings.TrimSpace(config.Build.Checksum.File) {
+			problems.add("build.manifest.file 不能与 build.checksum.file 相同, 当前均为 %q", name)
+		}
+	}
+
+	// SBOM配置
+	if sbom := config.Build.SBOM; sbom.Enabled {
+		if len(sbom.Formats) == 0 {
+			problems.add("build.sbom.formats 不能为空, 可用的格式: %s", strings.Join(SBOMFormats(), "、"))
+		}
+		for i, format := range sbom.Formats {
+			if !slices.Contains(SBOMFormats(), format) {
+				problems.add("build.sbom.formats: 不支持的SBOM格式 %q, 可用的格式: %s", format, strings.Join(SBOMFormats(), "、"))
+			} else if slices.Index(sbom.Formats, format) != i {
+				problems.add("build.sbom.formats: SBOM格式 %q 重复", format)
+			}
+		}
+	}
+
+	// Linux安装包配置
+	if pkg := config.Package.Linux; pkg.Enabled {
+		validateLinuxPackage(&problems, pkg)
+	}
+
+	// 容器镜像配置
+	if container := config.Container; container.Enabled {
+		if !slices.Contains(ContainerFormats(), container.Format) {
+			problems.add("container.format: 不支持的输出格式 %q, 可用的格式: %s", container.Format, strings.Join(ContainerFormats(), "、"))
+		}
+		if len(container.Tags) == 0 {
+			problems.add("container.tags 不能为空")
+		}
+		if !strings.HasPrefix(container.BinDir, "/") {
+			problems.add("container.bin_dir 必须是绝对路径, 当前为 %q", container.BinDir)
+		}
+		for _, name := range slices.Sorted(maps.Keys(container.Env)) {
+			if name == "" || strings.ContainsAny(name, "= ") {
+				problems.add("container.env: 无效的环境变量名 %q", name)
+			}
+		}
+	}
+
+	// 签名配置
+	if sign := config.Sign; sign.Enabled {
+		if !slices.Contains(SignMethods(), sign.Method) {
+			problems.add("sign.method: 不支持的签名方式 %q, 可用的方式: %s", sign.Method, strings.Join(SignMethods(), "、"))
+		}
+		if !sign.Checksum && !sign.Artifacts {
+			problems.add("sign.checksum 和 sign.artifacts 至少需要启用一项")
+		}
+		if sign.Checksum && !sign.Artifacts && !config.Build.Checksum.Enabled {
+			problems.add("sign.checksum 需要启用 build.checksum, 或启用 sign.artifacts 为每个产物签名")
+		}
+		if strings.TrimSpace(sign.KeyFile) == "" && sign.KeyEnv == "" {
+			problems.add("sign.key_file 和 sign.key_env 至少需要设置一项")
+		}
+	}
+
+	// 版本递增配置
+	if bump := config.Bump; strings.HasSuffix(bump.VersionFile, ".go") && !token.IsIdentifier(bump.VersionVar) {
+		problems.add("bump.version_var 必须是有效的Go标识符, 当前为 %q", bump.VersionVar)
+	}
+
+	// 变更日志配置, 未启用时仍会被 gob changelog 和 publish.release.changelog 使用
+	validateChangelog(&problems, config.Changelog)
+
+	// 包管理器清单配置
+	if manifests := config.Publish.Manifests; manifests.Enabled {
+		validatePublishManifests(&problems, manifests)
+	}
+
+	// 托管平台发布配置, 指定仓库后才会被 gob publish 使用
+	if release := config.Publish.Release; release.Owner != "" || release.Repo != "" {
+		validateRelease(&problems, release)
+	}
+
+	// 上传配置
+	if s3 := config.Publish.S3; s3.Enabled {
+		validateS3Upload(&problems, s3)
+	}
+	if upload := config.Publish.HTTP; upload.Enabled {
+		validateHTTPUpload(&problems, upload)
+	}
+
+	// 自定义变量
+	for _, name := range slices.Sorted(maps.Keys(config.Vars)) {
+		if _, err := parseVarSource(name, config.Vars[name]); err != nil {
+			problems.add("vars.%s: %v", name, err)
+		}
+	}
+
+	return problems.err()
+}
+
+// suggestConfigKey 为未知的配置项查找最相近的已知配置项
+//
+// 参数:
+//   - key: 未知配置项的路径, 如 [build output simple_name]
+//
+// 返回值:
+//   - string: 最相近的已知配置项路径, 找不到时返回空字符串
+func suggestConfigKey(key []string) string {
+	if len(key) == 0 {
+		return ""
+	}
+
+	parent := strings.Join(key[:len(key)-1], ".")
+	name := key[len(key)-1]
+	candidates := knownConfigKeys()[parent]
+
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		// simple_name 和 simple 这类仅多出后缀的写法视为最接近
+		if strings.HasPrefix(name, candidate+"_") || strings.HasPrefix(candidate, name+"_") {
+			distance = 1
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if best == "" || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return joinConfigPath(parent, best)
+}
+
+// knownConfigKeys 返回配置结构体中每个表下的已知配置项
+//
+// 返回值:
+//   - map[string][]string: 表路径到配置项名称列表的映射, 顶层表的路径为空字符串
+func knownConfigKeys() map[string][]string {
+	keys := make(map[string][]string)
+	collectConfigKeys(reflect.TypeFor[types.GobConfig](), "", keys)
+	return keys
+}
+
+// collectConfigKeys 递归收集结构体的TOML配置项
+//
+// 参数:
+//   - t: 结构体类型
+//   - prefix: 当前表路径
+//   - keys: 收集结果, 会被原地修改
+func collectConfigKeys(t reflect.Type, prefix string, keys map[string][]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		keys[prefix] = append(keys[prefix], name)
+
+		// 结构体和结构体数组(表数组)继续展开
+		ft := field.Type
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			collectConfigKeys(ft, joinConfigPath(prefix, name), keys)
+		}
+	}
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// validateLinuxPackage 校验Linux安装包配置
+//
+// 参数:
+//   - problems: 发现的问题, 会被原地追加
+//   - pkg: Linux安装包配置
+func validateLinuxPackage(problems *configProblems, pkg types.LinuxPackageConfig) {
+	if len(pkg.Formats) == 0 {
+		problems.add("package.linux.formats 不能为空, 可用的格式: %s", strings.Join(PackageFormats(), "、"))
+	}
+	for i, format := range pkg.Formats {
+		if !slices.Contains(PackageFormats(), format) {
+			problems.add("package.linux.formats: 不支持的安装包格式 %q, 可用的格式: %s", format, strings.Join(PackageFormats(), "、"))
+		} else if slices.Index(pkg.Formats, format) != i {
+			problems.add("package.linux.formats: 安装包格式 %q 重复", format)
+		}
+	}
+	for _, format := range slices.Sorted(maps.Keys(pkg.Overrides)) {
+		if !slices.Contains(PackageFormats(), format) {
+			problems.add("package.linux.overrides: 不支持的安装包格式 %q, 可用的格式: %s", format, strings.Join(PackageFormats(), "、"))
+		}
+	}
+
+	if strings.TrimSpace(pkg.Maintainer) == "" {
+		problems.add("package.linux.maintainer 不能为空, 如 'Name <mail@example.com>'")
+	}
+	if !packageReleaseRegexp.MatchString(pkg.Release) {
+		problems.add("package.linux.release 格式无效 %q, 只能包含字母、数字和 . + ~", pkg.Release)
+	} else if slices.Contains(pkg.Formats, "apk") && strings.Trim(pkg.Release, "0123456789") != "" {
+		problems.add("package.linux.release: apk 安装包的修订号必须为整数, 当前为 %q", pkg.Release)
+	}
+	if !strings.HasPrefix(pkg.BinDir, "/") {
+		problems.add("package.linux.bin_dir 必须是绝对路径, 当前为 %q", pkg.BinDir)
+	}
+
+	// 包关系
+	relations := map[string][]string{
+		"depends": pkg.Depends, "recommends": pkg.Recommends, "conflicts": pkg.Conflicts,
+		"provides": pkg.Provides, "replaces": pkg.Replaces,
+	}
+	for _, format := range slices.Sorted(maps.Keys(pkg.Overrides)) {
+		override := pkg.Overrides[format]
+		prefix := "overrides." + format + "."
+		relations[prefix+"depends"] = override.Depends
+		relations[prefix+"recommends"] = override.Recommends
+		relations[prefix+"conflicts"] = override.Conflicts
+		relations[prefix+"provides"] = override.Provides
+		relations[prefix+"replaces"] = override.Replaces
+	}
+	for _, key := range slices.Sorted(maps.Keys(relations)) {
+		for _, value := range relations[key] {
+			if _, err := parseDependency(value); err != nil {
+				problems.add("package.linux.%s: %v", key, err)
+			}
+		}
+	}
+
+	// 文件映射
+	for i, file := range pkg.Files {
+		if strings.TrimSpace(file.Src) == "" {
+			problems.add("package.linux.files[%d].src 不能为空", i)
+		}
+		if !strings.HasPrefix(file.Dst, "/") {
+			problems.add("package.linux.files[%d].dst 必须是绝对路径, 当前为 %q", i, file.Dst)
+		}
+		if file.Mode != "" {
+			if _, err := ParseFileMode(file.Mode); err != nil {
+				problems.add("package.linux.files[%d].mode: %v", i, err)
+			}
+		}
+	}
+}
+
+// validatePublishManifests 校验包管理器清单配置
+//
+// 参数:
+//   - problems: 发现的问题, 会被原地追加
+//   - cfg: 包管理器清单配置
+func validatePublishManifests(problems *configProblems, cfg types.PublishManifestsConfig) {
+	if len(cfg.Formats) == 0 {
+		problems.add("publish.manifests.formats 不能为空, 可用的格式: %s", strings.Join(PublishManifestFormats(), "、"))
+	}
+	for i, format := range cfg.Formats {
+		if !slices.Contains(PublishManifestFormats(), format) {
+			problems.add("publish.manifests.formats: 不支持的清单格式 %q, 可用的格式: %s", format, strings.Join(PublishManifestFormats(), "、"))
+		} else if slices.Index(cfg.Formats, format) != i {
+			problems.add("publish.manifests.formats: 清单格式 %q 重复", format)
+		}
+	}
+
+	if strings.TrimSpace(cfg.URL) == "" {
+		problems.add("publish.manifests.url 不能为空, 如 'https://github.com/owner/repo/releases/download/{{.Git.Version}}/{{.Artifact}}'")
+	} else if !strings.Contains(cfg.URL, ".Artifact") {
+		problems.add("publish.manifests.url 必须通过 {{.Artifact}} 引用产物文件名, 当前为 %q", cfg.URL)
+	}
+
+	if slices.Contains(cfg.Formats, "winget") {
+		if strings.TrimSpace(cfg.Winget.Publisher) == "" {
+			problems.add("publish.manifests.winget.publisher 不能为空")
+		}
+		if strings.TrimSpace(cfg.Winget.Locale) == "" {
+			problems.add("publish.manifests.winget.locale 不能为空, 如 %q", types.DefaultWingetLocale)
+		}
+		if cfg.Winget.PackageIdentifier != "" {
+			if _, err := WingetPackageIdentifier(cfg.Winget, ""); err != nil {
+				problems.add("publish.manifests.winget.package_identifier: %v", err)
+			}
+		}
+		if strings.TrimSpace(cfg.License) == "" {
+			problems.add("publish.manifests.license 不能为空, winget清单要求填写许可证")
+		}
+	}
+}
+
+// ValidateReleaseConfig 校验托管平台发布配置
+//
+// 参数:
+//   - cfg: 发布配置
+//
+// 返回值:
+//   - error: 包含所有问题的错误, 没有问题时为nil
+func ValidateReleaseConfig(cfg types.ReleaseConfig) error {
+	var problems configProblems
+	validateRelease(&problems, cfg)
+	return problems.err()
+}
+
+// validateRelease 校验托管平台发布配置
+//
+// 参数:
+//   - problems: 发现的问题, 会被原地追加
+//   - cfg: 发布配置
+func validateRelease(problems *configProblems, cfg types.ReleaseConfig) {
+	if !slices.Contains(ReleaseProviders(), cfg.Provider) {
+		problems.add("publish.release.provider: 不支持的托管平台 %q, 可用的平台: %s", cfg.Provider, strings.Join(ReleaseProviders(), "、"))
+	} else if ReleaseAPIURL(cfg) == "" {
+		problems.add("publish.release.url 不能为空, %s 需要指定API地址, 如 'https://gitea.example.com/api/v1'", cfg.Provider)
+	}
+	if cfg.URL != "" {
+		if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems.add("publish.release.url 必须是 http 或 https 地址, 当前为 %q", cfg.URL)
+		}
+	}
+	if strings.TrimSpace(cfg.Owner) == "" {
+		problems.add("publish.release.owner 不能为空")
+	}
+	if strings.TrimSpace(cfg.Repo) == "" {
+		problems.add("publish.release.repo 不能为空")
+	}
+	if cfg.Notes != "" && cfg.NotesFile != "" {
+		problems.add("publish.release.notes 和 publish.release.notes_file 不能同时设置")
+	}
+	if cfg.Changelog && (cfg.Notes != "" || cfg.NotesFile != "") {
+		problems.add("publish.release.changelog 不能与 publish.release.notes 或 publish.release.notes_file 同时设置")
+	}
+	if cfg.Draft && cfg.Provider == "gitee" {
+		problems.add("publish.release.draft: gitee 不支持草稿发布")
+	}
+	if cfg.Retries < 0 {
+		problems.add("publish.release.retries 不能为负数, 当前为 %d", cfg.Retries)
+	}
+}
+
+// validateChangelog 校验变更日志配置
+//
+// 参数:
+//   - problems: 发现的问题, 会被原地追加
+//   - cfg: 变更日志配置
+func validateChangelog(problems *configProblems, cfg types.ChangelogConfig) {
+	if cfg.Enabled {
+		if name := strings.TrimSpace(cfg.File); name == "" || name != filepath.Base(name) {
+			problems.add("changelog.file 必须是输出目录下的文件名, 当前为 %q", cfg.File)
+		}
+	}
+	for i, section := range cfg.Sections {
+		if strings.TrimSpace(section.Title) == "" {
+			problems.add("changelog.sections[%d].title 不能为空", i)
+		}
+		if len(section.Types) == 0 {
+			problems.add("changelog.sections[%d].types 不能为空", i)
+		}
+	}
+	for _, pattern := range cfg.Exclude {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems.add("changelog.exclude: 无效的正则表达式 %q: %v", pattern, err)
+		}
+	}
+}
+
+// validateS3Upload 校验对象存储上传配置
+//
+// 参数:
+//   - problems: 发现的问题, 会被原地追加
+//   - cfg: 对象存储上传配置
+func validateS3Upload(problems *configProblems, cfg types.S3UploadConfig) {
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		problems.add("publish.s3.endpoint 不能为空, 如 'https://minio.example.com'")
+	} else if _, err := S3ObjectURL(cfg, ""); err != nil {
+		problems.add("publish.s3.endpoint 必须是 http 或 https 地址, 当前为 %q", cfg.Endpoint)
+	}
+	if strings.TrimSpace(cfg.Bucket) == "" {
+		problems.add("publish.s3.bucket 不能为空")
+	}
+	if strings.TrimSpace(cfg.Region) == "" {
+		problems.add("publish.s3.region 不能为空, 如 %q", types.DefaultS3Region)
+	}
+	if !strings.Contains(cfg.Path, ".Artifact") {
+		problems.add("publish.s3.path 必须通过 {{.Artifact}} 引用产物文件名, 当前为 %q", cfg.Path)
+	}
+	if cfg.PartSize < types.MinS3PartSize {
+		problems.add("publish.s3.part_size 不能小于 %d, 当前为 %d", types.MinS3PartSize, cfg.PartSize)
+	}
+	if cfg.AccessKeyEnv == "" || cfg.SecretKeyEnv == "" {
+		problems.add("publish.s3.access_key_env 和 publish.s3.secret_key_env 不能为空")
+	}
+	if cfg.Retries < 0 {
+		problems.add("publish.s3.retries 不能为负数, 当前为 %d", cfg.Retries)
+	}
+}
+
+// validateHTTPUpload 校验HTTP上传配置
+//
+// 参数:
+//   - problems: 发现的问题, 会被原地追加
+//   - cfg: HTTP上传配置
+func validateHTTPUpload(problems *configProblems, cfg types.HTTPUploadConfig) {
+	switch {
+	case strings.TrimSpace(cfg.URL) == "":
+		problems.add("publish.http.url 不能为空, 如 'https://repo.example.com/generic/myapp/{{.Git.Version}}/{{.Artifact}}'")
+	case !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://"):
+		problems.add("publish.http.url 必须是 http 或 https 地址, 当前为 %q", cfg.URL)
+	case !strings.Contains(cfg.URL, ".Artifact"):
+		problems.add("publish.http.url 必须通过 {{.Artifact}} 引用产物文件名, 当前为 %q", cfg.URL)
+	}
+	if (cfg.UsernameEnv == "") != (cfg.PasswordEnv == "") {
+		problems.add("publish.http.username_env 和 publish.http.password_env 必须同时设置")
+	}
+	if cfg.TokenEnv != "" && cfg.UsernameEnv != "" {
+		problems.add("publish.http.token_env 和 publish.http.username_env 不能同时设置")
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Headers)) {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			problems.add("publish.http.headers: 无效的请求头名称 %q", name)
+		}
+	}
+	if cfg.Retries < 0 {
+		problems.add("publish.http.retries 不能为负数, 当前为 %d", cfg.Retries)
+	}
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfigKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		migrated []string
+		want     []string
+	}{
+		{
+			name:    "有效配置",
+			content: "version = 2\n[build.output]\nsimple = true\n",
+		},
+		{
+			name:    "未知配置项及建议",
+			content: "version = 2\n[build.output]\nsimple_name = true\n",
+			want:    []string{"gob.toml:3:1: 未知的配置项 build.output.simple_name, 是否应为 build.output.simple?"},
+		},
+		{
+			name:    "多个未知配置项",
+			content: "version = 2\n[build.output]\nsimple_name = true\n[build.target]\nbatchs = true\n",
+			want: []string{
+				"gob.toml:3:1: 未知的配置项 build.output.simple_name",
+				"gob.toml:5:1: 未知的配置项 build.target.batchs, 是否应为 build.target.batch?",
+			},
+		},
+		{
+			name:    "没有相近的配置项",
+			content: "version = 2\n[build.output]\nzzzzzzzzzzzzzzzz = 1\n",
+			want:    []string{"gob.toml:3:1: 未知的配置项 build.output.zzzzzzzzzzzzzzzz"},
+		},
+		{
+			name:     "已迁移的配置项不报告",
+			content:  "version = 2\n[build.output]\nold_key = true\n",
+			migrated: []string{"build.output.old_key"},
+		},
+		{
+			name:    "类型错误",
+			content: "version = 2\n[build.output]\nsimple = 'yes'\n",
+			want:    []string{"gob.toml:3:", "类型错误"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := checkConfigKeys("gob.toml", []byte(tt.content), tt.migrated)
+			if len(tt.want) == 0 {
+				if len(problems) != 0 {
+					t.Errorf("期望没有问题, got %v", problems)
+				}
+				return
+			}
+			var msgs []string
+			for _, p := range problems {
+				msgs = append(msgs, p.Error())
+			}
+			got := strings.Join(msgs, "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("期望包含 %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateConfig(GetDefaultConfig()); err != nil {
+		t.Fatalf("默认配置应通过校验: %v", err)
+	}
+
+	config := GetDefaultConfig()
+	config.Build.Compiler.Timeout = "60"
+	config.Build.Output.Name = " "
+	config.Build.Source.MainFile = filepath.Join("cmd", "missing.go")
+	config.Build.Target.Jobs = -1
+	config.Build.Git.Sources = []string{"git", "svn", "git"}
+
+	err := ValidateConfig(config)
+	if err == nil {
+		t.Fatal("期望返回校验错误")
+	}
+	want := []string{
+		`build.compiler.timeout 格式无效 "60"`,
+		"build.output.name 不能为空",
+		"build.source.main_file 指定的入口文件 " + filepath.Join("cmd", "missing.go") + " 不存在",
+		"build.target.jobs 不能为负数",
+		`build.git.sources: 未知的来源 "svn"`,
+		`build.git.sources: 来源 "git" 重复`,
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != len(want) {
+		t.Errorf("期望一次性报告 %d 个问题, got %d:\n%v", len(want), len(lines), err)
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("期望包含 %q, got:\n%v", w, err)
+		}
+	}
+}
+
+func TestValidateConfigNestedSections(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := GetDefaultConfig()
+	config.Package.Linux.Enabled = true
+	config.Package.Linux.Formats = []string{"deb", "msi"}
+	config.Package.Linux.Maintainer = ""
+
+	err := ValidateConfig(config)
+	if err == nil {
+		t.Fatal("期望返回校验错误")
+	}
+	for _, w := range []string{
+		`package.linux.formats: 不支持的安装包格式 "msi"`,
+		"package.linux.maintainer 不能为空",
+	} {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("期望包含 %q, got:\n%v", w, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"gitee.com/MM-Q/gob/internal/types"
+	"gitee.com/MM-Q/shellx"
+)
+
+// ResolveVars 解析[vars]中定义的用户变量
+//
+// 参数:
+//   - config: 配置结构体
+//
+// 返回值:
+//   - map[string]string: 变量名到变量值的映射
+//   - error: 变量定义无效或命令执行失败时返回错误
+//
+// 注意:
+//   - 变量按名称排序后依次解析, 命令类变量在启动时执行一次
+//   - 命令使用 shellx 在工作目录下执行, 继承系统环境变量和[env]中的环境变量
+func ResolveVars(config *types.GobConfig) (map[string]string, error) {
+	values := make(map[string]string, len(config.Vars))
+	for _, name := range slices.Sorted(maps.Keys(config.Vars)) {
+		source, err := parseVarSource(name, config.Vars[name])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := resolveVar(config, name, source)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// parseVarSource 将[vars]中的原始值解析为变量来源
+//
+// 参数:
+//   - name: 变量名
+//   - raw: TOML解析得到的原始值
+//
+// 返回值:
+//   - types.VarSource: 变量来源
+//   - error: 定义无效时返回错误
+func parseVarSource(name string, raw any) (types.VarSource, error) {
+	var source types.VarSource
+
+	table, ok := raw.(map[string]any)
+	if !ok {
+		// 标量直接作为静态值
+		switch v := raw.(type) {
+		case string:
+			source.Value = v
+		case int64, float64, bool:
+			source.Value = fmt.Sprint(v)
+		default:
+			return source, fmt.Errorf("变量 %s 的类型 %T 无效, 应为字符串或包含 value、env、cmd 的表", name, raw)
+		}
+		return source, nil
+	}
+
+	// 表形式的变量
+	fields := map[string]*string{
+		"value":   &source.Value,
+		"env":     &source.Env,
+		"cmd":     &source.Cmd,
+		"default": &source.Default,
+	}
+	for _, key := range slices.Sorted(maps.Keys(table)) {
+		field, ok := fields[key]
+		if !ok {
+			return source, fmt.Errorf("变量 %s 包含未知的键 %q, 可用的键: value、env、cmd、default", name, key)
+		}
+		switch v := table[key].(type) {
+		case string:
+			*field = v
+		case int64, float64, bool:
+			*field = fmt.Sprint(v)
+		default:
+			return source, fmt.Errorf("变量 %s 的 %s 类型 %T 无效, 应为字符串", name, key, v)
+		}
+	}
+
+	// value、env、cmd 必须且只能指定一个
+	count := 0
+	for _, key := range []string{"value", "env", "cmd"} {
+		if _, ok := table[key]; ok {
+			count++
+		}
+	}
+	if count != 1 {
+		return source, fmt.Errorf("变量 %s 必须且只能指定 value、env、cmd 中的一个", name)
+	}
+	for _, key := range []string{"env", "cmd"} {
+		if _, ok := table[key]; ok && *fields[key] == "" {
+			return source, fmt.Errorf("变量 %s 的 %s 不能为空", name, key)
+		}
+	}
+	if _, ok := table["value"]; ok && source.Default != "" {
+		return source, fmt.Errorf("变量 %s 的 default 只能与 env 或 cmd 一起使用", name)
+	}
+
+	return source, nil
+}
+
+// resolveVar 计算单个变量的值
+//
+// 参数:
+//   - config: 配置结构体
+//   - name: 变量名
+//   - source: 变量来源
+//
+// 返回值:
+//   - string: 变量值
+//   - error: 命令执行失败时返回错误
+func resolveVar(config *types.GobConfig, name string, source types.VarSource) (string, error) {
+	var value string
+	switch {
+	case source.Env != "":
+		value = os.Getenv(source.Env)
+
+	case source.Cmd != "":
+		if _, err := shellx.SplitE(source.Cmd); err != nil {
+			return "", fmt.Errorf("变量 %s 的命令 '%s' 无效: %w", name, source.Cmd, err)
+		}
+
+		workDir := config.Build.WorkDir
+		if workDir == "" {
+			workDir = "."
+		}
+
+		// 附加[env]中的环境变量
+		var envs []string
+		for _, k := range slices.Sorted(maps.Keys(config.Env)) {
+			envs = append(envs, fmt.Sprintf("%s=%s", k, config.Env[k]))
+		}
+
+		shell := shellx.ShellSh
+		if runtime.GOOS == "windows" {
+			shell = shellx.ShellPowerShell
+		}
+
+		output, err := shellx.NewCmdStr(source.Cmd).WithShell(shell).WithWorkDir(workDir).WithEnvs(envs).WithTimeout(config.Build.TimeoutDuration).ExecStdout()
+		if err != nil {
+			return "", fmt.Errorf("变量 %s 执行命令 '%s' 失败: %w", name, source.Cmd, err)
+		}
+		value = strings.TrimSpace(string(output))
+
+	default:
+		return source.Value, nil
+	}
+
+	if value == "" {
+		value = source.Default
+	}
+	return value, nil
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles 在临时目录中写入配置文件, 返回目录路径
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveVars(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试命令使用 sh 语法")
+	}
+	dir := writeConfigFiles(t, map[string]string{
+		"gob.toml": `version = 2
+
+[env]
+CHANNEL = "beta"
+
+[vars]
+region = "cn"
+replicas = 3
+debug = true
+ratio = 0.5
+from_env = { env = "GOB_TEST_REGION" }
+missing_env = { env = "GOB_TEST_MISSING", default = "fallback" }
+static = { value = "fixed" }
+commit = { cmd = "  echo '  abc123  '  " }
+channel = { cmd = "printf %s $CHANNEL" }
+pwd = { cmd = "basename $(pwd)" }
+empty_cmd = { cmd = "true", default = "none" }
+`,
+		"work/.keep": "",
+	})
+	t.Setenv("GOB_TEST_REGION", "eu")
+	t.Setenv("GOB_TEST_MISSING", "")
+
+	config, err := LoadConfig(filepath.Join(dir, "gob.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Build.WorkDir = filepath.Join(dir, "work")
+
+	values, err := ResolveVars(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"region":      "cn",
+		"replicas":    "3",
+		"debug":       "true",
+		"ratio":       "0.5",
+		"from_env":    "eu",
+		"missing_env": "fallback",
+		"static":      "fixed",
+		"commit":      "abc123",
+		"channel":     "beta",
+		"pwd":         "work",
+		"empty_cmd":   "none",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("变量值 = %v\n期望 %v", values, want)
+	}
+}
+
+func TestResolveVarsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  any
+		want string
+	}{
+		{"类型无效", []any{"a"}, "类型 []interface {} 无效"},
+		{"未知的键", map[string]any{"value": "a", "shell": "bash"}, `未知的键 "shell"`},
+		{"同时指定多个来源", map[string]any{"value": "a", "env": "A"}, "必须且只能指定"},
+		{"未指定来源", map[string]any{"default": "a"}, "必须且只能指定"},
+		{"env为空", map[string]any{"env": ""}, "env 不能为空"},
+		{"value与default同时使用", map[string]any{"value": "a", "default": "b"}, "default 只能与 env 或 cmd 一起使用"},
+		{"字段类型无效", map[string]any{"cmd": []any{"echo"}}, "cmd 类型 []interface {} 无效"},
+		{"命令执行失败", map[string]any{"cmd": "exit 3"}, "执行命令 'exit 3' 失败"},
+		{"命令引号不匹配", map[string]any{"cmd": "echo 'abc"}, "无效"},
+	}
+	for _, tt := range tests {
+		config := GetDefaultConfig()
+		config.Vars = map[string]any{"broken": tt.raw}
+		_, err := ResolveVars(config)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "broken") {
+			t.Errorf("%s: 期望错误包含变量名和 %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}